@@ -0,0 +1,111 @@
+package httphand
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/gofrs/uuid"
+	"github.com/other_project/crockroach/internal/problem"
+	"github.com/other_project/crockroach/internal/storage"
+	"github.com/other_project/crockroach/models"
+)
+
+// CertificateStore saves and reads the certificates presented by the servers
+type CertificateStore interface {
+	StoreTLSInspection(ctx context.Context, inspection *models.TLSInspection) (*models.TLSInspection, error)
+	GetTLSInspection(ctx context.Context, serverID string) (*models.TLSInspection, error)
+}
+
+// CertificateResponse is the TLS handshake with a server, the chain starts with the leaf.
+// The expiration is omitted when the handshake failed
+type CertificateResponse struct {
+	ServerID         string               `json:"server_id"`
+	AnalysisID       string               `json:"analysis_id"`
+	Address          string               `json:"address"`
+	ServerName       string               `json:"server_name"`
+	TLSVersion       string               `json:"tls_version"`
+	ChainVerified    bool                 `json:"chain_verified"`
+	HostnameVerified bool                 `json:"hostname_verified"`
+	VerifyError      string               `json:"verify_error,omitempty"`
+	Error            string               `json:"error,omitempty"`
+	ExpiresAt        *time.Time           `json:"expires_at,omitempty"`
+	DaysLeft         *int                 `json:"days_left,omitempty"`
+	Chain            []models.Certificate `json:"chain"`
+	InspectedAt      time.Time            `json:"inspected_at"`
+}
+
+// NewCertificateResponse converts the inspection to its v1 representation
+func NewCertificateResponse(inspection *models.TLSInspection, now time.Time) *CertificateResponse {
+	res := &CertificateResponse{
+		ServerID:         inspection.ServerID,
+		AnalysisID:       inspection.AnalysisID,
+		Address:          inspection.Address,
+		ServerName:       inspection.ServerName,
+		TLSVersion:       inspection.Version,
+		ChainVerified:    inspection.ChainVerified,
+		HostnameVerified: inspection.HostnameVerified,
+		VerifyError:      inspection.VerifyError,
+		Error:            inspection.Error,
+		Chain:            inspection.Chain,
+	}
+
+	if res.Chain == nil {
+		res.Chain = []models.Certificate{}
+	}
+
+	if leaf := inspection.Leaf(); leaf != nil {
+		expires := leaf.NotAfter.UTC()
+		days, _ := inspection.DaysLeft(now)
+
+		res.ExpiresAt = &expires
+		res.DaysLeft = &days
+	}
+
+	if inspection.CreationDate != nil {
+		res.InspectedAt = inspection.CreationDate.UTC()
+	}
+
+	return res
+}
+
+// GetCertificate returns the last certificate chain presented by the server
+func (p *HandlerRequest) GetCertificate(w http.ResponseWriter, r *http.Request) {
+	serverID := chi.URLParam(r, "id")
+
+	_, err := uuid.FromString(serverID)
+	if err != nil {
+		problem.Error(w, r, storage.ErrTLSInspectionNotFound)
+		return
+	}
+
+	inspection, err := p.certificates.GetTLSInspection(r.Context(), serverID)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, NewCertificateResponse(inspection, time.Now()))
+}
+
+// storeCertificates saves the inspections made during the analysis of the servers
+func storeCertificates(ctx context.Context, store CertificateStore, domain *models.Domain, analysisID string) error {
+	for _, server := range domain.Servers {
+		if server.TLS == nil {
+			continue
+		}
+
+		server.TLS.ServerID = server.ServerID
+		server.TLS.AnalysisID = analysisID
+
+		inspection, err := store.StoreTLSInspection(ctx, server.TLS)
+		if err != nil {
+			return err
+		}
+
+		server.TLS = inspection
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package httphand
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/internal/storage"
+	"github.com/other_project/crockroach/models"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryCertificates is an in memory CertificateStore
+type memoryCertificates struct {
+	inspections []*models.TLSInspection
+}
+
+func (m *memoryCertificates) StoreTLSInspection(ctx context.Context, inspection *models.TLSInspection) (*models.TLSInspection, error) {
+	m.inspections = append(m.inspections, inspection)
+
+	return inspection, nil
+}
+
+func (m *memoryCertificates) GetTLSInspection(ctx context.Context, serverID string) (*models.TLSInspection, error) {
+	for i := len(m.inspections) - 1; i >= 0; i-- {
+		if m.inspections[i].ServerID == serverID {
+			return m.inspections[i], nil
+		}
+	}
+
+	return nil, storage.ErrTLSInspectionNotFound
+}
+
+func TestGetCertificate(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	spec := loadOpenAPI(c)
+	now := time.Now()
+	store := &memoryCertificates{}
+
+	domain, err := models.NewDomain(false, false, "example.com", "", "", "unknown", "unknown")
+	c.NoError(err)
+
+	server, err := models.NewServer("10.0.0.1", "unknown", "unknown", "unknown", domain)
+	c.NoError(err)
+
+	failed, err := models.NewServer("10.0.0.2", "unknown", "unknown", "unknown", domain)
+	c.NoError(err)
+
+	server.TLS = &models.TLSInspection{
+		InspectionID: "5b0a3f3e-8a0c-4a4e-9d6b-1e2f3a4b5c6d", Address: server.Address, ServerName: "example.com",
+		Version: "TLS 1.3", ChainVerified: true, HostnameVerified: true, CreationDate: &now,
+		Chain: []models.Certificate{{Subject: "CN=example.com", SANs: []string{"example.com"}, NotAfter: now.Add(20*24*time.Hour + time.Hour), KeyType: "ECDSA", KeySize: 256}},
+	}
+	failed.TLS = &models.TLSInspection{InspectionID: "6c1b4f4f-9b1d-4b5f-8e7c-2f3a4b5c6d7e", Address: failed.Address, Error: "connection refused", CreationDate: &now}
+	domain.Servers = []*models.Server{server, failed}
+
+	c.NoError(storeCertificates(context.Background(), store, domain, domain.DomainID))
+	c.Len(store.inspections, 2)
+	c.Equal(server.ServerID, store.inspections[0].ServerID)
+	c.Equal(domain.DomainID, store.inspections[0].AnalysisID)
+
+	handler := &HandlerRequest{certificates: store}
+	mux := chi.NewMux()
+	mux.Get("/api/v1/servers/{id}/certificate", handler.GetCertificate)
+
+	rec, body := getJSON(c, mux, "/api/v1/servers/"+server.ServerID+"/certificate")
+	c.Equal(http.StatusOK, rec.Code)
+	validateResponse(c, spec, spec.Components.Schemas["ServerCertificate"], body)
+
+	res := body.(map[string]interface{})
+	c.Equal(float64(20), res["days_left"])
+	c.Equal("TLS 1.3", res["tls_version"])
+	c.Len(res["chain"], 1)
+
+	rec, body = getJSON(c, mux, "/api/v1/servers/"+failed.ServerID+"/certificate")
+	c.Equal(http.StatusOK, rec.Code)
+	validateResponse(c, spec, spec.Components.Schemas["ServerCertificate"], body)
+
+	res = body.(map[string]interface{})
+	c.Equal("connection refused", res["error"])
+	c.Nil(res["days_left"], "a failed handshake does not have an expiration")
+
+	rec, _ = getJSON(c, mux, "/api/v1/servers/0b9c1e1e-1111-4222-8333-444455556666/certificate")
+	c.Equal(http.StatusNotFound, rec.Code)
+
+	rec, _ = getJSON(c, mux, "/api/v1/servers/not-a-uuid/certificate")
+	c.Equal(http.StatusNotFound, rec.Code)
+}
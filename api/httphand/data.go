@@ -3,16 +3,25 @@ package httphand
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"os/exec"
-	"regexp"
+	"strings"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/other_project/crockroach/internal/apperr"
+	"github.com/other_project/crockroach/internal/dnslookup"
+	"github.com/other_project/crockroach/internal/favicon"
+	"github.com/other_project/crockroach/internal/geoip"
+	"github.com/other_project/crockroach/internal/jobs"
 	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/internal/pagemeta"
+	"github.com/other_project/crockroach/internal/tlsinspect"
+	"github.com/other_project/crockroach/internal/tlsscan"
+	"github.com/other_project/crockroach/internal/uptime"
 	"github.com/other_project/crockroach/models"
 )
 
@@ -37,6 +46,7 @@ type InfoLabSSL struct {
 	Protocol        string
 	IsPublic        bool
 	Status          string
+	StatusMessage   string
 	StartTime       int64
 	TestTime        int64
 	EngineVersion   string
@@ -46,13 +56,13 @@ type InfoLabSSL struct {
 
 // ParseServerJSON model structure for parse server
 type ParseServerJSON struct {
-	Address  string `json:"address"`
-	SSLGrade string `json:"ssl_grade"`
-	Country  string `json:"country"`
-	Owner    string `json:"owner"`
+	Address  string `json:"address" yaml:"address"`
+	SSLGrade string `json:"ssl_grade" yaml:"ssl_grade"`
+	Country  string `json:"country" yaml:"country"`
+	Owner    string `json:"owner" yaml:"owner"`
 }
 
-//InfoWHOISCommand model struct owner and country
+// InfoWHOISCommand model struct owner and country
 type InfoWHOISCommand struct {
 	country string
 	owner   string
@@ -60,141 +70,461 @@ type InfoWHOISCommand struct {
 
 // ParseDomainJSON model structure for parse domain
 type ParseDomainJSON struct {
-	Servers          []*ParseServerJSON `json:"servers"`
-	ServerChanged    bool               `json:"servers_changed"`
-	SSLGrade         string             `json:"ssl_grade"`
-	PreviousSSLGrade string             `json:"previous_ssl_grade"`
-	Logo             string             `json:"logo"`
-	Title            string             `json:"title"`
-	IsDown           bool               `json:"is_down"`
+	Servers          []*ParseServerJSON `json:"servers" yaml:"servers"`
+	ServerChanged    bool               `json:"servers_changed" yaml:"servers_changed"`
+	LogoChanged      bool               `json:"logo_changed" yaml:"logo_changed"`
+	SSLGrade         string             `json:"ssl_grade" yaml:"ssl_grade"`
+	PreviousSSLGrade string             `json:"previous_ssl_grade" yaml:"previous_ssl_grade"`
+	Logo             string             `json:"logo" yaml:"logo"`
+	Title            string             `json:"title" yaml:"title"`
+	IsDown           bool               `json:"is_down" yaml:"is_down"`
 }
 
 // InfoDomainPage contain the information about the domain
 type InfoDomainPage struct {
-	Title string
-	Logo  string
+	Title    string
+	Logo     string
+	URL      string
+	Metadata models.PageMetadata
+	Text     string
+}
+
+// Options configure the analysis performed by ProcessDataWithOptions
+type Options struct {
+	// Providers used to build the servers of the domain
+	Providers []string
+	// Timeout of every outbound request
+	Timeout time.Duration
+	// SkipWHOIS does not run the whois command for the servers
+	SkipWHOIS bool
+	// PollInterval waits for SSL Labs to finish the assessment when it's positive,
+	// otherwise the first answer is used
+	PollInterval time.Duration
+	// Report receives the stages of the analysis, it can be nil
+	Report jobs.ProgressFunc
+	// Health describes the answer of a healthy site, the zero value accepts any 2xx or 3xx
+	Health models.HealthCheck
+	// DNSServer receives the queries of the dns provider, empty uses the server of the system
+	DNSServer string
+	// GeoIP are the databases of the geoip provider, nil skips the provider
+	GeoIP *geoip.Databases
+	// LogoMaxBytes is the largest logo downloaded by the logo provider
+	LogoMaxBytes int64
 }
 
 const (
 	// Timeout time to perform the request to the API
 	Timeout = 15 * time.Second
+	// ProviderSSLLabs obtains the servers and their grades from the SSL Labs API
+	ProviderSSLLabs = "ssllabs"
+	// ProviderWHOIS fills the country and owner of every server with whois
+	ProviderWHOIS = "whois"
+	// ProviderTLS inspects the certificate of every server, without SSL Labs the servers are resolved
+	ProviderTLS = "tls"
+	// ProviderScan grades the resolved servers with the local TLS scanner instead of SSL Labs
+	ProviderScan = "scan"
+	// ProviderDNS saves the records of the domain, the resolved servers use its addresses
+	ProviderDNS = "dns"
+	// ProviderGeoIP fills the geolocated country and the ASN of every server with the local databases
+	ProviderGeoIP = "geoip"
+	// ProviderLogo downloads the logo of the page so the service can serve it
+	ProviderLogo = "logo"
+	// UnknownInfo is stored when a provider was skipped
+	UnknownInfo = "unknown"
+
+	// StageStatus checks if the site is up
+	StageStatus = "status"
+	// StagePage reads the title and the logo of the site
+	StagePage = "page"
+	// StageSSLLabs waits for the SSL Labs assessment
+	StageSSLLabs = "ssllabs"
+	// StageWHOIS obtains the country and owner of the servers
+	StageWHOIS = "whois"
+	// StageTLS inspects the certificates of the servers
+	StageTLS = "tls"
+	// StageScan grades the servers with the local TLS scanner
+	StageScan = "scan"
+	// StageDNS resolves the records of the domain
+	StageDNS = "dns"
+	// StageLogo downloads the logo of the page
+	StageLogo = "logo"
+
+	// sslLabsReady and sslLabsError are the final states of an SSL Labs assessment
+	sslLabsReady = "READY"
+	sslLabsError = "ERROR"
 )
 
 var (
 	// ErrEmptyDomainName when check the status server
-	ErrEmptyDomainName = errors.New("cannot be empty domain name")
+	ErrEmptyDomainName = apperr.New(apperr.Unprocessable, "empty_domain_name", "cannot be empty domain name")
 	// ErrInvalidServers when search info servers
-	ErrInvalidServers = errors.New("cannot extract data about the servers")
+	ErrInvalidServers = apperr.New(apperr.Upstream, "ssllabs_without_endpoints", "cannot extract data about the servers")
 	// ErrWithoutAnwserSSLLabs when search
-	ErrWithoutAnwserSSLLabs = errors.New("cannot obtain  answser SSL labs info")
+	ErrWithoutAnwserSSLLabs = apperr.New(apperr.Upstream, "ssllabs_error", "cannot obtain  answser SSL labs info")
 	// ErrDomainConsulted when search the domain
-	ErrDomainConsulted = errors.New("cannot obtain answer the domain")
+	ErrDomainConsulted = apperr.New(apperr.Upstream, "domain_unreachable", "cannot obtain answer the domain")
+	// ErrUnknownProvider when the options select a provider that does not exist
+	ErrUnknownProvider = apperr.New(apperr.Invalid, "unknown_provider", "unknown provider")
+	// Providers contains every provider that can be selected
+	Providers = []string{ProviderSSLLabs, ProviderWHOIS, ProviderTLS, ProviderScan, ProviderDNS, ProviderGeoIP, ProviderLogo}
 )
 
+// DefaultOptions returns the options used by the API
+func DefaultOptions() Options {
+	return Options{
+		Providers:    Providers,
+		Timeout:      Timeout,
+		DNSServer:    DNSResolver,
+		GeoIP:        GeoIPDatabases,
+		LogoMaxBytes: LogoMaxBytes,
+	}
+}
+
+// Validate ensure that the options select known providers
+func (o Options) Validate() error {
+	for _, provider := range o.Providers {
+		found := false
+
+		for _, known := range Providers {
+			if provider == known {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return fmt.Errorf("%w: %s", ErrUnknownProvider, provider)
+		}
+	}
+
+	return nil
+}
+
+// uses reports if the provider was selected
+func (o Options) uses(provider string) bool {
+	if provider == ProviderWHOIS && o.SkipWHOIS {
+		return false
+	}
+
+	for _, selected := range o.Providers {
+		if selected == provider {
+			return true
+		}
+	}
+
+	return false
+}
+
+// report sends the stage of the analysis to the Report function
+func (o Options) report(stage string, progress int, message string, endpoints []*models.EndpointProgress) {
+	if o.Report == nil {
+		return
+	}
+
+	o.Report(jobs.Event{Stage: stage, Progress: progress, Message: message, Endpoints: endpoints})
+}
+
+// timeout returns the timeout of every outbound request
+func (o Options) timeout() time.Duration {
+	if o.Timeout <= 0 {
+		return Timeout
+	}
+
+	return o.Timeout
+}
+
+// logoMaxBytes returns the largest logo downloaded by the logo provider
+func (o Options) logoMaxBytes() int64 {
+	if o.LogoMaxBytes <= 0 {
+		return LogoMaxBytes
+	}
+
+	return o.LogoMaxBytes
+}
+
+// client returns the http client used by the providers
+func (o Options) client() *http.Client {
+	return &http.Client{
+		Timeout: o.timeout(),
+	}
+}
+
 // ProcessData to build the domain object
 func ProcessData(ctx context.Context, domainName string) (*models.Domain, error) {
-	isDown, err := GetStatusServer(domainName)
+	return ProcessDataWithOptions(ctx, domainName, DefaultOptions())
+}
+
+// ProcessDataWithOptions build the domain object with the selected providers
+func ProcessDataWithOptions(ctx context.Context, domainName string, opts Options) (*models.Domain, error) {
+	err := opts.Validate()
 	if err != nil {
-		//logs.Log().Errorf("Error isDown %s", err.Error())
 		return nil, err
 	}
 
-	infoPage, err := GetInfoDomainPage(domainName)
-	if err != nil {
-		//logs.Log().Errorf("Error infoPage %s", err.Error())
-		return nil, err
+	client := opts.client()
+
+	opts.report(StageStatus, 5, "checking the site", nil)
+
+	if domainName == "" {
+		return nil, ErrEmptyDomainName
+	}
+
+	check := uptime.Check(ctx, client, opts.Health.URL(domainName), opts.Health)
+	check.DomainName = domainName
+
+	if ctx.Err() != nil {
+		return nil, upstreamError("domain_unreachable", ctx.Err())
+	}
+
+	// a down site keeps the analysis, the title and the logo are unknown when the page cannot be read
+	infoPage := &InfoDomainPage{Title: UnknownInfo, Logo: UnknownInfo}
+
+	if readsPage(check, opts.Health) {
+		opts.report(StagePage, 10, "reading the page", nil)
+
+		page, err := getInfoDomainPage(ctx, client, domainName)
+		if ctx.Err() != nil {
+			return nil, upstreamError("domain_unreachable", ctx.Err())
+		}
+
+		if err != nil {
+			logs.Log().Errorf("cannot read the page of %s: %s", domainName, err.Error())
+		} else {
+			infoPage = page
+		}
 	}
 
-	domain, err := models.NewDomain(false, isDown, domainName, "", "", infoPage.Logo, infoPage.Title)
+	domain, err := models.NewDomain(false, !check.Up, domainName, "", "", infoPage.Logo, infoPage.Title)
 	if err != nil {
 		//logs.Log().Errorf("cannot create the domain %s", err.Error())
 		return nil, err
 	}
 
-	infoDomainSSL, err := InfoServers(domainName)
-	if err != nil {
-		return nil, err
+	domain.Uptime = check
+
+	// the page is kept to compare its content with the next analysis
+	if infoPage.URL != "" {
+		domain.Page, err = models.NewPageSnapshot(domainName, infoPage.URL, infoPage.Title, infoPage.Text, infoPage.Metadata)
+		if err != nil {
+			logs.Log().Errorf("cannot create the page snapshot of %s: %s", domainName, err.Error())
+		}
+	}
+
+	// the scraped address is kept when the logo cannot be downloaded
+	if opts.uses(ProviderLogo) && infoPage.Logo != UnknownInfo {
+		opts.report(StageLogo, 11, "downloading the logo", nil)
+
+		domain.LogoImage, err = favicon.Download(ctx, client, infoPage.Logo, opts.logoMaxBytes())
+		if err != nil {
+			logs.Log().Errorf("cannot download the logo of %s: %s", domainName, err.Error())
+		}
+	}
+
+	// the records are saved even when the site does not answer, they may explain why
+	if opts.uses(ProviderDNS) {
+		opts.report(StageDNS, 12, "resolving the records", nil)
+
+		domain.DNS = dnslookup.NewResolver(opts.DNSServer, opts.timeout()).Snapshot(ctx, domainName)
+		if ctx.Err() != nil {
+			return nil, upstreamError("domain_unreachable", ctx.Err())
+		}
 	}
 
-	servers := infoDomainSSL.Endpoints
+	// the servers cannot be assessed when the site does not answer
+	if !check.Reachable() {
+		return domain, nil
+	}
+
+	var servers []*InfoLabSSLEndpoints
+
+	switch {
+	case opts.uses(ProviderSSLLabs):
+		infoDomainSSL, err := pollServers(ctx, client, domainName, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		servers = infoDomainSSL.Endpoints
+	case opts.uses(ProviderScan):
+		servers, err = scanServers(ctx, domain, opts)
+		if err != nil {
+			return nil, err
+		}
+	case opts.uses(ProviderTLS):
+		servers, err = resolveServers(ctx, domain)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return domain, nil
+	}
+
+	inspector := tlsinspect.NewInspector(opts.timeout())
 
 	serversNumber := len(servers)
 
 	for i := 0; i < serversNumber; i++ {
 		serverSSL := servers[i]
 
-		infoWhois, err := getInfoWhois(serverSSL.IPAddress)
-		if err != nil {
-			//logs.Log().Errorf("cannot extract Country whois command: %s", err.Error())
-			return nil, err
+		infoWhois := &InfoWHOISCommand{country: UnknownInfo, owner: UnknownInfo}
+
+		if opts.uses(ProviderWHOIS) {
+			opts.report(StageWHOIS, 85+10*i/serversNumber, "whois "+serverSSL.IPAddress, nil)
+
+			infoWhois, err = getInfoWhois(serverSSL.IPAddress)
+			if err != nil {
+				//logs.Log().Errorf("cannot extract Country whois command: %s", err.Error())
+				return nil, err
+			}
 		}
 
 		server, err := models.NewServer(serverSSL.IPAddress, serverSSL.Grade, infoWhois.country, infoWhois.owner, domain)
 		if err != nil {
 			//logs.Log().Errorf("cannot create the server of the domain %s", err.Error())
-			return nil, err
+			return nil, serverError(err)
+		}
+
+		if opts.uses(ProviderGeoIP) && opts.GeoIP.Enabled() {
+			locateServer(opts.GeoIP, server)
+		}
+
+		if opts.uses(ProviderTLS) {
+			opts.report(StageTLS, 95+4*i/serversNumber, "tls "+serverSSL.IPAddress, nil)
+
+			server.TLS = inspector.Inspect(ctx, serverSSL.IPAddress, domainName)
 		}
 
 		domain.Servers = append(domain.Servers, server)
 	}
 
+	domain.SSLGrade = models.LowestGrade(domain.Servers)
+	domain.DualStack = models.CheckDualStack(domain.Servers)
+
 	return domain, nil
 }
 
-// GetStatusServer check server status
-func GetStatusServer(domainName string) (bool, error) {
-	if domainName == "" {
-		return false, ErrEmptyDomainName
-	}
+// resolveServers returns the addresses of the domain when SSL Labs is not used, their grade is unknown.
+// The addresses of the dns snapshot are preferred over the resolver of the system
+func resolveServers(ctx context.Context, domain *models.Domain) ([]*InfoLabSSLEndpoints, error) {
+	servers := []*InfoLabSSLEndpoints{}
 
-	url := fmt.Sprintf("https://%s", domainName)
-	timeout := time.Duration(Timeout)
-	client := http.Client{
-		Timeout: timeout,
+	if domain.DNS != nil && len(domain.DNS.Records.Addresses()) > 0 {
+		for _, address := range domain.DNS.Records.Addresses() {
+			servers = append(servers, &InfoLabSSLEndpoints{IPAddress: address, Grade: UnknownInfo})
+		}
+
+		return servers, nil
 	}
 
-	request, err := http.NewRequest("GET", url, nil)
+	addresses, err := net.DefaultResolver.LookupIPAddr(ctx, domain.DomainName)
 	if err != nil {
-		logs.Log().Errorf("Error request wraps %s ", err.Error())
-		return true, err
+		logs.Log().Errorf("cannot resolve %s: %s", domain.DomainName, err.Error())
+		return nil, upstreamError("domain_unreachable", err)
 	}
 
-	resp, err := client.Do(request)
+	for _, address := range addresses {
+		servers = append(servers, &InfoLabSSLEndpoints{IPAddress: address.IP.String(), Grade: UnknownInfo})
+	}
+
+	return servers, nil
+}
+
+// scanServers grades the addresses of the domain with the local TLS scanner, it returns the
+// endpoints in the shape of SSL Labs. An address without handshake has an unknown grade
+func scanServers(ctx context.Context, domain *models.Domain, opts Options) ([]*InfoLabSSLEndpoints, error) {
+	domainName := domain.DomainName
+
+	servers, err := resolveServers(ctx, domain)
 	if err != nil {
-		logs.Log().Errorf("Error check status server %s ", err.Error())
-		return false, err
+		return nil, err
 	}
 
-	defer func() {
-		err := resp.Body.Close()
-		if err != nil {
-			logs.Log().Errorf("Error response body close %s ", err.Error())
+	scanner := tlsscan.NewScanner(opts.timeout())
+
+	for i, server := range servers {
+		opts.report(StageScan, 15+70*i/len(servers), "scanning "+server.IPAddress, nil)
+
+		report := scanner.Scan(ctx, server.IPAddress, domainName)
+		if ctx.Err() != nil {
+			return nil, upstreamError("domain_unreachable", ctx.Err())
 		}
-	}()
 
-	statusRequest := fmt.Sprintf("%d OK", http.StatusOK)
-	if resp.Status != statusRequest {
-		logs.Log().Errorf("the server does not work statuscode %d\n", resp.StatusCode)
-		return false, nil
+		server.ServerName = domainName
+		server.StatusMessage = "Ready"
+
+		if report.Grade != "" {
+			server.Grade = report.Grade
+		} else {
+			server.StatusMessage = report.Error
+		}
+	}
+
+	return servers, nil
+}
+
+// upstreamError classifies the errors of the external services, the timeouts keep their kind
+func upstreamError(code string, err error) error {
+	if apperr.KindOf(err) == apperr.Timeout {
+		return apperr.Wrap(apperr.Timeout, "analysis_timeout", err)
 	}
 
-	return true, nil
+	return apperr.Wrap(apperr.Upstream, code, err)
+}
+
+// serverError classifies the errors of creating a server. Its values come from the external
+// services, so a missing value is an upstream error that keeps the code of the model
+func serverError(err error) error {
+	if apperr.KindOf(err) != apperr.Unprocessable {
+		return err
+	}
+
+	return apperr.Wrap(apperr.Upstream, apperr.CodeOf(err), err)
+}
+
+// GetStatusServer check server status, a site that does not answer is down without error
+func GetStatusServer(domainName string) (bool, error) {
+	if domainName == "" {
+		return false, ErrEmptyDomainName
+	}
+
+	opts := DefaultOptions()
+	check := uptime.Check(context.Background(), opts.client(), opts.Health.URL(domainName), opts.Health)
+
+	return check.Up, nil
 }
 
 // GetInfoDomainPage ...
 func GetInfoDomainPage(domainName string) (*InfoDomainPage, error) {
+	return getInfoDomainPage(context.Background(), DefaultOptions().client(), domainName)
+}
+
+// readsPage reports if the home page can be read after the health check. A GET of the home page
+// tells it from its status: a 204, a 401 or any other error status does not have the page, the
+// redirects are followed by the page request. Other paths and methods do not tell it
+func readsPage(check *models.UptimeCheck, health models.HealthCheck) bool {
+	if !check.Reachable() {
+		return false
+	}
+
+	if (health.Path != "" && health.Path != "/") || health.RequestMethod() != http.MethodGet {
+		return true
+	}
+
+	code := check.StatusCode
+
+	return code == http.StatusOK || (code >= 300 && code < 400)
+}
+
+// getInfoDomainPage extract the title and logo of the page with the given client
+func getInfoDomainPage(ctx context.Context, client *http.Client, domainName string) (*InfoDomainPage, error) {
 	if domainName == "" {
 		logs.Log().Errorf("missing domain name %s ", ErrEmptyDomainName)
 		return nil, ErrEmptyDomainName
 	}
 
 	url := fmt.Sprintf("https://%s", domainName)
-	timeout := time.Duration(Timeout)
-	client := http.Client{
-		Timeout: timeout,
-	}
 
-	request, err := http.NewRequest("GET", url, nil)
+	request, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		logs.Log().Errorf("Error request wraps %s ", err.Error())
 		return nil, err
@@ -203,7 +533,7 @@ func GetInfoDomainPage(domainName string) (*InfoDomainPage, error) {
 	resp, err := client.Do(request)
 	if err != nil {
 		logs.Log().Errorf("Error check status server %s ", err.Error())
-		return nil, err
+		return nil, upstreamError("domain_unreachable", err)
 	}
 
 	defer func() {
@@ -213,8 +543,7 @@ func GetInfoDomainPage(domainName string) (*InfoDomainPage, error) {
 		}
 	}()
 
-	statusRequest := fmt.Sprintf("%d OK", http.StatusOK)
-	if resp.Status != statusRequest {
+	if resp.StatusCode != http.StatusOK {
 		logs.Log().Errorf("the dominio %s does not work: statuscode %d\n", resp.Request.URL, resp.StatusCode)
 		return nil, ErrDomainConsulted
 	}
@@ -222,72 +551,131 @@ func GetInfoDomainPage(domainName string) (*InfoDomainPage, error) {
 	doc, err := goquery.NewDocumentFromReader(resp.Body)
 	if err != nil {
 		logs.Log().Errorf("Error read document HTML %s ", err.Error())
-		return nil, err
+		return nil, upstreamError("invalid_page", err)
 	}
 
 	title := ""
 
 	doc.Find("title").Each(func(i int, s *goquery.Selection) { title = s.Text() })
 
-	iconPath := ""
+	// the links are resolved against the URL of the final response, after the redirects
+	icon := favicon.Find(ctx, client, doc, resp.Request.URL)
+
+	return &InfoDomainPage{
+		Title:    title,
+		Logo:     icon.URL,
+		URL:      resp.Request.URL.String(),
+		Metadata: pagemeta.Extract(doc, favicon.Base(doc, resp.Request.URL)),
+		Text:     pagemeta.Text(doc),
+	}, nil
+}
 
-	doc.Find("link").Each(func(i int, s *goquery.Selection) {
-		rel, err := s.Attr("rel")
-		if !err {
-			logs.Log().Errorf("Not found rel attribute HTML %v ", err)
-			return
+// InfoServers ...
+func InfoServers(domain string) (*InfoLabSSL, error) {
+	return infoServers(context.Background(), DefaultOptions().client(), domain)
+}
+
+// infoServers query the SSL Labs API with the given client
+func infoServers(ctx context.Context, client *http.Client, domain string) (*InfoLabSSL, error) {
+	infoDomainSSL, err := requestServers(ctx, client, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	return withEndpoints(infoDomainSSL)
+}
+
+// pollServers query the SSL Labs API every PollInterval until the assessment finishes,
+// the progress of the endpoints is sent to the Report function
+func pollServers(ctx context.Context, client *http.Client, domain string, opts Options) (*InfoLabSSL, error) {
+	for {
+		infoDomainSSL, err := requestServers(ctx, client, domain)
+		if err != nil {
+			return nil, err
 		}
 
-		if rel == "shortcut icon" {
-			iconPath, err = s.Attr("href")
-			if !err {
-				logs.Log().Errorf("Not found href attribute HTML %v ", err)
-				return
-			}
+		progress, endpoints := sslLabsProgress(infoDomainSSL)
+		opts.report(StageSSLLabs, progress, sslLabsMessage(infoDomainSSL), endpoints)
+
+		if opts.PollInterval <= 0 || infoDomainSSL.Status == sslLabsReady || infoDomainSSL.Status == sslLabsError {
+			return withEndpoints(infoDomainSSL)
 		}
 
-		if rel == "icon" {
-			iconPath, err = s.Attr("href")
-			if !err {
-				logs.Log().Errorf("Not found href attribute HTML %s ", err)
-				return
-			}
+		timer := time.NewTimer(opts.PollInterval)
 
-			re, err := regexp.Compile("^/")
-			if err != nil {
-				logs.Log().Errorf("Not found href attribute HTML %s ", err.Error())
-				return
-			}
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, upstreamError("ssllabs_unavailable", ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
 
-			found := re.MatchString(iconPath)
-			if found {
-				iconPath = resp.Request.URL.String() + iconPath
-			}
-			return
+// sslLabsProgress converts the progress of the endpoints to the progress of the analysis, from 15 to 85
+func sslLabsProgress(info *InfoLabSSL) (int, []*models.EndpointProgress) {
+	endpoints := make([]*models.EndpointProgress, 0, len(info.Endpoints))
+	total := 0
+
+	for _, endpoint := range info.Endpoints {
+		progress := int(endpoint.Progress)
+		if endpoint.Grade != "" || endpoint.StatusMessage == "Ready" {
+			progress = 100
 		}
-	})
 
-	return &InfoDomainPage{
-		Title: title,
-		Logo:  iconPath,
-	}, nil
+		if progress < 0 {
+			progress = 0
+		}
+
+		total += progress
+
+		endpoints = append(endpoints, &models.EndpointProgress{
+			IPAddress:     endpoint.IPAddress,
+			StatusMessage: endpoint.StatusMessage,
+			Progress:      progress,
+			Grade:         endpoint.Grade,
+		})
+	}
+
+	if info.Status == sslLabsReady {
+		return 85, endpoints
+	}
+
+	if len(endpoints) == 0 {
+		return 15, endpoints
+	}
+
+	return 15 + 70*total/(100*len(endpoints)), endpoints
 }
 
-// InfoServers ...
-func InfoServers(domain string) (*InfoLabSSL, error) {
-	if domain == "" {
-		return nil, ErrEmptyDomainName
+// sslLabsMessage describes the state of the assessment
+func sslLabsMessage(info *InfoLabSSL) string {
+	if info.StatusMessage != "" {
+		return "ssl labs: " + info.StatusMessage
 	}
 
-	url := fmt.Sprintf("https://api.ssllabs.com/api/v3/analyze?host=%s", domain)
+	return "ssl labs: " + strings.ToLower(strings.ReplaceAll(info.Status, "_", " "))
+}
 
-	timeout := time.Duration(Timeout)
+// withEndpoints ensures that the assessment found the servers of the domain
+func withEndpoints(infoDomainSSL *InfoLabSSL) (*InfoLabSSL, error) {
+	if infoDomainSSL.Endpoints == nil {
+		logs.Log().Errorf("cannot found info servers %s", ErrInvalidServers.Error())
+		return nil, ErrInvalidServers
+	}
+
+	return infoDomainSSL, nil
+}
 
-	client := http.Client{
-		Timeout: timeout,
+// requestServers query the current state of the SSL Labs assessment, the first request starts it
+func requestServers(ctx context.Context, client *http.Client, domain string) (*InfoLabSSL, error) {
+	if domain == "" {
+		return nil, ErrEmptyDomainName
 	}
 
-	request, err := http.NewRequest("GET", url, nil)
+	url := fmt.Sprintf("https://api.ssllabs.com/api/v3/analyze?host=%s", domain)
+
+	request, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		logs.Log().Errorf("Error wraps request %s", err.Error())
 		return nil, err
@@ -296,7 +684,7 @@ func InfoServers(domain string) (*InfoLabSSL, error) {
 	resp, err := client.Do(request)
 	if err != nil {
 		logs.Log().Errorf("Error wraps request %s", err.Error())
-		return nil, err
+		return nil, upstreamError("ssllabs_unavailable", err)
 	}
 
 	defer func() {
@@ -309,7 +697,7 @@ func InfoServers(domain string) (*InfoLabSSL, error) {
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		logs.Log().Errorf("Error response body close %s ", err.Error())
-		return nil, err
+		return nil, upstreamError("ssllabs_unavailable", err)
 	}
 
 	//var result map[string]map[string]string
@@ -318,7 +706,7 @@ func InfoServers(domain string) (*InfoLabSSL, error) {
 	err = json.Unmarshal(body, &resultSSL)
 	if err != nil {
 		logs.Log().Errorf("Error unmarshal infoDomainSSL %s ", err.Error())
-		return nil, err
+		return nil, upstreamError("ssllabs_invalid_response", err)
 	}
 
 	if resultSSL["errors"] != nil {
@@ -331,15 +719,10 @@ func InfoServers(domain string) (*InfoLabSSL, error) {
 	err = json.Unmarshal(body, &infoDomainSSL)
 	if err != nil {
 		logs.Log().Errorf("Error unmarshal infoDomainSSL %s ", err.Error())
-		return nil, err
+		return nil, upstreamError("ssllabs_invalid_response", err)
 	}
 
-	fmt.Println("struct 1 info ssl-labs: ", infoDomainSSL)
-
-	if infoDomainSSL.Endpoints == nil {
-		logs.Log().Errorf("cannot found info servers %s", ErrInvalidServers.Error())
-		return nil, ErrInvalidServers
-	}
+	logs.Log().Debugf("struct 1 info ssl-labs: %v", infoDomainSSL)
 
 	return &infoDomainSSL, nil
 }
@@ -348,7 +731,7 @@ func InfoServers(domain string) (*InfoLabSSL, error) {
 func RunWHOIS(cmd string, args ...string) (string, error) {
 	value, err := exec.Command("bash", args...).Output() //nolint:gosec
 	if err != nil {
-		fmt.Printf("Failed to execute command: %s", err.Error())
+		logs.Log().Errorf("Failed to execute command: %s", err.Error())
 		return "", err
 	}
 
@@ -363,8 +746,8 @@ func getInfoWhois(ipAddress string) (*InfoWHOISCommand, error) {
 
 	value, err := RunWHOIS("bash", "-c", command)
 	if err != nil {
-		fmt.Printf("cannot extract country whois command %s", err.Error())
-		return nil, err
+		logs.Log().Errorf("cannot extract country whois command %s", err.Error())
+		return nil, upstreamError("whois_failed", err)
 	}
 
 	infoWhois.country = string(value)
@@ -373,8 +756,8 @@ func getInfoWhois(ipAddress string) (*InfoWHOISCommand, error) {
 
 	value, err = RunWHOIS("bash", "-c", command)
 	if err != nil {
-		fmt.Printf("cannot extract name whois command %s", err.Error())
-		return nil, err
+		logs.Log().Errorf("cannot extract name whois command %s", err.Error())
+		return nil, upstreamError("whois_failed", err)
 	}
 
 	infoWhois.owner = string(value)
@@ -382,16 +765,21 @@ func getInfoWhois(ipAddress string) (*InfoWHOISCommand, error) {
 	return infoWhois, nil
 }
 
-// parseJSON parse the data to return to the API
-func parseJSON(domain *models.Domain) *ParseDomainJSON {
-	parseDomain := new(ParseDomainJSON)
-
-	serversNumber := len(domain.Servers)
-
+// ParseJSON parse the data to return to the API
+func ParseJSON(domain *models.Domain) *ParseDomainJSON {
 	if len(domain.Servers) == 0 {
 		return nil
 	}
 
+	return NewParseDomainJSON(domain)
+}
+
+// NewParseDomainJSON parse the domain even when it does not have servers
+func NewParseDomainJSON(domain *models.Domain) *ParseDomainJSON {
+	parseDomain := &ParseDomainJSON{Servers: []*ParseServerJSON{}}
+
+	serversNumber := len(domain.Servers)
+
 	for i := 0; i < serversNumber; i++ {
 		parseServer := new(ParseServerJSON)
 		parseServer.Address = domain.Servers[i].Address
@@ -402,6 +790,7 @@ func parseJSON(domain *models.Domain) *ParseDomainJSON {
 	}
 
 	parseDomain.ServerChanged = domain.ServerChanged
+	parseDomain.LogoChanged = domain.LogoChanged
 	parseDomain.SSLGrade = domain.SSLGrade
 	parseDomain.PreviousSSLGrade = domain.PreviousSSLGrade
 	parseDomain.Logo = domain.Logo
@@ -416,7 +805,7 @@ func parseListJSON(domains []*models.Domain) []*ParseDomainJSON {
 	objects := []*ParseDomainJSON{}
 
 	for i := 0; i < len(domains); i++ {
-		parseDomain := parseJSON(domains[i])
+		parseDomain := ParseJSON(domains[i])
 		objects = append(objects, parseDomain)
 	}
 
@@ -2,11 +2,15 @@ package httphand
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"testing"
 	"time"
 
+	"github.com/other_project/crockroach/internal/apperr"
 	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/models"
 	"github.com/stretchr/testify/require"
 )
 
@@ -38,8 +42,8 @@ func TestGetStatusServer(t *testing.T) {
 	c.Equal(false, isdown)
 
 	isdown, err = GetStatusServer("googlee.com")
-	c.Error(err)
-	c.Equal(true, isdown)
+	c.NoError(err, "a site that does not answer is down")
+	c.Equal(false, isdown)
 }
 
 func TestGetInfoDomainPage(t *testing.T) {
@@ -51,7 +55,7 @@ func TestGetInfoDomainPage(t *testing.T) {
 	infoPage, err := GetInfoDomainPage("gitlab.com")
 	c.NoError(err)
 	c.NotEmpty(infoPage)
-	c.Equal("https://about.gitlab.com//ico/favicon-32x32.png", infoPage.Logo)
+	c.Equal("https://about.gitlab.com/ico/favicon-32x32.png", infoPage.Logo)
 	c.Equal("\nDevOps Platform Delivered as a Single Application\n|\nGitLab\n", infoPage.Title)
 
 	infoPage, err = GetInfoDomainPage("")
@@ -95,3 +99,70 @@ func TestRunWHOIS(t *testing.T) {
 	c.NoError(err)
 	c.NotEmpty(string(out))
 }
+
+func TestSSLLabsProgress(t *testing.T) {
+	c := require.New(t)
+
+	info := &InfoLabSSL{
+		Status: "IN_PROGRESS",
+		Endpoints: []*InfoLabSSLEndpoints{
+			{IPAddress: "10.0.0.1", StatusMessage: "Ready", Grade: "A"},
+			{IPAddress: "10.0.0.2", StatusMessage: "In progress", Progress: 50},
+			{IPAddress: "10.0.0.3", StatusMessage: "Pending", Progress: -1},
+		},
+	}
+
+	progress, endpoints := sslLabsProgress(info)
+	c.Equal(15+70*150/300, progress)
+	c.Len(endpoints, 3)
+	c.Equal(100, endpoints[0].Progress)
+	c.Equal(0, endpoints[2].Progress)
+	c.Equal("ssl labs: in progress", sslLabsMessage(info))
+
+	progress, endpoints = sslLabsProgress(&InfoLabSSL{Status: "DNS", StatusMessage: "Resolving domain names"})
+	c.Equal(15, progress)
+	c.Empty(endpoints)
+
+	progress, _ = sslLabsProgress(&InfoLabSSL{Status: "READY"})
+	c.Equal(85, progress)
+}
+
+func TestServerError(t *testing.T) {
+	c := require.New(t)
+
+	domain := &models.Domain{DomainName: "example.com"}
+
+	for _, values := range [][]string{{"", "US", "ACME"}, {"A", "", "ACME"}, {"A", "US", ""}} {
+		_, err := models.NewServer("10.0.0.1", values[0], values[1], values[2], domain)
+
+		classified := serverError(err)
+		c.True(errors.Is(classified, err))
+		c.Equal(apperr.Upstream, apperr.KindOf(classified))
+		c.Equal(apperr.CodeOf(err), apperr.CodeOf(classified))
+	}
+
+	c.Equal(context.Canceled, serverError(context.Canceled))
+}
+
+func TestReadsPage(t *testing.T) {
+	c := require.New(t)
+
+	cases := []struct {
+		code   int
+		health models.HealthCheck
+		reads  bool
+	}{
+		{0, models.HealthCheck{}, false},
+		{200, models.HealthCheck{}, true},
+		{301, models.HealthCheck{AcceptedStatus: []int{301}}, true},
+		{204, models.HealthCheck{AcceptedStatus: []int{204}}, false},
+		{401, models.HealthCheck{AcceptedStatus: []int{401}}, false},
+		{503, models.HealthCheck{Path: "/"}, false},
+		{401, models.HealthCheck{Path: "/healthz"}, true},
+		{204, models.HealthCheck{Method: http.MethodOptions}, true},
+	}
+
+	for _, tc := range cases {
+		c.Equal(tc.reads, readsPage(&models.UptimeCheck{StatusCode: tc.code}, tc.health), "%d %+v", tc.code, tc.health)
+	}
+}
@@ -0,0 +1,117 @@
+package httphand
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/other_project/crockroach/internal/problem"
+	"github.com/other_project/crockroach/internal/storage"
+	"github.com/other_project/crockroach/models"
+	"github.com/other_project/crockroach/shared/env"
+)
+
+var (
+	// DNSResolver is the server of the dns provider, empty uses the server of the system
+	DNSResolver = env.GetString("DNS_RESOLVER", "")
+)
+
+// DNSStore saves and lists the records resolved during the analyses
+type DNSStore interface {
+	StoreDNSSnapshot(ctx context.Context, snapshot *models.DNSSnapshot) (*models.DNSSnapshot, error)
+	GetLastDNSSnapshot(ctx context.Context, domainName string) (*models.DNSSnapshot, error)
+	ListDNSSnapshots(ctx context.Context, domainName string, limit, offset int64) ([]*models.DNSSnapshot, error)
+}
+
+// DNSSnapshotResponse is the records of a domain resolved during an analysis with the changes
+// since the previous snapshot
+type DNSSnapshotResponse struct {
+	ID         string             `json:"id"`
+	AnalysisID string             `json:"analysis_id"`
+	Domain     string             `json:"domain"`
+	Resolver   string             `json:"resolver"`
+	Records    models.DNSRecords  `json:"records"`
+	Errors     map[string]string  `json:"errors"`
+	Changed    bool               `json:"changed"`
+	Changes    []models.DNSChange `json:"changes"`
+	ResolvedAt time.Time          `json:"resolved_at"`
+}
+
+// DNSSnapshotList is a page of snapshots, the newest first
+type DNSSnapshotList struct {
+	Items  []*DNSSnapshotResponse `json:"items"`
+	Limit  int64                  `json:"limit"`
+	Offset int64                  `json:"offset"`
+}
+
+// NewDNSSnapshotResponse converts the snapshot to its v1 representation
+func NewDNSSnapshotResponse(snapshot *models.DNSSnapshot) *DNSSnapshotResponse {
+	res := &DNSSnapshotResponse{
+		ID:         snapshot.SnapshotID,
+		AnalysisID: snapshot.AnalysisID,
+		Domain:     snapshot.DomainName,
+		Resolver:   snapshot.Resolver,
+		Records:    snapshot.Records,
+		Errors:     snapshot.Errors,
+		Changed:    len(snapshot.Changes) > 0,
+		Changes:    snapshot.Changes,
+	}
+
+	if res.Errors == nil {
+		res.Errors = map[string]string{}
+	}
+
+	if res.Changes == nil {
+		res.Changes = []models.DNSChange{}
+	}
+
+	if snapshot.CreationDate != nil {
+		res.ResolvedAt = snapshot.CreationDate.UTC()
+	}
+
+	return res
+}
+
+// ListDNSSnapshots returns the records resolved for the domain, the newest first
+func (p *HandlerRequest) ListDNSSnapshots(w http.ResponseWriter, r *http.Request) {
+	domainName, err := models.NormalizeDomainName(chi.URLParam(r, "name"))
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	limit, offset, err := parsePagination(r)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	items, err := p.dns.ListDNSSnapshots(r.Context(), domainName, limit, offset)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	list := &DNSSnapshotList{Items: []*DNSSnapshotResponse{}, Limit: limit, Offset: offset}
+
+	for _, snapshot := range items {
+		list.Items = append(list.Items, NewDNSSnapshotResponse(snapshot))
+	}
+
+	respondwithJSON(w, http.StatusOK, list)
+}
+
+// storeDNSSnapshot compares the snapshot with the previous one of the domain and saves it
+func storeDNSSnapshot(ctx context.Context, store DNSStore, snapshot *models.DNSSnapshot, analysisID string) (*models.DNSSnapshot, error) {
+	previous, err := store.GetLastDNSSnapshot(ctx, snapshot.DomainName)
+	if err != nil && !errors.Is(err, storage.ErrDNSSnapshotNotFound) {
+		return nil, err
+	}
+
+	snapshot.AnalysisID = analysisID
+	snapshot.Changes = models.DiffDNS(previous, snapshot)
+
+	return store.StoreDNSSnapshot(ctx, snapshot)
+}
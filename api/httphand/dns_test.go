@@ -0,0 +1,123 @@
+package httphand
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/internal/storage"
+	"github.com/other_project/crockroach/models"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryDNS is an in memory DNSStore
+type memoryDNS struct {
+	snapshots []*models.DNSSnapshot
+}
+
+func (m *memoryDNS) StoreDNSSnapshot(ctx context.Context, snapshot *models.DNSSnapshot) (*models.DNSSnapshot, error) {
+	m.snapshots = append([]*models.DNSSnapshot{snapshot}, m.snapshots...)
+
+	return snapshot, nil
+}
+
+func (m *memoryDNS) GetLastDNSSnapshot(ctx context.Context, domainName string) (*models.DNSSnapshot, error) {
+	items, _ := m.ListDNSSnapshots(ctx, domainName, 1, 0)
+	if len(items) == 0 {
+		return nil, storage.ErrDNSSnapshotNotFound
+	}
+
+	return items[0], nil
+}
+
+func (m *memoryDNS) ListDNSSnapshots(ctx context.Context, domainName string, limit, offset int64) ([]*models.DNSSnapshot, error) {
+	items := []*models.DNSSnapshot{}
+
+	for _, snapshot := range m.snapshots {
+		if snapshot.DomainName == domainName {
+			items = append(items, snapshot)
+		}
+	}
+
+	if offset >= int64(len(items)) {
+		return []*models.DNSSnapshot{}, nil
+	}
+
+	items = items[offset:]
+	if int64(len(items)) > limit {
+		items = items[:limit]
+	}
+
+	return items, nil
+}
+
+func TestDNSSnapshots(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	spec := loadOpenAPI(c)
+	store := &memoryDNS{}
+
+	snapshot := func(addresses ...string) *models.DNSSnapshot {
+		now := time.Now()
+
+		return &models.DNSSnapshot{
+			SnapshotID: "5b0a3f3e-8a0c-4a4e-9d6b-1e2f3a4b5c6d", DomainName: "example.com", Resolver: "127.0.0.1:53",
+			Records:      models.DNSRecords{A: addresses, NS: []string{"ns1.example.com"}},
+			Errors:       map[string]string{},
+			CreationDate: &now,
+		}
+	}
+
+	first, err := storeDNSSnapshot(context.Background(), store, snapshot("192.0.2.1"), "6c1b4f4f-9b1d-4b5f-8e7c-2f3a4b5c6d7e")
+	c.NoError(err)
+	c.Empty(first.Changes, "the first snapshot does not have changes")
+	c.Equal("6c1b4f4f-9b1d-4b5f-8e7c-2f3a4b5c6d7e", first.AnalysisID)
+
+	second, err := storeDNSSnapshot(context.Background(), store, snapshot("192.0.2.2"), "7d2c5a5a-ac2e-4c6a-9f8d-3a4b5c6d7e8f")
+	c.NoError(err)
+	c.Equal([]models.DNSChange{{Type: models.DNSTypeA, Added: []string{"192.0.2.2"}, Removed: []string{"192.0.2.1"}}}, second.Changes)
+
+	handler := &HandlerRequest{dns: store}
+	mux := chi.NewMux()
+	mux.Get("/api/v1/domains/{name}/dns", handler.ListDNSSnapshots)
+
+	rec, body := getJSON(c, mux, "/api/v1/domains/Example.com/dns?limit=1")
+	c.Equal(http.StatusOK, rec.Code)
+	validateResponse(c, spec, spec.Components.Schemas["DNSSnapshotList"], body)
+
+	items := body.(map[string]interface{})["items"].([]interface{})
+	c.Len(items, 1)
+	c.Equal(true, items[0].(map[string]interface{})["changed"])
+
+	rec, body = getJSON(c, mux, "/api/v1/domains/other.com/dns")
+	c.Equal(http.StatusOK, rec.Code)
+	c.Empty(body.(map[string]interface{})["items"])
+
+	rec, _ = getJSON(c, mux, "/api/v1/domains/example.com/dns?limit=0")
+	c.Equal(http.StatusBadRequest, rec.Code)
+}
+
+func TestProcessDataDNS(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	// a resolver that does not listen makes every query fail without stopping the analysis
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	c.NoError(err)
+
+	server := conn.LocalAddr().String()
+	c.NoError(conn.Close())
+
+	opts := Options{Providers: []string{ProviderDNS}, Timeout: time.Second, DNSServer: server}
+
+	domain, err := ProcessDataWithOptions(context.Background(), "example.invalid", opts)
+	c.NoError(err)
+	c.True(domain.IsDown)
+	c.Equal(server, domain.DNS.Resolver)
+	c.Len(domain.DNS.Errors, len(models.DNSTypes))
+}
@@ -0,0 +1,157 @@
+package httphand
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/gofrs/uuid"
+	"github.com/other_project/crockroach/internal/apperr"
+	"github.com/other_project/crockroach/internal/jobs"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/internal/problem"
+	"github.com/other_project/crockroach/internal/storage"
+	"github.com/other_project/crockroach/models"
+	"github.com/other_project/crockroach/shared/env"
+)
+
+const (
+	// EventProgress is sent when the job changes its stage or progress
+	EventProgress = "progress"
+	// eventRetry is the time in milliseconds the browser waits before reconnecting
+	eventRetry = 1000
+)
+
+var (
+	// EventStreamDuration is the maximum duration of an event stream, it must be lower
+	// than the write timeout of the server. The browser reconnects when the stream ends
+	EventStreamDuration = time.Duration(env.GetInt64("EVENT_STREAM_SECONDS", 10)) * time.Second
+	// ErrStreamingUnsupported when the response cannot be flushed
+	ErrStreamingUnsupported = apperr.New(apperr.Internal, "streaming_unsupported", "the response does not support streaming")
+)
+
+// eventStream writes Server-Sent Events
+type eventStream struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+// send writes the value as the data of an event and flushes it
+func (s *eventStream) send(event string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, data)
+	if err != nil {
+		return err
+	}
+
+	s.flusher.Flush()
+
+	return nil
+}
+
+// JobEvents streams the progress of the job as Server-Sent Events until it finishes.
+// The last event is named after the final state and contains the job with its result
+func (p *HandlerRequest) JobEvents(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "id")
+
+	_, err := uuid.FromString(jobID)
+	if err != nil {
+		problem.Error(w, r, storage.ErrJobNotFound)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		problem.Error(w, r, ErrStreamingUnsupported)
+		return
+	}
+
+	events, unsubscribe := p.jobs.Subscribe(jobID)
+	defer unsubscribe()
+
+	job, err := p.jobs.Get(r.Context(), jobID)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	stream := &eventStream{w: w, flusher: flusher}
+
+	_, err = fmt.Fprintf(w, "retry: %d\n\n", eventRetry)
+	if err != nil {
+		return
+	}
+
+	if job.Finished() {
+		p.sendResult(r, stream, job)
+		return
+	}
+
+	err = stream.send(EventProgress, jobs.Event{
+		JobID:    job.JobID,
+		State:    job.State,
+		Stage:    job.State,
+		Progress: job.Progress,
+		Message:  job.Message,
+	})
+	if err != nil {
+		return
+	}
+
+	timer := time.NewTimer(EventStreamDuration)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-timer.C:
+			return
+		case event, ok := <-events:
+			if !ok {
+				job, err = p.jobs.Get(r.Context(), jobID)
+				if err != nil {
+					logs.Log().Errorf("cannot load the job %s: %s", jobID, err.Error())
+					return
+				}
+
+				p.sendResult(r, stream, job)
+
+				return
+			}
+
+			if event.State != models.JobRunning {
+				continue
+			}
+
+			if stream.send(EventProgress, event) != nil {
+				return
+			}
+		}
+	}
+}
+
+// sendResult writes the final event of the job, named after its state
+func (p *HandlerRequest) sendResult(r *http.Request, stream *eventStream, job *models.Job) {
+	res, err := p.jobResponse(r.Context(), job)
+	if err != nil {
+		logs.Log().Errorf("cannot load the result of the job %s: %s", job.JobID, err.Error())
+		res = NewJobResponse(job)
+	}
+
+	err = stream.send(job.State, res)
+	if err != nil {
+		logs.Log().Errorf("cannot send the result of the job %s: %s", job.JobID, err.Error())
+	}
+}
@@ -0,0 +1,111 @@
+package httphand
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/other_project/crockroach/internal/jobs"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/models"
+	"github.com/stretchr/testify/require"
+)
+
+// sseEvent is an event read from a stream
+type sseEvent struct {
+	name string
+	data map[string]interface{}
+}
+
+// readEvent returns the next event of the stream, the retry field is skipped
+func readEvent(c *require.Assertions, reader *bufio.Reader) sseEvent {
+	event := sseEvent{}
+
+	for {
+		line, err := reader.ReadString('\n')
+		c.NoError(err)
+
+		line = strings.TrimSuffix(line, "\n")
+
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			event.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			c.NoError(json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event.data))
+		case line == "" && event.name != "":
+			return event
+		}
+	}
+}
+
+func TestJobEvents(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	spec := loadOpenAPI(c)
+	analysis := newTestDomain(c, "example.com", "10.0.0.1")
+	start := make(chan struct{})
+
+	ctx, cancelfunc := context.WithCancel(context.Background())
+	defer cancelfunc()
+
+	mux := newJobsRouter(ctx, &memoryAnalyses{analyses: []*models.Domain{analysis}}, func(ctx context.Context, job *models.Job, report jobs.ProgressFunc) (string, error) {
+		<-start
+		report(jobs.Event{Stage: StageSSLLabs, Progress: 50, Message: "ssl labs: in progress", Endpoints: []*models.EndpointProgress{
+			{IPAddress: "10.0.0.1", StatusMessage: "In progress", Progress: 50},
+		}})
+
+		return analysis.DomainID, nil
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/api/v1/domains/example.com/analyses", "application/json", nil)
+	c.NoError(err)
+	c.NoError(resp.Body.Close())
+	c.Equal(http.StatusAccepted, resp.StatusCode)
+
+	location := resp.Header.Get("Location")
+
+	resp, err = http.Get(server.URL + location + "/events")
+	c.NoError(err)
+
+	defer func() {
+		c.NoError(resp.Body.Close())
+	}()
+
+	c.Equal(http.StatusOK, resp.StatusCode)
+	c.Equal("text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+
+	event := readEvent(c, reader)
+	c.Equal(EventProgress, event.name)
+	validateResponse(c, spec, spec.Components.Schemas["JobEvent"], event.data)
+
+	close(start)
+
+	event = readEvent(c, reader)
+	c.Equal(EventProgress, event.name)
+	validateResponse(c, spec, spec.Components.Schemas["JobEvent"], event.data)
+	c.Equal(StageSSLLabs, event.data["stage"])
+	c.Len(event.data["endpoints"], 1)
+
+	event = readEvent(c, reader)
+	c.Equal(models.JobDone, event.name)
+	validateResponse(c, spec, spec.Components.Schemas["Job"], event.data)
+	c.Equal(analysis.DomainID, event.data["result"].(map[string]interface{})["id"])
+
+	// a finished job sends its result at once
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, location+"/events", nil))
+	c.Equal(http.StatusOK, rec.Code)
+
+	event = readEvent(c, bufio.NewReader(rec.Body))
+	c.Equal(models.JobDone, event.name)
+}
@@ -0,0 +1,72 @@
+package httphand
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/other_project/crockroach/internal/export"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/internal/problem"
+)
+
+// streamWriter sends the response headers with the first byte of the body,
+// so an error found before streaming can still be answered with an error status
+type streamWriter struct {
+	w           http.ResponseWriter
+	contentType string
+	filename    string
+	started     bool
+}
+
+// Write implements io.Writer
+func (s *streamWriter) Write(b []byte) (int, error) {
+	if !s.started {
+		s.started = true
+		s.w.Header().Set("Content-Type", s.contentType)
+		s.w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, s.filename))
+		s.w.WriteHeader(http.StatusOK)
+	}
+
+	return s.w.Write(b)
+}
+
+// Export streams the stored analyses and their servers as CSV or NDJSON
+func (p *HandlerRequest) Export(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	format := query.Get("format")
+	if format == "" {
+		format = export.FormatCSV
+	}
+
+	if format != export.FormatCSV && format != export.FormatNDJSON {
+		problem.Error(w, r, fmt.Errorf("%w: format must be csv or ndjson", export.ErrUnknownFormat))
+		return
+	}
+
+	from, to, err := export.ParseRange(query.Get("from"), query.Get("to"), time.Now())
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	stream := &streamWriter{
+		w:           w,
+		contentType: export.ContentType(format),
+		filename:    fmt.Sprintf("domains.%s", format),
+	}
+
+	ctx, cancelfunc := context.WithCancel(r.Context())
+	defer cancelfunc()
+
+	count, err := export.Export(ctx, p.exportSource, format, from, to, stream)
+	if err != nil {
+		logs.Log().Errorf("cannot export the domains after %d rows: %s", count, err.Error())
+
+		if !stream.started {
+			problem.Error(w, r, err)
+		}
+	}
+}
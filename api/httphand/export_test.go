@@ -0,0 +1,65 @@
+package httphand
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/internal/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExport(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	var received time.Time
+
+	handler := &HandlerRequest{
+		exportSource: func(ctx context.Context, from, to time.Time, fn func(*storage.ExportRow) error) error {
+			received = from
+			return fn(&storage.ExportRow{DomainID: "d1", DomainName: "example.com", ServerID: "s1", Address: "10.0.0.1"})
+		},
+	}
+
+	rec := httptest.NewRecorder()
+	handler.Export(rec, httptest.NewRequest(http.MethodGet, "/export?format=ndjson&from=2026-10-01", nil))
+
+	c.Equal(http.StatusOK, rec.Code)
+	c.Equal("application/x-ndjson", rec.Header().Get("Content-Type"))
+	c.Contains(rec.Body.String(), `"domain_name":"example.com"`)
+	c.Equal(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), received)
+
+	rec = httptest.NewRecorder()
+	handler.Export(rec, httptest.NewRequest(http.MethodGet, "/export", nil))
+	c.Equal(http.StatusOK, rec.Code)
+	c.True(strings.HasPrefix(rec.Body.String(), "domain_id,domain_name"))
+}
+
+func TestExportFailure(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	handler := &HandlerRequest{
+		exportSource: func(ctx context.Context, from, to time.Time, fn func(*storage.ExportRow) error) error {
+			return errors.New("cannot query the database")
+		},
+	}
+
+	rec := httptest.NewRecorder()
+	handler.Export(rec, httptest.NewRequest(http.MethodGet, "/export?format=csv", nil))
+	c.Equal(http.StatusInternalServerError, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.Export(rec, httptest.NewRequest(http.MethodGet, "/export?format=xml", nil))
+	c.Equal(http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.Export(rec, httptest.NewRequest(http.MethodGet, "/export?from=tomorrow", nil))
+	c.Equal(http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,106 @@
+package httphand
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/other_project/crockroach/internal/apperr"
+	"github.com/other_project/crockroach/models"
+)
+
+var (
+	// ErrInvalidFreshness when refresh or max_age cannot be parsed
+	ErrInvalidFreshness = apperr.New(apperr.Invalid, "invalid_freshness", "refresh must be a boolean and max_age a number of seconds or a duration such as 1h")
+)
+
+// freshness is the age accepted by the client for a stored analysis
+type freshness struct {
+	refresh bool
+	maxAge  time.Duration
+	hasAge  bool
+}
+
+// parseFreshness reads the refresh and max_age query parameters
+func parseFreshness(r *http.Request) (*freshness, error) {
+	query := r.URL.Query()
+	f := new(freshness)
+
+	var err error
+
+	if value := query.Get("refresh"); value != "" {
+		f.refresh, err = strconv.ParseBool(value)
+		if err != nil {
+			return nil, ErrInvalidFreshness
+		}
+	}
+
+	if value := query.Get("max_age"); value != "" {
+		f.maxAge, err = parseMaxAge(value)
+		if err != nil {
+			return nil, ErrInvalidFreshness
+		}
+
+		f.hasAge = true
+	}
+
+	return f, nil
+}
+
+// parseMaxAge accepts a number of seconds or a duration
+func parseMaxAge(value string) (time.Duration, error) {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, nil
+	}
+
+	age, err := time.ParseDuration(value)
+	if err != nil || age < 0 {
+		return 0, ErrInvalidFreshness
+	}
+
+	return age, nil
+}
+
+// stale reports if the analysis must be replaced by a new one, nil analyses are stale
+// only when the client asked for a refresh or a maximum age
+func (f *freshness) stale(analysis *models.Domain, now time.Time) bool {
+	if f.refresh {
+		return true
+	}
+
+	if !f.hasAge {
+		return false
+	}
+
+	return analysis == nil || now.Sub(analysis.AnalyzedAt()) > f.maxAge
+}
+
+// analysisETag identifies the version of a stored analysis
+func analysisETag(analysis *models.Domain) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%s|%s|%t|%t", analysis.DomainID, analysis.AnalyzedAt().UnixNano(),
+		analysis.SSLGrade, analysis.PreviousSSLGrade, analysis.ServerChanged, analysis.LogoChanged)))
+
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// notModified reports if one of the If-None-Match tags is the current tag
+func notModified(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,32 @@
+package httphand
+
+import (
+	"github.com/other_project/crockroach/internal/geoip"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/models"
+	"github.com/other_project/crockroach/shared/env"
+)
+
+var (
+	// GeoIPCountryDB is the path of the MaxMind country database, empty skips it
+	GeoIPCountryDB = env.GetString("GEOIP_COUNTRY_DB", "")
+	// GeoIPCityDB is the path of the MaxMind city database, empty skips it
+	GeoIPCityDB = env.GetString("GEOIP_CITY_DB", "")
+	// GeoIPASNDB is the path of the MaxMind ASN database, empty skips it
+	GeoIPASNDB = env.GetString("GEOIP_ASN_DB", "")
+	// GeoIPDatabases are shared by every analysis, the files are reloaded when they change
+	GeoIPDatabases = geoip.New(GeoIPCountryDB, GeoIPCityDB, GeoIPASNDB)
+)
+
+// locateServer fills the geolocated country and the autonomous system of the server. A database
+// that cannot be read is logged and the fields it would provide stay empty
+func locateServer(databases *geoip.Databases, server *models.Server) {
+	location, err := databases.Lookup(server.Address)
+	if err != nil {
+		logs.Log().Errorf("cannot read the geoip databases for %s: %s", server.Address, err.Error())
+	}
+
+	server.GeoCountry = location.Country
+	server.ASN = location.ASN
+	server.ASNOrg = location.ASNOrg
+}
@@ -3,48 +3,130 @@ package httphand
 import (
 	"context"
 	"encoding/json"
-	"io/ioutil"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/other_project/crockroach/internal/apperr"
+	"github.com/other_project/crockroach/internal/export"
+	"github.com/other_project/crockroach/internal/importer"
+	"github.com/other_project/crockroach/internal/jobs"
 	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/internal/problem"
 	"github.com/other_project/crockroach/internal/storage"
+	"github.com/other_project/crockroach/models"
+	"github.com/other_project/crockroach/shared/env"
 )
 
 // NewHandlerRequest ...
 func NewHandlerRequest(store *storage.Store) *HandlerRequest {
-	return &HandlerRequest{
-		store: store,
+	handler := &HandlerRequest{
+		store:        store,
+		analyzeWith:  ProcessDataWithOptions,
+		probes:       newProbeCache(ProbeCacheTTL, ProbeCacheSize),
+		exportSource: storage.ExportDomains,
+		keys:         store,
+		analyses:     store,
+		tracked:      store,
+		rules:        store,
+		uptime:       store,
+		certificates: store,
+		dns:          store,
+		logos:        store,
+		pages:        store,
+		imports:      store,
 	}
+
+	handler.refresh = handler.analyzeAndStore
+	handler.saveAnalysis = handler.storeAnalysis
+	handler.jobs = jobs.NewRunner(store, handler.runJob, JobWorkers, JobQueueSize, JobTimeout)
+	handler.scheduler = jobs.NewScheduler(store, handler.jobs, SchedulerTick)
+
+	return handler
 }
 
+// Analyzer runs the analysis of a domain
+type Analyzer func(ctx context.Context, domainName string) (*models.Domain, error)
+
+var (
+	// MaxBodySize is the maximum size in bytes of a JSON request body
+	MaxBodySize = env.GetInt64("MAX_BODY_BYTES", 1<<16)
+	// ErrEmptyBody when the request does not have a body
+	ErrEmptyBody = apperr.New(apperr.Invalid, "empty_body", "the request body is empty")
+	// ErrBodyTooLarge when the request body exceeds MaxBodySize
+	ErrBodyTooLarge = apperr.New(apperr.TooLarge, "body_too_large", "the request body is too large")
+	// ErrTrailingData when the body contains more than one JSON value
+	ErrTrailingData = apperr.New(apperr.Invalid, "invalid_json", "the request body must contain a single JSON value")
+)
+
 // HandlerRequest ...
 type HandlerRequest struct {
-	store *storage.Store
+	store        *storage.Store
+	analyzeWith  OptionsAnalyzer
+	probes       *probeCache
+	exportSource export.Source
+	imports      ImportStore
+	keys         KeyStore
+	analyses     AnalysisStore
+	refresh      importer.ProcessFunc
+	saveAnalysis func(ctx context.Context, domain *models.Domain) (*models.Domain, error)
+	jobs         *jobs.Runner
+	scheduler    *jobs.Scheduler
+	tracked      TrackedStore
+	rules        RuleStore
+	uptime       UptimeStore
+	certificates CertificateStore
+	dns          DNSStore
+	logos        LogoStore
+	pages        PageStore
 }
 
 // RequestBody contain the information of body of the request
 type RequestBody struct {
-	DomainName string
+	DomainName string `json:"domainName"`
 }
 
 // Create a new domain
 func (p *HandlerRequest) Create(w http.ResponseWriter, r *http.Request) {
-	domainName := parseRequest(r, w)
+	var body RequestBody
+
+	err := decodeJSON(w, r, &body)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	domainName, err := models.NormalizeDomainName(body.DomainName)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
 
 	ctx, cancelfunc := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancelfunc()
 
-	_, err := p.store.ReloadRecord(ctx)
+	stored, err := p.analyzeAndStoreDomain(ctx, domainName, p.analyzeDomain)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "can't reload the last domains")
+		problem.Error(w, r, err)
 		return
 	}
 
-	domain, err := ProcessData(ctx, domainName)
+	// a site that is down is stored without servers, it is answered too
+	parseResponse := NewParseDomainJSON(stored)
+
+	respondwithJSON(w, http.StatusCreated, parseResponse)
+}
+
+// StoreAnalysis saves the domain, its servers, their certificates, its records, its page and its uptime check,
+// then it fills the previous grade and the servers changed attribute comparing with the last records
+func StoreAnalysis(ctx context.Context, store *storage.Store, domain *models.Domain) (*models.Domain, error) {
+	// the domain is saved with the address of the cached logo
+	err := storeLogo(ctx, store, domain)
 	if err != nil {
-		respondWithError(w, http.StatusConflict, "can't create the domain")
-		return
+		return nil, err
 	}
 
 	// reasignar el attributo Servers
@@ -52,18 +134,16 @@ func (p *HandlerRequest) Create(w http.ResponseWriter, r *http.Request) {
 		FromDomain: domain,
 	}
 
-	result1, err := p.store.TransferTxServers(r.Context(), argPre)
+	result1, err := store.TransferTxServers(ctx, argPre)
 	if err != nil {
-		respondWithError(w, http.StatusConflict, "error in create a server of the domain")
-		return
+		return nil, err
 	}
 
 	nDomain := result1.FromDomain
 
-	_, err = p.store.NewRecord(nDomain)
+	_, err = store.NewRecord(nDomain)
 	if err != nil {
-		respondWithError(w, http.StatusConflict, "error saving log domain")
-		return
+		return nil, err
 	}
 
 	// reasignar el attributo previoGradeSSL
@@ -71,21 +151,53 @@ func (p *HandlerRequest) Create(w http.ResponseWriter, r *http.Request) {
 		FromDomain: nDomain,
 	}
 
-	result2, err := p.store.TransferTxInitialize(ctx, argIni)
+	result2, err := store.TransferTxInitialize(ctx, argIni)
 	if err != nil {
-		respondWithError(w, http.StatusConflict, "error in create a server of the domain")
-		return
+		return nil, err
 	}
 
-	_, err = p.store.NewRecord(nDomain)
+	_, err = store.NewRecord(nDomain)
 	if err != nil {
-		respondWithError(w, http.StatusConflict, "error saving log domain")
-		return
+		return nil, err
 	}
 
-	parseResponse := parseJSON(result2.ToDomain)
+	stored := result2.ToDomain
 
-	respondwithJSON(w, http.StatusCreated, parseResponse)
+	if domain.Uptime != nil {
+		domain.Uptime.AnalysisID = stored.DomainID
+
+		stored.Uptime, err = store.StoreUptimeCheck(ctx, domain.Uptime)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if domain.DNS != nil {
+		stored.DNS, err = storeDNSSnapshot(ctx, store, domain.DNS, stored.DomainID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if domain.Page != nil {
+		stored.Page, err = storePageSnapshot(ctx, store, domain.Page, stored.DomainID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = storeCertificates(ctx, store, domain, stored.DomainID)
+	if err != nil {
+		return nil, err
+	}
+
+	// the rules look at the servers of the analysis, they carry the certificates
+	evaluated := *stored
+	evaluated.Servers = domain.Servers
+
+	evaluateRules(ctx, store, &evaluated)
+
+	return stored, nil
 }
 
 // RequestLastDomains get the last records
@@ -95,7 +207,7 @@ func (p *HandlerRequest) RequestLastDomains(w http.ResponseWriter, r *http.Reque
 
 	_, err := p.store.ReloadRecord(ctx)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "can't reload the last domains")
+		problem.Error(w, r, err)
 		return
 	}
 
@@ -123,26 +235,39 @@ func respondwithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	}
 }
 
-// respondwithError return error message
-func respondWithError(w http.ResponseWriter, code int, msg string) {
-	respondwithJSON(w, code, map[string]string{"message": msg})
-}
+// decodeJSON decodes the body of the request into v. The body must be a single JSON value
+// smaller than MaxBodySize without unknown fields
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	r.Body = http.MaxBytesReader(w, r.Body, MaxBodySize)
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
 
-// parseRequest extract the body of the request
-func parseRequest(r *http.Request, w http.ResponseWriter) string {
-	body, err := ioutil.ReadAll(r.Body)
+	err := decoder.Decode(v)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "can't read body")
-		return ""
-	}
+		var tooLarge *http.MaxBytesError
 
-	var reqBody RequestBody
+		switch {
+		case errors.Is(err, io.EOF):
+			return ErrEmptyBody
+		case errors.As(err, &tooLarge):
+			return bodyTooLargeError(tooLarge)
+		case strings.HasPrefix(err.Error(), "json: unknown field"):
+			// encoding/json does not have a type for the unknown fields
+			return apperr.Wrap(apperr.Invalid, "unknown_field", err)
+		}
 
-	err = json.Unmarshal(body, &reqBody)
-	if err != nil {
-		logs.Log().Errorf("Error Unmarshal request body: %s", err.Error())
-		return ""
+		return apperr.Wrap(apperr.Invalid, "invalid_json", err)
+	}
+
+	if decoder.More() {
+		return ErrTrailingData
 	}
 
-	return reqBody.DomainName
+	return nil
+}
+
+// bodyTooLargeError reports the limit that the body exceeded
+func bodyTooLargeError(err *http.MaxBytesError) error {
+	return fmt.Errorf("%w: the limit is %d bytes", ErrBodyTooLarge, err.Limit)
 }
@@ -0,0 +1,77 @@
+package httphand
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/internal/problem"
+	"github.com/other_project/crockroach/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateInvalidBody(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	handler := &HandlerRequest{}
+
+	cases := []struct {
+		body   string
+		status int
+		code   string
+	}{
+		{"", http.StatusBadRequest, "empty_body"},
+		{"{", http.StatusBadRequest, "invalid_json"},
+		{`{"domainName": 1}`, http.StatusBadRequest, "invalid_json"},
+		{`{"domain": "example.com"}`, http.StatusBadRequest, "unknown_field"},
+		{`{"domainName": "example.com"} {}`, http.StatusBadRequest, "invalid_json"},
+		{`{"domainName": "` + strings.Repeat("a", int(MaxBodySize)) + `"}`, http.StatusRequestEntityTooLarge, "body_too_large"},
+		{`{"domainName": ""}`, http.StatusUnprocessableEntity, "empty_domain_name"},
+		{`{"domainName": "exa mple.com"}`, http.StatusUnprocessableEntity, "invalid_domain_name"},
+	}
+
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		handler.Create(rec, httptest.NewRequest(http.MethodPost, "/domain", strings.NewReader(tc.body)))
+
+		c.Equal(tc.status, rec.Code, tc.body)
+		c.Equal(problem.ContentType, rec.Header().Get("Content-Type"))
+
+		p := new(problem.Problem)
+		c.NoError(json.Unmarshal(rec.Body.Bytes(), p))
+		c.Equal(tc.code, p.Code, tc.body)
+		c.Equal("/domain", p.Instance)
+	}
+}
+
+func TestCreateDownSite(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	handler := &HandlerRequest{
+		analyzeWith: func(ctx context.Context, domainName string, opts Options) (*models.Domain, error) {
+			return models.NewDomain(false, true, domainName, "", "", "unknown", "unknown")
+		},
+		saveAnalysis: func(ctx context.Context, domain *models.Domain) (*models.Domain, error) {
+			return domain, nil
+		},
+		tracked: &memoryTracked{},
+	}
+
+	rec := httptest.NewRecorder()
+	handler.Create(rec, httptest.NewRequest(http.MethodPost, "/domain", strings.NewReader(`{"domainName": "example.com"}`)))
+
+	c.Equal(http.StatusCreated, rec.Code)
+
+	// the site is stored without servers and it is still answered
+	var res ParseDomainJSON
+	c.NoError(json.Unmarshal(rec.Body.Bytes(), &res))
+	c.True(res.IsDown)
+	c.Equal([]*ParseServerJSON{}, res.Servers)
+	c.Equal("unknown", res.Title)
+}
@@ -0,0 +1,203 @@
+package httphand
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/gofrs/uuid"
+	"github.com/other_project/crockroach/internal/apperr"
+	"github.com/other_project/crockroach/internal/importer"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/internal/problem"
+	"github.com/other_project/crockroach/internal/ratelimit"
+	"github.com/other_project/crockroach/internal/storage"
+	"github.com/other_project/crockroach/models"
+	"github.com/other_project/crockroach/shared/env"
+)
+
+var (
+	// MaxImportSize is the maximum size in bytes of an uploaded import file
+	MaxImportSize = env.GetInt64("IMPORT_MAX_BYTES", 1<<20)
+)
+
+var (
+	// ErrMissingImportFile when the multipart field file is not in the request
+	ErrMissingImportFile = apperr.New(apperr.Invalid, "missing_import_file", "the multipart field file is missing")
+)
+
+// analyzeAndStore runs the analysis of the domain and saves the result
+func (p *HandlerRequest) analyzeAndStore(ctx context.Context, domainName string) error {
+	_, err := p.analyzeAndStoreDomain(ctx, domainName, p.analyzeDomain)
+	return err
+}
+
+// analyzeAndStoreDomain runs the analysis of the domain with analyze and returns the stored result
+func (p *HandlerRequest) analyzeAndStoreDomain(ctx context.Context, domainName string, analyze Analyzer) (*models.Domain, error) {
+	domain, err := analyze(ctx, domainName)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.saveAnalysis(ctx, domain)
+}
+
+// storeAnalysis saves the analysis, it is compared with the records of the last hour
+func (p *HandlerRequest) storeAnalysis(ctx context.Context, domain *models.Domain) (*models.Domain, error) {
+	_, err := p.store.ReloadRecord(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return StoreAnalysis(ctx, p.store, domain)
+}
+
+// ImportStore saves the imports, their progress is read from the jobs of their domains
+type ImportStore interface {
+	StoreImport(ctx context.Context, record *models.Import) (*models.Import, error)
+	GetImport(ctx context.Context, importID string) (*models.Import, error)
+	ListJobsByImport(ctx context.Context, importID string) ([]*models.Job, error)
+}
+
+// Import reads a list of domains from the multipart field "file", tracks them and queues a job
+// for every domain. Every domain is charged to the analyze quota of the client
+func (p *HandlerRequest) Import(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, MaxImportSize)
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		logs.Log().Errorf("cannot read import file: %s", err.Error())
+		problem.Error(w, r, importFileError(err))
+
+		return
+	}
+
+	defer func() {
+		if err := file.Close(); err != nil {
+			logs.Log().Errorf("Error close import file %s", err.Error())
+		}
+	}()
+
+	parsed, err := importer.Parse(file)
+	if err != nil {
+		problem.Error(w, r, apperr.Wrap(apperr.Invalid, "invalid_import_file", err))
+		return
+	}
+
+	if len(parsed.Domains) == 0 {
+		details := problem.FromError(r, importer.ErrNoDomains)
+		details.Errors = parsed.Errors
+		problem.Write(w, details)
+
+		return
+	}
+
+	if !ratelimit.Charge(w, r, len(parsed.Domains)) {
+		return
+	}
+
+	record, err := p.StartImport(r.Context(), parsed)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", "/domains/import/"+record.ID)
+	respondwithJSON(w, http.StatusAccepted, record)
+}
+
+// StartImport stores the import, tracks its domains and submits their jobs. The API and the CLI
+// import through it. The import is stored first so a tracked domain always has its import
+func (p *HandlerRequest) StartImport(ctx context.Context, parsed *importer.ParseResult) (*importer.Operation, error) {
+	if len(parsed.Domains) == 0 {
+		return nil, importer.ErrNoDomains
+	}
+
+	record, err := models.NewImport(len(parsed.Domains), parsed.Duplicates, parsed.Errors)
+	if err != nil {
+		return nil, err
+	}
+
+	record, err = p.imports.StoreImport(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, domainName := range parsed.Domains {
+		err = p.trackImported(ctx, domainName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	items, err := p.jobs.SubmitImport(ctx, record.ImportID, parsed.Domains)
+	if err != nil {
+		return nil, err
+	}
+
+	return importer.Summarize(record, items), nil
+}
+
+// trackImported adds the domain to the watchlist with the default settings, a tracked domain
+// keeps its settings. The import job is its first check, so the scheduler waits an interval
+func (p *HandlerRequest) trackImported(ctx context.Context, domainName string) error {
+	tracked, err := models.NewTrackedDomain(domainName)
+	if err != nil {
+		return err
+	}
+
+	tracked.LastCheckDate = tracked.CreationDate
+
+	_, err = p.tracked.StoreTrackedDomain(ctx, tracked)
+	if errors.Is(err, storage.ErrTrackedDomainExists) {
+		return nil
+	}
+
+	return err
+}
+
+// importFileError classifies the errors of reading the uploaded file
+func importFileError(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return bodyTooLargeError(tooLarge)
+	}
+
+	return fmt.Errorf("%w: %s", ErrMissingImportFile, err.Error())
+}
+
+// ImportStatus returns the progress of an import
+func (p *HandlerRequest) ImportStatus(w http.ResponseWriter, r *http.Request) {
+	importID := chi.URLParam(r, "id")
+
+	_, err := uuid.FromString(importID)
+	if err != nil {
+		problem.Error(w, r, storage.ErrImportNotFound)
+		return
+	}
+
+	op, err := p.ImportProgress(r.Context(), importID)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, op)
+}
+
+// ImportProgress returns the progress of a stored import from the jobs of its domains
+func (p *HandlerRequest) ImportProgress(ctx context.Context, importID string) (*importer.Operation, error) {
+	record, err := p.imports.GetImport(ctx, importID)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := p.imports.ListJobsByImport(ctx, importID)
+	if err != nil {
+		return nil, err
+	}
+
+	return importer.Summarize(record, items), nil
+}
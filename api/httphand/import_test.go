@@ -0,0 +1,246 @@
+package httphand
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/other_project/crockroach/internal/importer"
+	"github.com/other_project/crockroach/internal/jobs"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/internal/ratelimit"
+	"github.com/other_project/crockroach/internal/storage"
+	"github.com/other_project/crockroach/models"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryImports is an in memory ImportStore that reads the jobs of memoryJobs
+type memoryImports struct {
+	*memoryJobs
+	records map[string]models.Import
+	err     error
+}
+
+func (m *memoryImports) StoreImport(ctx context.Context, record *models.Import) (*models.Import, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	m.records[record.ImportID] = *record
+	copied := *record
+
+	return &copied, nil
+}
+
+func (m *memoryImports) GetImport(ctx context.Context, importID string) (*models.Import, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	record, ok := m.records[importID]
+	if !ok {
+		return nil, storage.ErrImportNotFound
+	}
+
+	return &record, nil
+}
+
+func (m *memoryImports) ListJobsByImport(ctx context.Context, importID string) ([]*models.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	items := []*models.Job{}
+
+	for _, job := range m.jobs {
+		if job.ImportID == importID {
+			copied := job
+			items = append(items, &copied)
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].CreationDate.Before(*items[j].CreationDate)
+	})
+
+	return items, nil
+}
+
+// newImportRequest builds a multipart request with the file content
+func newImportRequest(c *require.Assertions, content string) *http.Request {
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+
+	part, err := writer.CreateFormFile("file", "domains.txt")
+	c.NoError(err)
+
+	_, err = part.Write([]byte(content))
+	c.NoError(err)
+	c.NoError(writer.Close())
+
+	r := httptest.NewRequest(http.MethodPost, "/domains/import", body)
+	r.Header.Set("Content-Type", writer.FormDataContentType())
+
+	return r
+}
+
+// newImportRouter serves the import endpoints with in memory stores and jobs that run process
+func newImportRouter(ctx context.Context, tracked *memoryTracked, process jobs.ProcessFunc) *chi.Mux {
+	return newImportRouterWith(ctx, &memoryImports{
+		memoryJobs: &memoryJobs{jobs: make(map[string]models.Job)},
+		records:    make(map[string]models.Import),
+	}, tracked, process)
+}
+
+// newImportRouterWith serves the import endpoints with the imports store
+func newImportRouterWith(ctx context.Context, imports *memoryImports, tracked *memoryTracked, process jobs.ProcessFunc) *chi.Mux {
+	handler := &HandlerRequest{
+		imports: imports,
+		tracked: tracked,
+		jobs:    jobs.NewRunner(imports, process, 1, 10, time.Second),
+	}
+
+	handler.jobs.Start(ctx)
+
+	mux := chi.NewMux()
+	mux.Post("/domains/import", handler.Import)
+	mux.Get("/domains/import/{id}", handler.ImportStatus)
+
+	return mux
+}
+
+func TestImport(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	ctx, cancelfunc := context.WithCancel(context.Background())
+	defer cancelfunc()
+
+	tracked := &memoryTracked{domains: make(map[string]models.TrackedDomain)}
+	existing, err := models.NewTrackedDomain("example.org")
+	c.NoError(err)
+
+	existing.Tags = []string{"prod"}
+	tracked.domains[existing.DomainName] = *existing
+
+	processed := make(chan string, 10)
+	mux := newImportRouter(ctx, tracked, func(ctx context.Context, job *models.Job, report jobs.ProgressFunc) (string, error) {
+		processed <- job.DomainName
+		return "analysis-" + job.DomainName, nil
+	})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, newImportRequest(c, "example.com\nEXAMPLE.com\nexample.org\nnot valid\n"))
+	c.Equal(http.StatusAccepted, rec.Code)
+
+	op := new(importer.Operation)
+	c.NoError(json.Unmarshal(rec.Body.Bytes(), op))
+	c.Equal(2, op.Total)
+	c.Equal(1, op.Duplicates)
+	c.Len(op.LineErrors, 1)
+	c.Len(op.Items, 2)
+	c.NotEmpty(op.Items[0].JobID)
+	c.Equal("/domains/import/"+op.ID, rec.Header().Get("Location"))
+
+	c.ElementsMatch([]string{"example.com", "example.org"}, []string{<-processed, <-processed})
+
+	// the imported domains are tracked, the settings of a tracked domain are kept
+	c.Contains(tracked.domains, "example.com")
+	c.NotNil(tracked.domains["example.com"].LastCheckDate)
+	c.Equal([]string{"prod"}, tracked.domains["example.org"].Tags)
+
+	c.Eventually(func() bool {
+		rec = httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/domains/import/"+op.ID, nil))
+
+		status := new(importer.Operation)
+		_ = json.Unmarshal(rec.Body.Bytes(), status)
+
+		return rec.Code == http.StatusOK && status.State == importer.StateDone && status.Succeeded == 2
+	}, time.Second, 10*time.Millisecond)
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, newImportRequest(c, "nothing here\n"))
+	c.Equal(http.StatusUnprocessableEntity, rec.Code)
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/domains/import", nil))
+	c.Equal(http.StatusBadRequest, rec.Code)
+
+	limit := MaxImportSize
+	MaxImportSize = 64
+
+	defer func() { MaxImportSize = limit }()
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, newImportRequest(c, strings.Repeat("example.com\n", 20)))
+	c.Equal(http.StatusRequestEntityTooLarge, rec.Code)
+	c.Contains(rec.Body.String(), "the limit is 64 bytes")
+
+	for _, id := range []string{"unknown", "4a1f9e43-4bd2-4f7c-9d1e-2f4cb0b51a17"} {
+		rec = httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/domains/import/"+id, nil))
+		c.Equal(http.StatusNotFound, rec.Code)
+	}
+}
+
+func TestImportChargesEveryDomain(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	ctx, cancelfunc := context.WithCancel(context.Background())
+	defer cancelfunc()
+
+	tracked := &memoryTracked{domains: make(map[string]models.TrackedDomain)}
+	mux := newImportRouter(ctx, tracked, func(ctx context.Context, job *models.Job, report jobs.ProgressFunc) (string, error) {
+		return "analysis", nil
+	})
+
+	limiter := ratelimit.New(ratelimit.Policy{Name: "analyze", PerMinute: 60, Burst: 10, DailyQuota: 2})
+	handler := limiter.Deferred(mux)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, newImportRequest(c, "a.com\nb.com\nc.com\n"))
+	c.Equal(http.StatusTooManyRequests, rec.Code)
+	c.Empty(tracked.domains)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, newImportRequest(c, "a.com\nb.com\n"))
+	c.Equal(http.StatusAccepted, rec.Code)
+	c.Len(tracked.domains, 2)
+}
+
+func TestImportStoredBeforeTracking(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	ctx, cancelfunc := context.WithCancel(context.Background())
+	defer cancelfunc()
+
+	imports := &memoryImports{
+		memoryJobs: &memoryJobs{jobs: make(map[string]models.Job)},
+		records:    make(map[string]models.Import),
+		err:        storage.ErrInvalidQuery,
+	}
+
+	tracked := &memoryTracked{domains: make(map[string]models.TrackedDomain)}
+	mux := newImportRouterWith(ctx, imports, tracked, func(ctx context.Context, job *models.Job, report jobs.ProgressFunc) (string, error) {
+		return "analysis", nil
+	})
+
+	// the import could not be stored, its domains are not tracked
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, newImportRequest(c, "a.com\nb.com\n"))
+	c.Equal(http.StatusServiceUnavailable, rec.Code)
+	c.Empty(tracked.domains)
+	c.Empty(imports.jobs)
+}
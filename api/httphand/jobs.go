@@ -0,0 +1,162 @@
+package httphand
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/gofrs/uuid"
+	"github.com/other_project/crockroach/internal/jobs"
+	"github.com/other_project/crockroach/internal/problem"
+	"github.com/other_project/crockroach/internal/storage"
+	"github.com/other_project/crockroach/models"
+	"github.com/other_project/crockroach/shared/env"
+)
+
+var (
+	// JobWorkers is the number of analyses that run at the same time
+	JobWorkers = int(env.GetInt64("JOB_WORKERS", 2))
+	// JobQueueSize is the number of jobs that can wait for a worker
+	JobQueueSize = int(env.GetInt64("JOB_QUEUE_SIZE", 100))
+	// JobTimeout is the maximum duration of an asynchronous analysis
+	JobTimeout = time.Duration(env.GetInt64("JOB_TIMEOUT_SECONDS", 600)) * time.Second
+	// SSLLabsPollInterval is the time between the requests to SSL Labs while a job waits for the assessment
+	SSLLabsPollInterval = time.Duration(env.GetInt64("SSLLABS_POLL_SECONDS", 10)) * time.Second
+	// SchedulerEnabled analyzes the tracked domains again when their check interval elapses
+	SchedulerEnabled = env.GetBool("SCHEDULER_ENABLED", true)
+	// SchedulerTick is the time between the searches of tracked domains to analyze
+	SchedulerTick = time.Duration(env.GetInt64("SCHEDULER_TICK_SECONDS", 60)) * time.Second
+)
+
+// OptionsAnalyzer runs the analysis of a domain with the options
+type OptionsAnalyzer func(ctx context.Context, domainName string, opts Options) (*models.Domain, error)
+
+// JobError describes why a job failed
+type JobError struct {
+	Code   string `json:"code"`
+	Detail string `json:"detail"`
+}
+
+// JobResponse is an asynchronous analysis in the v1 API, Result is set when the job is done
+type JobResponse struct {
+	ID         string            `json:"id"`
+	Domain     string            `json:"domain"`
+	State      string            `json:"state"`
+	Progress   int               `json:"progress"`
+	Message    string            `json:"message"`
+	Error      *JobError         `json:"error,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+	StartedAt  *time.Time        `json:"started_at,omitempty"`
+	FinishedAt *time.Time        `json:"finished_at,omitempty"`
+	Result     *AnalysisResponse `json:"result,omitempty"`
+}
+
+// NewJobResponse converts the job to its v1 representation
+func NewJobResponse(job *models.Job) *JobResponse {
+	res := &JobResponse{
+		ID:         job.JobID,
+		Domain:     job.DomainName,
+		State:      job.State,
+		Progress:   job.Progress,
+		Message:    job.Message,
+		StartedAt:  job.StartedDate,
+		FinishedAt: job.FinishedDate,
+	}
+
+	if job.CreationDate != nil {
+		res.CreatedAt = job.CreationDate.UTC()
+	}
+
+	if job.State == models.JobFailed {
+		res.Error = &JobError{Code: job.ErrorCode, Detail: job.Error}
+	}
+
+	return res
+}
+
+// Start launches the background workers and the scheduler, the database must be available
+func (p *HandlerRequest) Start(ctx context.Context) {
+	p.jobs.Start(ctx)
+
+	if SchedulerEnabled {
+		p.scheduler.Start(ctx)
+	}
+}
+
+// runJob analyzes the domain of the job and stores the result
+func (p *HandlerRequest) runJob(ctx context.Context, job *models.Job, report jobs.ProgressFunc) (string, error) {
+	opts := p.trackedOptions(ctx, job.DomainName)
+	opts.PollInterval = SSLLabsPollInterval
+	opts.Report = report
+
+	analyze := func(ctx context.Context, domainName string) (*models.Domain, error) {
+		return p.analyzeWith(ctx, domainName, opts)
+	}
+
+	domain, err := p.analyzeAndStoreDomain(ctx, job.DomainName, analyze)
+	if err != nil {
+		return "", err
+	}
+
+	return domain.DomainID, nil
+}
+
+// SubmitAnalysis queues an analysis of the domain and returns the job
+func (p *HandlerRequest) SubmitAnalysis(w http.ResponseWriter, r *http.Request) {
+	domainName, err := models.NormalizeDomainName(chi.URLParam(r, "name"))
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	job, err := p.jobs.Submit(r.Context(), domainName)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", "/api/v1/jobs/"+job.JobID)
+	respondwithJSON(w, http.StatusAccepted, NewJobResponse(job))
+}
+
+// GetJob returns the state of a job and its result when it is done
+func (p *HandlerRequest) GetJob(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "id")
+
+	_, err := uuid.FromString(jobID)
+	if err != nil {
+		problem.Error(w, r, storage.ErrJobNotFound)
+		return
+	}
+
+	job, err := p.jobs.Get(r.Context(), jobID)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	res, err := p.jobResponse(r.Context(), job)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, res)
+}
+
+// jobResponse converts the job and loads its result when it is done
+func (p *HandlerRequest) jobResponse(ctx context.Context, job *models.Job) (*JobResponse, error) {
+	res := NewJobResponse(job)
+
+	if job.State == models.JobDone && job.AnalysisID != "" {
+		analysis, err := p.analyses.GetAnalysis(ctx, job.AnalysisID)
+		if err != nil {
+			return nil, err
+		}
+
+		res.Result = NewAnalysisResponse(analysis)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,154 @@
+package httphand
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/other_project/crockroach/internal/jobs"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/internal/storage"
+	"github.com/other_project/crockroach/models"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryJobs is an in memory jobs.Store
+type memoryJobs struct {
+	mu   sync.Mutex
+	jobs map[string]models.Job
+}
+
+func (m *memoryJobs) StoreJob(ctx context.Context, job *models.Job) (*models.Job, error) {
+	return m.UpdateJob(ctx, job)
+}
+
+func (m *memoryJobs) GetJob(ctx context.Context, jobID string) (*models.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[jobID]
+	if !ok {
+		return nil, storage.ErrJobNotFound
+	}
+
+	return &job, nil
+}
+
+func (m *memoryJobs) UpdateJob(ctx context.Context, job *models.Job) (*models.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.jobs[job.JobID] = *job
+	copied := *job
+
+	return &copied, nil
+}
+
+func (m *memoryJobs) ListJobsByState(ctx context.Context, states ...string) ([]*models.Job, error) {
+	return []*models.Job{}, nil
+}
+
+func newJobsRouter(ctx context.Context, analyses *memoryAnalyses, process jobs.ProcessFunc) *chi.Mux {
+	handler := &HandlerRequest{
+		analyses: analyses,
+		jobs:     jobs.NewRunner(&memoryJobs{jobs: make(map[string]models.Job)}, process, 1, 10, time.Second),
+	}
+
+	handler.jobs.Start(ctx)
+
+	mux := chi.NewMux()
+	mux.Post("/api/v1/domains/{name}/analyses", handler.SubmitAnalysis)
+	mux.Get("/api/v1/jobs/{id}", handler.GetJob)
+	mux.Get("/api/v1/jobs/{id}/events", handler.JobEvents)
+
+	return mux
+}
+
+func TestJobs(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	spec := loadOpenAPI(c)
+	analysis := newTestDomain(c, "example.com", "10.0.0.1")
+	analyses := &memoryAnalyses{analyses: []*models.Domain{analysis}}
+
+	ctx, cancelfunc := context.WithCancel(context.Background())
+	defer cancelfunc()
+
+	mux := newJobsRouter(ctx, analyses, func(ctx context.Context, job *models.Job, report jobs.ProgressFunc) (string, error) {
+		if job.DomainName == "fail.com" {
+			return "", storage.ErrEmptyServerByDomain
+		}
+
+		report(jobs.Event{Stage: StageSSLLabs, Progress: 50, Message: "analyzing"})
+
+		return analysis.DomainID, nil
+	})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/domains/EXAMPLE.com/analyses", nil))
+	c.Equal(http.StatusAccepted, rec.Code)
+
+	location := rec.Header().Get("Location")
+	c.Contains(location, "/api/v1/jobs/")
+
+	var body interface{}
+
+	c.Eventually(func() bool {
+		rec, body = getJSON(c, mux, location)
+		c.Equal(http.StatusOK, rec.Code)
+
+		return body.(map[string]interface{})["state"] == models.JobDone
+	}, time.Second, 5*time.Millisecond)
+
+	validateResponse(c, spec, spec.Components.Schemas["Job"], body)
+
+	job := body.(map[string]interface{})
+	c.Equal("example.com", job["domain"])
+	c.Equal(float64(100), job["progress"])
+	c.Equal(analysis.DomainID, job["result"].(map[string]interface{})["id"])
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/domains/fail.com/analyses", nil))
+	c.Equal(http.StatusAccepted, rec.Code)
+
+	location = rec.Header().Get("Location")
+
+	c.Eventually(func() bool {
+		rec, body = getJSON(c, mux, location)
+		return body.(map[string]interface{})["state"] == models.JobFailed
+	}, time.Second, 5*time.Millisecond)
+
+	validateResponse(c, spec, spec.Components.Schemas["Job"], body)
+	c.Nil(body.(map[string]interface{})["result"])
+	c.Equal("domain_without_servers", body.(map[string]interface{})["error"].(map[string]interface{})["code"])
+}
+
+func TestJobsErrors(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	spec := loadOpenAPI(c)
+	ctx, cancelfunc := context.WithCancel(context.Background())
+	defer cancelfunc()
+
+	mux := newJobsRouter(ctx, &memoryAnalyses{}, func(ctx context.Context, job *models.Job, report jobs.ProgressFunc) (string, error) {
+		return "", nil
+	})
+
+	rec, body := getJSON(c, mux, "/api/v1/jobs/not-an-id")
+	c.Equal(http.StatusNotFound, rec.Code)
+	validateResponse(c, spec, spec.Components.Schemas["Problem"], body)
+
+	rec, body = getJSON(c, mux, "/api/v1/jobs/6ba7b810-9dad-11d1-80b4-00c04fd430c8")
+	c.Equal(http.StatusNotFound, rec.Code)
+	validateResponse(c, spec, spec.Components.Schemas["Problem"], body)
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/domains/exa%20mple.com/analyses", nil))
+	c.Equal(http.StatusUnprocessableEntity, rec.Code)
+}
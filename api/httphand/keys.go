@@ -0,0 +1,96 @@
+package httphand
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/other_project/crockroach/internal/apperr"
+	"github.com/other_project/crockroach/internal/auth"
+	"github.com/other_project/crockroach/internal/problem"
+	"github.com/other_project/crockroach/models"
+)
+
+var (
+	// ErrRevokeCurrentKey when a key tries to revoke itself
+	ErrRevokeCurrentKey = apperr.New(apperr.Conflict, "revoke_current_key", "a key cannot revoke itself")
+)
+
+// KeyStore persists the API keys
+type KeyStore interface {
+	StoreAPIKey(ctx context.Context, key *models.APIKey) (*models.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]*models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, keyID string) error
+}
+
+// keyRequest is the body to create a key
+type keyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// keyResponse contains the created key and its secret, the secret cannot be retrieved again
+type keyResponse struct {
+	*models.APIKey
+	Secret string `json:"secret"`
+}
+
+// FindKey looks up a key by the hash of its secret, it is used by the authentication middleware
+func (p *HandlerRequest) FindKey(ctx context.Context, hash string) (*models.APIKey, error) {
+	return p.keys.GetAPIKeyByHash(ctx, hash)
+}
+
+// CreateKey creates a key with the name and scopes of the body
+func (p *HandlerRequest) CreateKey(w http.ResponseWriter, r *http.Request) {
+	var body keyRequest
+
+	err := decodeJSON(w, r, &body)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	key, secret, err := models.NewAPIKey(body.Name, body.Scopes)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	stored, err := p.keys.StoreAPIKey(r.Context(), key)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	respondwithJSON(w, http.StatusCreated, keyResponse{APIKey: stored, Secret: secret})
+}
+
+// ListKeys returns every key without their secrets
+func (p *HandlerRequest) ListKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := p.keys.ListAPIKeys(r.Context())
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, keys)
+}
+
+// RevokeKey revokes the key of the path, a key cannot revoke itself
+func (p *HandlerRequest) RevokeKey(w http.ResponseWriter, r *http.Request) {
+	keyID := chi.URLParam(r, "id")
+
+	if current := auth.KeyFromContext(r.Context()); current != nil && current.KeyID == keyID {
+		problem.Error(w, r, ErrRevokeCurrentKey)
+		return
+	}
+
+	err := p.keys.RevokeAPIKey(r.Context(), keyID)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,115 @@
+package httphand
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/other_project/crockroach/internal/auth"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/internal/storage"
+	"github.com/other_project/crockroach/models"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryKeys is an in memory KeyStore
+type memoryKeys struct {
+	keys []*models.APIKey
+}
+
+func (m *memoryKeys) StoreAPIKey(ctx context.Context, key *models.APIKey) (*models.APIKey, error) {
+	m.keys = append(m.keys, key)
+	return key, nil
+}
+
+func (m *memoryKeys) GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	for _, key := range m.keys {
+		if key.Hash == hash {
+			return key, nil
+		}
+	}
+
+	return nil, storage.ErrAPIKeyNotFound
+}
+
+func (m *memoryKeys) ListAPIKeys(ctx context.Context) ([]*models.APIKey, error) {
+	return m.keys, nil
+}
+
+func (m *memoryKeys) RevokeAPIKey(ctx context.Context, keyID string) error {
+	for _, key := range m.keys {
+		if key.KeyID == keyID && key.RevokedDate == nil {
+			now := time.Now()
+			key.RevokedDate = &now
+
+			return nil
+		}
+	}
+
+	return storage.ErrAPIKeyNotFound
+}
+
+func TestKeys(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	handler := &HandlerRequest{keys: &memoryKeys{}}
+	authenticator := auth.New(handler.FindKey, true, "bootstrap-secret")
+
+	mux := chi.NewMux()
+	mux.Route("/keys", func(r chi.Router) {
+		r.Use(authenticator.Require(models.ScopeAdmin))
+		r.Post("/", handler.CreateKey)
+		r.Get("/", handler.ListKeys)
+		r.Delete("/{id}", handler.RevokeKey)
+	})
+
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r.Header.Set("Authorization", "Bearer "+token)
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, r)
+
+		return rec
+	}
+
+	rec := do(http.MethodPost, "/keys", "bootstrap-secret", `{"name":"ci","scopes":["read"]}`)
+	c.Equal(http.StatusCreated, rec.Code)
+
+	created := struct {
+		KeyID  string   `json:"key_id"`
+		Scopes []string `json:"scopes"`
+		Secret string   `json:"secret"`
+	}{}
+	c.NoError(json.Unmarshal(rec.Body.Bytes(), &created))
+	c.Equal([]string{"read"}, created.Scopes)
+	c.NotEmpty(created.Secret)
+	c.NotContains(rec.Body.String(), models.HashAPIKey(created.Secret))
+
+	rec = do(http.MethodPost, "/keys", "bootstrap-secret", `{"name":"ci","scopes":["write"]}`)
+	c.Equal(http.StatusUnprocessableEntity, rec.Code)
+	c.Contains(rec.Body.String(), `"code":"invalid_scope"`)
+
+	rec = do(http.MethodPost, "/keys", "bootstrap-secret", `{"name":"ci","scope":["read"]}`)
+	c.Equal(http.StatusBadRequest, rec.Code)
+	c.Contains(rec.Body.String(), `"code":"unknown_field"`)
+
+	rec = do(http.MethodGet, "/keys", created.Secret, "")
+	c.Equal(http.StatusForbidden, rec.Code)
+
+	rec = do(http.MethodGet, "/keys", "bootstrap-secret", "")
+	c.Equal(http.StatusOK, rec.Code)
+	c.Contains(rec.Body.String(), created.KeyID)
+
+	rec = do(http.MethodDelete, "/keys/"+created.KeyID, "bootstrap-secret", "")
+	c.Equal(http.StatusNoContent, rec.Code)
+
+	rec = do(http.MethodDelete, "/keys/"+created.KeyID, "bootstrap-secret", "")
+	c.Equal(http.StatusNotFound, rec.Code)
+}
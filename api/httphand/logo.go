@@ -0,0 +1,84 @@
+package httphand
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/other_project/crockroach/internal/problem"
+	"github.com/other_project/crockroach/internal/storage"
+	"github.com/other_project/crockroach/models"
+	"github.com/other_project/crockroach/shared/env"
+)
+
+const (
+	// logoCacheControl lets the clients keep a logo forever, its content never changes for the hash
+	logoCacheControl = "public, max-age=31536000, immutable"
+	// logoSecurityPolicy stops the scripts of an svg logo opened as a document
+	logoSecurityPolicy = "default-src 'none'; style-src 'unsafe-inline'; sandbox"
+)
+
+var (
+	// LogoMaxBytes is the largest logo downloaded by the logo provider
+	LogoMaxBytes = env.GetInt64("LOGO_MAX_BYTES", 256<<10)
+	// LogoBaseURL is prepended to the path of the cached logos, empty keeps the path relative
+	LogoBaseURL = env.GetString("LOGO_BASE_URL", "")
+)
+
+// LogoStore saves and returns the logos downloaded during the analyses
+type LogoStore interface {
+	StoreLogo(ctx context.Context, logo *models.LogoImage) (*models.LogoImage, error)
+	GetLogo(ctx context.Context, hash string) (*models.LogoImage, error)
+}
+
+// GetLogo serves the logo with the hash of the path
+func (p *HandlerRequest) GetLogo(w http.ResponseWriter, r *http.Request) {
+	hash := chi.URLParam(r, "hash")
+	if !models.ValidLogoHash(hash) {
+		problem.Error(w, r, storage.ErrLogoNotFound)
+		return
+	}
+
+	logo, err := p.logos.GetLogo(r.Context(), hash)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	modified := time.Time{}
+	if logo.CreationDate != nil {
+		modified = *logo.CreationDate
+	}
+
+	w.Header().Set("Content-Type", logo.ContentType)
+	w.Header().Set("Cache-Control", logoCacheControl)
+	w.Header().Set("ETag", `"`+logo.Hash+`"`)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", logoSecurityPolicy)
+
+	http.ServeContent(w, r, "", modified, bytes.NewReader(logo.Content))
+}
+
+// storeLogo saves the logo downloaded by the analysis and points the logo of the domain to it
+func storeLogo(ctx context.Context, store LogoStore, domain *models.Domain) error {
+	if domain.LogoImage == nil {
+		return nil
+	}
+
+	logo, err := store.StoreLogo(ctx, domain.LogoImage)
+	if err != nil {
+		return err
+	}
+
+	domain.Logo = logoURL(logo.Hash)
+
+	return nil
+}
+
+// logoURL returns where the logo with the hash is served
+func logoURL(hash string) string {
+	return strings.TrimSuffix(LogoBaseURL, "/") + models.LogoPath + hash
+}
@@ -0,0 +1,110 @@
+package httphand
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/internal/storage"
+	"github.com/other_project/crockroach/models"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryLogos is an in memory LogoStore
+type memoryLogos struct {
+	logos map[string]*models.LogoImage
+}
+
+func (m *memoryLogos) StoreLogo(ctx context.Context, logo *models.LogoImage) (*models.LogoImage, error) {
+	if m.logos == nil {
+		m.logos = make(map[string]*models.LogoImage)
+	}
+
+	if stored, ok := m.logos[logo.Hash]; ok {
+		return stored, nil
+	}
+
+	now := time.Now()
+	logo.CreationDate = &now
+	m.logos[logo.Hash] = logo
+
+	return logo, nil
+}
+
+func (m *memoryLogos) GetLogo(ctx context.Context, hash string) (*models.LogoImage, error) {
+	logo, ok := m.logos[hash]
+	if !ok {
+		return nil, storage.ErrLogoNotFound
+	}
+
+	return logo, nil
+}
+
+func TestLogos(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	store := &memoryLogos{}
+	content := []byte("\x89PNG\r\n\x1a\nlogo")
+
+	domain := &models.Domain{DomainName: "example.com", Logo: "https://example.com/favicon.png"}
+	domain.LogoImage = models.NewLogoImage(domain.Logo, "image/png", content)
+
+	c.NoError(storeLogo(context.Background(), store, domain))
+	c.Equal("/logos/"+domain.LogoImage.Hash, domain.Logo)
+	c.Len(store.logos, 1)
+
+	// the same content is stored once
+	other := &models.Domain{DomainName: "example.org", LogoImage: models.NewLogoImage("https://example.org/icon.png", "image/png", content)}
+	c.NoError(storeLogo(context.Background(), store, other))
+	c.Equal(domain.Logo, other.Logo)
+	c.Len(store.logos, 1)
+
+	// without a downloaded logo the URL of the page stays
+	unknown := &models.Domain{DomainName: "example.net", Logo: UnknownInfo}
+	c.NoError(storeLogo(context.Background(), store, unknown))
+	c.Equal(UnknownInfo, unknown.Logo)
+
+	handler := &HandlerRequest{logos: store}
+	mux := chi.NewMux()
+	mux.Get("/logos/{hash}", handler.GetLogo)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, domain.Logo, nil))
+	c.Equal(http.StatusOK, rec.Code)
+	c.Equal(content, rec.Body.Bytes())
+	c.Equal("image/png", rec.Header().Get("Content-Type"))
+	c.Equal(logoCacheControl, rec.Header().Get("Cache-Control"))
+	c.Equal(`"`+domain.LogoImage.Hash+`"`, rec.Header().Get("ETag"))
+	c.Equal("nosniff", rec.Header().Get("X-Content-Type-Options"))
+
+	request := httptest.NewRequest(http.MethodGet, domain.Logo, nil)
+	request.Header.Set("If-None-Match", rec.Header().Get("ETag"))
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, request)
+	c.Equal(http.StatusNotModified, rec.Code)
+	c.Empty(rec.Body.Bytes())
+
+	for _, path := range []string{"/logos/not-a-hash", "/logos/" + models.NewLogoImage("", "image/png", []byte("other")).Hash} {
+		rec = httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		c.Equal(http.StatusNotFound, rec.Code, path)
+	}
+}
+
+func TestLogoURL(t *testing.T) {
+	c := require.New(t)
+
+	defer func(base string) { LogoBaseURL = base }(LogoBaseURL)
+
+	LogoBaseURL = "https://api.example.com/"
+	c.Equal("https://api.example.com/logos/abc", logoURL("abc"))
+
+	LogoBaseURL = ""
+	c.Equal("/logos/abc", logoURL("abc"))
+}
@@ -0,0 +1,855 @@
+package httphand
+
+import (
+	"net/http"
+)
+
+// OpenAPIDocument describes the v1 API, the schemas must match the json tags of
+// AnalysisResponse, ServerResponse, AnalysisList, CreateAnalysisRequest and problem.Problem
+const OpenAPIDocument = `{
+  "openapi": "3.0.3",
+  "info": {
+    "title": "crockroach",
+    "version": "1.0.0",
+    "description": "SSL grades, servers and page information of the analyzed domains."
+  },
+  "servers": [{"url": "/api/v1"}],
+  "security": [{"bearer": []}],
+  "paths": {
+    "/openapi.json": {
+      "get": {
+        "summary": "This document",
+        "security": [],
+        "responses": {
+          "200": {"description": "OpenAPI document", "content": {"application/json": {}}}
+        }
+      }
+    },
+    "/domains": {
+      "get": {
+        "summary": "Tracked domains with their last stored analysis",
+        "parameters": [
+          {"$ref": "#/components/parameters/Tag"},
+          {"$ref": "#/components/parameters/Limit"},
+          {"$ref": "#/components/parameters/Offset"}
+        ],
+        "responses": {
+          "200": {"description": "Tracked domains", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/TrackedDomainList"}}}},
+          "default": {"$ref": "#/components/responses/Problem"}
+        }
+      },
+      "post": {
+        "summary": "Analyze a domain and store the result",
+        "requestBody": {
+          "required": true,
+          "content": {"application/json": {"schema": {"$ref": "#/components/schemas/CreateAnalysisRequest"}}}
+        },
+        "responses": {
+          "201": {"description": "Stored analysis", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Analysis"}}}},
+          "default": {"$ref": "#/components/responses/Problem"}
+        }
+      }
+    },
+    "/domains/{name}": {
+      "parameters": [{"$ref": "#/components/parameters/DomainName"}],
+      "get": {
+        "summary": "Latest stored analysis of the domain",
+        "description": "When refresh is true or the stored analysis is older than max_age a job analyzes the domain again, which requires the analyze scope. The stored analysis is answered at once with a Link to the job, or the job when the domain has no analysis; the job is shared with the pending analysis of the domain.",
+        "parameters": [
+          {"name": "refresh", "in": "query", "schema": {"type": "boolean", "default": false}},
+          {"name": "max_age", "in": "query", "description": "Seconds or a duration such as 1h", "schema": {"type": "string", "example": "3600"}},
+          {"name": "If-None-Match", "in": "header", "schema": {"type": "string"}}
+        ],
+        "responses": {
+          "200": {
+            "description": "Analysis",
+            "headers": {
+              "ETag": {"schema": {"type": "string"}},
+              "Last-Modified": {"description": "Time of the analysis", "schema": {"type": "string"}},
+              "Link": {"description": "The job that replaces a stale analysis, with rel=monitor", "schema": {"type": "string"}}
+            },
+            "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Analysis"}}}
+          },
+          "202": {
+            "description": "The domain has no analysis, the job analyzes it",
+            "headers": {"Location": {"schema": {"type": "string"}}},
+            "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Job"}}}
+          },
+          "304": {"description": "The analysis did not change since the ETag of If-None-Match"},
+          "default": {"$ref": "#/components/responses/Problem"}
+        }
+      }
+    },
+    "/domains/{name}/analyses": {
+      "parameters": [{"$ref": "#/components/parameters/DomainName"}],
+      "get": {
+        "summary": "Stored analyses of the domain, the newest first",
+        "parameters": [
+          {"$ref": "#/components/parameters/Limit"},
+          {"$ref": "#/components/parameters/Offset"}
+        ],
+        "responses": {
+          "200": {"description": "Analyses", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/AnalysisList"}}}},
+          "default": {"$ref": "#/components/responses/Problem"}
+        }
+      },
+      "post": {
+        "summary": "Queue an analysis of the domain",
+        "description": "The domain is analyzed in background, the job of a domain that is already being analyzed is returned.",
+        "responses": {
+          "202": {
+            "description": "Job",
+            "headers": {"Location": {"schema": {"type": "string"}}},
+            "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Job"}}}
+          },
+          "default": {"$ref": "#/components/responses/Problem"}
+        }
+      }
+    },
+    "/domains/{name}/uptime": {
+      "parameters": [{"$ref": "#/components/parameters/DomainName"}],
+      "get": {
+        "summary": "Uptime percentage and latency percentiles over the last 24 hours, 7 days and 30 days",
+        "responses": {
+          "200": {"description": "Uptime", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Uptime"}}}},
+          "default": {"$ref": "#/components/responses/Problem"}
+        }
+      }
+    },
+    "/domains/{name}/dns": {
+      "parameters": [{"$ref": "#/components/parameters/DomainName"}],
+      "get": {
+        "summary": "DNS records resolved during the analyses with the changes since the previous snapshot, the newest first",
+        "parameters": [{"$ref": "#/components/parameters/Limit"}, {"$ref": "#/components/parameters/Offset"}],
+        "responses": {
+          "200": {"description": "DNS snapshots", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/DNSSnapshotList"}}}},
+          "default": {"$ref": "#/components/responses/Problem"}
+        }
+      }
+    },
+    "/domains/{name}/pages": {
+      "parameters": [{"$ref": "#/components/parameters/DomainName"}],
+      "get": {
+        "summary": "Metadata and text hash of the page read during the analyses with the changes since the previous snapshot, the newest first",
+        "parameters": [{"$ref": "#/components/parameters/Limit"}, {"$ref": "#/components/parameters/Offset"}],
+        "responses": {
+          "200": {"description": "Page snapshots", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/PageSnapshotList"}}}},
+          "default": {"$ref": "#/components/responses/Problem"}
+        }
+      }
+    },
+    "/domains/{name}/pages/diff": {
+      "parameters": [{"$ref": "#/components/parameters/DomainName"}],
+      "get": {
+        "summary": "Lines of visible text that changed between two snapshots of the page, the two newest without from and to",
+        "parameters": [
+          {"name": "from", "in": "query", "description": "Older snapshot, required with to", "schema": {"type": "string", "format": "uuid"}},
+          {"name": "to", "in": "query", "description": "Newer snapshot, required with from", "schema": {"type": "string", "format": "uuid"}}
+        ],
+        "responses": {
+          "200": {"description": "Text diff", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/PageDiff"}}}},
+          "default": {"$ref": "#/components/responses/Problem"}
+        }
+      }
+    },
+    "/jobs/{id}": {
+      "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string", "format": "uuid"}}],
+      "get": {
+        "summary": "State of an analysis job, the result is included when it is done",
+        "responses": {
+          "200": {"description": "Job", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Job"}}}},
+          "default": {"$ref": "#/components/responses/Problem"}
+        }
+      }
+    },
+    "/jobs/{id}/events": {
+      "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string", "format": "uuid"}}],
+      "get": {
+        "summary": "Progress of an analysis job as Server-Sent Events",
+        "description": "Every progress event contains a JobEvent. The last event is named done or failed and contains the Job with its result, the client must close the connection after it. The stream ends after a few seconds and the browser reconnects. EventSource cannot send an Authorization header, so the browsers pass a token of POST /jobs/{id}/events/token in the access_token parameter instead.",
+        "parameters": [{"name": "access_token", "in": "query", "description": "Stream token of the job, checked when the stream is opened", "schema": {"type": "string"}}],
+        "responses": {
+          "200": {"description": "Event stream", "content": {"text/event-stream": {"schema": {"type": "string"}}}},
+          "default": {"$ref": "#/components/responses/Problem"}
+        }
+      }
+    },
+    "/jobs/{id}/events/token": {
+      "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string", "format": "uuid"}}],
+      "post": {
+        "summary": "Short-lived token to open the event stream of the job without an Authorization header",
+        "responses": {
+          "201": {"description": "Stream token", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/StreamToken"}}}},
+          "default": {"$ref": "#/components/responses/Problem"}
+        }
+      }
+    },
+    "/tracked-domains": {
+      "get": {
+        "summary": "Settings of the tracked domains ordered by name",
+        "parameters": [
+          {"$ref": "#/components/parameters/Tag"},
+          {"$ref": "#/components/parameters/Limit"},
+          {"$ref": "#/components/parameters/Offset"}
+        ],
+        "responses": {
+          "200": {"description": "Tracked domains", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/TrackedDomainList"}}}},
+          "default": {"$ref": "#/components/responses/Problem"}
+        }
+      },
+      "post": {
+        "summary": "Track a domain, it is analyzed again every check_interval seconds while it is enabled",
+        "requestBody": {
+          "required": true,
+          "content": {"application/json": {"schema": {"$ref": "#/components/schemas/TrackedDomainRequest"}}}
+        },
+        "responses": {
+          "201": {
+            "description": "Tracked domain",
+            "headers": {"Location": {"schema": {"type": "string"}}},
+            "content": {"application/json": {"schema": {"$ref": "#/components/schemas/TrackedDomain"}}}
+          },
+          "default": {"$ref": "#/components/responses/Problem"}
+        }
+      }
+    },
+    "/tracked-domains/{name}": {
+      "parameters": [{"$ref": "#/components/parameters/DomainName"}],
+      "get": {
+        "summary": "Settings of a tracked domain and its last stored analysis",
+        "responses": {
+          "200": {"description": "Tracked domain", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/TrackedDomain"}}}},
+          "default": {"$ref": "#/components/responses/Problem"}
+        }
+      },
+      "patch": {
+        "summary": "Change the settings present in the body",
+        "requestBody": {
+          "required": true,
+          "content": {"application/json": {"schema": {"$ref": "#/components/schemas/TrackedDomainRequest"}}}
+        },
+        "responses": {
+          "200": {"description": "Tracked domain", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/TrackedDomain"}}}},
+          "default": {"$ref": "#/components/responses/Problem"}
+        }
+      },
+      "delete": {
+        "summary": "Stop tracking the domain, its analyses are kept",
+        "responses": {
+          "204": {"description": "The domain is not tracked"},
+          "default": {"$ref": "#/components/responses/Problem"}
+        }
+      }
+    },
+    "/rules": {
+      "get": {
+        "summary": "Alert rules ordered by creation",
+        "parameters": [
+          {"$ref": "#/components/parameters/Limit"},
+          {"$ref": "#/components/parameters/Offset"}
+        ],
+        "responses": {
+          "200": {"description": "Rules", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/RuleList"}}}},
+          "default": {"$ref": "#/components/responses/Problem"}
+        }
+      },
+      "post": {
+        "summary": "Create an alert rule, it is evaluated after every analysis of its domains",
+        "requestBody": {
+          "required": true,
+          "content": {"application/json": {"schema": {"$ref": "#/components/schemas/RuleRequest"}}}
+        },
+        "responses": {
+          "201": {
+            "description": "Rule",
+            "headers": {"Location": {"schema": {"type": "string"}}},
+            "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Rule"}}}
+          },
+          "default": {"$ref": "#/components/responses/Problem"}
+        }
+      }
+    },
+    "/rules/{id}": {
+      "parameters": [{"$ref": "#/components/parameters/RuleID"}],
+      "get": {
+        "summary": "Alert rule",
+        "responses": {
+          "200": {"description": "Rule", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Rule"}}}},
+          "default": {"$ref": "#/components/responses/Problem"}
+        }
+      },
+      "patch": {
+        "summary": "Change the settings present in the body, the kind cannot change",
+        "requestBody": {
+          "required": true,
+          "content": {"application/json": {"schema": {"$ref": "#/components/schemas/RuleRequest"}}}
+        },
+        "responses": {
+          "200": {"description": "Rule", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Rule"}}}},
+          "default": {"$ref": "#/components/responses/Problem"}
+        }
+      },
+      "delete": {
+        "summary": "Delete the rule, its evaluations and alerts are kept",
+        "responses": {
+          "204": {"description": "The rule was deleted"},
+          "default": {"$ref": "#/components/responses/Problem"}
+        }
+      }
+    },
+    "/rules/{id}/evaluations": {
+      "parameters": [{"$ref": "#/components/parameters/RuleID"}],
+      "get": {
+        "summary": "Results of the rule, the newest first",
+        "parameters": [
+          {"$ref": "#/components/parameters/Limit"},
+          {"$ref": "#/components/parameters/Offset"}
+        ],
+        "responses": {
+          "200": {"description": "Evaluations", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/RuleEvaluationList"}}}},
+          "default": {"$ref": "#/components/responses/Problem"}
+        }
+      }
+    },
+    "/alerts": {
+      "get": {
+        "summary": "Rules that started firing, the newest first",
+        "parameters": [
+          {"name": "domain", "in": "query", "description": "Only the alerts of the domain", "schema": {"type": "string"}},
+          {"$ref": "#/components/parameters/Limit"},
+          {"$ref": "#/components/parameters/Offset"}
+        ],
+        "responses": {
+          "200": {"description": "Alerts", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/AlertList"}}}},
+          "default": {"$ref": "#/components/responses/Problem"}
+        }
+      }
+    },
+    "/servers/{id}": {
+      "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string", "format": "uuid"}}],
+      "get": {
+        "summary": "Server of an analysis",
+        "responses": {
+          "200": {"description": "Server", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Server"}}}},
+          "default": {"$ref": "#/components/responses/Problem"}
+        }
+      }
+    },
+    "/servers/{id}/certificate": {
+      "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string", "format": "uuid"}}],
+      "get": {
+        "summary": "Certificate chain presented by the server, requires the tls provider",
+        "responses": {
+          "200": {"description": "Certificate", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/ServerCertificate"}}}},
+          "default": {"$ref": "#/components/responses/Problem"}
+        }
+      }
+    }
+  },
+  "components": {
+    "securitySchemes": {
+      "bearer": {"type": "http", "scheme": "bearer"}
+    },
+    "parameters": {
+      "DomainName": {"name": "name", "in": "path", "required": true, "schema": {"type": "string", "example": "example.com"}},
+      "Tag": {"name": "tag", "in": "query", "description": "Only the domains with the tag", "schema": {"type": "string"}},
+      "Limit": {"name": "limit", "in": "query", "schema": {"type": "integer", "minimum": 1, "maximum": 100, "default": 20}},
+      "Offset": {"name": "offset", "in": "query", "schema": {"type": "integer", "minimum": 0, "default": 0}},
+      "RuleID": {"name": "id", "in": "path", "required": true, "schema": {"type": "string", "format": "uuid"}}
+    },
+    "responses": {
+      "Problem": {
+        "description": "Error",
+        "content": {"application/problem+json": {"schema": {"$ref": "#/components/schemas/Problem"}}}
+      }
+    },
+    "schemas": {
+      "CreateAnalysisRequest": {
+        "type": "object",
+        "required": ["domain"],
+        "additionalProperties": false,
+        "properties": {
+          "domain": {"type": "string", "example": "example.com"}
+        }
+      },
+      "Server": {
+        "type": "object",
+        "required": ["id", "analysis_id", "address", "family", "ssl_grade", "country", "owner", "geo_country", "asn", "asn_org", "analyzed_at"],
+        "properties": {
+          "id": {"type": "string", "format": "uuid"},
+          "analysis_id": {"type": "string", "format": "uuid"},
+          "address": {"type": "string"},
+          "family": {"type": "string", "description": "Address family, empty when the address is not an IP", "enum": ["ipv4", "ipv6", ""]},
+          "ssl_grade": {"type": "string"},
+          "country": {"type": "string"},
+          "owner": {"type": "string"},
+          "geo_country": {"type": "string", "description": "Country of the GeoIP databases, empty when it is unknown"},
+          "asn": {"type": "integer", "description": "Autonomous system number, 0 when it is unknown"},
+          "asn_org": {"type": "string", "description": "Organization of the autonomous system"},
+          "analyzed_at": {"type": "string", "format": "date-time"}
+        }
+      },
+      "Analysis": {
+        "type": "object",
+        "required": ["id", "domain", "ssl_grade", "previous_ssl_grade", "servers_changed", "logo_changed", "is_down", "title", "logo", "analyzed_at", "servers"],
+        "properties": {
+          "id": {"type": "string", "format": "uuid"},
+          "domain": {"type": "string"},
+          "ssl_grade": {"type": "string"},
+          "previous_ssl_grade": {"type": "string"},
+          "servers_changed": {"type": "boolean"},
+          "logo_changed": {"type": "boolean"},
+          "is_down": {"type": "boolean"},
+          "title": {"type": "string"},
+          "logo": {"type": "string"},
+          "analyzed_at": {"type": "string", "format": "date-time"},
+          "servers": {"type": "array", "items": {"$ref": "#/components/schemas/Server"}},
+          "dual_stack": {"$ref": "#/components/schemas/DualStack"}
+        }
+      },
+      "DualStack": {
+        "type": "object",
+        "description": "Parity between the IPv4 and the IPv6 servers, omitted without servers",
+        "required": ["ipv4", "ipv6", "ipv4_grade", "ipv6_grade", "issues"],
+        "properties": {
+          "ipv4": {"type": "integer", "description": "Number of IPv4 servers"},
+          "ipv6": {"type": "integer", "description": "Number of IPv6 servers"},
+          "ipv4_grade": {"type": "string", "description": "Lowest known grade of the IPv4 servers"},
+          "ipv6_grade": {"type": "string", "description": "Lowest known grade of the IPv6 servers"},
+          "issues": {"type": "array", "description": "Empty when the families match", "items": {"type": "string", "enum": ["ipv6_missing", "grade_mismatch", "certificate_mismatch"]}}
+        }
+      },
+      "AnalysisList": {
+        "type": "object",
+        "required": ["items", "limit", "offset"],
+        "properties": {
+          "items": {"type": "array", "items": {"$ref": "#/components/schemas/Analysis"}},
+          "limit": {"type": "integer"},
+          "offset": {"type": "integer"}
+        }
+      },
+      "JobError": {
+        "type": "object",
+        "required": ["code", "detail"],
+        "properties": {
+          "code": {"type": "string"},
+          "detail": {"type": "string"}
+        }
+      },
+      "Job": {
+        "type": "object",
+        "required": ["id", "domain", "state", "progress", "message", "created_at"],
+        "properties": {
+          "id": {"type": "string", "format": "uuid"},
+          "domain": {"type": "string"},
+          "state": {"type": "string", "enum": ["queued", "running", "done", "failed"]},
+          "progress": {"type": "integer", "minimum": 0, "maximum": 100},
+          "message": {"type": "string"},
+          "error": {"$ref": "#/components/schemas/JobError"},
+          "created_at": {"type": "string", "format": "date-time"},
+          "started_at": {"type": "string", "format": "date-time"},
+          "finished_at": {"type": "string", "format": "date-time"},
+          "result": {"$ref": "#/components/schemas/Analysis"}
+        }
+      },
+      "StreamToken": {
+        "type": "object",
+        "required": ["token", "expires_at"],
+        "properties": {
+          "token": {"type": "string"},
+          "expires_at": {"type": "string", "format": "date-time"}
+        }
+      },
+      "TrackedDomainRequest": {
+        "type": "object",
+        "additionalProperties": false,
+        "properties": {
+          "domain": {"type": "string", "description": "Required to track a domain, it cannot change", "example": "example.com"},
+          "check_interval": {"type": "integer", "minimum": 300, "maximum": 2592000, "default": 3600},
+          "enabled": {"type": "boolean", "default": true},
+          "tags": {"type": "array", "maxItems": 20, "items": {"type": "string", "pattern": "^[a-z0-9][a-z0-9_.-]{0,31}$"}},
+          "notification_targets": {"type": "array", "maxItems": 10, "items": {"type": "string", "example": "mailto:ops@example.com"}},
+          "providers": {"type": "array", "items": {"type": "string", "enum": ["ssllabs", "whois", "tls", "scan", "dns", "geoip", "logo"]}},
+          "skip_whois": {"type": "boolean", "default": false},
+          "health_check": {"$ref": "#/components/schemas/HealthCheck"}
+        }
+      },
+      "HealthCheck": {
+        "type": "object",
+        "additionalProperties": false,
+        "description": "What a healthy answer looks like. The empty object requests / with GET and accepts any 2xx or 3xx answer after the redirects; the redirects are not followed when a 3xx code is accepted",
+        "properties": {
+          "path": {"type": "string", "default": "/", "example": "/healthz"},
+          "method": {"type": "string", "enum": ["GET", "HEAD", "OPTIONS"], "default": "GET"},
+          "accepted_status": {"type": "array", "items": {"type": "integer", "minimum": 100, "maximum": 599}, "example": [200, 301, 401]},
+          "body_contains": {"type": "string", "maxLength": 1024},
+          "body_regex": {"type": "string", "maxLength": 1024},
+          "headers": {"type": "object", "maxProperties": 20, "description": "Authorization, Proxy-Authorization and Cookie are shown as [redacted], sending [redacted] back keeps the stored value", "additionalProperties": {"type": "string"}},
+          "max_response_ms": {"type": "integer", "minimum": 0, "maximum": 60000, "description": "0 does not limit the response time"}
+        }
+      },
+      "TrackedDomain": {
+        "type": "object",
+        "required": ["id", "domain", "check_interval", "enabled", "tags", "notification_targets", "providers", "skip_whois", "health_check", "created_at", "updated_at"],
+        "properties": {
+          "id": {"type": "string", "format": "uuid"},
+          "domain": {"type": "string"},
+          "check_interval": {"type": "integer"},
+          "enabled": {"type": "boolean"},
+          "tags": {"type": "array", "items": {"type": "string"}},
+          "notification_targets": {"type": "array", "items": {"type": "string"}},
+          "providers": {"type": "array", "description": "Empty uses every provider", "items": {"type": "string"}},
+          "skip_whois": {"type": "boolean"},
+          "health_check": {"$ref": "#/components/schemas/HealthCheck"},
+          "last_checked_at": {"type": "string", "format": "date-time"},
+          "created_at": {"type": "string", "format": "date-time"},
+          "updated_at": {"type": "string", "format": "date-time"},
+          "latest": {"$ref": "#/components/schemas/Analysis"}
+        }
+      },
+      "TrackedDomainList": {
+        "type": "object",
+        "required": ["items", "limit", "offset"],
+        "properties": {
+          "items": {"type": "array", "items": {"$ref": "#/components/schemas/TrackedDomain"}},
+          "limit": {"type": "integer"},
+          "offset": {"type": "integer"}
+        }
+      },
+      "RuleRequest": {
+        "type": "object",
+        "additionalProperties": false,
+        "properties": {
+          "name": {"type": "string", "description": "Required to create a rule"},
+          "kind": {"type": "string", "description": "Required to create a rule, it cannot change", "enum": ["grade_below", "owner_changed", "server_count_drop", "down_consecutive", "grade_drops", "cert_expiry", "dual_stack", "logo_changed"]},
+          "domain": {"type": "string", "description": "Empty applies the rule to every domain"},
+          "grade": {"type": "string", "description": "grade_below fires when the grade is worse", "default": "B"},
+          "threshold": {"type": "integer", "description": "Percent for server_count_drop, analyses for down_consecutive and drops for grade_drops, days for cert_expiry"},
+          "window": {"type": "integer", "description": "Seconds looked at by grade_drops", "minimum": 60, "maximum": 2592000},
+          "enabled": {"type": "boolean", "default": true}
+        }
+      },
+      "Rule": {
+        "type": "object",
+        "required": ["id", "name", "kind", "domain", "grade", "threshold", "window", "enabled", "created_at", "updated_at"],
+        "properties": {
+          "id": {"type": "string", "format": "uuid"},
+          "name": {"type": "string"},
+          "kind": {"type": "string"},
+          "domain": {"type": "string"},
+          "grade": {"type": "string"},
+          "threshold": {"type": "integer"},
+          "window": {"type": "integer"},
+          "enabled": {"type": "boolean"},
+          "created_at": {"type": "string", "format": "date-time"},
+          "updated_at": {"type": "string", "format": "date-time"}
+        }
+      },
+      "RuleList": {
+        "type": "object",
+        "required": ["items", "limit", "offset"],
+        "properties": {
+          "items": {"type": "array", "items": {"$ref": "#/components/schemas/Rule"}},
+          "limit": {"type": "integer"},
+          "offset": {"type": "integer"}
+        }
+      },
+      "RuleEvaluation": {
+        "type": "object",
+        "required": ["id", "rule_id", "analysis_id", "domain", "fired", "message", "created_at"],
+        "properties": {
+          "id": {"type": "string", "format": "uuid"},
+          "rule_id": {"type": "string", "format": "uuid"},
+          "analysis_id": {"type": "string", "format": "uuid"},
+          "domain": {"type": "string"},
+          "fired": {"type": "boolean"},
+          "message": {"type": "string"},
+          "created_at": {"type": "string", "format": "date-time"}
+        }
+      },
+      "RuleEvaluationList": {
+        "type": "object",
+        "required": ["items", "limit", "offset"],
+        "properties": {
+          "items": {"type": "array", "items": {"$ref": "#/components/schemas/RuleEvaluation"}},
+          "limit": {"type": "integer"},
+          "offset": {"type": "integer"}
+        }
+      },
+      "Alert": {
+        "type": "object",
+        "required": ["id", "rule_id", "rule_name", "evaluation_id", "analysis_id", "domain", "message", "created_at"],
+        "properties": {
+          "id": {"type": "string", "format": "uuid"},
+          "rule_id": {"type": "string", "format": "uuid"},
+          "rule_name": {"type": "string"},
+          "evaluation_id": {"type": "string", "format": "uuid"},
+          "analysis_id": {"type": "string", "format": "uuid"},
+          "domain": {"type": "string"},
+          "message": {"type": "string"},
+          "created_at": {"type": "string", "format": "date-time"}
+        }
+      },
+      "AlertList": {
+        "type": "object",
+        "required": ["items", "limit", "offset"],
+        "properties": {
+          "items": {"type": "array", "items": {"$ref": "#/components/schemas/Alert"}},
+          "limit": {"type": "integer"},
+          "offset": {"type": "integer"}
+        }
+      },
+      "MXRecord": {
+        "type": "object",
+        "required": ["host", "preference"],
+        "properties": {
+          "host": {"type": "string"},
+          "preference": {"type": "integer"}
+        }
+      },
+      "CAARecord": {
+        "type": "object",
+        "required": ["flags", "tag", "value"],
+        "properties": {
+          "flags": {"type": "integer"},
+          "tag": {"type": "string", "description": "issue, issuewild or iodef"},
+          "value": {"type": "string"}
+        }
+      },
+      "DNSRecords": {
+        "type": "object",
+        "description": "Record types without values are omitted",
+        "properties": {
+          "a": {"type": "array", "items": {"type": "string"}},
+          "aaaa": {"type": "array", "items": {"type": "string"}},
+          "cname": {"type": "array", "description": "Chain of aliases followed from the domain", "items": {"type": "string"}},
+          "ns": {"type": "array", "items": {"type": "string"}},
+          "mx": {"type": "array", "items": {"$ref": "#/components/schemas/MXRecord"}},
+          "txt": {"type": "array", "items": {"type": "string"}},
+          "caa": {"type": "array", "items": {"$ref": "#/components/schemas/CAARecord"}}
+        }
+      },
+      "DNSChange": {
+        "type": "object",
+        "required": ["type", "added", "removed"],
+        "properties": {
+          "type": {"type": "string", "enum": ["A", "AAAA", "CNAME", "NS", "MX", "TXT", "CAA"]},
+          "added": {"type": "array", "items": {"type": "string"}},
+          "removed": {"type": "array", "items": {"type": "string"}}
+        }
+      },
+      "DNSSnapshot": {
+        "type": "object",
+        "required": ["id", "analysis_id", "domain", "resolver", "records", "errors", "changed", "changes", "resolved_at"],
+        "properties": {
+          "id": {"type": "string", "format": "uuid"},
+          "analysis_id": {"type": "string", "format": "uuid"},
+          "domain": {"type": "string"},
+          "resolver": {"type": "string", "description": "Host and port of the DNS server"},
+          "records": {"$ref": "#/components/schemas/DNSRecords"},
+          "errors": {"type": "object", "description": "Error of every record type whose query failed, those types are not compared", "additionalProperties": {"type": "string"}},
+          "changed": {"type": "boolean"},
+          "changes": {"type": "array", "items": {"$ref": "#/components/schemas/DNSChange"}},
+          "resolved_at": {"type": "string", "format": "date-time"}
+        }
+      },
+      "DNSSnapshotList": {
+        "type": "object",
+        "required": ["items", "limit", "offset"],
+        "properties": {
+          "items": {"type": "array", "items": {"$ref": "#/components/schemas/DNSSnapshot"}},
+          "limit": {"type": "integer"},
+          "offset": {"type": "integer"}
+        }
+      },
+      "PageMetadata": {
+        "type": "object",
+        "required": ["description", "canonical", "lang", "generator", "open_graph", "twitter"],
+        "properties": {
+          "description": {"type": "string"},
+          "canonical": {"type": "string", "description": "Canonical URL resolved against the page, empty without one"},
+          "lang": {"type": "string", "description": "lang attribute of the html element"},
+          "generator": {"type": "string"},
+          "open_graph": {"type": "object", "description": "og: properties without the prefix", "additionalProperties": {"type": "string"}},
+          "twitter": {"type": "object", "description": "twitter: properties without the prefix", "additionalProperties": {"type": "string"}}
+        }
+      },
+      "PageSnapshot": {
+        "type": "object",
+        "required": ["id", "analysis_id", "domain", "url", "title", "metadata", "text_hash", "title_changed", "content_changed", "read_at"],
+        "properties": {
+          "id": {"type": "string", "format": "uuid"},
+          "analysis_id": {"type": "string", "format": "uuid"},
+          "domain": {"type": "string"},
+          "url": {"type": "string", "description": "URL of the page after the redirects"},
+          "title": {"type": "string"},
+          "metadata": {"$ref": "#/components/schemas/PageMetadata"},
+          "text_hash": {"type": "string", "description": "SHA-256 of the normalized visible text"},
+          "title_changed": {"type": "boolean"},
+          "content_changed": {"type": "boolean"},
+          "read_at": {"type": "string", "format": "date-time"}
+        }
+      },
+      "PageSnapshotList": {
+        "type": "object",
+        "required": ["items", "limit", "offset"],
+        "properties": {
+          "items": {"type": "array", "items": {"$ref": "#/components/schemas/PageSnapshot"}},
+          "limit": {"type": "integer"},
+          "offset": {"type": "integer"}
+        }
+      },
+      "TextChange": {
+        "type": "object",
+        "required": ["op", "line", "text"],
+        "properties": {
+          "op": {"type": "string", "enum": ["added", "removed"]},
+          "line": {"type": "integer", "description": "Line number in the snapshot that has the line, starting at 1"},
+          "text": {"type": "string"}
+        }
+      },
+      "PageDiff": {
+        "type": "object",
+        "required": ["domain", "from", "to", "title_changed", "content_changed", "added", "removed", "changes"],
+        "properties": {
+          "domain": {"type": "string"},
+          "from": {"$ref": "#/components/schemas/PageSnapshot"},
+          "to": {"$ref": "#/components/schemas/PageSnapshot"},
+          "title_changed": {"type": "boolean"},
+          "content_changed": {"type": "boolean"},
+          "added": {"type": "integer"},
+          "removed": {"type": "integer"},
+          "changes": {"type": "array", "items": {"$ref": "#/components/schemas/TextChange"}}
+        }
+      },
+      "UptimeCheck": {
+        "type": "object",
+        "required": ["id", "analysis_id", "url", "up", "status_code", "redirects", "dns_ms", "connect_ms", "tls_ms", "ttfb_ms", "total_ms", "checked_at"],
+        "properties": {
+          "id": {"type": "string", "format": "uuid"},
+          "analysis_id": {"type": "string", "format": "uuid"},
+          "url": {"type": "string"},
+          "up": {"type": "boolean", "description": "The final answer has a 2xx or 3xx status code"},
+          "status_code": {"type": "integer", "description": "0 when the site did not answer"},
+          "redirects": {"type": "array", "description": "URLs of the redirect chain in order", "items": {"type": "string"}},
+          "dns_ms": {"type": "integer"},
+          "connect_ms": {"type": "integer"},
+          "tls_ms": {"type": "integer"},
+          "ttfb_ms": {"type": "integer"},
+          "total_ms": {"type": "integer"},
+          "error_class": {"type": "string", "enum": ["dns", "connect", "tls", "timeout", "too_many_redirects", "http_status", "body_mismatch", "slow_response", "other"]},
+          "checked_at": {"type": "string", "format": "date-time"}
+        }
+      },
+      "Certificate": {
+        "type": "object",
+        "required": ["subject", "sans", "issuer", "serial", "not_before", "not_after", "key_type", "key_size", "signature_algorithm", "sha256_fingerprint"],
+        "properties": {
+          "subject": {"type": "string"},
+          "sans": {"type": "array", "description": "DNS names, IP addresses and emails of the certificate", "items": {"type": "string"}},
+          "issuer": {"type": "string"},
+          "serial": {"type": "string"},
+          "not_before": {"type": "string", "format": "date-time"},
+          "not_after": {"type": "string", "format": "date-time"},
+          "key_type": {"type": "string", "description": "RSA, ECDSA, Ed25519 or the public key algorithm"},
+          "key_size": {"type": "integer", "description": "Bits of the public key"},
+          "signature_algorithm": {"type": "string"},
+          "sha256_fingerprint": {"type": "string"}
+        }
+      },
+      "ServerCertificate": {
+        "type": "object",
+        "required": ["server_id", "analysis_id", "address", "server_name", "tls_version", "chain_verified", "hostname_verified", "chain", "inspected_at"],
+        "properties": {
+          "server_id": {"type": "string", "format": "uuid"},
+          "analysis_id": {"type": "string", "format": "uuid"},
+          "address": {"type": "string"},
+          "server_name": {"type": "string", "description": "Name sent as SNI"},
+          "tls_version": {"type": "string"},
+          "chain_verified": {"type": "boolean", "description": "The chain ends in a trusted root"},
+          "hostname_verified": {"type": "boolean", "description": "The leaf is valid for the server name"},
+          "verify_error": {"type": "string"},
+          "error": {"type": "string", "description": "The handshake failed"},
+          "expires_at": {"type": "string", "format": "date-time"},
+          "days_left": {"type": "integer", "description": "Whole days until the leaf expires, negative when it expired"},
+          "chain": {"type": "array", "description": "Certificates presented by the server, the leaf first", "items": {"$ref": "#/components/schemas/Certificate"}},
+          "inspected_at": {"type": "string", "format": "date-time"}
+        }
+      },
+      "Percentiles": {
+        "type": "object",
+        "required": ["p50", "p90", "p95", "p99"],
+        "properties": {
+          "p50": {"type": "integer"},
+          "p90": {"type": "integer"},
+          "p95": {"type": "integer"},
+          "p99": {"type": "integer"}
+        }
+      },
+      "UptimeWindow": {
+        "type": "object",
+        "required": ["window", "checks", "up", "uptime_percent", "latency_ms"],
+        "properties": {
+          "window": {"type": "string", "enum": ["24h", "7d", "30d"]},
+          "checks": {"type": "integer"},
+          "up": {"type": "integer"},
+          "uptime_percent": {"type": "number", "minimum": 0, "maximum": 100},
+          "latency_ms": {"$ref": "#/components/schemas/Percentiles"}
+        }
+      },
+      "Uptime": {
+        "type": "object",
+        "required": ["domain", "windows"],
+        "properties": {
+          "domain": {"type": "string"},
+          "latest": {"$ref": "#/components/schemas/UptimeCheck"},
+          "windows": {"type": "array", "items": {"$ref": "#/components/schemas/UptimeWindow"}}
+        }
+      },
+      "EndpointProgress": {
+        "type": "object",
+        "required": ["ip_address", "status_message", "progress"],
+        "properties": {
+          "ip_address": {"type": "string"},
+          "status_message": {"type": "string"},
+          "progress": {"type": "integer", "minimum": 0, "maximum": 100},
+          "grade": {"type": "string"}
+        }
+      },
+      "JobEvent": {
+        "type": "object",
+        "required": ["job_id", "state", "stage", "progress", "message"],
+        "properties": {
+          "job_id": {"type": "string", "format": "uuid"},
+          "state": {"type": "string", "enum": ["queued", "running", "done", "failed"]},
+          "stage": {"type": "string", "enum": ["queued", "running", "status", "page", "ssllabs", "whois", "tls", "scan", "dns", "logo", "done", "failed"]},
+          "progress": {"type": "integer", "minimum": 0, "maximum": 100},
+          "message": {"type": "string"},
+          "endpoints": {"type": "array", "items": {"$ref": "#/components/schemas/EndpointProgress"}}
+        }
+      },
+      "Problem": {
+        "type": "object",
+        "required": ["type", "title", "status", "code"],
+        "properties": {
+          "type": {"type": "string"},
+          "title": {"type": "string"},
+          "status": {"type": "integer"},
+          "detail": {"type": "string"},
+          "instance": {"type": "string"},
+          "code": {"type": "string"},
+          "errors": {}
+        }
+      }
+    }
+  }
+}
+`
+
+// OpenAPI serves the OpenAPI document of the v1 API
+func OpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	_, _ = w.Write([]byte(OpenAPIDocument))
+}
@@ -0,0 +1,214 @@
+package httphand
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/other_project/crockroach/internal/jobs"
+	"github.com/other_project/crockroach/internal/problem"
+	"github.com/other_project/crockroach/internal/uptime"
+	"github.com/other_project/crockroach/models"
+	"github.com/stretchr/testify/require"
+)
+
+// openAPISchema is the part of a schema object checked by the tests
+type openAPISchema struct {
+	Ref        string                    `json:"$ref"`
+	Type       string                    `json:"type"`
+	Required   []string                  `json:"required"`
+	Properties map[string]*openAPISchema `json:"properties"`
+	Items      *openAPISchema            `json:"items"`
+	// Additional is false or the schema of the values of a map
+	Additional json.RawMessage `json:"additionalProperties"`
+}
+
+// values returns the schema of the properties that are not declared, nil when they are not allowed
+func (s *openAPISchema) values(c *require.Assertions) *openAPISchema {
+	if len(s.Additional) == 0 || string(s.Additional) == "false" {
+		return nil
+	}
+
+	values := new(openAPISchema)
+	c.NoError(json.Unmarshal(s.Additional, values))
+
+	return values
+}
+
+// openAPISpec is the part of the document checked by the tests
+type openAPISpec struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]*openAPISchema `json:"schemas"`
+	} `json:"components"`
+}
+
+func loadOpenAPI(c *require.Assertions) *openAPISpec {
+	spec := new(openAPISpec)
+	c.NoError(json.Unmarshal([]byte(OpenAPIDocument), spec))
+
+	return spec
+}
+
+// resolve follows the reference of the schema
+func (s *openAPISpec) resolve(c *require.Assertions, schema *openAPISchema) *openAPISchema {
+	if schema.Ref == "" {
+		return schema
+	}
+
+	name := strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+	resolved, ok := s.Components.Schemas[name]
+	c.True(ok, "missing schema %s", name)
+
+	return resolved
+}
+
+// schemaType returns the OpenAPI type of a Go type
+func schemaType(t reflect.Type) string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == reflect.TypeOf(time.Time{}) || t.Kind() == reflect.String:
+		return "string"
+	case t.Kind() == reflect.Bool:
+		return "boolean"
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return "integer"
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return "number"
+	case t.Kind() == reflect.Slice:
+		return "array"
+	case t.Kind() == reflect.Interface:
+		return ""
+	}
+
+	return "object"
+}
+
+func TestOpenAPISchemasMatchTypes(t *testing.T) {
+	c := require.New(t)
+
+	spec := loadOpenAPI(c)
+
+	types := map[string]interface{}{
+		"Analysis":              AnalysisResponse{},
+		"Server":                ServerResponse{},
+		"DualStack":             models.DualStack{},
+		"AnalysisList":          AnalysisList{},
+		"CreateAnalysisRequest": CreateAnalysisRequest{},
+		"Job":                   JobResponse{},
+		"JobError":              JobError{},
+		"JobEvent":              jobs.Event{},
+		"EndpointProgress":      models.EndpointProgress{},
+		"TrackedDomainRequest":  TrackedDomainRequest{},
+		"TrackedDomain":         TrackedDomainResponse{},
+		"TrackedDomainList":     TrackedDomainList{},
+		"HealthCheck":           models.HealthCheck{},
+		"RuleRequest":           RuleRequest{},
+		"Rule":                  RuleResponse{},
+		"RuleList":              RuleList{},
+		"RuleEvaluation":        EvaluationResponse{},
+		"RuleEvaluationList":    EvaluationList{},
+		"Alert":                 AlertResponse{},
+		"AlertList":             AlertList{},
+		"UptimeCheck":           UptimeCheckResponse{},
+		"Percentiles":           uptime.Percentiles{},
+		"UptimeWindow":          uptime.Summary{},
+		"Uptime":                UptimeResponse{},
+		"Certificate":           models.Certificate{},
+		"ServerCertificate":     CertificateResponse{},
+		"MXRecord":              models.MXRecord{},
+		"CAARecord":             models.CAARecord{},
+		"DNSRecords":            models.DNSRecords{},
+		"DNSChange":             models.DNSChange{},
+		"DNSSnapshot":           DNSSnapshotResponse{},
+		"DNSSnapshotList":       DNSSnapshotList{},
+		"PageMetadata":          models.PageMetadata{},
+		"PageSnapshot":          PageSnapshotResponse{},
+		"PageSnapshotList":      PageSnapshotList{},
+		"TextChange":            models.TextChange{},
+		"PageDiff":              PageDiffResponse{},
+		"Problem":               problem.Problem{},
+	}
+
+	for name, value := range types {
+		schema, ok := spec.Components.Schemas[name]
+		c.True(ok, "missing schema %s", name)
+
+		typ := reflect.TypeOf(value)
+		fields := []string{}
+		required := []string{}
+
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			tag := strings.Split(field.Tag.Get("json"), ",")
+
+			fields = append(fields, tag[0])
+			if len(tag) == 1 || tag[1] != "omitempty" {
+				required = append(required, tag[0])
+			}
+
+			property, ok := schema.Properties[tag[0]]
+			c.True(ok, "schema %s does not describe %s", name, tag[0])
+			c.Equal(schemaType(field.Type), spec.resolve(c, property).Type, "type of %s.%s", name, tag[0])
+		}
+
+		properties := []string{}
+		for property := range schema.Properties {
+			properties = append(properties, property)
+		}
+
+		sort.Strings(fields)
+		sort.Strings(properties)
+		sort.Strings(required)
+		sort.Strings(schema.Required)
+
+		c.Equal(fields, properties, "properties of %s", name)
+		c.ElementsMatch(required, schema.Required, "required properties of %s", name)
+	}
+}
+
+// validateResponse checks that the JSON value follows the schema
+func validateResponse(c *require.Assertions, spec *openAPISpec, schema *openAPISchema, value interface{}) {
+	schema = spec.resolve(c, schema)
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		c.True(ok, "expected an object, got %v", value)
+
+		for _, name := range schema.Required {
+			_, ok := object[name]
+			c.True(ok, "missing required property %s", name)
+		}
+
+		for name, property := range object {
+			propertySchema, ok := schema.Properties[name]
+			if !ok {
+				propertySchema = schema.values(c)
+			}
+
+			c.True(propertySchema != nil, "property %s is not in the document", name)
+
+			validateResponse(c, spec, propertySchema, property)
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		c.True(ok, "expected an array, got %v", value)
+
+		for _, item := range items {
+			validateResponse(c, spec, schema.Items, item)
+		}
+	case "string":
+		c.IsType("", value)
+	case "boolean":
+		c.IsType(true, value)
+	case "integer", "number":
+		c.IsType(float64(0), value)
+	}
+}
@@ -0,0 +1,221 @@
+package httphand
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/gofrs/uuid"
+	"github.com/other_project/crockroach/internal/apperr"
+	"github.com/other_project/crockroach/internal/problem"
+	"github.com/other_project/crockroach/internal/storage"
+	"github.com/other_project/crockroach/models"
+)
+
+var (
+	// ErrInvalidPageDiff when only one of the snapshots to compare is given
+	ErrInvalidPageDiff = apperr.New(apperr.Invalid, "invalid_page_diff", "from and to must be given together")
+)
+
+// PageStore saves and lists the pages read during the analyses
+type PageStore interface {
+	StorePageSnapshot(ctx context.Context, snapshot *models.PageSnapshot) (*models.PageSnapshot, error)
+	GetPageSnapshot(ctx context.Context, id string) (*models.PageSnapshot, error)
+	GetLastPageSnapshot(ctx context.Context, domainName string) (*models.PageSnapshot, error)
+	ListPageSnapshots(ctx context.Context, domainName string, limit, offset int64) ([]*models.PageSnapshot, error)
+}
+
+// PageSnapshotResponse is the page of a domain read during an analysis with the changes since
+// the previous snapshot
+type PageSnapshotResponse struct {
+	ID             string              `json:"id"`
+	AnalysisID     string              `json:"analysis_id"`
+	Domain         string              `json:"domain"`
+	URL            string              `json:"url"`
+	Title          string              `json:"title"`
+	Metadata       models.PageMetadata `json:"metadata"`
+	TextHash       string              `json:"text_hash"`
+	TitleChanged   bool                `json:"title_changed"`
+	ContentChanged bool                `json:"content_changed"`
+	ReadAt         time.Time           `json:"read_at"`
+}
+
+// PageSnapshotList is a page of snapshots, the newest first
+type PageSnapshotList struct {
+	Items  []*PageSnapshotResponse `json:"items"`
+	Limit  int64                   `json:"limit"`
+	Offset int64                   `json:"offset"`
+}
+
+// PageDiffResponse is the difference between two snapshots of the page of a domain
+type PageDiffResponse struct {
+	Domain         string                `json:"domain"`
+	From           *PageSnapshotResponse `json:"from"`
+	To             *PageSnapshotResponse `json:"to"`
+	TitleChanged   bool                  `json:"title_changed"`
+	ContentChanged bool                  `json:"content_changed"`
+	Added          int                   `json:"added"`
+	Removed        int                   `json:"removed"`
+	Changes        []models.TextChange   `json:"changes"`
+}
+
+// NewPageSnapshotResponse converts the snapshot to its v1 representation
+func NewPageSnapshotResponse(snapshot *models.PageSnapshot) *PageSnapshotResponse {
+	res := &PageSnapshotResponse{
+		ID:             snapshot.SnapshotID,
+		AnalysisID:     snapshot.AnalysisID,
+		Domain:         snapshot.DomainName,
+		URL:            snapshot.URL,
+		Title:          snapshot.Title,
+		Metadata:       snapshot.Metadata,
+		TextHash:       snapshot.TextHash,
+		TitleChanged:   snapshot.TitleChanged,
+		ContentChanged: snapshot.ContentChanged,
+	}
+
+	if res.Metadata.OpenGraph == nil {
+		res.Metadata.OpenGraph = map[string]string{}
+	}
+
+	if res.Metadata.Twitter == nil {
+		res.Metadata.Twitter = map[string]string{}
+	}
+
+	if snapshot.CreationDate != nil {
+		res.ReadAt = snapshot.CreationDate.UTC()
+	}
+
+	return res
+}
+
+// NewPageDiffResponse compares the text of two snapshots of the page
+func NewPageDiffResponse(from, to *models.PageSnapshot) *PageDiffResponse {
+	res := &PageDiffResponse{
+		Domain:         to.DomainName,
+		From:           NewPageSnapshotResponse(from),
+		To:             NewPageSnapshotResponse(to),
+		TitleChanged:   from.Title != to.Title,
+		ContentChanged: from.TextHash != to.TextHash,
+		Changes:        models.DiffText(from.Text, to.Text),
+	}
+
+	for _, change := range res.Changes {
+		if change.Op == models.TextAdded {
+			res.Added++
+		} else {
+			res.Removed++
+		}
+	}
+
+	return res
+}
+
+// ListPageSnapshots returns the pages read for the domain, the newest first
+func (p *HandlerRequest) ListPageSnapshots(w http.ResponseWriter, r *http.Request) {
+	domainName, err := models.NormalizeDomainName(chi.URLParam(r, "name"))
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	limit, offset, err := parsePagination(r)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	items, err := p.pages.ListPageSnapshots(r.Context(), domainName, limit, offset)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	list := &PageSnapshotList{Items: []*PageSnapshotResponse{}, Limit: limit, Offset: offset}
+
+	for _, snapshot := range items {
+		list.Items = append(list.Items, NewPageSnapshotResponse(snapshot))
+	}
+
+	respondwithJSON(w, http.StatusOK, list)
+}
+
+// GetPageDiff compares the text of the snapshots from and to of the query, without them it
+// compares the two newest snapshots of the domain
+func (p *HandlerRequest) GetPageDiff(w http.ResponseWriter, r *http.Request) {
+	domainName, err := models.NormalizeDomainName(chi.URLParam(r, "name"))
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	fromID, toID := r.URL.Query().Get("from"), r.URL.Query().Get("to")
+
+	if (fromID == "") != (toID == "") {
+		problem.Error(w, r, ErrInvalidPageDiff)
+		return
+	}
+
+	if fromID == "" {
+		items, err := p.pages.ListPageSnapshots(r.Context(), domainName, 2, 0)
+		if err != nil {
+			problem.Error(w, r, err)
+			return
+		}
+
+		if len(items) < 2 {
+			problem.Error(w, r, storage.ErrPageSnapshotNotFound)
+			return
+		}
+
+		// the list does not have the text
+		toID, fromID = items[0].SnapshotID, items[1].SnapshotID
+	}
+
+	from, err := p.domainPageSnapshot(r.Context(), domainName, fromID)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	to, err := p.domainPageSnapshot(r.Context(), domainName, toID)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, NewPageDiffResponse(from, to))
+}
+
+// domainPageSnapshot returns the snapshot with the id when it belongs to the domain
+func (p *HandlerRequest) domainPageSnapshot(ctx context.Context, domainName, id string) (*models.PageSnapshot, error) {
+	_, err := uuid.FromString(id)
+	if err != nil {
+		return nil, storage.ErrPageSnapshotNotFound
+	}
+
+	snapshot, err := p.pages.GetPageSnapshot(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if snapshot.DomainName != domainName {
+		return nil, storage.ErrPageSnapshotNotFound
+	}
+
+	return snapshot, nil
+}
+
+// storePageSnapshot compares the snapshot with the previous one of the domain and saves it
+func storePageSnapshot(ctx context.Context, store PageStore, snapshot *models.PageSnapshot, analysisID string) (*models.PageSnapshot, error) {
+	previous, err := store.GetLastPageSnapshot(ctx, snapshot.DomainName)
+	if err != nil && !errors.Is(err, storage.ErrPageSnapshotNotFound) {
+		return nil, err
+	}
+
+	snapshot.AnalysisID = analysisID
+	models.ComparePages(previous, snapshot)
+
+	return store.StorePageSnapshot(ctx, snapshot)
+}
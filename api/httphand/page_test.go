@@ -0,0 +1,181 @@
+package httphand
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/internal/storage"
+	"github.com/other_project/crockroach/models"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryPages is an in memory PageStore
+type memoryPages struct {
+	snapshots []*models.PageSnapshot
+}
+
+func (m *memoryPages) StorePageSnapshot(ctx context.Context, snapshot *models.PageSnapshot) (*models.PageSnapshot, error) {
+	m.snapshots = append([]*models.PageSnapshot{snapshot}, m.snapshots...)
+
+	return snapshot, nil
+}
+
+func (m *memoryPages) GetPageSnapshot(ctx context.Context, id string) (*models.PageSnapshot, error) {
+	for _, snapshot := range m.snapshots {
+		if snapshot.SnapshotID == id {
+			return snapshot, nil
+		}
+	}
+
+	return nil, storage.ErrPageSnapshotNotFound
+}
+
+func (m *memoryPages) GetLastPageSnapshot(ctx context.Context, domainName string) (*models.PageSnapshot, error) {
+	for _, snapshot := range m.snapshots {
+		if snapshot.DomainName == domainName {
+			return snapshot, nil
+		}
+	}
+
+	return nil, storage.ErrPageSnapshotNotFound
+}
+
+func (m *memoryPages) ListPageSnapshots(ctx context.Context, domainName string, limit, offset int64) ([]*models.PageSnapshot, error) {
+	items := []*models.PageSnapshot{}
+
+	for _, snapshot := range m.snapshots {
+		if snapshot.DomainName == domainName {
+			// like the database, the list does not have the text
+			item := *snapshot
+			item.Text = ""
+			items = append(items, &item)
+		}
+	}
+
+	if offset >= int64(len(items)) {
+		return []*models.PageSnapshot{}, nil
+	}
+
+	items = items[offset:]
+	if int64(len(items)) > limit {
+		items = items[:limit]
+	}
+
+	return items, nil
+}
+
+func TestPageSnapshots(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	spec := loadOpenAPI(c)
+	store := &memoryPages{}
+
+	snapshot := func(domainName, title, text string) *models.PageSnapshot {
+		page, err := models.NewPageSnapshot(domainName, "https://"+domainName+"/", title, text, models.PageMetadata{Lang: "en"})
+		c.NoError(err)
+
+		return page
+	}
+
+	first, err := storePageSnapshot(context.Background(), store, snapshot("example.com", "Example", "Welcome\nNews\nContact"), "6c1b4f4f-9b1d-4b5f-8e7c-2f3a4b5c6d7e")
+	c.NoError(err)
+	c.False(first.ContentChanged, "the first snapshot does not have changes")
+	c.Equal("6c1b4f4f-9b1d-4b5f-8e7c-2f3a4b5c6d7e", first.AnalysisID)
+
+	second, err := storePageSnapshot(context.Background(), store, snapshot("example.com", "Hacked", "Hacked by someone\nNews\nContact"), "7d2c5a5a-0c2e-4c6a-9f8d-3a4b5c6d7e8f")
+	c.NoError(err)
+	c.True(second.TitleChanged)
+	c.True(second.ContentChanged)
+
+	other, err := storePageSnapshot(context.Background(), store, snapshot("other.com", "Other", "Other"), "8e3d6b6b-1d3f-4d7b-8a9e-4b5c6d7e8f90")
+	c.NoError(err)
+
+	handler := &HandlerRequest{pages: store}
+	mux := chi.NewMux()
+	mux.Get("/api/v1/domains/{name}/pages", handler.ListPageSnapshots)
+	mux.Get("/api/v1/domains/{name}/pages/diff", handler.GetPageDiff)
+
+	rec, body := getJSON(c, mux, "/api/v1/domains/Example.com/pages")
+	c.Equal(http.StatusOK, rec.Code)
+	validateResponse(c, spec, spec.Components.Schemas["PageSnapshotList"], body)
+
+	items := body.(map[string]interface{})["items"].([]interface{})
+	c.Len(items, 2)
+	c.Equal(true, items[0].(map[string]interface{})["content_changed"])
+
+	rec, body = getJSON(c, mux, "/api/v1/domains/example.com/pages/diff")
+	c.Equal(http.StatusOK, rec.Code)
+	validateResponse(c, spec, spec.Components.Schemas["PageDiff"], body)
+
+	diff := body.(map[string]interface{})
+	c.Equal(true, diff["title_changed"])
+	c.Equal(float64(1), diff["added"])
+	c.Equal(float64(1), diff["removed"])
+	c.Equal(second.SnapshotID, diff["to"].(map[string]interface{})["id"])
+
+	changes := diff["changes"].([]interface{})
+	c.Equal("Welcome", changes[0].(map[string]interface{})["text"])
+	c.Equal("Hacked by someone", changes[1].(map[string]interface{})["text"])
+
+	// the snapshots can be compared in any order
+	rec, body = getJSON(c, mux, "/api/v1/domains/example.com/pages/diff?from="+second.SnapshotID+"&to="+first.SnapshotID)
+	c.Equal(http.StatusOK, rec.Code)
+	c.Equal("Welcome", body.(map[string]interface{})["changes"].([]interface{})[1].(map[string]interface{})["text"])
+
+	rec, _ = getJSON(c, mux, "/api/v1/domains/example.com/pages/diff?from="+first.SnapshotID)
+	c.Equal(http.StatusBadRequest, rec.Code)
+
+	rec, _ = getJSON(c, mux, "/api/v1/domains/example.com/pages/diff?from="+first.SnapshotID+"&to="+other.SnapshotID)
+	c.Equal(http.StatusNotFound, rec.Code, "the snapshot of another domain is not found")
+
+	rec, _ = getJSON(c, mux, "/api/v1/domains/example.com/pages/diff?from=not-a-uuid&to="+first.SnapshotID)
+	c.Equal(http.StatusNotFound, rec.Code)
+
+	rec, _ = getJSON(c, mux, "/api/v1/domains/other.com/pages/diff")
+	c.Equal(http.StatusNotFound, rec.Code, "a single snapshot cannot be compared")
+}
+
+func TestGetInfoDomainPageMetadata(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/home/", http.StatusFound)
+	})
+	mux.HandleFunc("/home/", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<html lang="en"><head><title>Example</title>
+			<meta name="description" content="An example">
+			<meta name="generator" content="Hugo 0.80">
+			<meta property="og:title" content="Example site">
+			<meta name="twitter:card" content="summary">
+			<link rel="canonical" href="index.html">
+			<link rel="icon" href="/favicon.png">
+		</head><body><h1>Welcome</h1><script>var hidden = true</script><p>Latest   news</p></body></html>`))
+	})
+
+	server := httptest.NewTLSServer(mux)
+	defer server.Close()
+
+	client := server.Client()
+	client.Timeout = time.Second
+
+	page, err := getInfoDomainPage(context.Background(), client, strings.TrimPrefix(server.URL, "https://"))
+	c.NoError(err)
+	c.Equal("Example", page.Title)
+	c.Equal(server.URL+"/home/", page.URL)
+	c.Equal(server.URL+"/home/index.html", page.Metadata.Canonical)
+	c.Equal("en", page.Metadata.Lang)
+	c.Equal("An example", page.Metadata.Description)
+	c.Equal("Hugo 0.80", page.Metadata.Generator)
+	c.Equal("Example site", page.Metadata.OpenGraph["title"])
+	c.Equal("summary", page.Metadata.Twitter["card"])
+	c.Equal("Welcome\nLatest news", page.Text)
+}
@@ -0,0 +1,300 @@
+package httphand
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/other_project/crockroach/internal/apperr"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/internal/metrics"
+	"github.com/other_project/crockroach/internal/problem"
+	"github.com/other_project/crockroach/internal/ratelimit"
+	"github.com/other_project/crockroach/models"
+	"github.com/other_project/crockroach/shared/env"
+	"golang.org/x/sync/singleflight"
+)
+
+var (
+	// ProbeCacheTTL is the time an analysis is reused by the probe endpoint
+	ProbeCacheTTL = time.Duration(env.GetInt64("PROBE_CACHE_TTL_SECONDS", 600)) * time.Second
+	// ProbeCacheSize is the number of targets kept by the probe cache, the least recently used is dropped
+	ProbeCacheSize = int(env.GetInt64("PROBE_CACHE_SIZE", 1000))
+	// ProbeTimeout is the maximum time of an analysis launched by the probe endpoint
+	ProbeTimeout = time.Duration(env.GetInt64("PROBE_TIMEOUT_SECONDS", 14)) * time.Second
+	// ErrMissingTarget when the probe does not have the target parameter
+	ErrMissingTarget = apperr.New(apperr.Invalid, "missing_target", "target parameter is missing")
+
+	// errProbeLimited when the probe that would analyze the target exceeded the rate limit
+	errProbeLimited = errors.New("probe rate limited")
+)
+
+// probeEntry is an analysis kept by the probe cache
+type probeEntry struct {
+	target     string
+	domain     *models.Domain
+	analyzedAt time.Time
+}
+
+// probeCache keeps the last analysis of the most recently probed targets
+type probeCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+	// flight runs a single analysis for the concurrent probes of a target that missed the cache
+	flight singleflight.Group
+}
+
+// newProbeCache creates a cache of up to size targets whose entries are fresh during ttl
+func newProbeCache(ttl time.Duration, size int) *probeCache {
+	if size < 1 {
+		size = 1
+	}
+
+	return &probeCache{
+		ttl:     ttl,
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// get returns the last entry of the target and if it is still fresh
+func (c *probeCache) get(target string) (*probeEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[target]
+	if !ok {
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	entry := element.Value.(*probeEntry)
+
+	return entry, time.Since(entry.analyzedAt) < c.ttl
+}
+
+// set stores the analysis of the target, dropping the least recently used target when the cache is full
+func (c *probeCache) set(target string, domain *models.Domain) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &probeEntry{target: target, domain: domain, analyzedAt: time.Now()}
+
+	if element, ok := c.entries[target]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+
+		return
+	}
+
+	c.entries[target] = c.order.PushFront(entry)
+
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*probeEntry).target)
+	}
+}
+
+// Probe returns Prometheus metrics about the domain selected by the target parameter
+func (p *HandlerRequest) Probe(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("target") == "" {
+		problem.Error(w, r, ErrMissingTarget)
+		return
+	}
+
+	// "https://Example.com/" and "example.com:443" share the analysis and the cache entry
+	target, err := models.NormalizeDomainName(r.URL.Query().Get("target"))
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	start := time.Now()
+
+	entry, fresh := p.probes.get(target)
+	cached := fresh
+
+	var families []*metrics.Family
+
+	if !fresh {
+		var limited bool
+
+		entry, limited, err = p.analyzeProbe(w, r, target)
+		if limited {
+			return
+		}
+
+		if err != nil {
+			logs.Log().Errorf("cannot probe %s: %s", target, err.Error())
+
+			families = append(families,
+				metrics.NewGauge("crockroach_probe_success", "Whether the analysis of the target succeeded", 0),
+				metrics.NewGauge("crockroach_probe_duration_seconds", "Duration of the probe in seconds", time.Since(start).Seconds()),
+			)
+
+			respondWithMetrics(w, families)
+
+			return
+		}
+	}
+
+	families = append(families,
+		metrics.NewGauge("crockroach_probe_success", "Whether the analysis of the target succeeded", 1),
+		metrics.NewGauge("crockroach_probe_duration_seconds", "Duration of the probe in seconds", time.Since(start).Seconds()),
+		metrics.NewGauge("crockroach_probe_cached", "Whether the analysis was read from the cache", boolValue(cached)),
+		metrics.NewGauge("crockroach_probe_analysis_age_seconds", "Age of the analysis in seconds", time.Since(entry.analyzedAt).Seconds()),
+	)
+	families = append(families, domainFamilies(entry.domain)...)
+
+	respondWithMetrics(w, families)
+}
+
+// analyzeProbe analyzes the target once for the concurrent probes that missed the cache and
+// stores it in the cache. Only the probe that runs the analysis charges the rate limit, limited
+// reports that it was exceeded and the response was written
+func (p *HandlerRequest) analyzeProbe(w http.ResponseWriter, r *http.Request, target string) (*probeEntry, bool, error) {
+	for {
+		charged := false
+
+		value, err, _ := p.probes.flight.Do(target, func() (interface{}, error) {
+			charged = true
+
+			// only a new analysis consumes the upstream budget
+			if !ratelimit.Charge(w, r, 1) {
+				return nil, errProbeLimited
+			}
+
+			ctx, cancelfunc := context.WithTimeout(r.Context(), ProbeTimeout)
+			defer cancelfunc()
+
+			domain, err := p.analyzeDomain(ctx, target)
+			if err != nil {
+				return nil, err
+			}
+
+			stale, _ := p.probes.get(target)
+			previous := p.previousAnalysis(r.Context(), stale, domain)
+			domain.ServerChanged = models.ServersChanged(domain, previous)
+			domain.LogoChanged = models.LogoChanged(domain, previous)
+
+			p.probes.set(target, domain)
+			entry, _ := p.probes.get(target)
+
+			return entry, nil
+		})
+
+		if errors.Is(err, errProbeLimited) {
+			if charged {
+				return nil, true, err
+			}
+
+			// the probe that waited for the analysis has its own budget
+			continue
+		}
+
+		if err != nil {
+			return nil, false, err
+		}
+
+		return value.(*probeEntry), false, nil
+	}
+}
+
+// previousAnalysis returns the analysis used to detect server changes, the cached one or else
+// the newest stored analysis of the domain
+func (p *HandlerRequest) previousAnalysis(ctx context.Context, entry *probeEntry, current *models.Domain) *models.Domain {
+	if entry != nil {
+		return entry.domain
+	}
+
+	if p.analyses == nil {
+		return nil
+	}
+
+	analyses, err := p.analyses.ListAnalyses(ctx, current.DomainName, 1, 0)
+	if err != nil || len(analyses) == 0 {
+		return nil
+	}
+
+	return analyses[0]
+}
+
+// domainFamilies builds the metrics of an analysis
+func domainFamilies(domain *models.Domain) []*metrics.Family {
+	grade := domain.SSLGrade
+	if grade == "" {
+		grade = models.LowestGrade(domain.Servers)
+	}
+
+	serverGrade := &metrics.Family{
+		Name: "crockroach_server_grade",
+		Help: "Numeric SSL grade of the server, higher is better (A+ is the highest, -1 unknown)",
+		Type: metrics.TypeGauge,
+	}
+
+	certificateDays := &metrics.Family{
+		Name: "crockroach_server_certificate_days_left",
+		Help: "Whole days until the certificate of the server expires, negative when it expired",
+		Type: metrics.TypeGauge,
+	}
+
+	now := time.Now()
+
+	for _, server := range domain.Servers {
+		serverGrade.Add(map[string]string{"address": server.Address}, float64(models.GradeValue(server.SSLGrade)))
+
+		// a server without a certificate, because the handshake failed or the tls provider is off, has no sample
+		if days, ok := server.TLS.DaysLeft(now); ok {
+			certificateDays.Add(map[string]string{"address": server.Address}, float64(days))
+		}
+	}
+
+	return []*metrics.Family{
+		metrics.NewGauge("crockroach_domain_grade", "Numeric SSL grade of the domain, higher is better (A+ is the highest, -1 unknown)", float64(models.GradeValue(grade))),
+		metrics.NewGauge("crockroach_domain_is_down", "Whether the domain is down", boolValue(domain.IsDown)),
+		metrics.NewGauge("crockroach_domain_servers", "Number of servers of the domain", float64(len(domain.Servers))),
+		metrics.NewGauge("crockroach_domain_server_changed", "Whether the servers changed since the previous analysis", boolValue(domain.ServerChanged)),
+		metrics.NewGauge("crockroach_domain_logo_changed", "Whether the cached logo changed since the previous analysis", boolValue(domain.LogoChanged)),
+		serverGrade,
+		certificateDays,
+	}
+}
+
+// respondWithMetrics writes the metrics in the Prometheus text format
+func respondWithMetrics(w http.ResponseWriter, families []*metrics.Family) {
+	body := new(bytes.Buffer)
+
+	err := metrics.Write(body, families)
+	if err != nil {
+		logs.Log().Errorf("Error write metrics %s", err.Error())
+		problem.Write(w, problem.FromError(nil, err))
+
+		return
+	}
+
+	w.Header().Set("Content-Type", metrics.ContentType)
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write(body.Bytes())
+	if err != nil {
+		logs.Log().Errorf("Error Write response %s", err.Error())
+	}
+}
+
+// boolValue converts a boolean to a metric value
+func boolValue(value bool) float64 {
+	if value {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,277 @@
+package httphand
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/internal/metrics"
+	"github.com/other_project/crockroach/internal/ratelimit"
+	"github.com/other_project/crockroach/models"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestDomain builds a domain with a server per address
+func newTestDomain(c *require.Assertions, domainName string, addresses ...string) *models.Domain {
+	domain, err := models.NewDomain(false, false, domainName, "", "", "https://example.com/favicon.ico", "Example")
+	c.NoError(err)
+
+	for _, address := range addresses {
+		server, err := models.NewServer(address, "A", "US", "EDGECAST", domain)
+		c.NoError(err)
+
+		domain.Servers = append(domain.Servers, server)
+	}
+
+	domain.SSLGrade = models.LowestGrade(domain.Servers)
+
+	return domain
+}
+
+func TestProbe(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	calls := 0
+	addresses := [][]string{{"10.0.0.1"}, {"10.0.0.1", "10.0.0.2"}}
+
+	handler := &HandlerRequest{
+		analyzeWith: func(ctx context.Context, domainName string, opts Options) (*models.Domain, error) {
+			domain := newTestDomain(c, domainName, addresses[calls]...)
+			calls++
+
+			return domain, nil
+		},
+		tracked: &memoryTracked{},
+		probes:  newProbeCache(time.Hour, 10),
+	}
+
+	rec := httptest.NewRecorder()
+	handler.Probe(rec, httptest.NewRequest(http.MethodGet, "/probe?target=example.com", nil))
+
+	c.Equal(http.StatusOK, rec.Code)
+	c.Equal(metrics.ContentType, rec.Header().Get("Content-Type"))
+	c.Contains(rec.Body.String(), "crockroach_probe_success 1\n")
+	c.Contains(rec.Body.String(), "crockroach_probe_cached 0\n")
+	c.Contains(rec.Body.String(), "crockroach_domain_grade 18\n")
+	c.Contains(rec.Body.String(), "crockroach_domain_servers 1\n")
+	c.Contains(rec.Body.String(), "crockroach_domain_server_changed 0\n")
+	c.Contains(rec.Body.String(), `crockroach_server_grade{address="10.0.0.1"} 18`)
+
+	// the target is normalized, every spelling of the domain shares the cache entry
+	for _, target := range []string{"example.com", "https://Example.com/", "example.com:443"} {
+		rec = httptest.NewRecorder()
+		handler.Probe(rec, httptest.NewRequest(http.MethodGet, "/probe?target="+url.QueryEscape(target), nil))
+		c.Contains(rec.Body.String(), "crockroach_probe_cached 1\n", target)
+	}
+
+	c.Equal(1, calls)
+
+	handler.probes.ttl = 0
+
+	rec = httptest.NewRecorder()
+	handler.Probe(rec, httptest.NewRequest(http.MethodGet, "/probe?target=example.com", nil))
+	c.Contains(rec.Body.String(), "crockroach_domain_servers 2\n")
+	c.Contains(rec.Body.String(), "crockroach_domain_server_changed 1\n")
+	c.Equal(2, calls)
+}
+
+func TestProbeUsesTrackedSettings(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	tracked, err := models.NewTrackedDomain("example.com")
+	c.NoError(err)
+
+	tracked.SkipWHOIS = true
+	tracked.HealthCheck = models.HealthCheck{Path: "/login", AcceptedStatus: []int{http.StatusUnauthorized}}
+
+	var used Options
+
+	handler := &HandlerRequest{
+		analyzeWith: func(ctx context.Context, domainName string, opts Options) (*models.Domain, error) {
+			used = opts
+			return newTestDomain(c, domainName, "10.0.0.1"), nil
+		},
+		tracked: &memoryTracked{domains: map[string]models.TrackedDomain{"example.com": *tracked}},
+		probes:  newProbeCache(time.Hour, 10),
+	}
+
+	rec := httptest.NewRecorder()
+	handler.Probe(rec, httptest.NewRequest(http.MethodGet, "/probe?target=example.com", nil))
+
+	c.Equal(http.StatusOK, rec.Code)
+	c.True(used.SkipWHOIS)
+	c.Equal(tracked.HealthCheck, used.Health)
+}
+
+func TestProbeFailure(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	handler := &HandlerRequest{
+		analyzeWith: func(ctx context.Context, domainName string, opts Options) (*models.Domain, error) {
+			return nil, errors.New("timeout")
+		},
+		tracked: &memoryTracked{},
+		probes:  newProbeCache(time.Hour, 10),
+	}
+
+	rec := httptest.NewRecorder()
+	handler.Probe(rec, httptest.NewRequest(http.MethodGet, "/probe?target=example.com", nil))
+	c.Equal(http.StatusOK, rec.Code)
+	c.Contains(rec.Body.String(), "crockroach_probe_success 0\n")
+	c.NotContains(rec.Body.String(), "crockroach_domain_grade")
+
+	rec = httptest.NewRecorder()
+	handler.Probe(rec, httptest.NewRequest(http.MethodGet, "/probe", nil))
+	c.Equal(http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.Probe(rec, httptest.NewRequest(http.MethodGet, "/probe?target=not_a_domain", nil))
+	c.Equal(http.StatusUnprocessableEntity, rec.Code)
+}
+
+func TestProbeChargesMisses(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	calls := 0
+
+	handler := &HandlerRequest{
+		analyzeWith: func(ctx context.Context, domainName string, opts Options) (*models.Domain, error) {
+			calls++
+			return newTestDomain(c, domainName, "10.0.0.1"), nil
+		},
+		tracked: &memoryTracked{},
+		probes:  newProbeCache(time.Hour, 10),
+	}
+
+	limiter := ratelimit.New(ratelimit.Policy{Name: "analyze", PerMinute: 1, Burst: 1})
+	probe := limiter.Deferred(http.HandlerFunc(handler.Probe))
+
+	do := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		probe.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/probe?target="+target, nil))
+
+		return rec
+	}
+
+	c.Equal(http.StatusOK, do("example.com").Code)
+
+	// the cached analysis does not consume the analyze budget
+	rec := do("example.com")
+	c.Equal(http.StatusOK, rec.Code)
+	c.Contains(rec.Body.String(), "crockroach_probe_cached 1\n")
+
+	rec = do("example.org")
+	c.Equal(http.StatusTooManyRequests, rec.Code)
+	c.Equal(1, calls)
+}
+
+func TestProbeDeduplicatesMisses(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	var calls int32
+
+	analyzing := make(chan struct{}, 1)
+	release := make(chan struct{})
+
+	handler := &HandlerRequest{
+		analyzeWith: func(ctx context.Context, domainName string, opts Options) (*models.Domain, error) {
+			atomic.AddInt32(&calls, 1)
+			analyzing <- struct{}{}
+			<-release
+
+			return newTestDomain(c, domainName, "10.0.0.1"), nil
+		},
+		tracked: &memoryTracked{},
+		probes:  newProbeCache(time.Hour, 10),
+	}
+
+	// the budget only covers one analysis
+	limiter := ratelimit.New(ratelimit.Policy{Name: "analyze", PerMinute: 1, Burst: 1})
+	probe := limiter.Deferred(http.HandlerFunc(handler.Probe))
+
+	const probes = 5
+
+	codes := make(chan int, probes)
+	do := func() {
+		rec := httptest.NewRecorder()
+		probe.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/probe?target=example.com", nil))
+		codes <- rec.Code
+	}
+
+	go do()
+	<-analyzing
+
+	for i := 1; i < probes; i++ {
+		go do()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	for i := 0; i < probes; i++ {
+		c.Equal(http.StatusOK, <-codes)
+	}
+
+	c.Equal(int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestProbeCacheSize(t *testing.T) {
+	c := require.New(t)
+
+	cache := newProbeCache(time.Hour, 2)
+	cache.set("a.com", &models.Domain{})
+	cache.set("b.com", &models.Domain{})
+
+	// reading a.com makes b.com the least recently used
+	_, fresh := cache.get("a.com")
+	c.True(fresh)
+
+	cache.set("c.com", &models.Domain{})
+	c.Len(cache.entries, 2)
+
+	_, fresh = cache.get("b.com")
+	c.False(fresh)
+
+	_, fresh = cache.get("a.com")
+	c.True(fresh)
+}
+
+func TestProbeCertificatesAndPrevious(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	// the newest stored analysis comes first and has the same servers as the probe
+	newest := newTestDomain(c, "example.com", "10.0.0.1", "10.0.0.2")
+	oldest := newTestDomain(c, "example.com", "10.0.0.1")
+
+	handler := &HandlerRequest{
+		analyzeWith: func(ctx context.Context, domainName string, opts Options) (*models.Domain, error) {
+			domain := newTestDomain(c, domainName, "10.0.0.1", "10.0.0.2")
+			domain.Servers[0].TLS = &models.TLSInspection{Chain: []models.Certificate{{NotAfter: time.Now().Add(10*24*time.Hour + time.Hour)}}}
+
+			return domain, nil
+		},
+		analyses: &memoryAnalyses{analyses: []*models.Domain{newest, oldest}},
+		tracked:  &memoryTracked{},
+		probes:   newProbeCache(time.Hour, 10),
+	}
+
+	rec := httptest.NewRecorder()
+	handler.Probe(rec, httptest.NewRequest(http.MethodGet, "/probe?target=example.com", nil))
+
+	c.Equal(http.StatusOK, rec.Code)
+	c.Contains(rec.Body.String(), "crockroach_domain_server_changed 0\n")
+	c.Contains(rec.Body.String(), `crockroach_server_certificate_days_left{address="10.0.0.1"} 10`)
+	c.NotContains(rec.Body.String(), `crockroach_server_certificate_days_left{address="10.0.0.2"}`, "a server without certificate has no sample")
+}
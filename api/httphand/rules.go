@@ -0,0 +1,413 @@
+package httphand
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/gofrs/uuid"
+	"github.com/other_project/crockroach/internal/apperr"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/internal/problem"
+	"github.com/other_project/crockroach/internal/rules"
+	"github.com/other_project/crockroach/internal/storage"
+	"github.com/other_project/crockroach/models"
+)
+
+var (
+	// ErrRuleKindChanged when an update tries to change the kind of a rule
+	ErrRuleKindChanged = apperr.New(apperr.Unprocessable, "rule_kind_changed", "the kind of a rule cannot change")
+)
+
+// RuleStore persists the alert rules and their results
+type RuleStore interface {
+	StoreRule(ctx context.Context, rule *models.Rule) (*models.Rule, error)
+	GetRule(ctx context.Context, ruleID string) (*models.Rule, error)
+	ListRules(ctx context.Context, limit, offset int64) ([]*models.Rule, error)
+	UpdateRule(ctx context.Context, rule *models.Rule) (*models.Rule, error)
+	DeleteRule(ctx context.Context, ruleID string) error
+	ListRuleEvaluations(ctx context.Context, ruleID string, limit, offset int64) ([]*models.RuleEvaluation, error)
+	ListAlerts(ctx context.Context, domainName string, limit, offset int64) ([]*models.Alert, error)
+}
+
+// RuleRequest is the body to create a rule or to change its settings.
+// The omitted settings keep their current or default value
+type RuleRequest struct {
+	Name      *string `json:"name,omitempty"`
+	Kind      string  `json:"kind,omitempty"`
+	Domain    *string `json:"domain,omitempty"`
+	Grade     *string `json:"grade,omitempty"`
+	Threshold *int64  `json:"threshold,omitempty"`
+	Window    *int64  `json:"window,omitempty"`
+	Enabled   *bool   `json:"enabled,omitempty"`
+}
+
+// RuleResponse is the v1 representation of an alert rule
+type RuleResponse struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Kind      string    `json:"kind"`
+	Domain    string    `json:"domain"`
+	Grade     string    `json:"grade"`
+	Threshold int64     `json:"threshold"`
+	Window    int64     `json:"window"`
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// RuleList is a page of rules
+type RuleList struct {
+	Items  []*RuleResponse `json:"items"`
+	Limit  int64           `json:"limit"`
+	Offset int64           `json:"offset"`
+}
+
+// EvaluationResponse is the result of a rule for an analysis
+type EvaluationResponse struct {
+	ID         string    `json:"id"`
+	RuleID     string    `json:"rule_id"`
+	AnalysisID string    `json:"analysis_id"`
+	Domain     string    `json:"domain"`
+	Fired      bool      `json:"fired"`
+	Message    string    `json:"message"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// EvaluationList is a page of evaluations, the newest first
+type EvaluationList struct {
+	Items  []*EvaluationResponse `json:"items"`
+	Limit  int64                 `json:"limit"`
+	Offset int64                 `json:"offset"`
+}
+
+// AlertResponse is a rule that started firing for a domain
+type AlertResponse struct {
+	ID           string    `json:"id"`
+	RuleID       string    `json:"rule_id"`
+	RuleName     string    `json:"rule_name"`
+	EvaluationID string    `json:"evaluation_id"`
+	AnalysisID   string    `json:"analysis_id"`
+	Domain       string    `json:"domain"`
+	Message      string    `json:"message"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// AlertList is a page of alerts, the newest first
+type AlertList struct {
+	Items  []*AlertResponse `json:"items"`
+	Limit  int64            `json:"limit"`
+	Offset int64            `json:"offset"`
+}
+
+// NewRuleResponse converts the rule to its v1 representation
+func NewRuleResponse(rule *models.Rule) *RuleResponse {
+	res := &RuleResponse{
+		ID:        rule.RuleID,
+		Name:      rule.Name,
+		Kind:      rule.Kind,
+		Domain:    rule.DomainName,
+		Grade:     rule.Grade,
+		Threshold: rule.Threshold,
+		Window:    rule.Window,
+		Enabled:   rule.Enabled,
+	}
+
+	if rule.CreationDate != nil {
+		res.CreatedAt = rule.CreationDate.UTC()
+	}
+
+	if rule.UpdateDate != nil {
+		res.UpdatedAt = rule.UpdateDate.UTC()
+	}
+
+	return res
+}
+
+// NewEvaluationResponse converts the evaluation to its v1 representation
+func NewEvaluationResponse(evaluation *models.RuleEvaluation) *EvaluationResponse {
+	res := &EvaluationResponse{
+		ID:         evaluation.EvaluationID,
+		RuleID:     evaluation.RuleID,
+		AnalysisID: evaluation.AnalysisID,
+		Domain:     evaluation.DomainName,
+		Fired:      evaluation.Fired,
+		Message:    evaluation.Message,
+	}
+
+	if evaluation.CreationDate != nil {
+		res.CreatedAt = evaluation.CreationDate.UTC()
+	}
+
+	return res
+}
+
+// NewAlertResponse converts the alert to its v1 representation
+func NewAlertResponse(alert *models.Alert) *AlertResponse {
+	res := &AlertResponse{
+		ID:           alert.AlertID,
+		RuleID:       alert.RuleID,
+		RuleName:     alert.RuleName,
+		EvaluationID: alert.EvaluationID,
+		AnalysisID:   alert.AnalysisID,
+		Domain:       alert.DomainName,
+		Message:      alert.Message,
+	}
+
+	if alert.CreationDate != nil {
+		res.CreatedAt = alert.CreationDate.UTC()
+	}
+
+	return res
+}
+
+// apply copies the settings of the body to the rule and validates them
+func (body *RuleRequest) apply(rule *models.Rule) error {
+	if body.Name != nil {
+		rule.Name = strings.TrimSpace(*body.Name)
+	}
+
+	if body.Domain != nil {
+		rule.DomainName = ""
+
+		if strings.TrimSpace(*body.Domain) != "" {
+			domainName, err := models.NormalizeDomainName(*body.Domain)
+			if err != nil {
+				return err
+			}
+
+			rule.DomainName = domainName
+		}
+	}
+
+	if body.Grade != nil {
+		rule.Grade = strings.ToUpper(strings.TrimSpace(*body.Grade))
+	}
+
+	if body.Threshold != nil {
+		rule.Threshold = *body.Threshold
+	}
+
+	if body.Window != nil {
+		rule.Window = *body.Window
+	}
+
+	if body.Enabled != nil {
+		rule.Enabled = *body.Enabled
+	}
+
+	return rule.Validate()
+}
+
+// evaluateRules applies the alert rules to a stored analysis. A failure is logged
+// because the analysis is already saved
+func evaluateRules(ctx context.Context, store rules.Store, analysis *models.Domain) {
+	alerts, err := rules.NewEvaluator(store).EvaluateAnalysis(ctx, analysis)
+	if err != nil {
+		logs.Log().Errorf("cannot evaluate the rules of %s: %s", analysis.DomainName, err.Error())
+	}
+
+	for _, alert := range alerts {
+		logs.Log().Infof("alert %s for %s: %s", alert.RuleName, alert.DomainName, alert.Message)
+	}
+}
+
+// ListRules returns a page of the alert rules
+func (p *HandlerRequest) ListRules(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := parsePagination(r)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	items, err := p.rules.ListRules(r.Context(), limit, offset)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	list := &RuleList{Items: []*RuleResponse{}, Limit: limit, Offset: offset}
+
+	for _, rule := range items {
+		list.Items = append(list.Items, NewRuleResponse(rule))
+	}
+
+	respondwithJSON(w, http.StatusOK, list)
+}
+
+// CreateRule saves the rule of the body, it is evaluated after the next analyses
+func (p *HandlerRequest) CreateRule(w http.ResponseWriter, r *http.Request) {
+	var body RuleRequest
+
+	err := decodeJSON(w, r, &body)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	name := ""
+	if body.Name != nil {
+		name = *body.Name
+	}
+
+	rule, err := models.NewRule(name, body.Kind)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	err = body.apply(rule)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	stored, err := p.rules.StoreRule(r.Context(), rule)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", "/api/v1/rules/"+stored.RuleID)
+	respondwithJSON(w, http.StatusCreated, NewRuleResponse(stored))
+}
+
+// GetRule returns a rule
+func (p *HandlerRequest) GetRule(w http.ResponseWriter, r *http.Request) {
+	rule, err := p.findRule(r)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, NewRuleResponse(rule))
+}
+
+// UpdateRule changes the settings present in the body
+func (p *HandlerRequest) UpdateRule(w http.ResponseWriter, r *http.Request) {
+	rule, err := p.findRule(r)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	var body RuleRequest
+
+	err = decodeJSON(w, r, &body)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	if body.Kind != "" && body.Kind != rule.Kind {
+		problem.Error(w, r, ErrRuleKindChanged)
+		return
+	}
+
+	err = body.apply(rule)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	updated, err := p.rules.UpdateRule(r.Context(), rule)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, NewRuleResponse(updated))
+}
+
+// DeleteRule removes a rule, its evaluations and alerts are kept
+func (p *HandlerRequest) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	rule, err := p.findRule(r)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	err = p.rules.DeleteRule(r.Context(), rule.RuleID)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListRuleEvaluations returns the results of a rule, the newest first
+func (p *HandlerRequest) ListRuleEvaluations(w http.ResponseWriter, r *http.Request) {
+	rule, err := p.findRule(r)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	limit, offset, err := parsePagination(r)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	items, err := p.rules.ListRuleEvaluations(r.Context(), rule.RuleID, limit, offset)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	list := &EvaluationList{Items: []*EvaluationResponse{}, Limit: limit, Offset: offset}
+
+	for _, evaluation := range items {
+		list.Items = append(list.Items, NewEvaluationResponse(evaluation))
+	}
+
+	respondwithJSON(w, http.StatusOK, list)
+}
+
+// ListAlerts returns the alerts, the newest first. ?domain= filters them
+func (p *HandlerRequest) ListAlerts(w http.ResponseWriter, r *http.Request) {
+	limit, offset, err := parsePagination(r)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	domainName := ""
+
+	if value := r.URL.Query().Get("domain"); value != "" {
+		domainName, err = models.NormalizeDomainName(value)
+		if err != nil {
+			problem.Error(w, r, err)
+			return
+		}
+	}
+
+	items, err := p.rules.ListAlerts(r.Context(), domainName, limit, offset)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	list := &AlertList{Items: []*AlertResponse{}, Limit: limit, Offset: offset}
+
+	for _, alert := range items {
+		list.Items = append(list.Items, NewAlertResponse(alert))
+	}
+
+	respondwithJSON(w, http.StatusOK, list)
+}
+
+// findRule loads the rule of the URL
+func (p *HandlerRequest) findRule(r *http.Request) (*models.Rule, error) {
+	ruleID := chi.URLParam(r, "id")
+
+	_, err := uuid.FromString(ruleID)
+	if err != nil {
+		return nil, storage.ErrRuleNotFound
+	}
+
+	return p.rules.GetRule(r.Context(), ruleID)
+}
@@ -0,0 +1,242 @@
+package httphand
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/internal/storage"
+	"github.com/other_project/crockroach/models"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryRules is an in memory RuleStore
+type memoryRules struct {
+	mu          sync.Mutex
+	rules       []models.Rule
+	evaluations []*models.RuleEvaluation
+	alerts      []*models.Alert
+}
+
+func (m *memoryRules) StoreRule(ctx context.Context, rule *models.Rule) (*models.Rule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.rules = append(m.rules, *rule)
+	copied := *rule
+
+	return &copied, nil
+}
+
+func (m *memoryRules) GetRule(ctx context.Context, ruleID string) (*models.Rule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, rule := range m.rules {
+		if rule.RuleID == ruleID {
+			copied := rule
+			return &copied, nil
+		}
+	}
+
+	return nil, storage.ErrRuleNotFound
+}
+
+func (m *memoryRules) ListRules(ctx context.Context, limit, offset int64) ([]*models.Rule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	items := []*models.Rule{}
+
+	for i := offset; i < int64(len(m.rules)) && int64(len(items)) < limit; i++ {
+		copied := m.rules[i]
+		items = append(items, &copied)
+	}
+
+	return items, nil
+}
+
+func (m *memoryRules) UpdateRule(ctx context.Context, rule *models.Rule) (*models.Rule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.rules {
+		if m.rules[i].RuleID == rule.RuleID {
+			m.rules[i] = *rule
+			copied := *rule
+
+			return &copied, nil
+		}
+	}
+
+	return nil, storage.ErrRuleNotFound
+}
+
+func (m *memoryRules) DeleteRule(ctx context.Context, ruleID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.rules {
+		if m.rules[i].RuleID == ruleID {
+			m.rules = append(m.rules[:i], m.rules[i+1:]...)
+			return nil
+		}
+	}
+
+	return storage.ErrRuleNotFound
+}
+
+func (m *memoryRules) ListRuleEvaluations(ctx context.Context, ruleID string, limit, offset int64) ([]*models.RuleEvaluation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	items := []*models.RuleEvaluation{}
+
+	for _, evaluation := range m.evaluations {
+		if evaluation.RuleID == ruleID {
+			items = append(items, evaluation)
+		}
+	}
+
+	return items, nil
+}
+
+func (m *memoryRules) ListAlerts(ctx context.Context, domainName string, limit, offset int64) ([]*models.Alert, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	items := []*models.Alert{}
+
+	for _, alert := range m.alerts {
+		if domainName == "" || alert.DomainName == domainName {
+			items = append(items, alert)
+		}
+	}
+
+	return items, nil
+}
+
+func newRulesRouter() (*chi.Mux, *memoryRules) {
+	store := &memoryRules{}
+	handler := &HandlerRequest{rules: store}
+
+	mux := chi.NewMux()
+	mux.Get("/api/v1/rules", handler.ListRules)
+	mux.Post("/api/v1/rules", handler.CreateRule)
+	mux.Get("/api/v1/rules/{id}", handler.GetRule)
+	mux.Patch("/api/v1/rules/{id}", handler.UpdateRule)
+	mux.Delete("/api/v1/rules/{id}", handler.DeleteRule)
+	mux.Get("/api/v1/rules/{id}/evaluations", handler.ListRuleEvaluations)
+	mux.Get("/api/v1/alerts", handler.ListAlerts)
+
+	return mux, store
+}
+
+func TestRules(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	spec := loadOpenAPI(c)
+	mux, store := newRulesRouter()
+
+	rec, body := sendJSON(c, mux, http.MethodPost, "/api/v1/rules", `{"name": "grade", "kind": "grade_below", "domain": "Example.com", "grade": "a"}`)
+	c.Equal(http.StatusCreated, rec.Code)
+	validateResponse(c, spec, spec.Components.Schemas["Rule"], body)
+
+	rule := body.(map[string]interface{})
+	c.Equal("example.com", rule["domain"])
+	c.Equal("A", rule["grade"])
+	c.Equal("/api/v1/rules/"+rule["id"].(string), rec.Header().Get("Location"))
+
+	rec, body = sendJSON(c, mux, http.MethodPost, "/api/v1/rules", `{"name": "down", "kind": "down_consecutive"}`)
+	c.Equal(http.StatusCreated, rec.Code)
+	c.Equal(float64(3), body.(map[string]interface{})["threshold"], "the default of the kind")
+	c.Equal("", body.(map[string]interface{})["domain"])
+
+	rec, body = getJSON(c, mux, "/api/v1/rules")
+	c.Equal(http.StatusOK, rec.Code)
+	validateResponse(c, spec, spec.Components.Schemas["RuleList"], body)
+	c.Len(body.(map[string]interface{})["items"], 2)
+
+	path := "/api/v1/rules/" + rule["id"].(string)
+
+	rec, body = sendJSON(c, mux, http.MethodPatch, path, `{"enabled": false, "domain": ""}`)
+	c.Equal(http.StatusOK, rec.Code)
+	validateResponse(c, spec, spec.Components.Schemas["Rule"], body)
+	c.Equal(false, body.(map[string]interface{})["enabled"])
+	c.Equal("", body.(map[string]interface{})["domain"])
+	c.Equal("A", body.(map[string]interface{})["grade"], "the omitted settings are kept")
+
+	now := time.Now()
+	store.evaluations = append(store.evaluations, &models.RuleEvaluation{
+		EvaluationID: "5b0a3f3e-8a0c-4a4e-9d6b-1e2f3a4b5c6d", RuleID: rule["id"].(string), AnalysisID: "6c1b4f4f-9b1d-4b5f-8e7c-2f3a4b5c6d7e",
+		DomainName: "example.com", Fired: true, Message: "grade B is below A", CreationDate: &now,
+	})
+	store.alerts = append(store.alerts, &models.Alert{
+		AlertID: "7d2c5a5a-0c2e-4c6a-9f8d-3a4b5c6d7e8f", RuleID: rule["id"].(string), RuleName: "grade",
+		EvaluationID: "5b0a3f3e-8a0c-4a4e-9d6b-1e2f3a4b5c6d", AnalysisID: "6c1b4f4f-9b1d-4b5f-8e7c-2f3a4b5c6d7e",
+		DomainName: "example.com", Message: "grade B is below A", CreationDate: &now,
+	})
+
+	rec, body = getJSON(c, mux, path+"/evaluations")
+	c.Equal(http.StatusOK, rec.Code)
+	validateResponse(c, spec, spec.Components.Schemas["RuleEvaluationList"], body)
+	c.Len(body.(map[string]interface{})["items"], 1)
+
+	rec, body = getJSON(c, mux, "/api/v1/alerts?domain=EXAMPLE.com")
+	c.Equal(http.StatusOK, rec.Code)
+	validateResponse(c, spec, spec.Components.Schemas["AlertList"], body)
+	c.Len(body.(map[string]interface{})["items"], 1)
+
+	rec, body = getJSON(c, mux, "/api/v1/alerts?domain=other.com")
+	c.Equal(http.StatusOK, rec.Code)
+	c.Empty(body.(map[string]interface{})["items"])
+
+	rec, _ = sendJSON(c, mux, http.MethodDelete, path, "")
+	c.Equal(http.StatusNoContent, rec.Code)
+
+	rec, body = getJSON(c, mux, path)
+	c.Equal(http.StatusNotFound, rec.Code)
+	validateResponse(c, spec, spec.Components.Schemas["Problem"], body)
+}
+
+func TestRulesErrors(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	spec := loadOpenAPI(c)
+	mux, _ := newRulesRouter()
+
+	rec, body := sendJSON(c, mux, http.MethodPost, "/api/v1/rules", `{"name": "owner", "kind": "owner_changed"}`)
+	c.Equal(http.StatusCreated, rec.Code)
+
+	path := "/api/v1/rules/" + body.(map[string]interface{})["id"].(string)
+
+	cases := []struct {
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{http.MethodPost, "/api/v1/rules", `{"kind": "grade_below"}`, http.StatusUnprocessableEntity},
+		{http.MethodPost, "/api/v1/rules", `{"name": "x", "kind": "latency"}`, http.StatusUnprocessableEntity},
+		{http.MethodPost, "/api/v1/rules", `{"name": "x", "kind": "grade_below", "grade": "Z"}`, http.StatusUnprocessableEntity},
+		{http.MethodPost, "/api/v1/rules", `{"name": "x", "kind": "down_consecutive", "threshold": 0}`, http.StatusUnprocessableEntity},
+		{http.MethodPost, "/api/v1/rules", `{"name": "x", "kind": "grade_drops", "window": 10}`, http.StatusUnprocessableEntity},
+		{http.MethodPost, "/api/v1/rules", `{"name": "x", "kind": "owner_changed", "scope": "all"}`, http.StatusBadRequest},
+		{http.MethodPatch, path, `{"kind": "grade_below"}`, http.StatusUnprocessableEntity},
+		{http.MethodPatch, "/api/v1/rules/not-a-uuid", `{"enabled": true}`, http.StatusNotFound},
+		{http.MethodDelete, "/api/v1/rules/7d2c5a5a-0c2e-4c6a-9f8d-3a4b5c6d7e8f", "", http.StatusNotFound},
+		{http.MethodGet, "/api/v1/alerts?limit=0", "", http.StatusBadRequest},
+	}
+
+	for _, tc := range cases {
+		rec, body := sendJSON(c, mux, tc.method, tc.path, tc.body)
+		c.Equal(tc.status, rec.Code, "%s %s %s", tc.method, tc.path, tc.body)
+		validateResponse(c, spec, spec.Components.Schemas["Problem"], body)
+	}
+}
@@ -0,0 +1,337 @@
+package httphand
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/other_project/crockroach/internal/apperr"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/internal/problem"
+	"github.com/other_project/crockroach/internal/storage"
+	"github.com/other_project/crockroach/models"
+)
+
+var (
+	// ErrDomainMismatch when the body of an update names another domain
+	ErrDomainMismatch = apperr.New(apperr.Unprocessable, "domain_mismatch", "the domain of the body does not match the URL")
+)
+
+// TrackedStore persists the watchlist
+type TrackedStore interface {
+	StoreTrackedDomain(ctx context.Context, tracked *models.TrackedDomain) (*models.TrackedDomain, error)
+	GetTrackedDomain(ctx context.Context, domainName string) (*models.TrackedDomain, error)
+	ListTrackedDomains(ctx context.Context, tag string, limit, offset int64) ([]*models.TrackedDomain, error)
+	UpdateTrackedDomain(ctx context.Context, tracked *models.TrackedDomain) (*models.TrackedDomain, error)
+	DeleteTrackedDomain(ctx context.Context, domainName string) error
+}
+
+// TrackedDomainRequest is the body to track a domain or to change its settings.
+// The omitted settings keep their current or default value
+type TrackedDomainRequest struct {
+	Domain              string   `json:"domain,omitempty"`
+	CheckInterval       *int64   `json:"check_interval,omitempty"`
+	Enabled             *bool    `json:"enabled,omitempty"`
+	Tags                []string `json:"tags,omitempty"`
+	NotificationTargets []string `json:"notification_targets,omitempty"`
+	Providers           []string `json:"providers,omitempty"`
+	SkipWHOIS           *bool    `json:"skip_whois,omitempty"`
+	// HealthCheck replaces every expectation of the check
+	HealthCheck *models.HealthCheck `json:"health_check,omitempty"`
+}
+
+// TrackedDomainResponse is a domain of the watchlist, Latest is its last stored analysis
+type TrackedDomainResponse struct {
+	ID                  string             `json:"id"`
+	Domain              string             `json:"domain"`
+	CheckInterval       int64              `json:"check_interval"`
+	Enabled             bool               `json:"enabled"`
+	Tags                []string           `json:"tags"`
+	NotificationTargets []string           `json:"notification_targets"`
+	Providers           []string           `json:"providers"`
+	SkipWHOIS           bool               `json:"skip_whois"`
+	HealthCheck         models.HealthCheck `json:"health_check"`
+	LastCheckedAt       *time.Time         `json:"last_checked_at,omitempty"`
+	CreatedAt           time.Time          `json:"created_at"`
+	UpdatedAt           time.Time          `json:"updated_at"`
+	Latest              *AnalysisResponse  `json:"latest,omitempty"`
+}
+
+// TrackedDomainList is a page of the watchlist
+type TrackedDomainList struct {
+	Items  []*TrackedDomainResponse `json:"items"`
+	Limit  int64                    `json:"limit"`
+	Offset int64                    `json:"offset"`
+}
+
+// NewTrackedDomainResponse converts the tracked domain to its v1 representation
+func NewTrackedDomainResponse(tracked *models.TrackedDomain) *TrackedDomainResponse {
+	res := &TrackedDomainResponse{
+		ID:                  tracked.TrackedID,
+		Domain:              tracked.DomainName,
+		CheckInterval:       tracked.CheckInterval,
+		Enabled:             tracked.Enabled,
+		Tags:                tracked.Tags,
+		NotificationTargets: tracked.NotificationTargets,
+		Providers:           tracked.Providers,
+		SkipWHOIS:           tracked.SkipWHOIS,
+		HealthCheck:         tracked.HealthCheck.Redacted(),
+		LastCheckedAt:       tracked.LastCheckDate,
+	}
+
+	if tracked.CreationDate != nil {
+		res.CreatedAt = tracked.CreationDate.UTC()
+	}
+
+	if tracked.UpdateDate != nil {
+		res.UpdatedAt = tracked.UpdateDate.UTC()
+	}
+
+	return res
+}
+
+// apply copies the settings of the body to the tracked domain and validates them
+func (body *TrackedDomainRequest) apply(tracked *models.TrackedDomain) error {
+	if body.CheckInterval != nil {
+		tracked.CheckInterval = *body.CheckInterval
+	}
+
+	if body.Enabled != nil {
+		tracked.Enabled = *body.Enabled
+	}
+
+	if body.Tags != nil {
+		tracked.Tags = body.Tags
+	}
+
+	if body.NotificationTargets != nil {
+		tracked.NotificationTargets = body.NotificationTargets
+	}
+
+	if body.Providers != nil {
+		err := Options{Providers: body.Providers}.Validate()
+		if err != nil {
+			return err
+		}
+
+		tracked.Providers = body.Providers
+	}
+
+	if body.SkipWHOIS != nil {
+		tracked.SkipWHOIS = *body.SkipWHOIS
+	}
+
+	if body.HealthCheck != nil {
+		// the check read from the API has the credentials redacted
+		tracked.HealthCheck = body.HealthCheck.WithSecretsOf(tracked.HealthCheck)
+	}
+
+	return tracked.Validate()
+}
+
+// analyzeDomain runs the analysis of the domain with its settings when it's tracked
+func (p *HandlerRequest) analyzeDomain(ctx context.Context, domainName string) (*models.Domain, error) {
+	return p.analyzeWith(ctx, domainName, p.trackedOptions(ctx, domainName))
+}
+
+// trackedOptions returns the analysis options of the domain, the defaults when it's not tracked
+func (p *HandlerRequest) trackedOptions(ctx context.Context, domainName string) Options {
+	opts := DefaultOptions()
+
+	tracked, err := p.tracked.GetTrackedDomain(ctx, domainName)
+	if err != nil {
+		if !errors.Is(err, storage.ErrTrackedDomainNotFound) {
+			logs.Log().Errorf("cannot load the settings of %s: %s", domainName, err.Error())
+		}
+
+		return opts
+	}
+
+	if len(tracked.Providers) > 0 {
+		opts.Providers = tracked.Providers
+	}
+
+	opts.SkipWHOIS = tracked.SkipWHOIS
+	opts.Health = tracked.HealthCheck
+
+	return opts
+}
+
+// ListDomains returns the tracked domains with their last stored analysis
+func (p *HandlerRequest) ListDomains(w http.ResponseWriter, r *http.Request) {
+	p.listTracked(w, r, true)
+}
+
+// ListTracked returns the settings of the tracked domains, ?tag= filters them
+func (p *HandlerRequest) ListTracked(w http.ResponseWriter, r *http.Request) {
+	p.listTracked(w, r, false)
+}
+
+// listTracked writes a page of the watchlist, withLatest adds the last analysis of every domain
+func (p *HandlerRequest) listTracked(w http.ResponseWriter, r *http.Request, withLatest bool) {
+	limit, offset, err := parsePagination(r)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	tag := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("tag")))
+
+	domains, err := p.tracked.ListTrackedDomains(r.Context(), tag, limit, offset)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	list := &TrackedDomainList{Items: []*TrackedDomainResponse{}, Limit: limit, Offset: offset}
+
+	for _, tracked := range domains {
+		res := NewTrackedDomainResponse(tracked)
+
+		if withLatest {
+			res.Latest, err = p.latestResponse(r.Context(), tracked.DomainName)
+			if err != nil {
+				problem.Error(w, r, err)
+				return
+			}
+		}
+
+		list.Items = append(list.Items, res)
+	}
+
+	respondwithJSON(w, http.StatusOK, list)
+}
+
+// CreateTracked adds the domain of the body to the watchlist
+func (p *HandlerRequest) CreateTracked(w http.ResponseWriter, r *http.Request) {
+	var body TrackedDomainRequest
+
+	err := decodeJSON(w, r, &body)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	tracked, err := models.NewTrackedDomain(body.Domain)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	err = body.apply(tracked)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	stored, err := p.tracked.StoreTrackedDomain(r.Context(), tracked)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", "/api/v1/tracked-domains/"+stored.DomainName)
+	respondwithJSON(w, http.StatusCreated, NewTrackedDomainResponse(stored))
+}
+
+// GetTracked returns the settings of a tracked domain and its last stored analysis
+func (p *HandlerRequest) GetTracked(w http.ResponseWriter, r *http.Request) {
+	tracked, err := p.findTracked(r)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	res := NewTrackedDomainResponse(tracked)
+
+	res.Latest, err = p.latestResponse(r.Context(), tracked.DomainName)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, res)
+}
+
+// UpdateTracked changes the settings present in the body
+func (p *HandlerRequest) UpdateTracked(w http.ResponseWriter, r *http.Request) {
+	tracked, err := p.findTracked(r)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	var body TrackedDomainRequest
+
+	err = decodeJSON(w, r, &body)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	if body.Domain != "" {
+		domainName, err := models.NormalizeDomainName(body.Domain)
+		if err != nil || domainName != tracked.DomainName {
+			problem.Error(w, r, ErrDomainMismatch)
+			return
+		}
+	}
+
+	err = body.apply(tracked)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	updated, err := p.tracked.UpdateTrackedDomain(r.Context(), tracked)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, NewTrackedDomainResponse(updated))
+}
+
+// DeleteTracked removes the domain from the watchlist, its analyses are kept
+func (p *HandlerRequest) DeleteTracked(w http.ResponseWriter, r *http.Request) {
+	domainName, err := models.NormalizeDomainName(chi.URLParam(r, "name"))
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	err = p.tracked.DeleteTrackedDomain(r.Context(), domainName)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// findTracked loads the tracked domain of the URL
+func (p *HandlerRequest) findTracked(r *http.Request) (*models.TrackedDomain, error) {
+	domainName, err := models.NormalizeDomainName(chi.URLParam(r, "name"))
+	if err != nil {
+		return nil, err
+	}
+
+	return p.tracked.GetTrackedDomain(r.Context(), domainName)
+}
+
+// latestResponse returns the last stored analysis of the domain, nil when it was never analyzed
+func (p *HandlerRequest) latestResponse(ctx context.Context, domainName string) (*AnalysisResponse, error) {
+	analysis, err := p.latestAnalysis(ctx, domainName)
+	if errors.Is(err, ErrAnalysisNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewAnalysisResponse(analysis), nil
+}
@@ -0,0 +1,274 @@
+package httphand
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/internal/storage"
+	"github.com/other_project/crockroach/models"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryTracked is an in memory TrackedStore
+type memoryTracked struct {
+	mu      sync.Mutex
+	domains map[string]models.TrackedDomain
+}
+
+func (m *memoryTracked) StoreTrackedDomain(ctx context.Context, tracked *models.TrackedDomain) (*models.TrackedDomain, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.domains[tracked.DomainName]; ok {
+		return nil, storage.ErrTrackedDomainExists
+	}
+
+	m.domains[tracked.DomainName] = *tracked
+	copied := *tracked
+
+	return &copied, nil
+}
+
+func (m *memoryTracked) GetTrackedDomain(ctx context.Context, domainName string) (*models.TrackedDomain, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tracked, ok := m.domains[domainName]
+	if !ok {
+		return nil, storage.ErrTrackedDomainNotFound
+	}
+
+	return &tracked, nil
+}
+
+func (m *memoryTracked) ListTrackedDomains(ctx context.Context, tag string, limit, offset int64) ([]*models.TrackedDomain, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	items := []*models.TrackedDomain{}
+
+	for _, tracked := range m.domains {
+		if tag == "" || tracked.HasTag(tag) {
+			copied := tracked
+			items = append(items, &copied)
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].DomainName < items[j].DomainName })
+
+	if offset >= int64(len(items)) {
+		return []*models.TrackedDomain{}, nil
+	}
+
+	items = items[offset:]
+	if int64(len(items)) > limit {
+		items = items[:limit]
+	}
+
+	return items, nil
+}
+
+func (m *memoryTracked) UpdateTrackedDomain(ctx context.Context, tracked *models.TrackedDomain) (*models.TrackedDomain, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.domains[tracked.DomainName]; !ok {
+		return nil, storage.ErrTrackedDomainNotFound
+	}
+
+	m.domains[tracked.DomainName] = *tracked
+	copied := *tracked
+
+	return &copied, nil
+}
+
+func (m *memoryTracked) DeleteTrackedDomain(ctx context.Context, domainName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.domains[domainName]; !ok {
+		return storage.ErrTrackedDomainNotFound
+	}
+
+	delete(m.domains, domainName)
+
+	return nil
+}
+
+func newTrackedRouter(analyses *memoryAnalyses) (*chi.Mux, *HandlerRequest) {
+	handler := &HandlerRequest{
+		analyses: analyses,
+		tracked:  &memoryTracked{domains: make(map[string]models.TrackedDomain)},
+	}
+
+	mux := chi.NewMux()
+	mux.Get("/api/v1/domains", handler.ListDomains)
+	mux.Get("/api/v1/tracked-domains", handler.ListTracked)
+	mux.Post("/api/v1/tracked-domains", handler.CreateTracked)
+	mux.Get("/api/v1/tracked-domains/{name}", handler.GetTracked)
+	mux.Patch("/api/v1/tracked-domains/{name}", handler.UpdateTracked)
+	mux.Delete("/api/v1/tracked-domains/{name}", handler.DeleteTracked)
+
+	return mux, handler
+}
+
+// sendJSON sends the body and decodes the response, empty responses decode to nil
+func sendJSON(c *require.Assertions, mux *chi.Mux, method, path, body string) (*httptest.ResponseRecorder, interface{}) {
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+
+	var res interface{}
+	if rec.Body.Len() > 0 {
+		c.NoError(json.Unmarshal(rec.Body.Bytes(), &res))
+	}
+
+	return rec, res
+}
+
+func TestTrackedDomains(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	spec := loadOpenAPI(c)
+	analysis := newTestDomain(c, "example.com", "10.0.0.1")
+	mux, _ := newTrackedRouter(&memoryAnalyses{analyses: []*models.Domain{analysis}})
+
+	rec, body := sendJSON(c, mux, http.MethodPost, "/api/v1/tracked-domains", `{"domain": "Example.com", "check_interval": 600, "tags": ["Prod"], "notification_targets": ["mailto:ops@example.com"]}`)
+	c.Equal(http.StatusCreated, rec.Code)
+	c.Equal("/api/v1/tracked-domains/example.com", rec.Header().Get("Location"))
+	validateResponse(c, spec, spec.Components.Schemas["TrackedDomain"], body)
+	c.Equal([]interface{}{"prod"}, body.(map[string]interface{})["tags"])
+
+	rec, _ = sendJSON(c, mux, http.MethodPost, "/api/v1/tracked-domains", `{"domain": "example.com"}`)
+	c.Equal(http.StatusConflict, rec.Code)
+
+	rec, _ = sendJSON(c, mux, http.MethodPost, "/api/v1/tracked-domains", `{"domain": "staging.example.com", "enabled": false, "providers": ["whois"]}`)
+	c.Equal(http.StatusCreated, rec.Code)
+
+	rec, body = getJSON(c, mux, "/api/v1/domains")
+	c.Equal(http.StatusOK, rec.Code)
+	validateResponse(c, spec, spec.Components.Schemas["TrackedDomainList"], body)
+
+	items := body.(map[string]interface{})["items"].([]interface{})
+	c.Len(items, 2)
+	c.Equal(analysis.DomainID, items[0].(map[string]interface{})["latest"].(map[string]interface{})["id"])
+	c.Nil(items[1].(map[string]interface{})["latest"], "the domain was never analyzed")
+
+	rec, body = getJSON(c, mux, "/api/v1/tracked-domains?tag=PROD")
+	c.Equal(http.StatusOK, rec.Code)
+	c.Len(body.(map[string]interface{})["items"], 1)
+	c.Nil(body.(map[string]interface{})["items"].([]interface{})[0].(map[string]interface{})["latest"])
+
+	rec, body = sendJSON(c, mux, http.MethodPatch, "/api/v1/tracked-domains/example.com", `{"enabled": false, "tags": []}`)
+	c.Equal(http.StatusOK, rec.Code)
+	validateResponse(c, spec, spec.Components.Schemas["TrackedDomain"], body)
+	c.Equal(false, body.(map[string]interface{})["enabled"])
+	c.Equal(float64(600), body.(map[string]interface{})["check_interval"], "the omitted settings are kept")
+	c.Empty(body.(map[string]interface{})["tags"])
+
+	rec, body = getJSON(c, mux, "/api/v1/tracked-domains/EXAMPLE.com")
+	c.Equal(http.StatusOK, rec.Code)
+	validateResponse(c, spec, spec.Components.Schemas["TrackedDomain"], body)
+
+	rec, _ = sendJSON(c, mux, http.MethodDelete, "/api/v1/tracked-domains/example.com", "")
+	c.Equal(http.StatusNoContent, rec.Code)
+
+	rec, body = getJSON(c, mux, "/api/v1/tracked-domains/example.com")
+	c.Equal(http.StatusNotFound, rec.Code)
+	validateResponse(c, spec, spec.Components.Schemas["Problem"], body)
+}
+
+func TestTrackedDomainsErrors(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	spec := loadOpenAPI(c)
+	mux, _ := newTrackedRouter(&memoryAnalyses{})
+
+	rec, _ := sendJSON(c, mux, http.MethodPost, "/api/v1/tracked-domains", `{"domain": "example.com"}`)
+	c.Equal(http.StatusCreated, rec.Code)
+
+	cases := []struct {
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{http.MethodPost, "/api/v1/tracked-domains", `{}`, http.StatusUnprocessableEntity},
+		{http.MethodPost, "/api/v1/tracked-domains", `{"domain": "other.com", "check_interval": 10}`, http.StatusUnprocessableEntity},
+		{http.MethodPost, "/api/v1/tracked-domains", `{"domain": "other.com", "notification_targets": ["ftp://example.com"]}`, http.StatusUnprocessableEntity},
+		{http.MethodPost, "/api/v1/tracked-domains", `{"domain": "other.com", "providers": ["nmap"]}`, http.StatusBadRequest},
+		{http.MethodPost, "/api/v1/tracked-domains", `{"domain": "other.com", "interval": 600}`, http.StatusBadRequest},
+		{http.MethodPost, "/api/v1/tracked-domains", `{"domain": "other.com", "health_check": {"method": "DELETE"}}`, http.StatusUnprocessableEntity},
+		{http.MethodPost, "/api/v1/tracked-domains", `{"domain": "other.com", "health_check": {"status": [200]}}`, http.StatusBadRequest},
+		{http.MethodPatch, "/api/v1/tracked-domains/example.com", `{"domain": "other.com"}`, http.StatusUnprocessableEntity},
+		{http.MethodPatch, "/api/v1/tracked-domains/other.com", `{"enabled": true}`, http.StatusNotFound},
+		{http.MethodDelete, "/api/v1/tracked-domains/other.com", "", http.StatusNotFound},
+	}
+
+	for _, tc := range cases {
+		rec, body := sendJSON(c, mux, tc.method, tc.path, tc.body)
+		c.Equal(tc.status, rec.Code, "%s %s %s", tc.method, tc.path, tc.body)
+		validateResponse(c, spec, spec.Components.Schemas["Problem"], body)
+	}
+}
+
+func TestTrackedOptions(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	mux, handler := newTrackedRouter(&memoryAnalyses{})
+
+	rec, _ := sendJSON(c, mux, http.MethodPost, "/api/v1/tracked-domains", `{"domain": "example.com", "providers": ["ssllabs"], "skip_whois": true}`)
+	c.Equal(http.StatusCreated, rec.Code)
+
+	opts := handler.trackedOptions(context.Background(), "example.com")
+	c.Equal([]string{ProviderSSLLabs}, opts.Providers)
+	c.True(opts.SkipWHOIS)
+
+	c.Equal(DefaultOptions(), handler.trackedOptions(context.Background(), "other.com"))
+}
+
+func TestTrackedHealthCheck(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	spec := loadOpenAPI(c)
+	mux, handler := newTrackedRouter(&memoryAnalyses{})
+
+	rec, body := sendJSON(c, mux, http.MethodPost, "/api/v1/tracked-domains", `{"domain": "staging.example.com", "health_check": {"path": "/healthz", "accepted_status": [200, 401], "headers": {"authorization": "Basic b3BzOnNlY3JldA=="}, "max_response_ms": 2000}}`)
+	c.Equal(http.StatusCreated, rec.Code)
+	validateResponse(c, spec, spec.Components.Schemas["TrackedDomain"], body)
+
+	health := body.(map[string]interface{})["health_check"].(map[string]interface{})
+	c.Equal("/healthz", health["path"])
+	c.Equal(map[string]interface{}{"Authorization": "[redacted]"}, health["headers"], "the credentials are not shown")
+
+	opts := handler.trackedOptions(context.Background(), "staging.example.com")
+	c.Equal("Basic b3BzOnNlY3JldA==", opts.Health.Headers["Authorization"])
+	c.Equal([]int{200, 401}, opts.Health.AcceptedStatus)
+
+	rec, body = sendJSON(c, mux, http.MethodPatch, "/api/v1/tracked-domains/staging.example.com", `{"enabled": false}`)
+	c.Equal(http.StatusOK, rec.Code)
+	c.Equal("/healthz", body.(map[string]interface{})["health_check"].(map[string]interface{})["path"], "the check is kept")
+
+	// the check read from the API is sent back with the credential redacted
+	rec, _ = sendJSON(c, mux, http.MethodPatch, "/api/v1/tracked-domains/staging.example.com", `{"health_check": {"path": "/ready", "headers": {"Authorization": "[redacted]"}}}`)
+	c.Equal(http.StatusOK, rec.Code)
+
+	opts = handler.trackedOptions(context.Background(), "staging.example.com")
+	c.Equal("/ready", opts.Health.Path)
+	c.Equal("Basic b3BzOnNlY3JldA==", opts.Health.Headers["Authorization"], "the stored credential is kept")
+
+	rec, body = sendJSON(c, mux, http.MethodPatch, "/api/v1/tracked-domains/staging.example.com", `{"health_check": {}}`)
+	c.Equal(http.StatusOK, rec.Code)
+	c.Empty(body.(map[string]interface{})["health_check"])
+}
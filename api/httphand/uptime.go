@@ -0,0 +1,96 @@
+package httphand
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/other_project/crockroach/internal/problem"
+	"github.com/other_project/crockroach/internal/uptime"
+	"github.com/other_project/crockroach/models"
+)
+
+// UptimeStore lists the availability checks saved with the analyses
+type UptimeStore interface {
+	ListUptimeChecks(ctx context.Context, domainName string, since time.Time) ([]*models.UptimeCheck, error)
+}
+
+// UptimeCheckResponse is the availability check of an analysis, the timings are in milliseconds
+type UptimeCheckResponse struct {
+	ID         string    `json:"id"`
+	AnalysisID string    `json:"analysis_id"`
+	URL        string    `json:"url"`
+	Up         bool      `json:"up"`
+	StatusCode int       `json:"status_code"`
+	Redirects  []string  `json:"redirects"`
+	DNS        int64     `json:"dns_ms"`
+	Connect    int64     `json:"connect_ms"`
+	TLS        int64     `json:"tls_ms"`
+	TTFB       int64     `json:"ttfb_ms"`
+	Total      int64     `json:"total_ms"`
+	ErrorClass string    `json:"error_class,omitempty"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
+
+// UptimeResponse is the availability of a domain over the uptime windows
+type UptimeResponse struct {
+	Domain  string               `json:"domain"`
+	Latest  *UptimeCheckResponse `json:"latest,omitempty"`
+	Windows []uptime.Summary     `json:"windows"`
+}
+
+// NewUptimeCheckResponse converts the check to its v1 representation
+func NewUptimeCheckResponse(check *models.UptimeCheck) *UptimeCheckResponse {
+	res := &UptimeCheckResponse{
+		ID:         check.CheckID,
+		AnalysisID: check.AnalysisID,
+		URL:        check.URL,
+		Up:         check.Up,
+		StatusCode: check.StatusCode,
+		Redirects:  check.Redirects,
+		DNS:        check.DNSTime,
+		Connect:    check.ConnectTime,
+		TLS:        check.TLSTime,
+		TTFB:       check.TTFB,
+		Total:      check.TotalTime,
+		ErrorClass: check.ErrorClass,
+	}
+
+	if res.Redirects == nil {
+		res.Redirects = []string{}
+	}
+
+	if check.CreationDate != nil {
+		res.CheckedAt = check.CreationDate.UTC()
+	}
+
+	return res
+}
+
+// GetUptime returns the uptime percentage and the latency percentiles of the domain
+// over the last 24 hours, 7 days and 30 days, with its last check
+func (p *HandlerRequest) GetUptime(w http.ResponseWriter, r *http.Request) {
+	domainName, err := models.NormalizeDomainName(chi.URLParam(r, "name"))
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	now := time.Now()
+	longest := uptime.Windows[len(uptime.Windows)-1]
+
+	checks, err := p.uptime.ListUptimeChecks(r.Context(), domainName, now.Add(-longest.Duration))
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	res := &UptimeResponse{Domain: domainName, Windows: uptime.Summarize(checks, now)}
+
+	if len(checks) > 0 {
+		res.Latest = NewUptimeCheckResponse(checks[0])
+	}
+
+	respondwithJSON(w, http.StatusOK, res)
+}
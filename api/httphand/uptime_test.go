@@ -0,0 +1,87 @@
+package httphand
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/models"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryUptime is an in memory UptimeStore
+type memoryUptime struct {
+	checks []*models.UptimeCheck
+}
+
+func (m *memoryUptime) ListUptimeChecks(ctx context.Context, domainName string, since time.Time) ([]*models.UptimeCheck, error) {
+	items := []*models.UptimeCheck{}
+
+	for _, check := range m.checks {
+		if check.DomainName == domainName && !check.CreationDate.Before(since) {
+			items = append(items, check)
+		}
+	}
+
+	return items, nil
+}
+
+func TestGetUptime(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	spec := loadOpenAPI(c)
+	now := time.Now()
+	store := &memoryUptime{}
+
+	add := func(ago time.Duration, up bool, status int, class string) {
+		created := now.Add(-ago)
+		store.checks = append(store.checks, &models.UptimeCheck{
+			CheckID: "5b0a3f3e-8a0c-4a4e-9d6b-1e2f3a4b5c6d", AnalysisID: "6c1b4f4f-9b1d-4b5f-8e7c-2f3a4b5c6d7e", DomainName: "example.com",
+			URL: "https://example.com", Up: up, StatusCode: status, TTFB: 120, TotalTime: 200, ErrorClass: class, CreationDate: &created,
+		})
+	}
+
+	add(time.Minute, false, 0, models.ErrorClassDNS)
+	add(time.Hour, true, http.StatusOK, "")
+	add(48*time.Hour, true, http.StatusOK, "")
+	add(60*24*time.Hour, true, http.StatusOK, "")
+
+	handler := &HandlerRequest{uptime: store}
+	mux := chi.NewMux()
+	mux.Get("/api/v1/domains/{name}/uptime", handler.GetUptime)
+
+	rec, body := getJSON(c, mux, "/api/v1/domains/Example.com/uptime")
+	c.Equal(http.StatusOK, rec.Code)
+	validateResponse(c, spec, spec.Components.Schemas["Uptime"], body)
+
+	res := body.(map[string]interface{})
+	c.Equal("dns", res["latest"].(map[string]interface{})["error_class"])
+
+	windows := res["windows"].([]interface{})
+	c.Len(windows, 3)
+	c.Equal(float64(2), windows[0].(map[string]interface{})["checks"])
+	c.Equal(float64(50), windows[0].(map[string]interface{})["uptime_percent"])
+	c.Equal(float64(3), windows[2].(map[string]interface{})["checks"], "the checks older than 30 days are not loaded")
+
+	rec, body = getJSON(c, mux, "/api/v1/domains/other.com/uptime")
+	c.Equal(http.StatusOK, rec.Code)
+	validateResponse(c, spec, spec.Components.Schemas["Uptime"], body)
+	c.Nil(body.(map[string]interface{})["latest"])
+}
+
+func TestProcessDataDownSite(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	domain, err := ProcessDataWithOptions(context.Background(), "example.invalid", DefaultOptions())
+	c.NoError(err, "a site that does not answer is recorded as down")
+	c.True(domain.IsDown)
+	c.Empty(domain.Servers)
+	c.Equal(UnknownInfo, domain.Title)
+	c.Equal(models.ErrorClassDNS, domain.Uptime.ErrorClass)
+	c.Equal("example.invalid", domain.Uptime.DomainName)
+}
@@ -0,0 +1,316 @@
+package httphand
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/gofrs/uuid"
+	"github.com/other_project/crockroach/internal/apperr"
+	"github.com/other_project/crockroach/internal/auth"
+	"github.com/other_project/crockroach/internal/problem"
+	"github.com/other_project/crockroach/internal/ratelimit"
+	"github.com/other_project/crockroach/internal/storage"
+	"github.com/other_project/crockroach/models"
+)
+
+const (
+	// defaultPageSize is the number of analyses returned when the limit is not set
+	defaultPageSize = 20
+	// maxPageSize is the maximum number of analyses returned by a page
+	maxPageSize = 100
+)
+
+var (
+	// ErrInvalidPagination when limit or offset are not valid numbers
+	ErrInvalidPagination = apperr.New(apperr.Invalid, "invalid_pagination", "limit must be between 1 and 100 and offset cannot be negative")
+	// ErrAnalysisNotFound when the domain was never analyzed
+	ErrAnalysisNotFound = apperr.New(apperr.NotFound, "analysis_not_found", "the domain does not have analyses")
+)
+
+// AnalysisStore reads the stored analyses
+type AnalysisStore interface {
+	ListAnalyses(ctx context.Context, domainName string, limit, offset int64) ([]*models.Domain, error)
+	GetAnalysis(ctx context.Context, domainID string) (*models.Domain, error)
+	GetServer(ctx context.Context, serverID string) (*models.Server, error)
+}
+
+// ServerResponse is a server of an analysis in the v1 API
+type ServerResponse struct {
+	ID         string    `json:"id"`
+	AnalysisID string    `json:"analysis_id"`
+	Address    string    `json:"address"`
+	Family     string    `json:"family"`
+	SSLGrade   string    `json:"ssl_grade"`
+	Country    string    `json:"country"`
+	Owner      string    `json:"owner"`
+	GeoCountry string    `json:"geo_country"`
+	ASN        uint64    `json:"asn"`
+	ASNOrg     string    `json:"asn_org"`
+	AnalyzedAt time.Time `json:"analyzed_at"`
+}
+
+// AnalysisResponse is an analysis of a domain in the v1 API
+type AnalysisResponse struct {
+	ID               string            `json:"id"`
+	Domain           string            `json:"domain"`
+	SSLGrade         string            `json:"ssl_grade"`
+	PreviousSSLGrade string            `json:"previous_ssl_grade"`
+	ServersChanged   bool              `json:"servers_changed"`
+	LogoChanged      bool              `json:"logo_changed"`
+	IsDown           bool              `json:"is_down"`
+	Title            string            `json:"title"`
+	Logo             string            `json:"logo"`
+	AnalyzedAt       time.Time         `json:"analyzed_at"`
+	Servers          []*ServerResponse `json:"servers"`
+	DualStack        *models.DualStack `json:"dual_stack,omitempty"`
+}
+
+// AnalysisList is a page of analyses
+type AnalysisList struct {
+	Items  []*AnalysisResponse `json:"items"`
+	Limit  int64               `json:"limit"`
+	Offset int64               `json:"offset"`
+}
+
+// CreateAnalysisRequest is the body to analyze a domain
+type CreateAnalysisRequest struct {
+	Domain string `json:"domain"`
+}
+
+// NewAnalysisResponse converts the domain to its v1 representation
+func NewAnalysisResponse(domain *models.Domain) *AnalysisResponse {
+	res := &AnalysisResponse{
+		ID:               domain.DomainID,
+		Domain:           domain.DomainName,
+		SSLGrade:         domain.SSLGrade,
+		PreviousSSLGrade: domain.PreviousSSLGrade,
+		ServersChanged:   domain.ServerChanged,
+		LogoChanged:      domain.LogoChanged,
+		IsDown:           domain.IsDown,
+		Title:            domain.Title,
+		Logo:             domain.Logo,
+		AnalyzedAt:       domain.AnalyzedAt(),
+		Servers:          []*ServerResponse{},
+		DualStack:        models.CheckDualStack(domain.Servers),
+	}
+
+	for _, server := range domain.Servers {
+		res.Servers = append(res.Servers, newServerResponse(server, domain))
+	}
+
+	return res
+}
+
+// newServerResponse converts the server of the domain to its v1 representation
+func newServerResponse(server *models.Server, domain *models.Domain) *ServerResponse {
+	res := &ServerResponse{
+		ID:         server.ServerID,
+		Address:    server.Address,
+		Family:     models.AddressFamily(server.Address),
+		SSLGrade:   server.SSLGrade,
+		Country:    server.Country,
+		Owner:      server.Owner,
+		GeoCountry: server.GeoCountry,
+		ASN:        server.ASN,
+		ASNOrg:     server.ASNOrg,
+	}
+
+	if domain != nil {
+		res.AnalysisID = domain.DomainID
+		res.AnalyzedAt = domain.AnalyzedAt()
+	}
+
+	return res
+}
+
+// CreateAnalysis analyzes the domain of the body and stores the result
+func (p *HandlerRequest) CreateAnalysis(w http.ResponseWriter, r *http.Request) {
+	var body CreateAnalysisRequest
+
+	err := decodeJSON(w, r, &body)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	domainName, err := models.NormalizeDomainName(body.Domain)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	ctx, cancelfunc := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancelfunc()
+
+	err = p.refresh(ctx, domainName)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	analysis, err := p.latestAnalysis(ctx, domainName)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", "/api/v1/domains/"+domainName)
+	respondwithJSON(w, http.StatusCreated, NewAnalysisResponse(analysis))
+}
+
+// GetDomain returns the latest stored analysis of the domain. With refresh=true, or when the
+// analysis is older than max_age, a job analyzes the domain again: the stale analysis is
+// answered with a link to the job, or the job itself when the domain was never analyzed.
+// The response carries an ETag so clients can poll with If-None-Match
+func (p *HandlerRequest) GetDomain(w http.ResponseWriter, r *http.Request) {
+	domainName, err := models.NormalizeDomainName(chi.URLParam(r, "name"))
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	fresh, err := parseFreshness(r)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	analysis, err := p.latestAnalysis(r.Context(), domainName)
+	if err != nil && !errors.Is(err, ErrAnalysisNotFound) {
+		problem.Error(w, r, err)
+		return
+	}
+
+	if fresh.stale(analysis, time.Now()) {
+		// only a new analysis needs the analyze scope and budget
+		if !auth.Allow(w, r, models.ScopeAnalyze) || !ratelimit.Charge(w, r, 1) {
+			return
+		}
+
+		job, err := p.jobs.Submit(r.Context(), domainName)
+		if err != nil {
+			problem.Error(w, r, err)
+			return
+		}
+
+		jobURL := "/api/v1/jobs/" + job.JobID
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="monitor"`, jobURL))
+
+		if analysis == nil {
+			w.Header().Set("Location", jobURL)
+			respondwithJSON(w, http.StatusAccepted, NewJobResponse(job))
+
+			return
+		}
+	}
+
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	etag := analysisETag(analysis)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", analysis.AnalyzedAt().Format(http.TimeFormat))
+	w.Header().Set("Cache-Control", "no-cache")
+
+	if notModified(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, NewAnalysisResponse(analysis))
+}
+
+// latestAnalysis returns the newest stored analysis of the domain
+func (p *HandlerRequest) latestAnalysis(ctx context.Context, domainName string) (*models.Domain, error) {
+	analyses, err := p.analyses.ListAnalyses(ctx, domainName, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(analyses) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrAnalysisNotFound, domainName)
+	}
+
+	return analyses[0], nil
+}
+
+// ListDomainAnalyses returns a page of the analyses of the domain, the newest first
+func (p *HandlerRequest) ListDomainAnalyses(w http.ResponseWriter, r *http.Request) {
+	domainName, err := models.NormalizeDomainName(chi.URLParam(r, "name"))
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	limit, offset, err := parsePagination(r)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	analyses, err := p.analyses.ListAnalyses(r.Context(), domainName, limit, offset)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	list := &AnalysisList{Items: []*AnalysisResponse{}, Limit: limit, Offset: offset}
+
+	for _, analysis := range analyses {
+		list.Items = append(list.Items, NewAnalysisResponse(analysis))
+	}
+
+	respondwithJSON(w, http.StatusOK, list)
+}
+
+// GetServer returns a server of an analysis
+func (p *HandlerRequest) GetServer(w http.ResponseWriter, r *http.Request) {
+	serverID := chi.URLParam(r, "id")
+
+	_, err := uuid.FromString(serverID)
+	if err != nil {
+		problem.Error(w, r, storage.ErrServerNotFound)
+		return
+	}
+
+	server, err := p.analyses.GetServer(r.Context(), serverID)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	respondwithJSON(w, http.StatusOK, newServerResponse(server, server.Domain))
+}
+
+// parsePagination reads the limit and offset query parameters
+func parsePagination(r *http.Request) (int64, int64, error) {
+	limit := int64(defaultPageSize)
+	offset := int64(0)
+
+	var err error
+
+	query := r.URL.Query()
+
+	if value := query.Get("limit"); value != "" {
+		limit, err = strconv.ParseInt(value, 10, 64)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			return 0, 0, ErrInvalidPagination
+		}
+	}
+
+	if value := query.Get("offset"); value != "" {
+		offset, err = strconv.ParseInt(value, 10, 64)
+		if err != nil || offset < 0 {
+			return 0, 0, ErrInvalidPagination
+		}
+	}
+
+	return limit, offset, nil
+}
@@ -0,0 +1,283 @@
+package httphand
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/other_project/crockroach/internal/auth"
+	"github.com/other_project/crockroach/internal/jobs"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/internal/ratelimit"
+	"github.com/other_project/crockroach/internal/storage"
+	"github.com/other_project/crockroach/models"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryAnalyses is an in memory AnalysisStore, the analyses are ordered from the newest
+type memoryAnalyses struct {
+	analyses []*models.Domain
+}
+
+func (m *memoryAnalyses) ListAnalyses(ctx context.Context, domainName string, limit, offset int64) ([]*models.Domain, error) {
+	items := []*models.Domain{}
+
+	for _, analysis := range m.analyses {
+		if analysis.DomainName == domainName {
+			items = append(items, analysis)
+		}
+	}
+
+	if offset >= int64(len(items)) {
+		return []*models.Domain{}, nil
+	}
+
+	items = items[offset:]
+	if int64(len(items)) > limit {
+		items = items[:limit]
+	}
+
+	return items, nil
+}
+
+func (m *memoryAnalyses) GetAnalysis(ctx context.Context, domainID string) (*models.Domain, error) {
+	for _, analysis := range m.analyses {
+		if analysis.DomainID == domainID {
+			return analysis, nil
+		}
+	}
+
+	return nil, storage.ErrDomainNotFound
+}
+
+func (m *memoryAnalyses) GetServer(ctx context.Context, serverID string) (*models.Server, error) {
+	for _, analysis := range m.analyses {
+		for _, server := range analysis.Servers {
+			if server.ServerID == serverID {
+				return server, nil
+			}
+		}
+	}
+
+	return nil, storage.ErrServerNotFound
+}
+
+func newV1Router(store AnalysisStore) *chi.Mux {
+	handler := &HandlerRequest{analyses: store}
+
+	mux := chi.NewMux()
+	mux.Get("/api/v1/domains/{name}", handler.GetDomain)
+	mux.Get("/api/v1/domains/{name}/analyses", handler.ListDomainAnalyses)
+	mux.Get("/api/v1/servers/{id}", handler.GetServer)
+
+	return mux
+}
+
+// getJSON requests the path and decodes the body
+func getJSON(c *require.Assertions, mux *chi.Mux, path string) (*httptest.ResponseRecorder, interface{}) {
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+	var body interface{}
+	c.NoError(json.Unmarshal(rec.Body.Bytes(), &body))
+
+	return rec, body
+}
+
+func TestV1Domains(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	spec := loadOpenAPI(c)
+
+	newest := newTestDomain(c, "example.com", "10.0.0.1", "10.0.0.2")
+	oldest := newTestDomain(c, "example.com", "10.0.0.1")
+	mux := newV1Router(&memoryAnalyses{analyses: []*models.Domain{newest, oldest}})
+
+	rec, body := getJSON(c, mux, "/api/v1/domains/EXAMPLE.com")
+	c.Equal(http.StatusOK, rec.Code)
+	validateResponse(c, spec, spec.Components.Schemas["Analysis"], body)
+	c.Equal(newest.DomainID, body.(map[string]interface{})["id"])
+
+	dualStack := body.(map[string]interface{})["dual_stack"].(map[string]interface{})
+	c.Equal(float64(2), dualStack["ipv4"])
+	c.Equal([]interface{}{models.ParityIPv6Missing}, dualStack["issues"])
+
+	rec, body = getJSON(c, mux, "/api/v1/domains/example.com/analyses?limit=1&offset=1")
+	c.Equal(http.StatusOK, rec.Code)
+	validateResponse(c, spec, spec.Components.Schemas["AnalysisList"], body)
+
+	items := body.(map[string]interface{})["items"].([]interface{})
+	c.Len(items, 1)
+	c.Equal(oldest.DomainID, items[0].(map[string]interface{})["id"])
+
+	rec, body = getJSON(c, mux, "/api/v1/servers/"+newest.Servers[1].ServerID)
+	c.Equal(http.StatusOK, rec.Code)
+	validateResponse(c, spec, spec.Components.Schemas["Server"], body)
+	c.Equal("10.0.0.2", body.(map[string]interface{})["address"])
+	c.Equal(models.FamilyIPv4, body.(map[string]interface{})["family"])
+}
+
+func TestV1Errors(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	spec := loadOpenAPI(c)
+	mux := newV1Router(&memoryAnalyses{})
+
+	cases := []struct {
+		path   string
+		status int
+	}{
+		{"/api/v1/domains/unknown.com", http.StatusNotFound},
+		{"/api/v1/domains/exa%20mple.com", http.StatusUnprocessableEntity},
+		{"/api/v1/domains/example.com/analyses?limit=500", http.StatusBadRequest},
+		{"/api/v1/servers/not-an-id", http.StatusNotFound},
+		{"/api/v1/servers/6ba7b810-9dad-11d1-80b4-00c04fd430c8", http.StatusNotFound},
+	}
+
+	for _, tc := range cases {
+		rec, body := getJSON(c, mux, tc.path)
+		c.Equal(tc.status, rec.Code, tc.path)
+		validateResponse(c, spec, spec.Components.Schemas["Problem"], body)
+	}
+}
+
+func TestV1DomainFreshness(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	stored := newTestDomain(c, "example.com", "10.0.0.1")
+	old := time.Now().Add(-2 * time.Hour)
+	stored.UpdateDate = &old
+
+	queue := &memoryJobs{jobs: make(map[string]models.Job)}
+	handler := &HandlerRequest{
+		analyses: &memoryAnalyses{analyses: []*models.Domain{stored}},
+		jobs:     jobs.NewRunner(queue, nil, 1, 10, time.Second),
+	}
+
+	mux := chi.NewMux()
+	mux.Get("/api/v1/domains/{name}", handler.GetDomain)
+
+	get := func(path, etag string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		if etag != "" {
+			r.Header.Set("If-None-Match", etag)
+		}
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, r)
+
+		return rec
+	}
+
+	rec := get("/api/v1/domains/example.com", "")
+	c.Equal(http.StatusOK, rec.Code)
+	c.Empty(rec.Header().Get("Link"))
+	c.Equal(old.UTC().Format(http.TimeFormat), rec.Header().Get("Last-Modified"))
+
+	etag := rec.Header().Get("ETag")
+	c.NotEmpty(etag)
+
+	rec = get("/api/v1/domains/example.com", etag)
+	c.Equal(http.StatusNotModified, rec.Code)
+	c.Empty(rec.Body.String())
+
+	rec = get("/api/v1/domains/example.com?max_age=3h", etag)
+	c.Equal(http.StatusNotModified, rec.Code)
+	c.Empty(queue.jobs)
+
+	// the stale analysis is answered at once and a job analyzes the domain again
+	rec = get("/api/v1/domains/example.com?max_age=3600", "")
+	c.Equal(http.StatusOK, rec.Code)
+	c.Equal(etag, rec.Header().Get("ETag"))
+	c.Contains(rec.Body.String(), "10.0.0.1")
+	c.Len(queue.jobs, 1)
+
+	link := rec.Header().Get("Link")
+	c.True(strings.HasPrefix(link, "</api/v1/jobs/"), link)
+	c.True(strings.HasSuffix(link, `>; rel="monitor"`), link)
+
+	job, err := queue.GetJob(context.Background(), strings.TrimSuffix(strings.TrimPrefix(link, "</api/v1/jobs/"), `>; rel="monitor"`))
+	c.NoError(err)
+	c.Equal("example.com", job.DomainName)
+
+	// the pending job is linked again instead of queuing another one
+	rec = get("/api/v1/domains/example.com?refresh=true", "")
+	c.Equal(http.StatusOK, rec.Code)
+	c.Equal(link, rec.Header().Get("Link"))
+	c.Len(queue.jobs, 1)
+
+	rec = get("/api/v1/domains/example.com?refresh=maybe", "")
+	c.Equal(http.StatusBadRequest, rec.Code)
+
+	rec = get("/api/v1/domains/new.example.com", "")
+	c.Equal(http.StatusNotFound, rec.Code)
+
+	// without a stored analysis there is only the job to answer
+	rec = get("/api/v1/domains/new.example.com?refresh=1", "")
+	c.Equal(http.StatusAccepted, rec.Code)
+	c.Len(queue.jobs, 2)
+
+	var res JobResponse
+	c.NoError(json.Unmarshal(rec.Body.Bytes(), &res))
+	c.Equal("new.example.com", res.Domain)
+	c.Equal(models.JobQueued, res.State)
+	c.Equal("/api/v1/jobs/"+res.ID, rec.Header().Get("Location"))
+}
+
+func TestV1DomainRefreshNeedsAnalyze(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	stored := newTestDomain(c, "example.com", "10.0.0.1")
+	old := time.Now().Add(-2 * time.Hour)
+	stored.UpdateDate = &old
+
+	queue := &memoryJobs{jobs: make(map[string]models.Job)}
+	handler := &HandlerRequest{
+		analyses: &memoryAnalyses{analyses: []*models.Domain{stored}},
+		jobs:     jobs.NewRunner(queue, nil, 1, 10, time.Second),
+	}
+
+	reader, readerSecret, err := models.NewAPIKey("reader", []string{models.ScopeRead})
+	c.NoError(err)
+
+	analyzer, analyzerSecret, err := models.NewAPIKey("analyzer", []string{models.ScopeRead, models.ScopeAnalyze})
+	c.NoError(err)
+
+	keys := map[string]*models.APIKey{reader.Hash: reader, analyzer.Hash: analyzer}
+	authenticator := auth.New(func(ctx context.Context, hash string) (*models.APIKey, error) {
+		return keys[hash], nil
+	}, true, "")
+	limiter := ratelimit.New(ratelimit.Policy{Name: "analyze", PerMinute: 1, Burst: 1})
+
+	mux := chi.NewMux()
+	mux.With(authenticator.Require(models.ScopeRead), authenticator.Deferred, limiter.Deferred).Get("/api/v1/domains/{name}", handler.GetDomain)
+
+	get := func(path, secret string) int {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.Header.Set("Authorization", "Bearer "+secret)
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, r)
+
+		return rec.Code
+	}
+
+	// a fresh analysis is read without the analyze scope and budget
+	c.Equal(http.StatusOK, get("/api/v1/domains/example.com?max_age=3h", readerSecret))
+	c.Equal(http.StatusOK, get("/api/v1/domains/example.com?max_age=3h", readerSecret))
+	c.Equal(http.StatusForbidden, get("/api/v1/domains/example.com?max_age=1h", readerSecret))
+	c.Empty(queue.jobs)
+
+	c.Equal(http.StatusOK, get("/api/v1/domains/example.com?max_age=1h", analyzerSecret))
+	c.Equal(http.StatusTooManyRequests, get("/api/v1/domains/example.com?refresh=true", analyzerSecret))
+	c.Len(queue.jobs, 1)
+}
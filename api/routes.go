@@ -2,16 +2,19 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
 	"github.com/other_project/crockroach/api/httphand"
-	db "github.com/other_project/crockroach/internal/storage"
+	"github.com/other_project/crockroach/internal/auth"
+	"github.com/other_project/crockroach/internal/ratelimit"
+	"github.com/other_project/crockroach/models"
 )
 
 // Routes create an router multiplexer
-func Routes(store *db.Store) *chi.Mux {
+func Routes(handler *httphand.HandlerRequest) *chi.Mux {
 	mux := chi.NewMux()
 
 	// globals middleware
@@ -20,15 +23,129 @@ func Routes(store *db.Store) *chi.Mux {
 		middleware.Recoverer, // recover if a panic occurs
 	)
 
-	handler := httphand.NewHandlerRequest(store)
+	authenticator := auth.New(handler.FindKey, auth.Enabled, auth.BootstrapKey)
+	// the routes that launch analyses share one budget, charged up front or by the handler
+	analyzeLimiter := ratelimit.New(ratelimit.AnalyzePolicy)
+	readLimit := limit(ratelimit.New(ratelimit.ReadPolicy).Middleware)
+	analyzeLimit := limit(analyzeLimiter.Middleware)
+	writeLimit := limit(ratelimit.New(ratelimit.WritePolicy).Middleware)
+	analyzeOnDemand := limit(analyzeLimiter.Deferred)
 
-	mux.Get("/status", showStatus)
-	mux.Post("/domain", handler.Create)
-	mux.Get("/get-last-domains", handler.RequestLastDomains)
+	mux.With(optional(authenticator, models.ScopeRead, auth.PublicStatus)).Get("/status", showStatus)
+	mux.With(optional(authenticator, models.ScopeRead, auth.PublicProbe), readLimit, analyzeOnDemand).Get("/probe", handler.Probe)
+	// the logos are loaded by the browsers of the web-app, they cannot send a key
+	mux.With(readLimit).Get("/logos/{hash}", handler.GetLogo)
+
+	mux.Route("/api/v1", func(v1 chi.Router) {
+		v1.Get("/openapi.json", httphand.OpenAPI)
+		// EventSource cannot send a key, the browsers open the stream with a short-lived token
+		v1.With(authenticator.RequireStream(models.ScopeRead, jobStream), readLimit).Get("/jobs/{id}/events", handler.JobEvents)
+
+		// read scope
+		v1.Group(func(r chi.Router) {
+			r.Use(authenticator.Require(models.ScopeRead), readLimit)
+			r.Get("/domains", handler.ListDomains)
+			// a stale analysis queues a new one with the analyze scope and budget
+			r.With(authenticator.Deferred, analyzeOnDemand).Get("/domains/{name}", handler.GetDomain)
+			r.Get("/domains/{name}/analyses", handler.ListDomainAnalyses)
+			r.Get("/domains/{name}/uptime", handler.GetUptime)
+			r.Get("/domains/{name}/dns", handler.ListDNSSnapshots)
+			r.Get("/domains/{name}/pages", handler.ListPageSnapshots)
+			r.Get("/domains/{name}/pages/diff", handler.GetPageDiff)
+			r.Get("/servers/{id}", handler.GetServer)
+			r.Get("/servers/{id}/certificate", handler.GetCertificate)
+			r.Get("/jobs/{id}", handler.GetJob)
+			r.Post("/jobs/{id}/events/token", authenticator.IssueStreamToken(jobStream))
+			r.Get("/tracked-domains", handler.ListTracked)
+			r.Get("/tracked-domains/{name}", handler.GetTracked)
+			r.Get("/rules", handler.ListRules)
+			r.Get("/rules/{id}", handler.GetRule)
+			r.Get("/rules/{id}/evaluations", handler.ListRuleEvaluations)
+			r.Get("/alerts", handler.ListAlerts)
+		})
+
+		// analyze scope
+		v1.Group(func(r chi.Router) {
+			r.Use(authenticator.Require(models.ScopeAnalyze), analyzeLimit)
+			r.Post("/domains", handler.CreateAnalysis)
+			r.Post("/domains/{name}/analyses", handler.SubmitAnalysis)
+		})
+
+		// analyze scope, the settings do not launch analyses so they have their own budget
+		v1.Group(func(r chi.Router) {
+			r.Use(authenticator.Require(models.ScopeAnalyze), writeLimit)
+			r.Post("/tracked-domains", handler.CreateTracked)
+			r.Patch("/tracked-domains/{name}", handler.UpdateTracked)
+			r.Delete("/tracked-domains/{name}", handler.DeleteTracked)
+			r.Post("/rules", handler.CreateRule)
+			r.Patch("/rules/{id}", handler.UpdateRule)
+			r.Delete("/rules/{id}", handler.DeleteRule)
+		})
+	})
+
+	// deprecated aliases used by the web-app, public by default and still rate limited
+	mux.With(deprecated("/api/v1/domains"), optional(authenticator, models.ScopeRead, auth.PublicLegacy), readLimit).Get("/get-last-domains", handler.RequestLastDomains)
+	mux.With(deprecated("/api/v1/domains"), authenticator.Require(models.ScopeAnalyze), analyzeLimit).Post("/domain", handler.Create)
+
+	// read scope
+	mux.Group(func(r chi.Router) {
+		r.Use(authenticator.Require(models.ScopeRead), readLimit)
+		r.Get("/export", handler.Export)
+		r.Get("/domains/import/{id}", handler.ImportStatus)
+	})
+
+	// analyze scope, the import charges every domain of the file
+	mux.Group(func(r chi.Router) {
+		r.Use(authenticator.Require(models.ScopeAnalyze), analyzeOnDemand)
+		r.Post("/domains/import", handler.Import)
+	})
+
+	// admin scope
+	mux.Route("/keys", func(r chi.Router) {
+		r.Use(authenticator.Require(models.ScopeAdmin))
+		r.Post("/", handler.CreateKey)
+		r.Get("/", handler.ListKeys)
+		r.Delete("/{id}", handler.RevokeKey)
+	})
 
 	return mux
 }
 
+// optional returns a middleware that requires the scope unless the route is public
+func optional(authenticator *auth.Authenticator, scope string, public bool) func(http.Handler) http.Handler {
+	if public {
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	return authenticator.Require(scope)
+}
+
+// limit returns the rate limit middleware, it does nothing when the limits are disabled
+func limit(middleware func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	if !ratelimit.Enabled {
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	return middleware
+}
+
+// deprecated marks the responses of a legacy route and links to the route that replaces it
+func deprecated(successor string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", "true")
+			w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, successor))
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// jobStream returns the resource signed by the stream tokens of the job
+func jobStream(r *http.Request) string {
+	return "jobs/" + chi.URLParam(r, "id") + "/events"
+}
+
 // showStatus return the status of the API
 func showStatus(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/other_project/crockroach/api/httphand"
+	"github.com/other_project/crockroach/internal/auth"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/internal/ratelimit"
+	"github.com/other_project/crockroach/internal/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestV1RoutesAreDocumented(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	spec := struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}{}
+	c.NoError(json.Unmarshal([]byte(httphand.OpenAPIDocument), &spec))
+
+	documented := map[string]bool{}
+
+	for path, operations := range spec.Paths {
+		for method := range operations {
+			if method != "parameters" {
+				documented[strings.ToUpper(method)+" "+path] = true
+			}
+		}
+	}
+
+	routed := map[string]bool{}
+
+	err := chi.Walk(Routes(httphand.NewHandlerRequest(storage.NewStore())), func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		if strings.HasPrefix(route, "/api/v1/") {
+			routed[method+" "+strings.TrimPrefix(route, "/api/v1")] = true
+		}
+
+		return nil
+	})
+	c.NoError(err)
+
+	c.Equal(documented, routed)
+}
+
+// withoutStorage cancels the context of the request, the queries fail at once because the
+// storage is not connected in the tests
+func withoutStorage(r *http.Request) *http.Request {
+	ctx, cancelfunc := context.WithCancel(r.Context())
+	cancelfunc()
+
+	return r.WithContext(ctx)
+}
+
+func TestDeprecatedRoutes(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	rec := httptest.NewRecorder()
+	Routes(httphand.NewHandlerRequest(storage.NewStore())).ServeHTTP(rec, withoutStorage(httptest.NewRequest(http.MethodGet, "/get-last-domains", nil)))
+
+	c.Equal("true", rec.Header().Get("Deprecation"))
+	c.Contains(rec.Header().Get("Link"), "/api/v1/domains")
+}
+
+func TestLegacyRoutesRequireKey(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	public := auth.PublicLegacy
+	defer func() { auth.PublicLegacy = public }()
+
+	getLast := func() *http.Request { return httptest.NewRequest(http.MethodGet, "/get-last-domains", nil) }
+	create := func() *http.Request {
+		return httptest.NewRequest(http.MethodPost, "/domain", strings.NewReader(`{"domainName":""}`))
+	}
+
+	auth.PublicLegacy = false
+	mux := Routes(httphand.NewHandlerRequest(storage.NewStore()))
+
+	for _, r := range []*http.Request{getLast(), create()} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, withoutStorage(r))
+
+		c.Equal(http.StatusUnauthorized, rec.Code, r.URL.Path)
+	}
+
+	// the flag only opens the listing, an analysis always needs a key
+	auth.PublicLegacy = true
+	mux = Routes(httphand.NewHandlerRequest(storage.NewStore()))
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, withoutStorage(getLast()))
+	c.NotEqual(http.StatusUnauthorized, rec.Code)
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, withoutStorage(create()))
+	c.Equal(http.StatusUnauthorized, rec.Code)
+}
+
+func TestJobEventsRequireKey(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	bootstrap := auth.BootstrapKey
+	auth.BootstrapKey = "bootstrap-secret"
+
+	defer func() { auth.BootstrapKey = bootstrap }()
+
+	mux := Routes(httphand.NewHandlerRequest(storage.NewStore()))
+
+	const events = "/api/v1/jobs/4a1f9e43-4bd2-4f7c-9d1e-2f4cb0b51a17/events"
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, events, nil))
+	c.Equal(http.StatusUnauthorized, rec.Code)
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, events+"?access_token=1.forged", nil))
+	c.Equal(http.StatusUnauthorized, rec.Code)
+
+	// the token is issued to a key with the read scope
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, events+"/token", nil))
+	c.Equal(http.StatusUnauthorized, rec.Code)
+
+	req := httptest.NewRequest(http.MethodPost, events+"/token", nil)
+	req.Header.Set("Authorization", "Bearer bootstrap-secret")
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	c.Equal(http.StatusCreated, rec.Code)
+
+	var token auth.StreamToken
+	c.NoError(json.Unmarshal(rec.Body.Bytes(), &token))
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, withoutStorage(httptest.NewRequest(http.MethodGet, events+"?access_token="+token.Token, nil)))
+	c.NotEqual(http.StatusUnauthorized, rec.Code)
+}
+
+func TestSettingsDoNotSpendTheAnalyzeBudget(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	enabled := auth.Enabled
+	auth.Enabled = false
+
+	defer func() { auth.Enabled = enabled }()
+
+	mux := Routes(httphand.NewHandlerRequest(storage.NewStore()))
+
+	// more writes than the burst of the analyze policy, the invalid bodies do not reach the storage
+	for i := 0; i <= ratelimit.AnalyzePolicy.Burst; i++ {
+		for _, path := range []string{"/api/v1/tracked-domains", "/api/v1/rules"} {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader("{")))
+			c.Equal(http.StatusBadRequest, rec.Code, path)
+		}
+	}
+}
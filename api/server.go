@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"database/sql"
 	"net/http"
 	"time"
@@ -19,6 +20,8 @@ const (
 	ReadTimeout = 15 * time.Second
 	// WriteTimeout ...
 	WriteTimeout = 15 * time.Second
+	// MigrationTimeout is the maximum time to create the tables on startup
+	MigrationTimeout = 30 * time.Second
 )
 
 var (
@@ -35,7 +38,11 @@ type MyServer struct {
 
 // NewServer initialize the server instance
 func NewServer(mux *chi.Mux) *MyServer {
-	handler := cors.Default().Handler(mux)
+	handler := cors.New(cors.Options{
+		AllowedMethods: []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete},
+		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "If-None-Match"},
+		ExposedHeaders: []string{"ETag", "Location", "Retry-After", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Deprecation", "Link"},
+	}).Handler(mux)
 
 	s := &http.Server{
 		Addr:           PortServer,
@@ -45,13 +52,22 @@ func NewServer(mux *chi.Mux) *MyServer {
 		MaxHeaderBytes: 1 << 20,
 	}
 
-	storage.CockroachClient = *cockroachdb.NewSQLClient()
+	storage.CockroachClient = cockroachdb.NewSQLClient()
+	if storage.CockroachClient != nil {
+		ctx, cancelfunc := context.WithTimeout(context.Background(), MigrationTimeout)
+		defer cancelfunc()
+
+		err := storage.Migrate(ctx)
+		if err != nil {
+			logs.Log().Errorf(`Error migrating the database . %s `, err.Error())
+		}
+	}
 
 	myServer := new(MyServer)
 
 	myServer.server = s
 	myServer.router = mux
-	myServer.clientDB = &storage.CockroachClient
+	myServer.clientDB = storage.CockroachClient
 
 	return myServer
 }
@@ -1,18 +1,29 @@
 module github.com/other_project/crockroach
 
-go 1.15
+go 1.19
 
 require (
 	github.com/PuerkitoBio/goquery v1.6.0
-	github.com/andybalholm/cascadia v1.2.0 // indirect
 	github.com/go-chi/chi v4.1.2+incompatible
 	github.com/gofrs/uuid v3.3.0+incompatible
 	github.com/lib/pq v1.9.0
+	github.com/oschwald/maxminddb-golang v1.12.0
 	github.com/rs/cors v1.7.0
-	github.com/stretchr/testify v1.6.1
-	go.uber.org/multierr v1.6.0 // indirect
+	github.com/stretchr/testify v1.8.4
 	go.uber.org/zap v1.16.0
-	golang.org/x/net v0.0.0-20201202161906-c7110b5ffcbb // indirect
+	golang.org/x/net v0.0.0-20201202161906-c7110b5ffcbb
+	golang.org/x/sync v0.10.0
+	gopkg.in/yaml.v2 v2.2.8
+)
+
+require (
+	github.com/andybalholm/cascadia v1.2.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	go.uber.org/atomic v1.7.0 // indirect
+	go.uber.org/multierr v1.6.0 // indirect
+	golang.org/x/sys v0.10.0 // indirect
+	golang.org/x/text v0.3.3 // indirect
 	golang.org/x/tools v0.0.0-20191112195655-aa38f8e97acc // indirect
-	gopkg.in/yaml.v2 v2.2.8 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
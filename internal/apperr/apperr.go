@@ -0,0 +1,103 @@
+package apperr
+
+import (
+	"context"
+	"errors"
+	"net"
+)
+
+// Kind classifies an error by its cause, the API maps every kind to a status code
+type Kind string
+
+const (
+	// Invalid when the request is malformed
+	Invalid Kind = "invalid"
+	// Unprocessable when the request is well formed but its values cannot be used
+	Unprocessable Kind = "unprocessable"
+	// NotFound when the resource does not exist
+	NotFound Kind = "not_found"
+	// Conflict when the request conflicts with the current state
+	Conflict Kind = "conflict"
+	// Unauthorized when the client is not authenticated
+	Unauthorized Kind = "unauthorized"
+	// Forbidden when the client cannot access the resource
+	Forbidden Kind = "forbidden"
+	// TooLarge when the request body exceeds the limit
+	TooLarge Kind = "too_large"
+	// RateLimited when the client exceeded its budget
+	RateLimited Kind = "rate_limited"
+	// Upstream when an external service failed or returned an unexpected answer
+	Upstream Kind = "upstream"
+	// Timeout when an external service did not answer in time
+	Timeout Kind = "timeout"
+	// Unavailable when a dependency of the service, such as the database, is not available
+	Unavailable Kind = "unavailable"
+	// Internal when the error is unexpected
+	Internal Kind = "internal"
+)
+
+// Error is an error with a kind and a stable code that clients can rely on
+type Error struct {
+	Kind Kind
+	Code string
+	Err  error
+}
+
+// New creates an error, it is used to declare sentinel errors
+func New(kind Kind, code, msg string) *Error {
+	return &Error{Kind: kind, Code: code, Err: errors.New(msg)}
+}
+
+// Wrap classifies err, it returns nil when err is nil
+func Wrap(kind Kind, code string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &Error{Kind: kind, Code: code, Err: err}
+}
+
+// Error implements error
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the cause of the error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// KindOf returns the kind of the first typed error of the chain.
+// Untyped deadline and network timeout errors are Timeout, the rest are Internal
+func KindOf(err error) Kind {
+	var typed *Error
+	if errors.As(err, &typed) {
+		return typed.Kind
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return Timeout
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return Timeout
+	}
+
+	return Internal
+}
+
+// CodeOf returns the code of the first typed error of the chain, or the kind when there is not
+func CodeOf(err error) string {
+	var typed *Error
+	if errors.As(err, &typed) && typed.Code != "" {
+		return typed.Code
+	}
+
+	return string(KindOf(err))
+}
+
+// Is reports if the kind of err is kind
+func Is(err error, kind Kind) bool {
+	return err != nil && KindOf(err) == kind
+}
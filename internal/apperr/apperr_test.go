@@ -0,0 +1,32 @@
+package apperr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKindOf(t *testing.T) {
+	c := require.New(t)
+
+	errMissing := New(NotFound, "domain_not_found", "domain was not found")
+
+	wrapped := fmt.Errorf("get example.com: %w", errMissing)
+	c.Equal(NotFound, KindOf(wrapped))
+	c.Equal("domain_not_found", CodeOf(wrapped))
+	c.True(errors.Is(wrapped, errMissing))
+	c.True(Is(wrapped, NotFound))
+
+	c.Equal(Timeout, KindOf(fmt.Errorf("ssllabs: %w", context.DeadlineExceeded)))
+	c.Equal(Internal, KindOf(errors.New("boom")))
+	c.Equal("internal", CodeOf(errors.New("boom")))
+	c.False(Is(nil, Internal))
+
+	upstream := Wrap(Upstream, "ssllabs_unavailable", errors.New("connection refused"))
+	c.Equal(Upstream, KindOf(upstream))
+	c.Equal("connection refused", upstream.Error())
+	c.Nil(Wrap(Upstream, "ssllabs_unavailable", nil))
+}
@@ -0,0 +1,187 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/other_project/crockroach/internal/apperr"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/internal/problem"
+	"github.com/other_project/crockroach/models"
+	"github.com/other_project/crockroach/shared/env"
+)
+
+// contextKey is the type of the values stored by the package in the request context
+type contextKey struct{}
+
+// authenticatorKey is the type of the authenticator stored in the request context by Deferred
+type authenticatorKey struct{}
+
+// bootstrapKeyID identifies the key configured by environment
+const bootstrapKeyID = "bootstrap"
+
+// streamKeyName names the keys of the requests authenticated by a stream token
+const streamKeyName = "stream"
+
+var (
+	// Enabled turns off the authentication when it's false, intended for local development
+	Enabled = env.GetBool("AUTH_ENABLED", true)
+	// PublicStatus allows to query /status without a key
+	PublicStatus = env.GetBool("AUTH_PUBLIC_STATUS", true)
+	// PublicProbe allows to query /probe without a key
+	PublicProbe = env.GetBool("AUTH_PUBLIC_PROBE", false)
+	// PublicLegacy allows the web-app to list the last domains through the deprecated alias
+	// without a key, the browser has no key to send
+	PublicLegacy = env.GetBool("AUTH_PUBLIC_LEGACY", false)
+	// BootstrapKey is an admin secret accepted without being stored, used to create the first keys
+	BootstrapKey = env.GetString("AUTH_BOOTSTRAP_KEY", "")
+
+	// ErrMissingKey when the request does not have a bearer token
+	ErrMissingKey = apperr.New(apperr.Unauthorized, "missing_api_key", "missing bearer api key")
+	// ErrInvalidKey when the key does not exist or was revoked
+	ErrInvalidKey = apperr.New(apperr.Unauthorized, "invalid_api_key", "invalid or revoked api key")
+	// ErrInsufficientScope when the key does not grant the scope of the route
+	ErrInsufficientScope = apperr.New(apperr.Forbidden, "insufficient_scope", "the api key does not grant the required scope")
+)
+
+// KeyFinder looks up a key by the hash of its secret
+type KeyFinder func(ctx context.Context, hash string) (*models.APIKey, error)
+
+// Authenticator validates the bearer keys of the requests
+type Authenticator struct {
+	find          KeyFinder
+	enabled       bool
+	bootstrapHash string
+	streamSecret  []byte
+}
+
+// New creates an authenticator that looks up the keys with find.
+// An empty bootstrap disables the bootstrap key
+func New(find KeyFinder, enabled bool, bootstrap string) *Authenticator {
+	a := &Authenticator{
+		find:         find,
+		enabled:      enabled,
+		streamSecret: newStreamSecret(StreamSecret),
+	}
+
+	if bootstrap != "" {
+		a.bootstrapHash = models.HashAPIKey(bootstrap)
+	}
+
+	return a
+}
+
+// Require returns a middleware that rejects the requests whose key does not grant the scope
+func (a *Authenticator) Require(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !a.enabled {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key, ok := a.check(w, r, scope)
+			if !ok {
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithKey(r.Context(), key)))
+		})
+	}
+}
+
+// Deferred returns a middleware that lets the handler require a scope with Allow, for the
+// routes that only need it for some requests
+func (a *Authenticator) Deferred(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), authenticatorKey{}, a)))
+	})
+}
+
+// Allow reports if the key of the request grants the scope, the rejection is written to w.
+// Requests without a deferred authenticator or with the authentication turned off are allowed
+func Allow(w http.ResponseWriter, r *http.Request, scope string) bool {
+	a, ok := r.Context().Value(authenticatorKey{}).(*Authenticator)
+	if !ok || !a.enabled {
+		return true
+	}
+
+	_, ok = a.check(w, r, scope)
+
+	return ok
+}
+
+// check returns the key of the request when it grants the scope, otherwise it writes the rejection
+func (a *Authenticator) check(w http.ResponseWriter, r *http.Request, scope string) (*models.APIKey, bool) {
+	key := KeyFromContext(r.Context())
+	if key == nil {
+		var err error
+
+		key, err = a.authenticate(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="crockroach"`)
+			problem.Error(w, r, err)
+
+			return nil, false
+		}
+	}
+
+	if !key.HasScope(scope) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="crockroach", error="insufficient_scope", scope="`+scope+`"`)
+		problem.Error(w, r, ErrInsufficientScope)
+
+		return nil, false
+	}
+
+	return key, true
+}
+
+// authenticate returns the key of the bearer token
+func (a *Authenticator) authenticate(r *http.Request) (*models.APIKey, error) {
+	secret := BearerToken(r)
+	if secret == "" {
+		return nil, ErrMissingKey
+	}
+
+	hash := models.HashAPIKey(secret)
+
+	if a.bootstrapHash != "" && subtle.ConstantTimeCompare([]byte(hash), []byte(a.bootstrapHash)) == 1 {
+		return &models.APIKey{KeyID: bootstrapKeyID, Name: bootstrapKeyID, Scopes: []string{models.ScopeAdmin}}, nil
+	}
+
+	key, err := a.find(r.Context(), hash)
+	if err != nil || key == nil || key.RevokedDate != nil {
+		if err != nil {
+			logs.Log().Errorf("cannot authenticate api key: %s", err.Error())
+		}
+
+		return nil, ErrInvalidKey
+	}
+
+	return key, nil
+}
+
+// BearerToken returns the token of the Authorization header, empty when there is not
+func BearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+
+	const prefix = "bearer "
+	if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return ""
+	}
+
+	return strings.TrimSpace(header[len(prefix):])
+}
+
+// WithKey stores the authenticated key in the context
+func WithKey(ctx context.Context, key *models.APIKey) context.Context {
+	return context.WithValue(ctx, contextKey{}, key)
+}
+
+// KeyFromContext returns the authenticated key, nil when the request was not authenticated
+func KeyFromContext(ctx context.Context) *models.APIKey {
+	key, _ := ctx.Value(contextKey{}).(*models.APIKey)
+	return key
+}
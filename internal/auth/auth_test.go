@@ -0,0 +1,232 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/models"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAuthenticator(t *testing.T, keys ...*models.APIKey) *Authenticator {
+	_ = logs.InitLogger()
+
+	byHash := map[string]*models.APIKey{}
+	for _, key := range keys {
+		byHash[key.Hash] = key
+	}
+
+	return New(func(ctx context.Context, hash string) (*models.APIKey, error) {
+		key, ok := byHash[hash]
+		if !ok {
+			return nil, ErrInvalidKey
+		}
+
+		return key, nil
+	}, true, "bootstrap-secret")
+}
+
+func serve(a *Authenticator, scope, token string) *httptest.ResponseRecorder {
+	var seen *models.APIKey
+
+	handler := a.Require(scope)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = KeyFromContext(r.Context())
+		if seen != nil {
+			w.Header().Set("X-Key", seen.KeyID)
+		}
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestRequire(t *testing.T) {
+	c := require.New(t)
+
+	reader, readerSecret, err := models.NewAPIKey("reader", []string{models.ScopeRead})
+	c.NoError(err)
+
+	revoked, revokedSecret, err := models.NewAPIKey("old", []string{models.ScopeAdmin})
+	c.NoError(err)
+
+	now := time.Now()
+	revoked.RevokedDate = &now
+
+	a := newTestAuthenticator(t, reader, revoked)
+
+	rec := serve(a, models.ScopeRead, "")
+	c.Equal(http.StatusUnauthorized, rec.Code)
+	c.Contains(rec.Header().Get("WWW-Authenticate"), "Bearer")
+
+	rec = serve(a, models.ScopeRead, "Bearer crk_unknown")
+	c.Equal(http.StatusUnauthorized, rec.Code)
+
+	rec = serve(a, models.ScopeRead, "Bearer "+revokedSecret)
+	c.Equal(http.StatusUnauthorized, rec.Code)
+
+	rec = serve(a, models.ScopeRead, "bearer "+readerSecret)
+	c.Equal(http.StatusOK, rec.Code)
+	c.Equal(reader.KeyID, rec.Header().Get("X-Key"))
+
+	rec = serve(a, models.ScopeAnalyze, "Bearer "+readerSecret)
+	c.Equal(http.StatusForbidden, rec.Code)
+	c.Contains(rec.Header().Get("WWW-Authenticate"), "insufficient_scope")
+
+	rec = serve(a, models.ScopeAdmin, "Bearer bootstrap-secret")
+	c.Equal(http.StatusOK, rec.Code)
+	c.Equal(bootstrapKeyID, rec.Header().Get("X-Key"))
+}
+
+func TestRequireDisabled(t *testing.T) {
+	c := require.New(t)
+
+	a := New(nil, false, "")
+
+	rec := serve(a, models.ScopeAdmin, "")
+	c.Equal(http.StatusOK, rec.Code)
+}
+
+func TestAllow(t *testing.T) {
+	c := require.New(t)
+
+	reader, readerSecret, err := models.NewAPIKey("reader", []string{models.ScopeRead})
+	c.NoError(err)
+
+	analyzer, analyzerSecret, err := models.NewAPIKey("analyzer", []string{models.ScopeRead, models.ScopeAnalyze})
+	c.NoError(err)
+
+	a := newTestAuthenticator(t, reader, analyzer)
+
+	allowed := false
+	handler := a.Require(models.ScopeRead)(a.Deferred(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// only the requests that ask for it need the analyze scope
+		if r.URL.Query().Get("analyze") == "" {
+			return
+		}
+
+		allowed = Allow(w, r, models.ScopeAnalyze)
+	})))
+
+	do := func(path, secret string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+secret)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	c.Equal(http.StatusOK, do("/", readerSecret).Code)
+
+	rec := do("/?analyze=1", readerSecret)
+	c.Equal(http.StatusForbidden, rec.Code)
+	c.False(allowed)
+	c.Contains(rec.Header().Get("WWW-Authenticate"), `scope="analyze"`)
+
+	c.Equal(http.StatusOK, do("/?analyze=1", analyzerSecret).Code)
+	c.True(allowed)
+
+	// without a deferred authenticator or with the authentication off every request is allowed
+	c.True(Allow(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), models.ScopeAnalyze))
+
+	off := New(nil, false, "")
+	off.Deferred(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowed = Allow(w, r, models.ScopeAdmin)
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	c.True(allowed)
+}
+
+func TestRequireStream(t *testing.T) {
+	c := require.New(t)
+
+	reader, readerSecret, err := models.NewAPIKey("reader", []string{models.ScopeRead})
+	c.NoError(err)
+
+	a := newTestAuthenticator(t, reader)
+	resource := func(r *http.Request) string { return r.URL.Path }
+
+	var seen *models.APIKey
+
+	handler := a.RequireStream(models.ScopeRead, resource)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = KeyFromContext(r.Context())
+	}))
+
+	do := func(path, secret string) *httptest.ResponseRecorder {
+		seen = nil
+
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if secret != "" {
+			req.Header.Set("Authorization", "Bearer "+secret)
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	c.Equal(http.StatusUnauthorized, do("/jobs/1/events", "").Code)
+	c.Equal(http.StatusOK, do("/jobs/1/events", readerSecret).Code)
+	c.Equal(reader.KeyID, seen.KeyID)
+
+	token := a.NewStreamToken("/jobs/1/events", reader.KeyID, time.Now().Add(time.Minute))
+	c.Equal(http.StatusOK, do("/jobs/1/events?access_token="+token.Token, "").Code)
+	c.Equal(reader.KeyID, seen.KeyID)
+	c.True(seen.HasScope(models.ScopeRead))
+	c.False(seen.HasScope(models.ScopeAnalyze))
+
+	// the token only opens the stream it was issued for and only until it expires
+	c.Equal(http.StatusUnauthorized, do("/jobs/2/events?access_token="+token.Token, "").Code)
+
+	expired := a.NewStreamToken("/jobs/1/events", reader.KeyID, time.Now().Add(-time.Second))
+	c.Equal(http.StatusUnauthorized, do("/jobs/1/events?access_token="+expired.Token, "").Code)
+
+	other := newTestAuthenticator(t, reader).NewStreamToken("/jobs/1/events", reader.KeyID, time.Now().Add(time.Minute))
+	rec := do("/jobs/1/events?access_token="+other.Token, "")
+	c.Equal(http.StatusUnauthorized, rec.Code)
+	c.Contains(rec.Body.String(), "invalid_stream_token")
+}
+
+func TestIssueStreamToken(t *testing.T) {
+	c := require.New(t)
+
+	reader, readerSecret, err := models.NewAPIKey("reader", []string{models.ScopeRead})
+	c.NoError(err)
+
+	a := newTestAuthenticator(t, reader)
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+readerSecret)
+
+	rec := httptest.NewRecorder()
+	a.Require(models.ScopeRead)(a.IssueStreamToken(func(r *http.Request) string { return "jobs/1/events" })).ServeHTTP(rec, req)
+	c.Equal(http.StatusCreated, rec.Code)
+	c.Equal("no-store", rec.Header().Get("Cache-Control"))
+
+	var token StreamToken
+	c.NoError(json.Unmarshal(rec.Body.Bytes(), &token))
+	c.True(token.ExpiresAt.After(time.Now()))
+	keyID, ok := a.streamKey(token.Token, "jobs/1/events", time.Now())
+	c.True(ok)
+	c.Equal(reader.KeyID, keyID)
+
+	_, ok = a.streamKey(token.Token, "jobs/1/events", token.ExpiresAt)
+	c.False(ok)
+
+	_, ok = a.streamKey(strings.Replace(token.Token, reader.KeyID, "other", 1), "jobs/1/events", time.Now())
+	c.False(ok)
+}
@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/other_project/crockroach/internal/apperr"
+	"github.com/other_project/crockroach/internal/problem"
+	"github.com/other_project/crockroach/models"
+	"github.com/other_project/crockroach/shared/env"
+)
+
+// streamTokenParam is the query parameter of the stream tokens, EventSource cannot send headers
+const streamTokenParam = "access_token"
+
+var (
+	// StreamTokenTTL is the time a stream token can be used to open its stream
+	StreamTokenTTL = time.Duration(env.GetInt64("AUTH_STREAM_TOKEN_SECONDS", 60)) * time.Second
+	// StreamSecret signs the stream tokens, a random one is used when it's empty so the
+	// instances behind a load balancer must share it
+	StreamSecret = env.GetString("AUTH_STREAM_SECRET", "")
+
+	// ErrInvalidStreamToken when the stream token is malformed, expired or for another resource
+	ErrInvalidStreamToken = apperr.New(apperr.Unauthorized, "invalid_stream_token", "invalid or expired stream token")
+)
+
+// StreamToken is a short-lived credential of one stream, for the clients that cannot send a key
+type StreamToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// newStreamSecret returns the key that signs the stream tokens
+func newStreamSecret(secret string) []byte {
+	if secret != "" {
+		return []byte(secret)
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic("cannot generate the stream secret: " + err.Error())
+	}
+
+	return key
+}
+
+// NewStreamToken signs a token of the key for the resource that expires at the given time
+func (a *Authenticator) NewStreamToken(resource, keyID string, expires time.Time) *StreamToken {
+	claims := strconv.FormatInt(expires.Unix(), 10) + "." + keyID
+
+	return &StreamToken{
+		Token:     claims + "." + a.signStream(resource, claims),
+		ExpiresAt: time.Unix(expires.Unix(), 0).UTC(),
+	}
+}
+
+// streamKey returns the key that was issued the token when it was signed for the resource
+// and has not expired
+func (a *Authenticator) streamKey(token, resource string, now time.Time) (string, bool) {
+	dot := strings.LastIndexByte(token, '.')
+	if dot < 0 {
+		return "", false
+	}
+
+	claims, signature := token[:dot], token[dot+1:]
+	if !hmac.Equal([]byte(signature), []byte(a.signStream(resource, claims))) {
+		return "", false
+	}
+
+	expiry, keyID, ok := strings.Cut(claims, ".")
+	if !ok {
+		return "", false
+	}
+
+	unix, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil || now.Unix() >= unix {
+		return "", false
+	}
+
+	return keyID, true
+}
+
+// signStream returns the signature of the resource and claims of a stream token
+func (a *Authenticator) signStream(resource, claims string) string {
+	mac := hmac.New(sha256.New, a.streamSecret)
+	mac.Write([]byte(resource + "\n" + claims))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// IssueStreamToken returns a handler that answers a stream token for the resource of the request,
+// it must be mounted behind Require so only the keys that can read the stream get one
+func (a *Authenticator) IssueStreamToken(resource func(*http.Request) string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		keyID := ""
+		if key := KeyFromContext(r.Context()); key != nil {
+			keyID = key.KeyID
+		}
+
+		token := a.NewStreamToken(resource(r), keyID, time.Now().Add(StreamTokenTTL))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(token)
+	}
+}
+
+// RequireStream works as Require but also accepts a stream token of the resource in the
+// access_token query parameter, the token is checked when the stream is opened and the
+// request counts as one of the key that was issued it
+func (a *Authenticator) RequireStream(scope string, resource func(*http.Request) string) func(http.Handler) http.Handler {
+	require := a.Require(scope)
+
+	return func(next http.Handler) http.Handler {
+		withKey := require(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := r.URL.Query().Get(streamTokenParam)
+			if !a.enabled || token == "" || BearerToken(r) != "" {
+				withKey.ServeHTTP(w, r)
+				return
+			}
+
+			keyID, ok := a.streamKey(token, resource(r), time.Now())
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="crockroach", error="invalid_token"`)
+				problem.Error(w, r, ErrInvalidStreamToken)
+
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithKey(r.Context(), &models.APIKey{KeyID: keyID, Name: streamKeyName, Scopes: []string{scope}})))
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package check
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/other_project/crockroach/models"
+)
+
+// Status is the result of a check using the Nagios plugin exit codes
+type Status int
+
+const (
+	// StatusOK when every threshold is satisfied
+	StatusOK Status = 0
+	// StatusWarning when a warning threshold is exceeded
+	StatusWarning Status = 1
+	// StatusCritical when a critical threshold is exceeded
+	StatusCritical Status = 2
+	// StatusUnknown when the domain could not be evaluated
+	StatusUnknown Status = 3
+	// Disabled turns off a numeric threshold
+	Disabled = -1
+)
+
+// String returns the label printed in the status line
+func (s Status) String() string {
+	switch s {
+	case StatusOK:
+		return "OK"
+	case StatusWarning:
+		return "WARNING"
+	case StatusCritical:
+		return "CRITICAL"
+	}
+
+	return "UNKNOWN"
+}
+
+// Thresholds configure when a domain is in warning or critical state
+type Thresholds struct {
+	// WarningGrade is the minimum grade before a warning, empty to disable it
+	WarningGrade string
+	// CriticalGrade is the minimum grade before a critical, empty to disable it
+	CriticalGrade string
+	// CriticalIfDown reports a critical when the domain is down
+	CriticalIfDown bool
+	// WarningServerChange is the maximum change of the server count before a warning
+	WarningServerChange int
+	// CriticalServerChange is the maximum change of the server count before a critical
+	CriticalServerChange int
+}
+
+// Perfdata is a performance value appended to the status line
+type Perfdata struct {
+	Label    string
+	Value    int
+	Warning  string
+	Critical string
+	Min      string
+}
+
+// String formats the value as 'label'=value;warn;crit;min
+func (p Perfdata) String() string {
+	return fmt.Sprintf("'%s'=%d;%s;%s;%s", p.Label, p.Value, p.Warning, p.Critical, p.Min)
+}
+
+// Result of evaluating a domain
+type Result struct {
+	DomainName string
+	Status     Status
+	Messages   []string
+	Perfdata   []Perfdata
+}
+
+// NewUnknown returns the result used when the domain cannot be evaluated
+func NewUnknown(domainName string, err error) *Result {
+	return &Result{
+		DomainName: domainName,
+		Status:     StatusUnknown,
+		Messages:   []string{err.Error()},
+	}
+}
+
+// String returns the status line followed by the performance data
+func (r *Result) String() string {
+	line := fmt.Sprintf("%s - %s", r.Status.String(), r.DomainName)
+
+	if len(r.Messages) > 0 {
+		line = fmt.Sprintf("%s: %s", line, strings.Join(r.Messages, ", "))
+	}
+
+	if len(r.Perfdata) == 0 {
+		return line
+	}
+
+	values := make([]string, 0, len(r.Perfdata))
+	for _, p := range r.Perfdata {
+		values = append(values, p.String())
+	}
+
+	return fmt.Sprintf("%s | %s", line, strings.Join(values, " "))
+}
+
+// raise keeps the worst status
+func (r *Result) raise(status Status, msg string) {
+	if status > r.Status {
+		r.Status = status
+	}
+
+	r.Messages = append(r.Messages, msg)
+}
+
+// Evaluate compares the domain against the thresholds.
+// previousServers is the server count of the previous check, Disabled when it is unknown
+func Evaluate(domain *models.Domain, previousServers int, th Thresholds) *Result {
+	result := &Result{
+		DomainName: domain.DomainName,
+		Status:     StatusOK,
+	}
+
+	if domain.IsDown && th.CriticalIfDown {
+		result.raise(StatusCritical, "site is down")
+	}
+
+	grade := domain.SSLGrade
+	if grade == "" {
+		grade = models.LowestGrade(domain.Servers)
+	}
+
+	switch {
+	case th.CriticalGrade != "" && models.IsGradeBelow(grade, th.CriticalGrade):
+		result.raise(StatusCritical, fmt.Sprintf("grade %s is below %s", gradeLabel(grade), th.CriticalGrade))
+	case th.WarningGrade != "" && models.IsGradeBelow(grade, th.WarningGrade):
+		result.raise(StatusWarning, fmt.Sprintf("grade %s is below %s", gradeLabel(grade), th.WarningGrade))
+	}
+
+	servers := len(domain.Servers)
+	change := 0
+
+	if previousServers != Disabled {
+		change = servers - previousServers
+		if change < 0 {
+			change = -change
+		}
+
+		switch {
+		case th.CriticalServerChange != Disabled && change > th.CriticalServerChange:
+			result.raise(StatusCritical, fmt.Sprintf("server count changed from %d to %d", previousServers, servers))
+		case th.WarningServerChange != Disabled && change > th.WarningServerChange:
+			result.raise(StatusWarning, fmt.Sprintf("server count changed from %d to %d", previousServers, servers))
+		}
+	}
+
+	if len(result.Messages) == 0 {
+		result.Messages = append(result.Messages, fmt.Sprintf("grade %s, %d servers", gradeLabel(grade), servers))
+	}
+
+	down := 0
+	if domain.IsDown {
+		down = 1
+	}
+
+	result.Perfdata = []Perfdata{
+		{Label: "grade", Value: models.GradeValue(grade), Warning: thresholdGrade(th.WarningGrade), Critical: thresholdGrade(th.CriticalGrade), Min: "0"},
+		{Label: "is_down", Value: down, Min: "0"},
+		{Label: "servers", Value: servers, Min: "0"},
+		{Label: "server_change", Value: change, Warning: thresholdInt(th.WarningServerChange), Critical: thresholdInt(th.CriticalServerChange), Min: "0"},
+	}
+
+	return result
+}
+
+// gradeLabel prints a readable value for missing grades
+func gradeLabel(grade string) string {
+	if grade == "" {
+		return "unknown"
+	}
+
+	return grade
+}
+
+// thresholdGrade formats a grade threshold as the Nagios range "value:" (alert below value)
+func thresholdGrade(grade string) string {
+	if grade == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("%d:", models.GradeValue(grade))
+}
+
+// thresholdInt formats a numeric threshold
+func thresholdInt(value int) string {
+	if value == Disabled {
+		return ""
+	}
+
+	return fmt.Sprintf("%d", value)
+}
@@ -0,0 +1,96 @@
+package check
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/other_project/crockroach/models"
+	"github.com/stretchr/testify/require"
+)
+
+// newDomain builds a domain with a server per grade
+func newDomain(c *require.Assertions, isDown bool, grades ...string) *models.Domain {
+	domain, err := models.NewDomain(false, isDown, "example.com", "", "", "https://example.com/favicon.ico", "Example")
+	c.NoError(err)
+
+	for _, grade := range grades {
+		server, err := models.NewServer("93.184.216.34", grade, "US", "EDGECAST", domain)
+		c.NoError(err)
+
+		domain.Servers = append(domain.Servers, server)
+	}
+
+	return domain
+}
+
+func TestEvaluateOK(t *testing.T) {
+	c := require.New(t)
+
+	th := Thresholds{WarningGrade: "A", CriticalGrade: "B", CriticalIfDown: true, WarningServerChange: 1, CriticalServerChange: 2}
+
+	result := Evaluate(newDomain(c, false, "A+", "A"), 2, th)
+	c.Equal(StatusOK, result.Status)
+	c.Equal("OK - example.com: grade A, 2 servers | 'grade'=18;18:;15:;0 'is_down'=0;;;0 'servers'=2;;;0 'server_change'=0;1;2;0", result.String())
+}
+
+func TestEvaluateWarningAndCritical(t *testing.T) {
+	c := require.New(t)
+
+	th := Thresholds{WarningGrade: "A", CriticalGrade: "B", CriticalIfDown: true, WarningServerChange: 1, CriticalServerChange: 3}
+
+	result := Evaluate(newDomain(c, false, "A-"), 1, th)
+	c.Equal(StatusWarning, result.Status)
+	c.Equal([]string{"grade A- is below A"}, result.Messages)
+
+	result = Evaluate(newDomain(c, false, "A", "A", "A"), 1, th)
+	c.Equal(StatusWarning, result.Status)
+	c.Equal([]string{"server count changed from 1 to 3"}, result.Messages)
+
+	result = Evaluate(newDomain(c, true, "C"), Disabled, th)
+	c.Equal(StatusCritical, result.Status)
+	c.Equal([]string{"site is down", "grade C is below B"}, result.Messages)
+
+	th.CriticalIfDown = false
+	result = Evaluate(newDomain(c, true, "A"), Disabled, th)
+	c.Equal(StatusOK, result.Status)
+
+	result = Evaluate(newDomain(c, false), Disabled, th)
+	c.Equal(StatusCritical, result.Status)
+	c.Equal([]string{"grade unknown is below B"}, result.Messages)
+}
+
+func TestNewUnknown(t *testing.T) {
+	c := require.New(t)
+
+	result := NewUnknown("example.com", errors.New("timeout"))
+	c.Equal(StatusUnknown, result.Status)
+	c.Equal("UNKNOWN - example.com: timeout", result.String())
+}
+
+func TestState(t *testing.T) {
+	c := require.New(t)
+
+	dir, err := ioutil.TempDir("", "check")
+	c.NoError(err)
+
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	path := filepath.Join(dir, "state.json")
+
+	state, err := LoadState(path)
+	c.NoError(err)
+	c.Nil(state)
+
+	c.NoError(SaveState(path, &State{Servers: 3, SSLGrade: "A", CheckedAt: time.Now()}))
+
+	state, err = LoadState(path)
+	c.NoError(err)
+	c.Equal(3, state.Servers)
+	c.Equal("A", state.SSLGrade)
+}
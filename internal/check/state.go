@@ -0,0 +1,46 @@
+package check
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+// State keeps the values of the last check between executions
+type State struct {
+	Servers   int       `json:"servers"`
+	SSLGrade  string    `json:"ssl_grade"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// LoadState reads the state saved by the previous check, nil when there is none
+func LoadState(path string) (*State, error) {
+	content, err := ioutil.ReadFile(path) //nolint:gosec
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	state := new(State)
+
+	err = json.Unmarshal(content, state)
+	if err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// SaveState writes the state for the next check
+func SaveState(path string, state *State) error {
+	content, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, content, 0600)
+}
@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/other_project/crockroach/api/httphand"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// FormatJSON prints the result as indented JSON
+	FormatJSON = "json"
+	// FormatYAML prints the result as YAML
+	FormatYAML = "yaml"
+	// FormatTable prints the result as a human-readable table
+	FormatTable = "table"
+)
+
+var (
+	// ErrUnknownFormat when the output format is not supported
+	ErrUnknownFormat = errors.New("unknown output format")
+	// analyze runs the analysis pipeline, tests replace it to avoid the network
+	analyze = httphand.ProcessDataWithOptions
+)
+
+// analysisFlags are shared by the commands that run the analysis pipeline
+type analysisFlags struct {
+	providers string
+	timeout   time.Duration
+	skipWHOIS bool
+}
+
+// register adds the analysis flags to fs
+func (f *analysisFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.providers, "providers", strings.Join(httphand.Providers, ","), "comma separated providers used in the analysis")
+	fs.DurationVar(&f.timeout, "timeout", httphand.Timeout, "timeout of every outbound request")
+	fs.BoolVar(&f.skipWHOIS, "skip-whois", false, "do not run whois for the servers")
+}
+
+// options builds the pipeline options
+func (f *analysisFlags) options() httphand.Options {
+	providers := []string{}
+
+	for _, provider := range strings.Split(f.providers, ",") {
+		provider = strings.TrimSpace(provider)
+		if provider != "" {
+			providers = append(providers, provider)
+		}
+	}
+
+	return httphand.Options{
+		Providers: providers,
+		Timeout:   f.timeout,
+		SkipWHOIS: f.skipWHOIS,
+	}
+}
+
+// runAnalyze runs the analysis pipeline for a domain and prints the result
+func runAnalyze(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("analyze", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	format := fs.String("format", FormatJSON, "output format: json, yaml or table")
+	analysis := new(analysisFlags)
+	analysis.register(fs)
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+
+	domainName, err := domainArg(positional)
+	if err != nil {
+		fmt.Fprintf(stderr, "analyze: %s\n", err.Error())
+		return ExitUsage
+	}
+
+	err = validateFormat(*format)
+	if err != nil {
+		fmt.Fprintf(stderr, "analyze: %s\n", err.Error())
+		return ExitUsage
+	}
+
+	opts := analysis.options()
+
+	err = opts.Validate()
+	if err != nil {
+		fmt.Fprintf(stderr, "analyze: %s\n", err.Error())
+		return ExitUsage
+	}
+
+	domain, err := analyze(ctx, domainName, opts)
+	if err != nil {
+		fmt.Fprintf(stderr, "analyze: cannot analyze %s: %s\n", domainName, err.Error())
+		return ExitFailure
+	}
+
+	err = writeResult(stdout, *format, domainName, httphand.NewParseDomainJSON(domain))
+	if err != nil {
+		fmt.Fprintf(stderr, "analyze: %s\n", err.Error())
+		return ExitFailure
+	}
+
+	return ExitOK
+}
+
+// validateFormat ensure that the output format is supported
+func validateFormat(format string) error {
+	switch format {
+	case FormatJSON, FormatYAML, FormatTable:
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+}
+
+// writeResult prints the result of the analysis in the selected format
+func writeResult(w io.Writer, format, domainName string, result *httphand.ParseDomainJSON) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(result)
+	case FormatYAML:
+		return yaml.NewEncoder(w).Encode(result)
+	case FormatTable:
+		return writeTable(w, domainName, result)
+	}
+
+	return validateFormat(format)
+}
+
+// writeTable prints the result as a human-readable table
+func writeTable(w io.Writer, domainName string, result *httphand.ParseDomainJSON) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Domain:\t%s\n", domainName)
+	fmt.Fprintf(tw, "Title:\t%s\n", strings.TrimSpace(result.Title))
+	fmt.Fprintf(tw, "Logo:\t%s\n", result.Logo)
+	fmt.Fprintf(tw, "SSL grade:\t%s\n", result.SSLGrade)
+	fmt.Fprintf(tw, "Previous SSL grade:\t%s\n", result.PreviousSSLGrade)
+	fmt.Fprintf(tw, "Servers changed:\t%t\n", result.ServerChanged)
+	fmt.Fprintf(tw, "Is down:\t%t\n", result.IsDown)
+	fmt.Fprintln(tw)
+
+	fmt.Fprintln(tw, "ADDRESS\tSSL GRADE\tCOUNTRY\tOWNER")
+
+	for _, server := range result.Servers {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", server.Address, server.SSLGrade, oneLine(server.Country), oneLine(server.Owner))
+	}
+
+	return tw.Flush()
+}
+
+// oneLine joins the lines of the whois output so they fit in a table cell
+func oneLine(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
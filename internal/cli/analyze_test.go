@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/other_project/crockroach/api/httphand"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/models"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDomain builds the domain returned by the fake analyzer
+func fakeDomain(c *require.Assertions, domainName string) *models.Domain {
+	domain, err := models.NewDomain(false, false, domainName, "", "", "https://example.com/favicon.ico", "Example Domain")
+	c.NoError(err)
+
+	server, err := models.NewServer("93.184.216.34", "A", "US", "EDGECAST\nNETBLK", domain)
+	c.NoError(err)
+
+	domain.Servers = append(domain.Servers, server)
+	domain.SSLGrade = "A"
+
+	return domain
+}
+
+// withAnalyzer replaces the analysis pipeline during the test
+func withAnalyzer(fn func(ctx context.Context, domainName string, opts httphand.Options) (*models.Domain, error), cb func()) {
+	previous := analyze
+	analyze = fn
+
+	defer func() {
+		analyze = previous
+	}()
+
+	cb()
+}
+
+func TestRunAnalyzeJSON(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	var received httphand.Options
+
+	fn := func(ctx context.Context, domainName string, opts httphand.Options) (*models.Domain, error) {
+		received = opts
+		return fakeDomain(c, domainName), nil
+	}
+
+	withAnalyzer(fn, func() {
+		stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+
+		code := Run(context.Background(), []string{"analyze", "example.com", "--skip-whois", "--timeout", "3s", "--providers", "ssllabs"}, stdout, stderr)
+		c.Equal(ExitOK, code, stderr.String())
+
+		result := new(httphand.ParseDomainJSON)
+		c.NoError(json.Unmarshal(stdout.Bytes(), result))
+		c.Equal("A", result.SSLGrade)
+		c.Equal("Example Domain", result.Title)
+		c.Len(result.Servers, 1)
+		c.Equal("93.184.216.34", result.Servers[0].Address)
+	})
+
+	c.True(received.SkipWHOIS)
+	c.Equal([]string{httphand.ProviderSSLLabs}, received.Providers)
+	c.Equal("3s", received.Timeout.String())
+}
+
+func TestRunAnalyzeFormats(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	fn := func(ctx context.Context, domainName string, opts httphand.Options) (*models.Domain, error) {
+		return fakeDomain(c, domainName), nil
+	}
+
+	withAnalyzer(fn, func() {
+		stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+
+		code := Run(context.Background(), []string{"analyze", "--format", "yaml", "example.com"}, stdout, stderr)
+		c.Equal(ExitOK, code, stderr.String())
+		c.Contains(stdout.String(), "ssl_grade: A")
+		c.Contains(stdout.String(), "address: 93.184.216.34")
+
+		stdout.Reset()
+
+		code = Run(context.Background(), []string{"analyze", "--format", "table", "example.com"}, stdout, stderr)
+		c.Equal(ExitOK, code, stderr.String())
+		c.Contains(stdout.String(), "Domain:")
+		c.Contains(stdout.String(), "EDGECAST NETBLK")
+	})
+}
+
+func TestRunAnalyzeFailure(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	calls := 0
+	fn := func(ctx context.Context, domainName string, opts httphand.Options) (*models.Domain, error) {
+		calls++
+		return nil, errors.New("boom")
+	}
+
+	withAnalyzer(fn, func() {
+		stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+
+		c.Equal(ExitFailure, Run(context.Background(), []string{"analyze", "example.com"}, stdout, stderr))
+		c.Contains(stderr.String(), "boom")
+
+		c.Equal(ExitUsage, Run(context.Background(), []string{"analyze"}, stdout, stderr))
+		c.Equal(ExitUsage, Run(context.Background(), []string{"analyze", "a.com", "b.com"}, stdout, stderr))
+		c.Equal(ExitUsage, Run(context.Background(), []string{"analyze", "--format", "xml", "a.com"}, stdout, stderr))
+		c.Equal(ExitUsage, Run(context.Background(), []string{"analyze", "--providers", "nmap", "a.com"}, stdout, stderr))
+		c.Equal(ExitUsage, Run(context.Background(), []string{"unknown"}, stdout, stderr))
+		c.Equal(ExitUsage, Run(context.Background(), nil, stdout, stderr))
+	})
+
+	c.Equal(1, calls)
+}
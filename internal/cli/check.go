@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/other_project/crockroach/internal/check"
+	"github.com/other_project/crockroach/models"
+)
+
+// runCheck evaluates a domain against thresholds and exits with the Nagios plugin codes
+func runCheck(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	th := check.Thresholds{}
+	fs.StringVar(&th.WarningGrade, "warning-grade", "", "minimum grade before a WARNING")
+	fs.StringVar(&th.CriticalGrade, "min-grade", "", "minimum grade before a CRITICAL")
+	fs.BoolVar(&th.CriticalIfDown, "critical-if-down", true, "report CRITICAL when the site is down")
+	fs.IntVar(&th.WarningServerChange, "warning-server-change", check.Disabled, "maximum change of the server count before a WARNING")
+	fs.IntVar(&th.CriticalServerChange, "max-server-change", check.Disabled, "maximum change of the server count before a CRITICAL")
+	stateFile := fs.String("state-file", "", "file that keeps the server count between checks")
+
+	analysis := new(analysisFlags)
+	analysis.register(fs)
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return int(check.StatusUnknown)
+	}
+
+	domainName, err := domainArg(positional)
+	if err != nil {
+		return unknown(stdout, domainName, err)
+	}
+
+	for _, grade := range []string{th.WarningGrade, th.CriticalGrade} {
+		if grade != "" && models.GradeRank(grade) == -1 {
+			return unknown(stdout, domainName, fmt.Errorf("invalid grade %q", grade))
+		}
+	}
+
+	opts := analysis.options()
+
+	err = opts.Validate()
+	if err != nil {
+		return unknown(stdout, domainName, err)
+	}
+
+	previousServers := check.Disabled
+
+	if *stateFile != "" {
+		state, err := check.LoadState(*stateFile)
+		if err != nil {
+			return unknown(stdout, domainName, err)
+		}
+
+		if state != nil {
+			previousServers = state.Servers
+		}
+	}
+
+	domain, err := analyze(ctx, domainName, opts)
+	if err != nil {
+		return unknown(stdout, domainName, err)
+	}
+
+	result := check.Evaluate(domain, previousServers, th)
+
+	if *stateFile != "" {
+		err = check.SaveState(*stateFile, &check.State{
+			Servers:   len(domain.Servers),
+			SSLGrade:  domain.SSLGrade,
+			CheckedAt: time.Now(),
+		})
+		if err != nil {
+			fmt.Fprintf(stderr, "check: cannot save state: %s\n", err.Error())
+		}
+	}
+
+	fmt.Fprintln(stdout, result.String())
+
+	return int(result.Status)
+}
+
+// unknown prints an UNKNOWN status line
+func unknown(w io.Writer, domainName string, err error) int {
+	result := check.NewUnknown(domainName, err)
+	fmt.Fprintln(w, result.String())
+
+	return int(result.Status)
+}
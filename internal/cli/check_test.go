@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/other_project/crockroach/api/httphand"
+	"github.com/other_project/crockroach/internal/check"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunCheck(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	dir, err := ioutil.TempDir("", "cli")
+	c.NoError(err)
+
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	stateFile := filepath.Join(dir, "state.json")
+	c.NoError(check.SaveState(stateFile, &check.State{Servers: 4}))
+
+	fn := func(ctx context.Context, domainName string, opts httphand.Options) (*models.Domain, error) {
+		return fakeDomain(c, domainName), nil
+	}
+
+	withAnalyzer(fn, func() {
+		stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+
+		code := Run(context.Background(), []string{"check", "example.com", "--min-grade", "B"}, stdout, stderr)
+		c.Equal(int(check.StatusOK), code, stderr.String())
+		c.Contains(stdout.String(), "OK - example.com")
+
+		stdout.Reset()
+
+		code = Run(context.Background(), []string{"check", "example.com", "--warning-grade", "A+"}, stdout, stderr)
+		c.Equal(int(check.StatusWarning), code)
+
+		stdout.Reset()
+
+		code = Run(context.Background(), []string{"check", "example.com", "--max-server-change", "2", "--state-file", stateFile}, stdout, stderr)
+		c.Equal(int(check.StatusCritical), code)
+		c.Contains(stdout.String(), "server count changed from 4 to 1")
+
+		state, err := check.LoadState(stateFile)
+		c.NoError(err)
+		c.Equal(1, state.Servers)
+
+		stdout.Reset()
+
+		code = Run(context.Background(), []string{"check", "example.com", "--min-grade", "Z"}, stdout, stderr)
+		c.Equal(int(check.StatusUnknown), code)
+	})
+
+	fn = func(ctx context.Context, domainName string, opts httphand.Options) (*models.Domain, error) {
+		return nil, errors.New("connection refused")
+	}
+
+	withAnalyzer(fn, func() {
+		stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+
+		code := Run(context.Background(), []string{"check", "example.com"}, stdout, stderr)
+		c.Equal(int(check.StatusUnknown), code)
+		c.Equal("UNKNOWN - example.com: connection refused\n", stdout.String())
+	})
+}
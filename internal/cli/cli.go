@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	// ExitOK when the command finished successfully
+	ExitOK = 0
+	// ExitFailure when the command could not complete its work
+	ExitFailure = 1
+	// ExitUsage when the command line is invalid
+	ExitUsage = 2
+)
+
+var (
+	// ErrUnknownCommand when the command does not exist
+	ErrUnknownCommand = errors.New("unknown command")
+	// ErrMissingDomain when the command needs a domain name
+	ErrMissingDomain = errors.New("missing domain name")
+	// ErrTooManyArguments when the command receives unexpected arguments
+	ErrTooManyArguments = errors.New("too many arguments")
+)
+
+// command runs with the arguments that follow its name and returns the exit code
+type command func(ctx context.Context, args []string, stdout, stderr io.Writer) int
+
+// commands registered in the command line
+var commands = map[string]command{
+	"analyze": runAnalyze,
+	"check":   runCheck,
+	"export":  runExport,
+	"import":  runImport,
+}
+
+// Run executes the command selected by args and returns the exit code of the process
+func Run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return ExitUsage
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "%s: %s\n", ErrUnknownCommand.Error(), args[0])
+		usage(stderr)
+
+		return ExitUsage
+	}
+
+	return cmd(ctx, args[1:], stdout, stderr)
+}
+
+// usage prints the available commands
+func usage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	fmt.Fprintf(w, "usage: crockroach [serve|%s] [flags] [args]\n", strings.Join(names, "|"))
+}
+
+// parseArgs parses the flags that appear before or after the positional arguments
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	positional := []string{}
+
+	for {
+		err := fs.Parse(args)
+		if err != nil {
+			return nil, err
+		}
+
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// domainArg returns the single domain name expected by a command
+func domainArg(args []string) (string, error) {
+	if len(args) == 0 {
+		return "", ErrMissingDomain
+	}
+
+	if len(args) > 1 {
+		return "", ErrTooManyArguments
+	}
+
+	return args[0], nil
+}
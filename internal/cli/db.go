@@ -0,0 +1,27 @@
+package cli
+
+import (
+	"errors"
+
+	"github.com/other_project/crockroach/internal/storage"
+	"github.com/other_project/crockroach/shared/cockroachdb"
+)
+
+var (
+	// ErrDatabaseUnavailable when the command cannot connect to the database
+	ErrDatabaseUnavailable = errors.New("cannot connect to the database")
+	// connect opens the database used by the commands, tests replace it
+	connect = connectDB
+)
+
+// connectDB opens the connection used by the storage package
+func connectDB() error {
+	client := cockroachdb.NewSQLClient()
+	if client == nil {
+		return ErrDatabaseUnavailable
+	}
+
+	storage.CockroachClient = client
+
+	return nil
+}
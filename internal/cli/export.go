@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/other_project/crockroach/internal/export"
+	"github.com/other_project/crockroach/internal/storage"
+)
+
+// exportSource streams the stored analyses, tests replace it to avoid the database
+var exportSource export.Source = storage.ExportDomains
+
+// runExport writes the stored analyses as CSV or NDJSON
+func runExport(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	format := fs.String("format", export.FormatCSV, "output format: csv or ndjson")
+	from := fs.String("from", "", "export the analyses updated since this date (RFC 3339 or YYYY-MM-DD)")
+	to := fs.String("to", "", "export the analyses updated before this date (RFC 3339 or YYYY-MM-DD)")
+	output := fs.String("output", "", "file to write, standard output when it's empty")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+
+	if len(positional) > 0 {
+		fmt.Fprintf(stderr, "export: %s\n", ErrTooManyArguments.Error())
+		return ExitUsage
+	}
+
+	if *format != export.FormatCSV && *format != export.FormatNDJSON {
+		fmt.Fprintf(stderr, "export: %s: %s\n", export.ErrUnknownFormat.Error(), *format)
+		return ExitUsage
+	}
+
+	start, end, err := export.ParseRange(*from, *to, time.Now())
+	if err != nil {
+		fmt.Fprintf(stderr, "export: %s\n", err.Error())
+		return ExitUsage
+	}
+
+	err = connect()
+	if err != nil {
+		fmt.Fprintf(stderr, "export: %s\n", err.Error())
+		return ExitFailure
+	}
+
+	out := stdout
+
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(stderr, "export: %s\n", err.Error())
+			return ExitFailure
+		}
+
+		defer func() {
+			if err := file.Close(); err != nil {
+				fmt.Fprintf(stderr, "export: %s\n", err.Error())
+			}
+		}()
+
+		out = file
+	}
+
+	count, err := export.Export(ctx, exportSource, *format, start, end, out)
+	if err != nil {
+		fmt.Fprintf(stderr, "export: failed after %d rows: %s\n", count, err.Error())
+		return ExitFailure
+	}
+
+	fmt.Fprintf(stderr, "export: %d rows written\n", count)
+
+	return ExitOK
+}
@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/internal/storage"
+	"github.com/stretchr/testify/require"
+)
+
+// withDatabase replaces the database connection and the export source during the test
+func withDatabase(connectErr error, cb func()) {
+	previousConnect, previousSource := connect, exportSource
+
+	connect = func() error {
+		return connectErr
+	}
+
+	exportSource = func(ctx context.Context, from, to time.Time, fn func(*storage.ExportRow) error) error {
+		return fn(&storage.ExportRow{DomainID: "d1", DomainName: "example.com", ServerID: "s1", Address: "10.0.0.1"})
+	}
+
+	defer func() {
+		connect, exportSource = previousConnect, previousSource
+	}()
+
+	cb()
+}
+
+func TestRunExport(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	dir, err := ioutil.TempDir("", "cli")
+	c.NoError(err)
+
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	withDatabase(nil, func() {
+		stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+
+		code := Run(context.Background(), []string{"export", "--format", "ndjson", "--from", "2026-01-01"}, stdout, stderr)
+		c.Equal(ExitOK, code, stderr.String())
+		c.Contains(stdout.String(), `"domain_name":"example.com"`)
+		c.Contains(stderr.String(), "1 rows written")
+
+		output := filepath.Join(dir, "domains.csv")
+
+		code = Run(context.Background(), []string{"export", "--output", output}, stdout, stderr)
+		c.Equal(ExitOK, code, stderr.String())
+
+		content, err := ioutil.ReadFile(output)
+		c.NoError(err)
+		c.True(strings.HasPrefix(string(content), "domain_id,"))
+
+		c.Equal(ExitUsage, Run(context.Background(), []string{"export", "--format", "xml"}, stdout, stderr))
+		c.Equal(ExitUsage, Run(context.Background(), []string{"export", "--from", "later"}, stdout, stderr))
+	})
+
+	withDatabase(errors.New("refused"), func() {
+		stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+		c.Equal(ExitFailure, Run(context.Background(), []string{"export"}, stdout, stderr))
+	})
+}
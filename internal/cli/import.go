@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/other_project/crockroach/api/httphand"
+	"github.com/other_project/crockroach/internal/importer"
+	"github.com/other_project/crockroach/internal/storage"
+)
+
+// importPollInterval is the time between the reads of the progress of an import with --wait
+var importPollInterval = 5 * time.Second
+
+// importService stores the imports and reads their progress, the service analyzes their jobs
+type importService interface {
+	StartImport(ctx context.Context, parsed *importer.ParseResult) (*importer.Operation, error)
+	ImportProgress(ctx context.Context, importID string) (*importer.Operation, error)
+}
+
+// newImportService uses the same storage and jobs as POST /domains/import, tests replace it
+var newImportService = func() importService {
+	return httphand.NewHandlerRequest(storage.NewStore())
+}
+
+// runImport tracks every domain of a CSV or plain text file and queues its analysis
+func runImport(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	dryRun := fs.Bool("dry-run", false, "only validate the file and print the normalized domains")
+	wait := fs.Bool("wait", false, "wait until the service analyzed every domain")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+
+	if len(positional) != 1 {
+		fmt.Fprintln(stderr, "import: expected a file name or - to read the standard input")
+		return ExitUsage
+	}
+
+	parsed, err := parseImportFile(positional[0])
+	if err != nil {
+		fmt.Fprintf(stderr, "import: %s\n", err.Error())
+		return ExitFailure
+	}
+
+	for _, lineErr := range parsed.Errors {
+		fmt.Fprintf(stderr, "import: line %d: %q: %s\n", lineErr.Line, lineErr.Value, lineErr.Error)
+	}
+
+	if *dryRun {
+		return printJSON(stdout, stderr, parsed)
+	}
+
+	err = connect()
+	if err != nil {
+		fmt.Fprintf(stderr, "import: %s\n", err.Error())
+		return ExitFailure
+	}
+
+	service := newImportService()
+
+	op, err := service.StartImport(ctx, parsed)
+	if err == nil && *wait {
+		op, err = waitImport(ctx, service, op)
+	}
+
+	if err != nil {
+		fmt.Fprintf(stderr, "import: %s\n", err.Error())
+		return ExitFailure
+	}
+
+	code := printJSON(stdout, stderr, op)
+	if code == ExitOK && op.Failed > 0 {
+		return ExitFailure
+	}
+
+	return code
+}
+
+// waitImport reads the progress of the import until every domain was processed
+func waitImport(ctx context.Context, service importService, op *importer.Operation) (*importer.Operation, error) {
+	ticker := time.NewTicker(importPollInterval)
+	defer ticker.Stop()
+
+	for op.State != importer.StateDone {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+
+		var err error
+
+		op, err = service.ImportProgress(ctx, op.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return op, nil
+}
+
+// parseImportFile reads the file or the standard input
+func parseImportFile(name string) (*importer.ParseResult, error) {
+	if name == "-" {
+		return importer.Parse(os.Stdin)
+	}
+
+	file, err := os.Open(name) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	return importer.Parse(file)
+}
+
+// printJSON writes the value as indented JSON
+func printJSON(stdout, stderr io.Writer, value interface{}) int {
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+
+	err := encoder.Encode(value)
+	if err != nil {
+		fmt.Fprintf(stderr, "%s\n", err.Error())
+		return ExitFailure
+	}
+
+	return ExitOK
+}
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/other_project/crockroach/internal/importer"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryImports is an import service whose domains finish on the next read, the last one fails
+type memoryImports struct {
+	started []string
+	reads   int
+}
+
+func (m *memoryImports) StartImport(ctx context.Context, parsed *importer.ParseResult) (*importer.Operation, error) {
+	m.started = append(m.started, parsed.Domains...)
+
+	op := &importer.Operation{ID: "import", State: importer.StateQueued, Total: len(parsed.Domains)}
+	for _, domain := range parsed.Domains {
+		op.Items = append(op.Items, &importer.Item{Domain: domain, Status: importer.ItemPending})
+	}
+
+	return op, nil
+}
+
+func (m *memoryImports) ImportProgress(ctx context.Context, importID string) (*importer.Operation, error) {
+	m.reads++
+
+	op := &importer.Operation{ID: importID, State: importer.StateDone, Total: len(m.started), Processed: len(m.started)}
+
+	for i, domain := range m.started {
+		item := &importer.Item{Domain: domain, Status: importer.ItemSucceeded}
+		if i == len(m.started)-1 {
+			item.Status = importer.ItemFailed
+			op.Failed++
+		} else {
+			op.Succeeded++
+		}
+
+		op.Items = append(op.Items, item)
+	}
+
+	return op, nil
+}
+
+func TestRunImport(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	dir, err := ioutil.TempDir("", "cli")
+	c.NoError(err)
+
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	file := filepath.Join(dir, "domains.txt")
+	c.NoError(ioutil.WriteFile(file, []byte("example.com\nfail.com\nexample.com\nbad domain\n"), 0600))
+
+	service := &memoryImports{}
+	previousService, previousInterval := newImportService, importPollInterval
+	newImportService = func() importService { return service }
+	importPollInterval = time.Millisecond
+
+	defer func() {
+		newImportService, importPollInterval = previousService, previousInterval
+	}()
+
+	withDatabase(nil, func() {
+		stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+
+		code := Run(context.Background(), []string{"import", "--dry-run", file}, stdout, stderr)
+		c.Equal(ExitOK, code, stderr.String())
+		c.Contains(stderr.String(), `line 4: "bad domain"`)
+
+		parsed := new(importer.ParseResult)
+		c.NoError(json.Unmarshal(stdout.Bytes(), parsed))
+		c.Equal([]string{"example.com", "fail.com"}, parsed.Domains)
+		c.Empty(service.started)
+
+		// without --wait the import is queued for the jobs of the service
+		stdout.Reset()
+
+		code = Run(context.Background(), []string{"import", file}, stdout, stderr)
+		c.Equal(ExitOK, code, stderr.String())
+
+		op := new(importer.Operation)
+		c.NoError(json.Unmarshal(stdout.Bytes(), op))
+		c.Equal(importer.StateQueued, op.State)
+		c.Equal([]string{"example.com", "fail.com"}, service.started)
+		c.Zero(service.reads)
+
+		service.started = nil
+		stdout.Reset()
+
+		code = Run(context.Background(), []string{"import", file, "--wait"}, stdout, stderr)
+		c.Equal(ExitFailure, code)
+
+		op = new(importer.Operation)
+		c.NoError(json.Unmarshal(stdout.Bytes(), op))
+		c.Equal(importer.StateDone, op.State)
+		c.Equal(1, op.Succeeded)
+		c.Equal(1, op.Failed)
+		c.Equal(1, service.reads)
+
+		c.Equal(ExitUsage, Run(context.Background(), []string{"import"}, stdout, stderr))
+		c.Equal(ExitFailure, Run(context.Background(), []string{"import", filepath.Join(dir, "missing.txt")}, stdout, stderr))
+	})
+}
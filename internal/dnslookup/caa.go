@@ -0,0 +1,100 @@
+package dnslookup
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+
+	"github.com/other_project/crockroach/models"
+)
+
+var (
+	// ErrMalformedAnswer when the answer cannot be read
+	ErrMalformedAnswer = errors.New("malformed dns answer")
+)
+
+// caa queries the certification authority authorizations. dnsmessage does not parse them, so the
+// answer section is read by hand
+func (r *Resolver) caa(ctx context.Context, domainName string) ([]models.CAARecord, error) {
+	records := []models.CAARecord{}
+
+	msg, err := r.exchange(ctx, domainName, typeCAA)
+	if err != nil {
+		return records, err
+	}
+
+	if len(msg) < 12 {
+		return records, ErrMalformedAnswer
+	}
+
+	questions := int(binary.BigEndian.Uint16(msg[4:6]))
+	answers := int(binary.BigEndian.Uint16(msg[6:8]))
+	off := 12
+
+	for i := 0; i < questions; i++ {
+		off, err = skipName(msg, off)
+		if err != nil {
+			return records, err
+		}
+
+		// type and class
+		off += 4
+	}
+
+	for i := 0; i < answers; i++ {
+		off, err = skipName(msg, off)
+		if err != nil {
+			return records, err
+		}
+
+		// type, class, ttl and length
+		if off+10 > len(msg) {
+			return records, ErrMalformedAnswer
+		}
+
+		recordType := binary.BigEndian.Uint16(msg[off:])
+		length := int(binary.BigEndian.Uint16(msg[off+8:]))
+		off += 10
+
+		if off+length > len(msg) {
+			return records, ErrMalformedAnswer
+		}
+
+		data := msg[off : off+length]
+		off += length
+
+		if recordType != uint16(typeCAA) {
+			continue
+		}
+
+		// flags, tag length, tag and value
+		if len(data) < 2 || 2+int(data[1]) > len(data) {
+			return records, ErrMalformedAnswer
+		}
+
+		tagEnd := 2 + int(data[1])
+		records = append(records, models.CAARecord{Flags: data[0], Tag: string(data[2:tagEnd]), Value: string(data[tagEnd:])})
+	}
+
+	return records, nil
+}
+
+// skipName returns the offset after the name, a compressed name ends with its pointer
+func skipName(msg []byte, off int) (int, error) {
+	for {
+		if off >= len(msg) {
+			return 0, ErrMalformedAnswer
+		}
+
+		length := int(msg[off])
+
+		switch {
+		case length == 0:
+			return off + 1, nil
+		case length&0xC0 == 0xC0:
+			return off + 2, nil
+		}
+
+		off += 1 + length
+	}
+}
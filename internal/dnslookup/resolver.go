@@ -0,0 +1,405 @@
+package dnslookup
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/models"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	// ResolvConf is read to find the server when none is configured
+	ResolvConf = "/etc/resolv.conf"
+	// FallbackServer is used when the system does not have a name server
+	FallbackServer = "127.0.0.1:53"
+	// MaxCNAMEChain is the longest chain of aliases followed from the domain
+	MaxCNAMEChain = 8
+
+	// typeCAA is not defined by dnsmessage
+	typeCAA dnsmessage.Type = 257
+	// maxMessageSize is the size of the UDP buffer, larger answers are truncated and asked over TCP
+	maxMessageSize = 4096
+)
+
+var (
+	// ErrNotFound when the domain does not exist
+	ErrNotFound = errors.New("the domain does not exist")
+	// ErrServerFailure when the server cannot answer the query
+	ErrServerFailure = errors.New("the server failed to answer")
+	// ErrMismatchedAnswer when the answer does not belong to the query
+	ErrMismatchedAnswer = errors.New("the answer does not match the query")
+)
+
+// Resolver sends the queries of the snapshots to a single DNS server
+type Resolver struct {
+	// Server is the host and port of the DNS server
+	Server string
+	// Timeout of every query
+	Timeout time.Duration
+}
+
+// NewResolver creates a resolver for the server, an empty server uses the one of the system
+func NewResolver(server string, timeout time.Duration) *Resolver {
+	if server == "" {
+		server = SystemServer()
+	}
+
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+
+	return &Resolver{Server: server, Timeout: timeout}
+}
+
+// SystemServer returns the first name server of resolv.conf
+func SystemServer() string {
+	file, err := os.Open(ResolvConf)
+	if err != nil {
+		return FallbackServer
+	}
+
+	defer func() {
+		if err := file.Close(); err != nil {
+			logs.Log().Errorf("Error resolv.conf close %s ", err.Error())
+		}
+	}()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			return net.JoinHostPort(fields[1], "53")
+		}
+	}
+
+	return FallbackServer
+}
+
+// Snapshot resolves every record type of the domain. A failed query is recorded in Errors and
+// does not stop the others
+func (r *Resolver) Snapshot(ctx context.Context, domainName string) *models.DNSSnapshot {
+	snapshotID, err := uuid.NewV4()
+	if err != nil {
+		logs.Log().Errorf("cannot create the dns snapshot id %s", err.Error())
+	}
+
+	now := time.Now()
+
+	snapshot := &models.DNSSnapshot{
+		SnapshotID:   snapshotID.String(),
+		DomainName:   domainName,
+		Resolver:     r.Server,
+		Errors:       map[string]string{},
+		Changes:      []models.DNSChange{},
+		CreationDate: &now,
+		Records: models.DNSRecords{
+			A: []string{}, AAAA: []string{}, CNAME: []string{}, NS: []string{},
+			MX: []models.MXRecord{}, TXT: []string{}, CAA: []models.CAARecord{},
+		},
+	}
+
+	records := &snapshot.Records
+	fail := func(recordType string, err error) {
+		snapshot.Errors[recordType] = err.Error()
+	}
+
+	// the aliases are answered together with the addresses
+	answers, err := r.query(ctx, domainName, dnsmessage.TypeA)
+	if err != nil {
+		fail(models.DNSTypeA, err)
+		fail(models.DNSTypeCNAME, err)
+	} else {
+		records.CNAME = chain(domainName, answers)
+		records.A = addresses(answers)
+	}
+
+	answers, err = r.query(ctx, domainName, dnsmessage.TypeAAAA)
+	if err != nil {
+		fail(models.DNSTypeAAAA, err)
+	} else {
+		records.AAAA = addresses(answers)
+	}
+
+	answers, err = r.query(ctx, domainName, dnsmessage.TypeNS)
+	if err != nil {
+		fail(models.DNSTypeNS, err)
+	}
+
+	for _, answer := range answers {
+		if ns, ok := answer.Body.(*dnsmessage.NSResource); ok {
+			records.NS = append(records.NS, models.NormalizeDNSName(ns.NS.String()))
+		}
+	}
+
+	answers, err = r.query(ctx, domainName, dnsmessage.TypeMX)
+	if err != nil {
+		fail(models.DNSTypeMX, err)
+	}
+
+	for _, answer := range answers {
+		if mx, ok := answer.Body.(*dnsmessage.MXResource); ok {
+			records.MX = append(records.MX, models.MXRecord{Host: models.NormalizeDNSName(mx.MX.String()), Preference: mx.Pref})
+		}
+	}
+
+	answers, err = r.query(ctx, domainName, dnsmessage.TypeTXT)
+	if err != nil {
+		fail(models.DNSTypeTXT, err)
+	}
+
+	for _, answer := range answers {
+		if txt, ok := answer.Body.(*dnsmessage.TXTResource); ok {
+			records.TXT = append(records.TXT, strings.Join(txt.TXT, ""))
+		}
+	}
+
+	records.CAA, err = r.caa(ctx, domainName)
+	if err != nil {
+		fail(models.DNSTypeCAA, err)
+	}
+
+	sort.Strings(records.NS)
+	sort.Strings(records.TXT)
+	sort.Slice(records.MX, func(i, j int) bool {
+		if records.MX[i].Preference != records.MX[j].Preference {
+			return records.MX[i].Preference < records.MX[j].Preference
+		}
+
+		return records.MX[i].Host < records.MX[j].Host
+	})
+
+	return snapshot
+}
+
+// query returns the answers of the server, a domain without records of the type has no answers
+func (r *Resolver) query(ctx context.Context, domainName string, recordType dnsmessage.Type) ([]dnsmessage.Resource, error) {
+	msg, err := r.exchange(ctx, domainName, recordType)
+	if err != nil {
+		return nil, err
+	}
+
+	var parser dnsmessage.Parser
+
+	if _, err := parser.Start(msg); err != nil {
+		return nil, err
+	}
+
+	if err := parser.SkipAllQuestions(); err != nil {
+		return nil, err
+	}
+
+	answers := []dnsmessage.Resource{}
+
+	for {
+		header, err := parser.AnswerHeader()
+		if err == dnsmessage.ErrSectionDone {
+			return answers, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		// dnsmessage only parses the types it knows
+		if header.Type != dnsmessage.TypeA && header.Type != dnsmessage.TypeAAAA && header.Type != dnsmessage.TypeCNAME &&
+			header.Type != dnsmessage.TypeNS && header.Type != dnsmessage.TypeMX && header.Type != dnsmessage.TypeTXT {
+			if err := parser.SkipAnswer(); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		answer, err := parser.Answer()
+		if err != nil {
+			return nil, err
+		}
+
+		answers = append(answers, answer)
+	}
+}
+
+// exchange sends the query over UDP and repeats it over TCP when the answer was truncated
+func (r *Resolver) exchange(ctx context.Context, domainName string, recordType dnsmessage.Type) ([]byte, error) {
+	name, err := dnsmessage.NewName(strings.TrimSuffix(domainName, ".") + ".")
+	if err != nil {
+		return nil, err
+	}
+
+	id := uint16(rand.Intn(1 << 16)) //nolint:gosec
+
+	query := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: name, Type: recordType, Class: dnsmessage.ClassINET}},
+	}
+
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := r.send(ctx, "udp", packed)
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := check(msg, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if header.Truncated {
+		msg, err = r.send(ctx, "tcp", packed)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := check(msg, id); err != nil {
+			return nil, err
+		}
+	}
+
+	return msg, nil
+}
+
+// send writes the query to the server and reads its answer, TCP messages have a length prefix
+func (r *Resolver) send(ctx context.Context, network string, packed []byte) ([]byte, error) {
+	if r.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, r.Timeout)
+		defer cancel()
+	}
+
+	dialer := &net.Dialer{}
+
+	conn, err := dialer.DialContext(ctx, network, r.Server)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := conn.Close(); err != nil {
+			logs.Log().Errorf("Error dns connection close %s ", err.Error())
+		}
+	}()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return nil, err
+		}
+	}
+
+	if network == "udp" {
+		if _, err := conn.Write(packed); err != nil {
+			return nil, err
+		}
+
+		buf := make([]byte, maxMessageSize)
+
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+
+		return buf[:n], nil
+	}
+
+	prefixed := make([]byte, 2, len(packed)+2)
+	binary.BigEndian.PutUint16(prefixed, uint16(len(packed)))
+
+	if _, err := conn.Write(append(prefixed, packed...)); err != nil {
+		return nil, err
+	}
+
+	size := make([]byte, 2)
+	if _, err := io.ReadFull(conn, size); err != nil {
+		return nil, err
+	}
+
+	msg := make([]byte, binary.BigEndian.Uint16(size))
+	if _, err := io.ReadFull(conn, msg); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// check validates the header of the answer
+func check(msg []byte, id uint16) (dnsmessage.Header, error) {
+	var parser dnsmessage.Parser
+
+	header, err := parser.Start(msg)
+	if err != nil {
+		return header, err
+	}
+
+	if header.ID != id || !header.Response {
+		return header, ErrMismatchedAnswer
+	}
+
+	switch header.RCode {
+	case dnsmessage.RCodeSuccess:
+		return header, nil
+	case dnsmessage.RCodeNameError:
+		return header, ErrNotFound
+	}
+
+	return header, ErrServerFailure
+}
+
+// chain follows the aliases of the answers starting with the domain
+func chain(domainName string, answers []dnsmessage.Resource) []string {
+	aliases := make(map[string]string)
+
+	for _, answer := range answers {
+		if cname, ok := answer.Body.(*dnsmessage.CNAMEResource); ok {
+			aliases[models.NormalizeDNSName(answer.Header.Name.String())] = models.NormalizeDNSName(cname.CNAME.String())
+		}
+	}
+
+	items := []string{}
+	name := models.NormalizeDNSName(domainName)
+
+	for len(items) < MaxCNAMEChain {
+		target, ok := aliases[name]
+		if !ok {
+			break
+		}
+
+		items = append(items, target)
+		name = target
+	}
+
+	return items
+}
+
+// addresses returns the sorted IPv4 and IPv6 addresses of the answers
+func addresses(answers []dnsmessage.Resource) []string {
+	items := []string{}
+
+	for _, answer := range answers {
+		switch body := answer.Body.(type) {
+		case *dnsmessage.AResource:
+			items = append(items, net.IP(body.A[:]).String())
+		case *dnsmessage.AAAAResource:
+			items = append(items, net.IP(body.AAAA[:]).String())
+		}
+	}
+
+	sort.Strings(items)
+
+	return items
+}
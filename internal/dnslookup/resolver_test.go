@@ -0,0 +1,218 @@
+package dnslookup
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/models"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// standIn is a local DNS server that answers from a fixed zone over UDP and TCP. The TXT
+// records are too large for UDP, so they are only answered over TCP
+type standIn struct {
+	udp net.PacketConn
+	tcp net.Listener
+}
+
+func newStandIn(c *require.Assertions) *standIn {
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	c.NoError(err)
+
+	tcp, err := net.Listen("tcp", udp.LocalAddr().String())
+	c.NoError(err)
+
+	s := &standIn{udp: udp, tcp: tcp}
+
+	go func() {
+		buf := make([]byte, 512)
+
+		for {
+			n, addr, err := udp.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			_, _ = udp.WriteTo(answer(buf[:n], true), addr)
+		}
+	}()
+
+	go func() {
+		for {
+			conn, err := tcp.Accept()
+			if err != nil {
+				return
+			}
+
+			size := make([]byte, 2)
+			if _, err := io.ReadFull(conn, size); err == nil {
+				query := make([]byte, binary.BigEndian.Uint16(size))
+				if _, err := io.ReadFull(conn, query); err == nil {
+					msg := answer(query, false)
+					binary.BigEndian.PutUint16(size, uint16(len(msg)))
+					_, _ = conn.Write(append(size, msg...))
+				}
+			}
+
+			_ = conn.Close()
+		}
+	}()
+
+	return s
+}
+
+func (s *standIn) Close() {
+	_ = s.udp.Close()
+	_ = s.tcp.Close()
+}
+
+// answer builds the answer of the zone: www.example.com is an alias of example.com through
+// edge.cdn.net, missing.com does not exist and broken.com fails
+func answer(query []byte, udp bool) []byte {
+	var parser dnsmessage.Parser
+
+	header, err := parser.Start(query)
+	if err != nil {
+		return nil
+	}
+
+	question, err := parser.Question()
+	if err != nil {
+		return nil
+	}
+
+	name := strings.TrimSuffix(question.Name.String(), ".")
+	header.Response = true
+
+	switch name {
+	case "missing.com":
+		header.RCode = dnsmessage.RCodeNameError
+	case "broken.com":
+		header.RCode = dnsmessage.RCodeServerFailure
+	}
+
+	if question.Type == typeCAA {
+		return caaAnswer(header, question)
+	}
+
+	if question.Type == dnsmessage.TypeTXT && udp {
+		header.Truncated = true
+	}
+
+	builder := dnsmessage.NewBuilder(nil, header)
+	_ = builder.StartQuestions()
+	_ = builder.Question(question)
+	_ = builder.StartAnswers()
+
+	if header.RCode != dnsmessage.RCodeSuccess || header.Truncated {
+		msg, _ := builder.Finish()
+		return msg
+	}
+
+	resource := func(owner string) dnsmessage.ResourceHeader {
+		return dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(owner + "."), Class: dnsmessage.ClassINET, TTL: 60}
+	}
+
+	target := "example.com"
+
+	if name == "www.example.com" && (question.Type == dnsmessage.TypeA || question.Type == dnsmessage.TypeAAAA) {
+		_ = builder.CNAMEResource(resource("www.example.com"), dnsmessage.CNAMEResource{CNAME: dnsmessage.MustNewName("edge.cdn.net.")})
+		_ = builder.CNAMEResource(resource("edge.cdn.net"), dnsmessage.CNAMEResource{CNAME: dnsmessage.MustNewName("example.com.")})
+	}
+
+	switch question.Type {
+	case dnsmessage.TypeA:
+		_ = builder.AResource(resource(target), dnsmessage.AResource{A: [4]byte{192, 0, 2, 2}})
+		_ = builder.AResource(resource(target), dnsmessage.AResource{A: [4]byte{192, 0, 2, 1}})
+	case dnsmessage.TypeAAAA:
+		_ = builder.AAAAResource(resource(target), dnsmessage.AAAAResource{AAAA: [16]byte{0x20, 0x01, 0x0d, 0xb8, 15: 1}})
+	case dnsmessage.TypeNS:
+		_ = builder.NSResource(resource(name), dnsmessage.NSResource{NS: dnsmessage.MustNewName("NS2.Example.com.")})
+		_ = builder.NSResource(resource(name), dnsmessage.NSResource{NS: dnsmessage.MustNewName("ns1.example.com.")})
+	case dnsmessage.TypeMX:
+		_ = builder.MXResource(resource(name), dnsmessage.MXResource{Pref: 20, MX: dnsmessage.MustNewName("backup.example.com.")})
+		_ = builder.MXResource(resource(name), dnsmessage.MXResource{Pref: 10, MX: dnsmessage.MustNewName("mail.example.com.")})
+	case dnsmessage.TypeTXT:
+		_ = builder.TXTResource(resource(name), dnsmessage.TXTResource{TXT: []string{"v=spf1 ", "-all"}})
+	}
+
+	msg, _ := builder.Finish()
+
+	return msg
+}
+
+// caaAnswer builds by hand the answer of a CAA query, dnsmessage cannot
+func caaAnswer(header dnsmessage.Header, question dnsmessage.Question) []byte {
+	builder := dnsmessage.NewBuilder(nil, header)
+	_ = builder.StartQuestions()
+	_ = builder.Question(question)
+	msg, _ := builder.Finish()
+
+	if header.RCode != dnsmessage.RCodeSuccess {
+		return msg
+	}
+
+	records := [][2]string{{"issue", "letsencrypt.org"}, {"iodef", "mailto:security@example.com"}}
+	for _, record := range records {
+		data := append([]byte{0, byte(len(record[0]))}, record[0]+record[1]...)
+
+		// a pointer to the name of the question, type, class, ttl and length
+		msg = append(msg, 0xC0, 12, 1, 1, 0, 1, 0, 0, 0, 60, 0, byte(len(data)))
+		msg = append(msg, data...)
+	}
+
+	binary.BigEndian.PutUint16(msg[6:], uint16(len(records)))
+
+	return msg
+}
+
+func TestSnapshot(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	server := newStandIn(c)
+	defer server.Close()
+
+	resolver := NewResolver(server.udp.LocalAddr().String(), time.Second)
+
+	snapshot := resolver.Snapshot(context.Background(), "www.example.com")
+	c.Empty(snapshot.Errors)
+	c.Equal("www.example.com", snapshot.DomainName)
+	c.Equal(server.udp.LocalAddr().String(), snapshot.Resolver)
+
+	records := snapshot.Records
+	c.Equal([]string{"edge.cdn.net", "example.com"}, records.CNAME)
+	c.Equal([]string{"192.0.2.1", "192.0.2.2"}, records.A)
+	c.Equal([]string{"2001:db8::1"}, records.AAAA)
+	c.Equal([]string{"ns1.example.com", "ns2.example.com"}, records.NS)
+	c.Equal([]models.MXRecord{{Host: "mail.example.com", Preference: 10}, {Host: "backup.example.com", Preference: 20}}, records.MX)
+	c.Equal([]string{"v=spf1 -all"}, records.TXT, "the truncated answer is asked again over TCP")
+	c.Equal([]models.CAARecord{
+		{Tag: "issue", Value: "letsencrypt.org"},
+		{Tag: "iodef", Value: "mailto:security@example.com"},
+	}, records.CAA)
+	c.Equal([]string{"192.0.2.1", "192.0.2.2", "2001:db8::1"}, records.Addresses())
+
+	snapshot = resolver.Snapshot(context.Background(), "missing.com")
+	c.Len(snapshot.Errors, len(models.DNSTypes))
+	c.Equal(ErrNotFound.Error(), snapshot.Errors[models.DNSTypeA])
+
+	snapshot = resolver.Snapshot(context.Background(), "broken.com")
+	c.Equal(ErrServerFailure.Error(), snapshot.Errors[models.DNSTypeCAA])
+	c.Empty(snapshot.Records.A)
+}
+
+func TestNewResolver(t *testing.T) {
+	c := require.New(t)
+
+	c.Equal("10.0.0.1:53", NewResolver("10.0.0.1", time.Second).Server)
+	c.Equal("[2001:db8::1]:5353", NewResolver("[2001:db8::1]:5353", time.Second).Server)
+	c.NotEmpty(NewResolver("", time.Second).Server)
+}
@@ -0,0 +1,67 @@
+package export
+
+import (
+	"time"
+
+	"github.com/other_project/crockroach/internal/apperr"
+)
+
+const dateLayout = "2006-01-02"
+
+var (
+	// ErrInvalidDate when from or to cannot be parsed
+	ErrInvalidDate = apperr.New(apperr.Invalid, "invalid_date", "dates must use RFC 3339 or YYYY-MM-DD")
+	// ErrInvalidRange when from is after to
+	ErrInvalidRange = apperr.New(apperr.Invalid, "invalid_range", "from must be before to")
+)
+
+// ParseRange parses the from and to values of the export, both are optional.
+// An empty from exports since the beginning and an empty to exports until now. The end is
+// exclusive, so a date without time in to includes that whole day
+func ParseRange(from, to string, now time.Time) (time.Time, time.Time, error) {
+	start := time.Unix(0, 0).UTC()
+	end := now
+
+	var err error
+
+	if from != "" {
+		start, _, err = parseDate(from)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+
+	if to != "" {
+		var dateOnly bool
+
+		end, dateOnly, err = parseDate(to)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+
+		if dateOnly {
+			end = end.AddDate(0, 0, 1)
+		}
+	}
+
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, ErrInvalidRange
+	}
+
+	return start, end, nil
+}
+
+// parseDate accepts a timestamp or a date, dateOnly reports that the value did not have time
+func parseDate(value string) (t time.Time, dateOnly bool, err error) {
+	t, err = time.Parse(time.RFC3339, value)
+	if err == nil {
+		return t, false, nil
+	}
+
+	t, err = time.Parse(dateLayout, value)
+	if err != nil {
+		return time.Time{}, false, ErrInvalidDate
+	}
+
+	return t, true, nil
+}
@@ -0,0 +1,235 @@
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/other_project/crockroach/internal/apperr"
+	"github.com/other_project/crockroach/internal/storage"
+)
+
+const (
+	// FormatCSV writes a row per server of every analysis
+	FormatCSV = "csv"
+	// FormatNDJSON writes a JSON object per analysis with its servers
+	FormatNDJSON = "ndjson"
+)
+
+var (
+	// ErrUnknownFormat when the export format is not supported
+	ErrUnknownFormat = apperr.New(apperr.Invalid, "unknown_format", "unknown export format")
+
+	// csvHeader are the columns of the CSV export
+	csvHeader = []string{
+		"domain_id", "domain_name", "analyzed_at", "ssl_grade", "previous_ssl_grade", "servers_changed", "is_down", "title", "logo",
+		"server_address", "server_ssl_grade", "server_country", "server_owner",
+	}
+)
+
+// Writer receives the rows of the export
+type Writer interface {
+	// Write adds a row to the export
+	Write(row *storage.ExportRow) error
+	// Close writes the pending data
+	Close() error
+}
+
+// NewWriter creates the writer of the format
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w), nil
+	case FormatNDJSON:
+		return newNDJSONWriter(w), nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+}
+
+// ContentType returns the media type of the format
+func ContentType(format string) string {
+	if format == FormatCSV {
+		return "text/csv; charset=utf-8"
+	}
+
+	return "application/x-ndjson"
+}
+
+// csvWriter writes a line per server
+type csvWriter struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+// Write adds the row as a CSV record
+func (c *csvWriter) Write(row *storage.ExportRow) error {
+	err := c.header()
+	if err != nil {
+		return err
+	}
+
+	return c.w.Write([]string{
+		csvCell(row.DomainID),
+		csvCell(row.DomainName),
+		row.UpdateDate.UTC().Format(time.RFC3339),
+		csvCell(row.SSLGrade),
+		csvCell(row.PreviousSSLGrade),
+		strconv.FormatBool(row.ServerChanged),
+		strconv.FormatBool(row.IsDown),
+		csvCell(row.Title),
+		csvCell(row.Logo),
+		csvCell(row.Address),
+		csvCell(row.ServerSSLGrade),
+		csvCell(row.Country),
+		csvCell(row.Owner),
+	})
+}
+
+// csvCell escapes the text that a spreadsheet would run as a formula, the page titles and the
+// owners come from the analyzed sites
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+
+	return value
+}
+
+// Close writes the header of an empty export and flushes the records
+func (c *csvWriter) Close() error {
+	err := c.header()
+	if err != nil {
+		return err
+	}
+
+	c.w.Flush()
+
+	return c.w.Error()
+}
+
+// header writes the column names once
+func (c *csvWriter) header() error {
+	if c.wroteHeader {
+		return nil
+	}
+
+	c.wroteHeader = true
+
+	return c.w.Write(csvHeader)
+}
+
+// ndjsonServer is a server of an analysis
+type ndjsonServer struct {
+	Address  string `json:"address"`
+	SSLGrade string `json:"ssl_grade"`
+	Country  string `json:"country"`
+	Owner    string `json:"owner"`
+}
+
+// ndjsonDomain is an analysis with its servers
+type ndjsonDomain struct {
+	DomainID         string          `json:"domain_id"`
+	DomainName       string          `json:"domain_name"`
+	AnalyzedAt       time.Time       `json:"analyzed_at"`
+	SSLGrade         string          `json:"ssl_grade"`
+	PreviousSSLGrade string          `json:"previous_ssl_grade"`
+	ServerChanged    bool            `json:"servers_changed"`
+	IsDown           bool            `json:"is_down"`
+	Title            string          `json:"title"`
+	Logo             string          `json:"logo"`
+	Servers          []*ndjsonServer `json:"servers"`
+}
+
+// ndjsonWriter groups the consecutive rows of an analysis in a single line
+type ndjsonWriter struct {
+	encoder *json.Encoder
+	current *ndjsonDomain
+}
+
+func newNDJSONWriter(w io.Writer) *ndjsonWriter {
+	return &ndjsonWriter{encoder: json.NewEncoder(w)}
+}
+
+// Write adds the server to the current analysis or starts a new one
+func (n *ndjsonWriter) Write(row *storage.ExportRow) error {
+	if n.current == nil || n.current.DomainID != row.DomainID {
+		err := n.flush()
+		if err != nil {
+			return err
+		}
+
+		n.current = &ndjsonDomain{
+			DomainID:         row.DomainID,
+			DomainName:       row.DomainName,
+			AnalyzedAt:       row.UpdateDate.UTC(),
+			SSLGrade:         row.SSLGrade,
+			PreviousSSLGrade: row.PreviousSSLGrade,
+			ServerChanged:    row.ServerChanged,
+			IsDown:           row.IsDown,
+			Title:            row.Title,
+			Logo:             row.Logo,
+			Servers:          []*ndjsonServer{},
+		}
+	}
+
+	if row.ServerID != "" {
+		n.current.Servers = append(n.current.Servers, &ndjsonServer{
+			Address:  row.Address,
+			SSLGrade: row.ServerSSLGrade,
+			Country:  row.Country,
+			Owner:    row.Owner,
+		})
+	}
+
+	return nil
+}
+
+// Close writes the last analysis
+func (n *ndjsonWriter) Close() error {
+	return n.flush()
+}
+
+// flush writes the current analysis as a line
+func (n *ndjsonWriter) flush() error {
+	if n.current == nil {
+		return nil
+	}
+
+	current := n.current
+	n.current = nil
+
+	return n.encoder.Encode(current)
+}
+
+// Source streams the rows of the analyses updated between from and to
+type Source func(ctx context.Context, from, to time.Time, fn func(*storage.ExportRow) error) error
+
+// Export writes the analyses of the source in the format and returns the number of rows
+func Export(ctx context.Context, source Source, format string, from, to time.Time, w io.Writer) (int, error) {
+	writer, err := NewWriter(format, w)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+
+	err = source(ctx, from, to, func(row *storage.ExportRow) error {
+		count++
+		return writer.Write(row)
+	})
+	if err != nil {
+		return count, err
+	}
+
+	return count, writer.Close()
+}
@@ -0,0 +1,158 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/other_project/crockroach/internal/storage"
+	"github.com/stretchr/testify/require"
+)
+
+// rows returns two analyses, the second one without servers
+func rows() []*storage.ExportRow {
+	analyzed := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	return []*storage.ExportRow{
+		{DomainID: "d1", DomainName: "example.com", SSLGrade: "A", UpdateDate: analyzed, ServerID: "s1", Address: "10.0.0.1", ServerSSLGrade: "A", Country: "US", Owner: "EDGECAST"},
+		{DomainID: "d1", DomainName: "example.com", SSLGrade: "A", UpdateDate: analyzed, ServerID: "s2", Address: "10.0.0.2", ServerSSLGrade: "A+", Country: "US", Owner: "EDGECAST, \"Inc\""},
+		{DomainID: "d2", DomainName: "down.com", IsDown: true, UpdateDate: analyzed.Add(time.Hour)},
+	}
+}
+
+// fakeSource streams the rows in memory
+func fakeSource(list []*storage.ExportRow, failAfter int) Source {
+	return func(ctx context.Context, from, to time.Time, fn func(*storage.ExportRow) error) error {
+		for i, row := range list {
+			if i == failAfter {
+				return errors.New("connection lost")
+			}
+
+			err := fn(row)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	c := require.New(t)
+
+	out := new(bytes.Buffer)
+
+	count, err := Export(context.Background(), fakeSource(rows(), -1), FormatCSV, time.Time{}, time.Now(), out)
+	c.NoError(err)
+	c.Equal(3, count)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	c.Len(lines, 4)
+	c.Equal(strings.Join(csvHeader, ","), lines[0])
+	c.Equal(`d1,example.com,2026-10-01T12:00:00Z,A,,false,false,,,10.0.0.2,A+,US,"EDGECAST, ""Inc"""`, lines[2])
+	c.Equal(`d2,down.com,2026-10-01T13:00:00Z,,,false,true,,,,,,`, lines[3])
+}
+
+func TestExportCSVFormulas(t *testing.T) {
+	c := require.New(t)
+
+	analyzed := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	list := []*storage.ExportRow{
+		{DomainID: "d1", DomainName: "evil.com", UpdateDate: analyzed, Title: `=HYPERLINK("http://evil.com","x")`, ServerID: "s1", Owner: "+1-555"},
+		{DomainID: "d1", DomainName: "evil.com", UpdateDate: analyzed, Title: "@SUM(A1)", ServerID: "s2", Owner: "-2+3"},
+		{DomainID: "d2", DomainName: "tab.com", UpdateDate: analyzed, Title: "\t=1", Owner: "\r=1"},
+	}
+
+	out := new(bytes.Buffer)
+
+	_, err := Export(context.Background(), fakeSource(list, -1), FormatCSV, time.Time{}, time.Now(), out)
+	c.NoError(err)
+
+	records, err := csv.NewReader(out).ReadAll()
+	c.NoError(err)
+	c.Len(records, 4)
+
+	c.Equal(`'=HYPERLINK("http://evil.com","x")`, records[1][7])
+	c.Equal("'+1-555", records[1][12])
+	c.Equal("'@SUM(A1)", records[2][7])
+	c.Equal("'-2+3", records[2][12])
+	c.Equal("'\t=1", records[3][7])
+	c.Equal("'\r=1", records[3][12])
+
+	// the other values are written as they are
+	c.Equal("evil.com", records[1][1])
+}
+
+func TestExportNDJSON(t *testing.T) {
+	c := require.New(t)
+
+	out := new(bytes.Buffer)
+
+	count, err := Export(context.Background(), fakeSource(rows(), -1), FormatNDJSON, time.Time{}, time.Now(), out)
+	c.NoError(err)
+	c.Equal(3, count)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	c.Len(lines, 2)
+
+	first := new(ndjsonDomain)
+	c.NoError(json.Unmarshal([]byte(lines[0]), first))
+	c.Equal("example.com", first.DomainName)
+	c.Len(first.Servers, 2)
+
+	second := new(ndjsonDomain)
+	c.NoError(json.Unmarshal([]byte(lines[1]), second))
+	c.True(second.IsDown)
+	c.Empty(second.Servers)
+}
+
+func TestExportFailure(t *testing.T) {
+	c := require.New(t)
+
+	out := new(bytes.Buffer)
+
+	count, err := Export(context.Background(), fakeSource(rows(), 1), FormatNDJSON, time.Time{}, time.Now(), out)
+	c.Error(err)
+	c.Equal(1, count)
+
+	_, err = Export(context.Background(), fakeSource(rows(), -1), "xml", time.Time{}, time.Now(), out)
+	c.True(errors.Is(err, ErrUnknownFormat))
+}
+
+func TestParseRange(t *testing.T) {
+	c := require.New(t)
+
+	now := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+
+	from, to, err := ParseRange("", "", now)
+	c.NoError(err)
+	c.Equal(int64(0), from.Unix())
+	c.Equal(now, to)
+
+	from, to, err = ParseRange("2026-10-01", "2026-10-02T10:00:00Z", now)
+	c.NoError(err)
+	c.Equal(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), from)
+	c.Equal(time.Date(2026, 10, 2, 10, 0, 0, 0, time.UTC), to)
+
+	// a date in to includes that whole day
+	from, to, err = ParseRange("2026-10-19", "2026-10-19", now)
+	c.NoError(err)
+	c.Equal(time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), from)
+	c.Equal(time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC), to)
+
+	_, to, err = ParseRange("", "2026-10-19", now)
+	c.NoError(err)
+	c.Equal(time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC), to)
+
+	_, _, err = ParseRange("yesterday", "", now)
+	c.Equal(ErrInvalidDate, err)
+
+	_, _, err = ParseRange("2026-10-02", "2026-10-01", now)
+	c.Equal(ErrInvalidRange, err)
+}
@@ -0,0 +1,117 @@
+package favicon
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/other_project/crockroach/internal/apperr"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/models"
+)
+
+const (
+	// svgType is the only accepted type that is not sniffed, the content must have an svg element
+	svgType = "image/svg+xml"
+)
+
+var (
+	// ErrLogoTooLarge when the icon is larger than the limit
+	ErrLogoTooLarge = apperr.New(apperr.Upstream, "logo_too_large", "the logo is larger than the limit")
+	// ErrLogoContentType when the icon is not an image of an accepted type
+	ErrLogoContentType = apperr.New(apperr.Upstream, "logo_content_type", "the logo is not a png, ico, gif, jpeg, webp or svg image")
+	// ErrLogoUnavailable when the icon cannot be downloaded
+	ErrLogoUnavailable = apperr.New(apperr.Upstream, "logo_unavailable", "the logo cannot be downloaded")
+
+	// imageTypes are the content types accepted for a logo
+	imageTypes = []string{"image/png", "image/x-icon", "image/vnd.microsoft.icon", "image/gif", "image/jpeg", "image/webp", svgType}
+)
+
+// Download fetches the icon and checks that it is an image of an accepted type with up to
+// maxSize bytes. An image type must be declared and agree with the content, the sniffed type
+// is kept. A missing or generic type uses the sniffed one, except for svg that must be declared
+func Download(ctx context.Context, client *http.Client, iconURL string, maxSize int64) (*models.LogoImage, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, iconURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrLogoUnavailable, err.Error())
+	}
+
+	resp, err := client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrLogoUnavailable, err.Error())
+	}
+
+	defer func() {
+		erro := resp.Body.Close()
+		if erro != nil {
+			logs.Log().Errorf("Error response body close %s ", erro.Error())
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s answered %d", ErrLogoUnavailable, iconURL, resp.StatusCode)
+	}
+
+	if resp.ContentLength > maxSize {
+		return nil, fmt.Errorf("%w: %d bytes", ErrLogoTooLarge, resp.ContentLength)
+	}
+
+	content, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrLogoUnavailable, err.Error())
+	}
+
+	if int64(len(content)) > maxSize {
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrLogoTooLarge, maxSize)
+	}
+
+	contentType, err := imageType(resp.Header.Get("Content-Type"), content)
+	if err != nil {
+		return nil, err
+	}
+
+	return models.NewLogoImage(resp.Request.URL.String(), contentType, content), nil
+}
+
+// imageType returns the content type of the logo, checking the declared type against the content
+func imageType(declared string, content []byte) (string, error) {
+	mediaType, _, err := mime.ParseMediaType(declared)
+	if err != nil {
+		mediaType = ""
+	}
+
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(content))
+
+	switch {
+	case len(content) == 0:
+		return "", fmt.Errorf("%w: empty content", ErrLogoContentType)
+	case mediaType == svgType:
+		if !bytes.Contains(bytes.ToLower(content), []byte("<svg")) {
+			return "", fmt.Errorf("%w: the content is not svg", ErrLogoContentType)
+		}
+
+		return svgType, nil
+	case mediaType == "" || mediaType == "application/octet-stream" || mediaType == "text/plain":
+		mediaType = sniffed
+	case !strings.HasPrefix(mediaType, "image/"):
+		return "", fmt.Errorf("%w: %s", ErrLogoContentType, mediaType)
+	case !strings.HasPrefix(sniffed, "image/"):
+		return "", fmt.Errorf("%w: declared %s but the content is %s", ErrLogoContentType, mediaType, sniffed)
+	default:
+		// the content decides between the image types, servers often mislabel icons
+		mediaType = sniffed
+	}
+
+	for _, accepted := range imageTypes {
+		if mediaType == accepted && mediaType != svgType {
+			return mediaType, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrLogoContentType, mediaType)
+}
@@ -0,0 +1,94 @@
+package favicon
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// pngContent starts with the signature of a png image
+	pngContent = "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"
+	// icoContent starts with the header of an ico image
+	icoContent = "\x00\x00\x01\x00\x01\x00\x10\x10"
+)
+
+func TestDownload(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	files := map[string]struct {
+		contentType string
+		content     string
+	}{
+		"/icon.png":      {"image/png", pngContent},
+		"/favicon.ico":   {"image/vnd.microsoft.icon", icoContent},
+		"/generic.ico":   {"application/octet-stream", icoContent},
+		"/icon.svg":      {"image/svg+xml; charset=utf-8", `<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"></svg>`},
+		"/fake.svg":      {"image/svg+xml", "<html><body>not found</body></html>"},
+		"/page.png":      {"image/png", "<html><body>not found</body></html>"},
+		"/page.html":     {"text/html", "<html><body>not found</body></html>"},
+		"/large.png":     {"image/png", pngContent + strings.Repeat("\x00", 2048)},
+		"/untyped.svg":   {"", `<svg xmlns="http://www.w3.org/2000/svg"></svg>`},
+		"/png-as-html.x": {"text/html", pngContent},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header()["Content-Type"] = []string{file.contentType}
+		_, _ = w.Write([]byte(file.content))
+	}))
+	defer server.Close()
+
+	download := func(path string) (string, error) {
+		logo, err := Download(context.Background(), server.Client(), server.URL+path, 1024)
+		if err != nil {
+			return "", err
+		}
+
+		c.Equal(server.URL+path, logo.SourceURL)
+		c.Len(logo.Hash, 64)
+
+		return logo.ContentType, nil
+	}
+
+	cases := []struct {
+		path        string
+		contentType string
+		err         error
+	}{
+		{"/icon.png", "image/png", nil},
+		{"/favicon.ico", "image/x-icon", nil},
+		{"/generic.ico", "image/x-icon", nil},
+		{"/icon.svg", "image/svg+xml", nil},
+		{"/fake.svg", "", ErrLogoContentType},
+		{"/page.png", "", ErrLogoContentType},
+		{"/page.html", "", ErrLogoContentType},
+		{"/untyped.svg", "", ErrLogoContentType},
+		{"/png-as-html.x", "", ErrLogoContentType},
+		{"/large.png", "", ErrLogoTooLarge},
+		{"/missing.png", "", ErrLogoUnavailable},
+	}
+
+	for _, tc := range cases {
+		contentType, err := download(tc.path)
+		c.Equal(tc.contentType, contentType, tc.path)
+
+		if tc.err == nil {
+			c.NoError(err, tc.path)
+		} else {
+			c.True(errors.Is(err, tc.err), "%s: %v", tc.path, err)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package favicon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/other_project/crockroach/internal/logs"
+)
+
+const (
+	// RelIcon is declared with rel="icon" or rel="shortcut icon"
+	RelIcon = "icon"
+	// RelAppleTouchIcon is declared with rel="apple-touch-icon" or its precomposed variant
+	RelAppleTouchIcon = "apple-touch-icon"
+	// RelManifest is an icon of the web app manifest
+	RelManifest = "manifest"
+	// RelFallback is /favicon.ico when the page does not declare any icon
+	RelFallback = "fallback"
+
+	// ScalableSize is the size of the icons declared with sizes="any", usually SVG
+	ScalableSize = 1 << 16
+	// defaultIconSize is assumed for a rel="icon" without sizes, the size of a classic favicon
+	defaultIconSize = 16
+	// defaultTouchIconSize is assumed for an apple-touch-icon without sizes, the size used by iOS
+	defaultTouchIconSize = 180
+	// maxManifestSize is the longest manifest that is read
+	maxManifestSize = 256 << 10
+)
+
+// Icon is an icon declared by a page
+type Icon struct {
+	URL  string
+	Rel  string
+	Type string
+	// Size is the largest side declared in sizes, or the assumed size when it is missing
+	Size int
+}
+
+// manifest is the part of a web app manifest that lists the icons
+type manifest struct {
+	Icons []struct {
+		Src     string `json:"src"`
+		Sizes   string `json:"sizes"`
+		Type    string `json:"type"`
+		Purpose string `json:"purpose"`
+	} `json:"icons"`
+}
+
+// Find returns the best icon of the page, pageURL is the URL of the final response after the
+// redirects. The icons of the link tags and of the web app manifest are candidates, the largest
+// one wins and the first declared wins a tie. Without candidates it returns /favicon.ico
+func Find(ctx context.Context, client *http.Client, doc *goquery.Document, pageURL *url.URL) *Icon {
+	base := Base(doc, pageURL)
+	icons, manifestURL := Links(doc, base)
+
+	if manifestURL != nil {
+		manifestIcons, err := Manifest(ctx, client, manifestURL)
+		if err != nil {
+			logs.Log().Errorf("cannot read the manifest %s: %s", manifestURL, err.Error())
+		}
+
+		icons = append(icons, manifestIcons...)
+	}
+
+	best := Best(icons)
+	if best != nil {
+		return best
+	}
+
+	fallback := pageURL.ResolveReference(&url.URL{Path: "/favicon.ico"})
+
+	return &Icon{URL: fallback.String(), Rel: RelFallback, Size: defaultIconSize}
+}
+
+// Base returns the URL used to resolve the relative links of the page, the <base href> when it
+// is valid and the page URL otherwise
+func Base(doc *goquery.Document, pageURL *url.URL) *url.URL {
+	href, ok := doc.Find("base[href]").First().Attr("href")
+	if !ok {
+		return pageURL
+	}
+
+	base, err := pageURL.Parse(strings.TrimSpace(href))
+	if err != nil || !webURL(base) {
+		return pageURL
+	}
+
+	return base
+}
+
+// Links returns the icons of the link tags resolved against base and the URL of the manifest,
+// nil when the page does not have one
+func Links(doc *goquery.Document, base *url.URL) ([]*Icon, *url.URL) {
+	icons := []*Icon{}
+
+	var manifestURL *url.URL
+
+	doc.Find("link[rel][href]").Each(func(i int, s *goquery.Selection) {
+		rel := linkRel(s.AttrOr("rel", ""))
+		if rel == "" {
+			return
+		}
+
+		target, err := base.Parse(strings.TrimSpace(s.AttrOr("href", "")))
+		if err != nil || !webURL(target) {
+			return
+		}
+
+		if rel == RelManifest {
+			if manifestURL == nil {
+				manifestURL = target
+			}
+
+			return
+		}
+
+		icons = append(icons, &Icon{
+			URL:  target.String(),
+			Rel:  rel,
+			Type: s.AttrOr("type", ""),
+			Size: iconSize(s.AttrOr("sizes", ""), rel),
+		})
+	})
+
+	return icons, manifestURL
+}
+
+// Manifest downloads the web app manifest and returns its icons resolved against its URL.
+// Icons whose purpose is only maskable or monochrome are skipped
+func Manifest(ctx context.Context, client *http.Client, manifestURL *url.URL) ([]*Icon, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, manifestURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		erro := resp.Body.Close()
+		if erro != nil {
+			logs.Log().Errorf("Error response body close %s ", erro.Error())
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("manifest %s answered %d", manifestURL, resp.StatusCode)
+	}
+
+	var content manifest
+
+	err = json.NewDecoder(io.LimitReader(resp.Body, maxManifestSize)).Decode(&content)
+	if err != nil {
+		return nil, err
+	}
+
+	icons := []*Icon{}
+
+	for _, item := range content.Icons {
+		if item.Purpose != "" && !containsToken(item.Purpose, "any") {
+			continue
+		}
+
+		target, err := resp.Request.URL.Parse(strings.TrimSpace(item.Src))
+		if err != nil || item.Src == "" || !webURL(target) {
+			continue
+		}
+
+		icons = append(icons, &Icon{
+			URL:  target.String(),
+			Rel:  RelManifest,
+			Type: item.Type,
+			Size: iconSize(item.Sizes, RelManifest),
+		})
+	}
+
+	return icons, nil
+}
+
+// Best returns the largest icon, the first one wins a tie. It returns nil without icons
+func Best(icons []*Icon) *Icon {
+	var best *Icon
+
+	for _, icon := range icons {
+		if best == nil || icon.Size > best.Size {
+			best = icon
+		}
+	}
+
+	return best
+}
+
+// linkRel returns the kind of icon of the rel attribute, empty when it is not an icon
+func linkRel(rel string) string {
+	switch {
+	case containsToken(rel, "icon"):
+		return RelIcon
+	case containsToken(rel, "apple-touch-icon"), containsToken(rel, "apple-touch-icon-precomposed"):
+		return RelAppleTouchIcon
+	case containsToken(rel, "manifest"):
+		return RelManifest
+	}
+
+	return ""
+}
+
+// iconSize returns the largest side of the sizes attribute, like "16x16 32x32" or "any".
+// Without valid sizes it assumes the usual size of the rel
+func iconSize(sizes, rel string) int {
+	largest := 0
+
+	for _, size := range strings.Fields(strings.ToLower(sizes)) {
+		if size == "any" {
+			return ScalableSize
+		}
+
+		parts := strings.Split(size, "x")
+		if len(parts) != 2 {
+			continue
+		}
+
+		width, errWidth := strconv.Atoi(parts[0])
+		height, errHeight := strconv.Atoi(parts[1])
+
+		if errWidth != nil || errHeight != nil || width <= 0 || height <= 0 {
+			continue
+		}
+
+		if width > largest {
+			largest = width
+		}
+
+		if height > largest {
+			largest = height
+		}
+	}
+
+	switch {
+	case largest > 0:
+		return largest
+	case rel == RelAppleTouchIcon:
+		return defaultTouchIconSize
+	case rel == RelIcon:
+		return defaultIconSize
+	}
+
+	return 0
+}
+
+// containsToken reports if the space separated list contains the token, ignoring the case
+func containsToken(list, token string) bool {
+	for _, item := range strings.Fields(list) {
+		if strings.EqualFold(item, token) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// webURL reports if the URL can be downloaded over http or https
+func webURL(target *url.URL) bool {
+	return (target.Scheme == "http" || target.Scheme == "https") && target.Host != ""
+}
@@ -0,0 +1,83 @@
+package favicon
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/stretchr/testify/require"
+)
+
+// find parses the page served at pageURL and returns its best icon
+func find(c *require.Assertions, client *http.Client, pageURL, page string) *Icon {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	c.NoError(err)
+
+	target, err := url.Parse(pageURL)
+	c.NoError(err)
+
+	return Find(context.Background(), client, doc, target)
+}
+
+func TestFind(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/static/site.webmanifest", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"icons": [
+			{"src": "android-192.png", "sizes": "192x192", "type": "image/png"},
+			{"src": "maskable-512.png", "sizes": "512x512", "purpose": "maskable"},
+			{"src": "/android-256.png", "sizes": "256x256", "purpose": "any maskable"}
+		]}`))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := server.Client()
+	page := server.URL + "/blog/post"
+
+	cases := []struct {
+		name string
+		html string
+		url  string
+		rel  string
+	}{
+		{"nothing declared", `<html><head><title>Example</title></head></html>`, server.URL + "/favicon.ico", RelFallback},
+		{"relative path", `<link rel="icon" href="img/icon.png">`, server.URL + "/blog/img/icon.png", RelIcon},
+		{"shortcut icon", `<link rel="Shortcut Icon" href="/favicon.png">`, server.URL + "/favicon.png", RelIcon},
+		{"base href", `<base href="https://cdn.example.com/assets/"><link rel="icon" href="icon.png">`, "https://cdn.example.com/assets/icon.png", RelIcon},
+		{"protocol relative", `<link rel="icon" href="//cdn.example.com/icon.png">`, "http://cdn.example.com/icon.png", RelIcon},
+		{"largest size wins", `<link rel="icon" href="/16.png" sizes="16x16"><link rel="icon" href="/32.png" sizes="16x16 32x32"><link rel="icon" href="/24.png" sizes="24x24">`, server.URL + "/32.png", RelIcon},
+		{"apple touch icon without sizes", `<link rel="icon" href="/favicon.ico"><link rel="apple-touch-icon" href="/apple.png">`, server.URL + "/apple.png", RelAppleTouchIcon},
+		{"scalable icon", `<link rel="apple-touch-icon" href="/apple.png" sizes="180x180"><link rel="icon" href="/icon.svg" sizes="any" type="image/svg+xml">`, server.URL + "/icon.svg", RelIcon},
+		{"manifest", `<link rel="icon" href="/favicon.ico" sizes="48x48"><link rel="manifest" href="/static/site.webmanifest">`, server.URL + "/android-256.png", RelManifest},
+		{"missing manifest", `<link rel="manifest" href="/missing.webmanifest">`, server.URL + "/favicon.ico", RelFallback},
+		{"unsupported scheme", `<link rel="icon" href="data:image/png;base64,AAAA">`, server.URL + "/favicon.ico", RelFallback},
+		{"mask icon", `<link rel="mask-icon" href="/mask.svg">`, server.URL + "/favicon.ico", RelFallback},
+	}
+
+	for _, tc := range cases {
+		icon := find(c, client, page, tc.html)
+		c.Equal(tc.url, icon.URL, tc.name)
+		c.Equal(tc.rel, icon.Rel, tc.name)
+	}
+}
+
+func TestIconSize(t *testing.T) {
+	c := require.New(t)
+
+	c.Equal(32, iconSize("16x16 32x32", RelIcon))
+	c.Equal(64, iconSize("64X32", RelIcon))
+	c.Equal(ScalableSize, iconSize("any", RelIcon))
+	c.Equal(16, iconSize("", RelIcon))
+	c.Equal(16, iconSize("big", RelIcon))
+	c.Equal(180, iconSize("", RelAppleTouchIcon))
+	c.Equal(0, iconSize("", RelManifest))
+}
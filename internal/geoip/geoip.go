@@ -0,0 +1,170 @@
+package geoip
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+	"github.com/other_project/crockroach/internal/apperr"
+)
+
+// ErrInvalidDatabase when the file is not a MaxMind DB or it is corrupted
+var ErrInvalidDatabase = apperr.New(apperr.Internal, "invalid_geoip_database", "the file is not a valid MaxMind database")
+
+// Location is the geolocation and the autonomous system of an address
+type Location struct {
+	Country string
+	ASN     uint64
+	ASNOrg  string
+}
+
+// Databases looks up the addresses in the country, city and ASN databases that are configured.
+// Every lookup checks the files and reloads the ones that changed on disk, a file that cannot
+// be read or parsed keeps the previous version
+type Databases struct {
+	files []*file
+}
+
+// record holds the fields read from any of the databases
+type record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	RegisteredCountry struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"registered_country"`
+	ASN    uint64 `maxminddb:"autonomous_system_number"`
+	ASNOrg string `maxminddb:"autonomous_system_organization"`
+}
+
+// file is a database that is reloaded when its size or modification time changes
+type file struct {
+	path string
+
+	mu      sync.Mutex
+	reader  *maxminddb.Reader
+	size    int64
+	modTime time.Time
+}
+
+// New creates the databases of the paths, an empty path skips the database. Any database can
+// provide any field, the first one that has it wins
+func New(countryPath, cityPath, asnPath string) *Databases {
+	databases := &Databases{}
+
+	for _, path := range []string{countryPath, cityPath, asnPath} {
+		if path != "" {
+			databases.files = append(databases.files, &file{path: path})
+		}
+	}
+
+	return databases
+}
+
+// Enabled reports if there is at least one database configured
+func (d *Databases) Enabled() bool {
+	return d != nil && len(d.files) > 0
+}
+
+// Lookup returns the location of the address. The error reports the first database that could
+// not be loaded, the location still has the fields of the others
+func (d *Databases) Lookup(address string) (Location, error) {
+	location := Location{}
+
+	ip := net.ParseIP(address)
+	if ip == nil || !d.Enabled() {
+		return location, nil
+	}
+
+	var firstErr error
+
+	for _, database := range d.files {
+		reader, err := database.load()
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+
+		if reader == nil {
+			continue
+		}
+
+		// an IPv4 database does not have IPv6 networks
+		if ip.To4() == nil && reader.Metadata.IPVersion == 4 {
+			continue
+		}
+
+		var fields record
+
+		err = reader.Lookup(ip, &fields)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = invalidDatabase(database.path, err)
+			}
+
+			continue
+		}
+
+		location.fill(fields)
+	}
+
+	return location, firstErr
+}
+
+// fill sets the fields of the location that are empty with the values of the record
+func (l *Location) fill(fields record) {
+	if l.Country == "" {
+		l.Country = fields.Country.ISOCode
+	}
+
+	if l.Country == "" {
+		l.Country = fields.RegisteredCountry.ISOCode
+	}
+
+	if l.ASN == 0 {
+		l.ASN = fields.ASN
+	}
+
+	if l.ASNOrg == "" {
+		l.ASNOrg = fields.ASNOrg
+	}
+}
+
+// load returns the reader of the file, reading it again when it changed since the last load.
+// The previous reader is returned with the error when the new version cannot be used
+func (f *file) load() (*maxminddb.Reader, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return f.reader, err
+	}
+
+	if f.reader != nil && info.Size() == f.size && info.ModTime().Equal(f.modTime) {
+		return f.reader, nil
+	}
+
+	content, err := ioutil.ReadFile(f.path) //nolint:gosec
+	if err != nil {
+		return f.reader, err
+	}
+
+	// the reader keeps the content in memory, no file stays open between reloads
+	reader, err := maxminddb.FromBytes(content)
+	if err != nil {
+		return f.reader, invalidDatabase(f.path, err)
+	}
+
+	f.reader, f.size, f.modTime = reader, info.Size(), info.ModTime()
+
+	return reader, nil
+}
+
+// invalidDatabase reports the error of the library as an invalid database of the path
+func invalidDatabase(path string, err error) error {
+	return fmt.Errorf("%s: %w: %s", path, ErrInvalidDatabase, err.Error())
+}
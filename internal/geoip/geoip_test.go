@@ -0,0 +1,105 @@
+package geoip
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+	"github.com/stretchr/testify/require"
+)
+
+// fixture returns the content of a test database of testdata. country.mmdb has 192.0.2.0/24 in
+// US and 2001:db8::/32 in DE, country-updated.mmdb has 192.0.2.0/24 in CA, country-ipv4.mmdb is
+// an IPv4 database with 192.0.2.0/24 in US and asn.mmdb has 192.0.2.0/24 in AS64500
+func fixture(c *require.Assertions, name string) []byte {
+	content, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	c.NoError(err)
+
+	return content
+}
+
+// writeDatabase writes the content and moves its modification time so the change is detected
+func writeDatabase(c *require.Assertions, path string, content []byte, modTime time.Time) {
+	c.NoError(ioutil.WriteFile(path, content, 0600))
+	c.NoError(os.Chtimes(path, modTime, modTime))
+}
+
+func TestFixtures(t *testing.T) {
+	c := require.New(t)
+
+	for _, name := range []string{"country.mmdb", "country-updated.mmdb", "country-ipv4.mmdb", "asn.mmdb"} {
+		reader, err := maxminddb.FromBytes(fixture(c, name))
+		c.NoError(err, name)
+		c.NoError(reader.Verify(), name)
+	}
+}
+
+func TestDatabasesLookup(t *testing.T) {
+	c := require.New(t)
+
+	dir := t.TempDir()
+	countryPath := filepath.Join(dir, "country.mmdb")
+	asnPath := filepath.Join(dir, "asn.mmdb")
+	now := time.Now()
+
+	writeDatabase(c, countryPath, fixture(c, "country.mmdb"), now)
+	writeDatabase(c, asnPath, fixture(c, "asn.mmdb"), now)
+
+	databases := New(countryPath, "", asnPath)
+	c.True(databases.Enabled())
+	c.False(New("", "", "").Enabled())
+
+	location, err := databases.Lookup("192.0.2.10")
+	c.NoError(err)
+	c.Equal(Location{Country: "US", ASN: 64500, ASNOrg: "Example Networks"}, location)
+
+	location, err = databases.Lookup("2001:db8::1")
+	c.NoError(err)
+	c.Equal(Location{Country: "DE"}, location)
+
+	location, err = databases.Lookup("198.51.100.1")
+	c.NoError(err)
+	c.Equal(Location{}, location)
+
+	location, err = databases.Lookup("unknown")
+	c.NoError(err)
+	c.Equal(Location{}, location)
+
+	// the updated file is used in the next lookup
+	writeDatabase(c, countryPath, fixture(c, "country-updated.mmdb"), now.Add(time.Minute))
+
+	location, err = databases.Lookup("192.0.2.10")
+	c.NoError(err)
+	c.Equal("CA", location.Country)
+
+	// a broken update keeps the previous version
+	writeDatabase(c, countryPath, []byte("partial"), now.Add(2*time.Minute))
+
+	location, err = databases.Lookup("192.0.2.10")
+	c.True(errors.Is(err, ErrInvalidDatabase))
+	c.Equal(Location{Country: "CA", ASN: 64500, ASNOrg: "Example Networks"}, location)
+
+	// a database that does not exist does not hide the others
+	location, err = New(filepath.Join(dir, "missing.mmdb"), "", asnPath).Lookup("192.0.2.10")
+	c.Error(err)
+	c.Equal(uint64(64500), location.ASN)
+}
+
+func TestDatabasesIPv4(t *testing.T) {
+	c := require.New(t)
+
+	databases := New(filepath.Join("testdata", "country-ipv4.mmdb"), "", "")
+
+	location, err := databases.Lookup("192.0.2.10")
+	c.NoError(err)
+	c.Equal("US", location.Country)
+
+	// an IPv4 database does not have IPv6 networks
+	location, err = databases.Lookup("2001:db8::1")
+	c.NoError(err)
+	c.Equal(Location{}, location)
+}
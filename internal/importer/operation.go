@@ -0,0 +1,111 @@
+package importer
+
+import (
+	"context"
+	"time"
+
+	"github.com/other_project/crockroach/internal/apperr"
+	"github.com/other_project/crockroach/models"
+)
+
+const (
+	// StateQueued when the operation has not started
+	StateQueued = "queued"
+	// StateRunning when the domains are being processed
+	StateRunning = "running"
+	// StateDone when every domain was processed
+	StateDone = "done"
+
+	// ItemPending when the domain has not been processed
+	ItemPending = "pending"
+	// ItemSucceeded when the domain was analyzed and stored
+	ItemSucceeded = "succeeded"
+	// ItemFailed when the domain could not be processed
+	ItemFailed = "failed"
+)
+
+var (
+	// ErrNoDomains when the import does not contain valid domains
+	ErrNoDomains = apperr.New(apperr.Unprocessable, "no_valid_domains", "the import does not contain valid domains")
+)
+
+// ProcessFunc handles a domain of the import
+type ProcessFunc func(ctx context.Context, domainName string) error
+
+// Item is the state of a domain of the import
+type Item struct {
+	Domain string `json:"domain"`
+	JobID  string `json:"job_id,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Operation is the progress of a stored import
+type Operation struct {
+	ID         string       `json:"id"`
+	State      string       `json:"state"`
+	Total      int          `json:"total"`
+	Processed  int          `json:"processed"`
+	Succeeded  int          `json:"succeeded"`
+	Failed     int          `json:"failed"`
+	Duplicates int          `json:"duplicates"`
+	Items      []*Item      `json:"items"`
+	LineErrors []*LineError `json:"line_errors"`
+	CreatedAt  time.Time    `json:"created_at"`
+	FinishedAt *time.Time   `json:"finished_at,omitempty"`
+}
+
+// Summarize returns the progress of a stored import from the jobs of its domains
+func Summarize(record *models.Import, jobs []*models.Job) *Operation {
+	op := &Operation{
+		ID:         record.ImportID,
+		State:      StateQueued,
+		Total:      record.Total,
+		Duplicates: record.Duplicates,
+		Items:      []*Item{},
+		LineErrors: record.LineErrors,
+	}
+
+	if record.CreationDate != nil {
+		op.CreatedAt = *record.CreationDate
+	}
+
+	var finished time.Time
+
+	for _, job := range jobs {
+		item := &Item{Domain: job.DomainName, JobID: job.JobID, Status: ItemPending}
+
+		switch job.State {
+		case models.JobDone:
+			item.Status = ItemSucceeded
+			op.Succeeded++
+		case models.JobFailed:
+			item.Status = ItemFailed
+			item.Error = job.Error
+			op.Failed++
+		case models.JobRunning:
+			op.State = StateRunning
+		}
+
+		if job.Finished() {
+			op.Processed++
+
+			if job.FinishedDate != nil && job.FinishedDate.After(finished) {
+				finished = *job.FinishedDate
+			}
+		}
+
+		op.Items = append(op.Items, item)
+	}
+
+	if op.Processed > 0 {
+		op.State = StateRunning
+	}
+
+	if op.Processed == op.Total {
+		op.State = StateDone
+		op.FinishedAt = &finished
+	}
+
+	return op
+}
@@ -0,0 +1,53 @@
+package importer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/other_project/crockroach/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarize(t *testing.T) {
+	c := require.New(t)
+
+	record, err := models.NewImport(3, 1, nil)
+	c.NoError(err)
+
+	jobs := []*models.Job{}
+
+	for _, domain := range []string{"example.com", "bad.com", "slow.com"} {
+		job, err := models.NewJob(domain)
+		c.NoError(err)
+
+		job.ImportID = record.ImportID
+		jobs = append(jobs, job)
+	}
+
+	op := Summarize(record, jobs)
+	c.Equal(record.ImportID, op.ID)
+	c.Equal(StateQueued, op.State)
+	c.Equal(3, op.Total)
+	c.Equal(1, op.Duplicates)
+	c.Equal(jobs[0].JobID, op.Items[0].JobID)
+	c.Equal(ItemPending, op.Items[0].Status)
+	c.NotNil(op.LineErrors)
+
+	finished := time.Now()
+	jobs[0].State, jobs[0].FinishedDate = models.JobDone, &finished
+	jobs[1].State, jobs[1].Error, jobs[1].FinishedDate = models.JobFailed, "cannot analyze", &finished
+
+	op = Summarize(record, jobs)
+	c.Equal(StateRunning, op.State)
+	c.Equal(2, op.Processed)
+	c.Equal(1, op.Succeeded)
+	c.Equal(1, op.Failed)
+	c.Equal("cannot analyze", op.Items[1].Error)
+	c.Nil(op.FinishedAt)
+
+	jobs[2].State, jobs[2].FinishedDate = models.JobDone, &finished
+
+	op = Summarize(record, jobs)
+	c.Equal(StateDone, op.State)
+	c.Equal(finished, *op.FinishedAt)
+}
@@ -0,0 +1,126 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"strings"
+
+	"github.com/other_project/crockroach/models"
+)
+
+const (
+	// MaxLines is the maximum number of lines read from an import file
+	MaxLines = 10000
+	// domainColumn is the header of the CSV column that contains the domains
+	domainColumn = "domain"
+)
+
+// LineError describes a line that could not be imported, it is kept with the import
+type LineError = models.ImportLineError
+
+// ParseResult contains the normalized domains and the rejected lines
+type ParseResult struct {
+	Domains    []string     `json:"domains"`
+	Duplicates int          `json:"duplicates"`
+	Errors     []*LineError `json:"errors"`
+}
+
+// Parse reads a plain text list of domains (one per line) or a CSV file.
+// For CSV files the column named "domain" is used, or the first column when there is no header.
+// Empty lines and lines starting with # are ignored, domains are normalized and deduplicated
+func Parse(r io.Reader) (*ParseResult, error) {
+	result := &ParseResult{
+		Domains: []string{},
+		Errors:  []*LineError{},
+	}
+
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	column := 0
+	line := 0
+
+	for scanner.Scan() {
+		line++
+		if line > MaxLines {
+			result.Errors = append(result.Errors, &LineError{Line: line, Error: "too many lines, the rest of the file was ignored"})
+			break
+		}
+
+		text := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields, err := splitFields(text)
+		if err != nil {
+			result.Errors = append(result.Errors, &LineError{Line: line, Value: text, Error: err.Error()})
+			continue
+		}
+
+		if line == 1 && len(fields) > 1 {
+			if i := headerColumn(fields); i >= 0 {
+				column = i
+				continue
+			}
+		}
+
+		if column >= len(fields) {
+			result.Errors = append(result.Errors, &LineError{Line: line, Value: text, Error: "missing domain column"})
+			continue
+		}
+
+		value := strings.TrimSpace(fields[column])
+
+		name, err := models.NormalizeDomainName(value)
+		if err != nil {
+			if line == 1 && strings.EqualFold(value, domainColumn) {
+				continue
+			}
+
+			result.Errors = append(result.Errors, &LineError{Line: line, Value: value, Error: err.Error()})
+
+			continue
+		}
+
+		if seen[name] {
+			result.Duplicates++
+			continue
+		}
+
+		seen[name] = true
+		result.Domains = append(result.Domains, name)
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// splitFields splits a CSV line, plain text lines return a single field
+func splitFields(text string) ([]string, error) {
+	if !strings.ContainsAny(text, ",;\"") {
+		return []string{text}, nil
+	}
+
+	reader := csv.NewReader(strings.NewReader(text))
+	if !strings.Contains(text, ",") && strings.Contains(text, ";") {
+		reader.Comma = ';'
+	}
+
+	return reader.Read()
+}
+
+// headerColumn returns the position of the domain column in a CSV header, -1 when it's not a header
+func headerColumn(fields []string) int {
+	for i, field := range fields {
+		if strings.EqualFold(strings.TrimSpace(field), domainColumn) {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,51 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePlainText(t *testing.T) {
+	c := require.New(t)
+
+	input := `# our domains
+example.com
+https://Example.com/login
+
+www.example.org:443
+not a domain
+bücher.de
+`
+
+	result, err := Parse(strings.NewReader(input))
+	c.NoError(err)
+	c.Equal([]string{"example.com", "www.example.org", "xn--bcher-kva.de"}, result.Domains)
+	c.Equal(1, result.Duplicates)
+	c.Len(result.Errors, 1)
+	c.Equal(6, result.Errors[0].Line)
+	c.Equal("not a domain", result.Errors[0].Value)
+}
+
+func TestParseCSV(t *testing.T) {
+	c := require.New(t)
+
+	input := "owner,domain,notes\nteam-a,example.com,\"main, public\"\nteam-b,EXAMPLE.com,\nteam-c,,\n"
+
+	result, err := Parse(strings.NewReader(input))
+	c.NoError(err)
+	c.Equal([]string{"example.com"}, result.Domains)
+	c.Equal(1, result.Duplicates)
+	c.Len(result.Errors, 1)
+	c.Equal(4, result.Errors[0].Line)
+
+	result, err = Parse(strings.NewReader("example.com;prod\nexample.net;staging\n"))
+	c.NoError(err)
+	c.Equal([]string{"example.com", "example.net"}, result.Domains)
+
+	result, err = Parse(strings.NewReader("domain\nexample.com\n"))
+	c.NoError(err)
+	c.Equal([]string{"example.com"}, result.Domains)
+	c.Empty(result.Errors)
+}
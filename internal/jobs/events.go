@@ -0,0 +1,86 @@
+package jobs
+
+import (
+	"sync"
+
+	"github.com/other_project/crockroach/models"
+)
+
+// subscriberBuffer is the number of events kept for a slow subscriber, the newer ones are dropped
+const subscriberBuffer = 16
+
+// Event is a progress update of a job
+type Event struct {
+	JobID     string                     `json:"job_id"`
+	State     string                     `json:"state"`
+	Stage     string                     `json:"stage"`
+	Progress  int                        `json:"progress"`
+	Message   string                     `json:"message"`
+	Endpoints []*models.EndpointProgress `json:"endpoints,omitempty"`
+}
+
+// broker delivers the events of the jobs to their subscribers
+type broker struct {
+	mu   sync.Mutex
+	subs map[string]map[chan Event]struct{}
+}
+
+func newBroker() *broker {
+	return &broker{subs: make(map[string]map[chan Event]struct{})}
+}
+
+// subscribe returns the events of the job and a function to stop receiving them.
+// The channel is closed when the job finishes
+func (b *broker) subscribe(jobID string) (<-chan Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	events := make(chan Event, subscriberBuffer)
+
+	if b.subs[jobID] == nil {
+		b.subs[jobID] = make(map[chan Event]struct{})
+	}
+
+	b.subs[jobID][events] = struct{}{}
+
+	return events, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if _, ok := b.subs[jobID][events]; !ok {
+			return
+		}
+
+		delete(b.subs[jobID], events)
+		close(events)
+
+		if len(b.subs[jobID]) == 0 {
+			delete(b.subs, jobID)
+		}
+	}
+}
+
+// publish sends the event without waiting for the subscribers
+func (b *broker) publish(event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for events := range b.subs[event.JobID] {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
+
+// close ends the subscriptions of the job
+func (b *broker) close(jobID string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for events := range b.subs[jobID] {
+		close(events)
+	}
+
+	delete(b.subs, jobID)
+}
@@ -0,0 +1,359 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/other_project/crockroach/internal/apperr"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/models"
+)
+
+const (
+	// resumeInterval is the time between the searches of stored jobs that did not fit in the queue
+	resumeInterval = 30 * time.Second
+)
+
+var (
+	// ErrQueueFull when the queue cannot accept more jobs
+	ErrQueueFull = apperr.New(apperr.Unavailable, "job_queue_full", "too many analyses are queued, try again later")
+)
+
+// Store persists the jobs
+type Store interface {
+	StoreJob(ctx context.Context, job *models.Job) (*models.Job, error)
+	GetJob(ctx context.Context, jobID string) (*models.Job, error)
+	UpdateJob(ctx context.Context, job *models.Job) (*models.Job, error)
+	ListJobsByState(ctx context.Context, states ...string) ([]*models.Job, error)
+}
+
+// ProgressFunc reports the stage of a job and its progress from 0 to 100, the runner fills the job ID and state
+type ProgressFunc func(event Event)
+
+// ProcessFunc runs the analysis of the job and returns the ID of the stored analysis
+type ProcessFunc func(ctx context.Context, job *models.Job, report ProgressFunc) (string, error)
+
+// Runner processes the jobs with a pool of workers. The jobs are persisted
+// so the pending ones are resumed when the runner starts again
+type Runner struct {
+	store   Store
+	process ProcessFunc
+	timeout time.Duration
+	workers int
+	queue   chan string
+	resume  time.Duration
+
+	events *broker
+
+	mu         sync.Mutex
+	active     map[string]string
+	queued     map[string]bool
+	submitting map[string]*submission
+}
+
+// submission is a Submit in progress, the other submissions of the domain wait for its job
+type submission struct {
+	done chan struct{}
+	job  *models.Job
+	err  error
+}
+
+// NewRunner creates a runner, Start must be called to process the jobs
+func NewRunner(store Store, process ProcessFunc, workers, queueSize int, timeout time.Duration) *Runner {
+	if workers < 1 {
+		workers = 1
+	}
+
+	return &Runner{
+		store:      store,
+		process:    process,
+		timeout:    timeout,
+		workers:    workers,
+		queue:      make(chan string, queueSize),
+		resume:     resumeInterval,
+		events:     newBroker(),
+		active:     make(map[string]string),
+		queued:     make(map[string]bool),
+		submitting: make(map[string]*submission),
+	}
+}
+
+// Start launches the workers and queues again the jobs that were pending when the service stopped.
+// The stored jobs that do not fit in the queue are queued when there is room
+func (r *Runner) Start(ctx context.Context) {
+	for i := 0; i < r.workers; i++ {
+		go r.work(ctx)
+	}
+
+	go func() {
+		ticker := time.NewTicker(r.resume)
+		defer ticker.Stop()
+
+		for {
+			r.Resume(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Resume queues the stored jobs that are pending and not in the queue, until the queue is full
+func (r *Runner) Resume(ctx context.Context) {
+	pending, err := r.store.ListJobsByState(ctx, models.JobQueued, models.JobRunning)
+	if err != nil {
+		logs.Log().Errorf("cannot resume the pending jobs: %s", err.Error())
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, job := range pending {
+		if r.queued[job.JobID] {
+			continue
+		}
+
+		if !r.enqueue(job) {
+			return
+		}
+	}
+}
+
+// Submit creates a job for the domain, the running job is returned when the domain already has one.
+// The concurrent submissions of a domain wait for the first one instead of storing their own job
+func (r *Runner) Submit(ctx context.Context, domainName string) (*models.Job, error) {
+	r.mu.Lock()
+
+	if pending, ok := r.submitting[domainName]; ok {
+		r.mu.Unlock()
+
+		select {
+		case <-pending.done:
+			return pending.job, pending.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	jobID, active := r.active[domainName]
+	pending := &submission{done: make(chan struct{})}
+	r.submitting[domainName] = pending
+
+	r.mu.Unlock()
+
+	pending.job, pending.err = r.submit(ctx, domainName, jobID, active)
+
+	r.mu.Lock()
+	delete(r.submitting, domainName)
+	r.mu.Unlock()
+
+	close(pending.done)
+
+	return pending.job, pending.err
+}
+
+// submit returns the active job of the domain when it's not finished, otherwise it stores and
+// queues a new one. The lock is only held to queue the job
+func (r *Runner) submit(ctx context.Context, domainName, jobID string, active bool) (*models.Job, error) {
+	if active {
+		job, err := r.store.GetJob(ctx, jobID)
+		if err == nil && !job.Finished() {
+			return job, nil
+		}
+	}
+
+	job, err := models.NewJob(domainName)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err = r.store.StoreJob(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	queued := r.enqueue(job)
+	r.mu.Unlock()
+
+	if !queued {
+		r.fail(ctx, job, ErrQueueFull)
+		return nil, ErrQueueFull
+	}
+
+	return job, nil
+}
+
+// SubmitImport creates a job for every domain of an import. The jobs that do not fit in the
+// queue stay stored as queued and are resumed when the workers have room, so a large import
+// does not fail and survives a restart
+func (r *Runner) SubmitImport(ctx context.Context, importID string, domains []string) ([]*models.Job, error) {
+	items := make([]*models.Job, 0, len(domains))
+
+	for _, domainName := range domains {
+		job, err := models.NewJob(domainName)
+		if err != nil {
+			return nil, err
+		}
+
+		job.ImportID = importID
+
+		job, err = r.store.StoreJob(ctx, job)
+		if err != nil {
+			return nil, err
+		}
+
+		// only the queue needs the lock, the other submissions do not wait for the database
+		r.mu.Lock()
+		r.enqueue(job)
+		r.mu.Unlock()
+
+		items = append(items, job)
+	}
+
+	return items, nil
+}
+
+// enqueue sends the job to the workers without waiting, it reports false when the queue is full.
+// The caller must hold the lock
+func (r *Runner) enqueue(job *models.Job) bool {
+	select {
+	case r.queue <- job.JobID:
+	default:
+		return false
+	}
+
+	r.queued[job.JobID] = true
+	r.active[job.DomainName] = job.JobID
+
+	return true
+}
+
+// Get returns the job
+func (r *Runner) Get(ctx context.Context, jobID string) (*models.Job, error) {
+	return r.store.GetJob(ctx, jobID)
+}
+
+// Subscribe returns the progress of the job until it finishes and a function to stop receiving it.
+// Slow subscribers can miss events, the channel is closed once the final state is saved
+func (r *Runner) Subscribe(jobID string) (<-chan Event, func()) {
+	return r.events.subscribe(jobID)
+}
+
+// work runs the queued jobs until the context is canceled
+func (r *Runner) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case jobID := <-r.queue:
+			r.run(ctx, jobID)
+		}
+	}
+}
+
+// run processes a job and saves its result
+func (r *Runner) run(ctx context.Context, jobID string) {
+	job, err := r.store.GetJob(ctx, jobID)
+	if err != nil {
+		logs.Log().Errorf("cannot load the job %s: %s", jobID, err.Error())
+		r.release(&models.Job{JobID: jobID})
+
+		return
+	}
+
+	defer r.release(job)
+
+	if job.Finished() {
+		return
+	}
+
+	started := time.Now()
+	job.State = models.JobRunning
+	job.StartedDate = &started
+	job.Progress = 0
+	job.Message = "starting"
+	job = r.save(ctx, job)
+
+	jobCtx, cancelfunc := context.WithTimeout(ctx, r.timeout)
+	defer cancelfunc()
+
+	analysisID, err := r.process(jobCtx, job, func(event Event) {
+		if event.Progress < job.Progress {
+			event.Progress = job.Progress
+		}
+
+		job.Progress = event.Progress
+		job.Message = event.Message
+		job = r.save(ctx, job)
+
+		event.JobID = job.JobID
+		event.State = job.State
+		r.events.publish(event)
+	})
+	if err != nil {
+		r.fail(ctx, job, err)
+		return
+	}
+
+	finished := time.Now()
+	job.State = models.JobDone
+	job.Progress = 100
+	job.Message = "done"
+	job.AnalysisID = analysisID
+	job.FinishedDate = &finished
+	r.finish(ctx, job)
+}
+
+// fail marks the job as failed with the error
+func (r *Runner) fail(ctx context.Context, job *models.Job, err error) {
+	finished := time.Now()
+	job.State = models.JobFailed
+	job.Message = "failed"
+	job.Error = err.Error()
+	job.ErrorCode = apperr.CodeOf(err)
+	job.FinishedDate = &finished
+	r.finish(ctx, job)
+}
+
+// finish saves the final state of the job and notifies the subscribers
+func (r *Runner) finish(ctx context.Context, job *models.Job) {
+	job = r.save(ctx, job)
+
+	r.events.publish(Event{
+		JobID:    job.JobID,
+		State:    job.State,
+		Stage:    job.State,
+		Progress: job.Progress,
+		Message:  job.Message,
+	})
+	r.events.close(job.JobID)
+}
+
+// save persists the job, the local copy is kept when the update fails
+func (r *Runner) save(ctx context.Context, job *models.Job) *models.Job {
+	updated, err := r.store.UpdateJob(ctx, job)
+	if err != nil {
+		logs.Log().Errorf("cannot update the job %s: %s", job.JobID, err.Error())
+		return job
+	}
+
+	return updated
+}
+
+// release forgets the queued job and the active job of the domain
+func (r *Runner) release(job *models.Job) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.queued, job.JobID)
+
+	if r.active[job.DomainName] == job.JobID {
+		delete(r.active, job.DomainName)
+	}
+}
@@ -0,0 +1,302 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/other_project/crockroach/internal/apperr"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/internal/storage"
+	"github.com/other_project/crockroach/models"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore is an in memory Store
+type memoryStore struct {
+	mu   sync.Mutex
+	jobs map[string]models.Job
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{jobs: make(map[string]models.Job)}
+}
+
+func (m *memoryStore) StoreJob(ctx context.Context, job *models.Job) (*models.Job, error) {
+	return m.UpdateJob(ctx, job)
+}
+
+func (m *memoryStore) GetJob(ctx context.Context, jobID string) (*models.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.jobs[jobID]
+	if !ok {
+		return nil, storage.ErrJobNotFound
+	}
+
+	return &job, nil
+}
+
+func (m *memoryStore) UpdateJob(ctx context.Context, job *models.Job) (*models.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.jobs[job.JobID] = *job
+	copied := *job
+
+	return &copied, nil
+}
+
+func (m *memoryStore) ListJobsByState(ctx context.Context, states ...string) ([]*models.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	items := []*models.Job{}
+
+	for _, job := range m.jobs {
+		for _, state := range states {
+			if job.State == state {
+				copied := job
+				items = append(items, &copied)
+			}
+		}
+	}
+
+	return items, nil
+}
+
+// waitFinished polls the job until it reaches a final state
+func waitFinished(c *require.Assertions, r *Runner, jobID string) *models.Job {
+	var job *models.Job
+
+	c.Eventually(func() bool {
+		var err error
+
+		job, err = r.Get(context.Background(), jobID)
+		c.NoError(err)
+
+		return job.Finished()
+	}, time.Second, 5*time.Millisecond)
+
+	return job
+}
+
+func TestRunner(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	release := make(chan struct{})
+
+	r := NewRunner(newMemoryStore(), func(ctx context.Context, job *models.Job, report ProgressFunc) (string, error) {
+		report(Event{Stage: "ssllabs", Progress: 50, Message: "assessing"})
+		<-release
+
+		if job.DomainName == "fail.com" {
+			return "", apperr.New(apperr.Upstream, "ssllabs_error", "ssl labs failed")
+		}
+
+		return "analysis-" + job.DomainName, nil
+	}, 2, 10, time.Second)
+
+	ctx, cancelfunc := context.WithCancel(context.Background())
+	defer cancelfunc()
+
+	r.Start(ctx)
+
+	job, err := r.Submit(ctx, "example.com")
+	c.NoError(err)
+	c.Equal(models.JobQueued, job.State)
+
+	again, err := r.Submit(ctx, "example.com")
+	c.NoError(err)
+	c.Equal(job.JobID, again.JobID)
+
+	failed, err := r.Submit(ctx, "fail.com")
+	c.NoError(err)
+
+	c.Eventually(func() bool {
+		current, err := r.Get(ctx, job.JobID)
+		return err == nil && current.State == models.JobRunning && current.Progress == 50
+	}, time.Second, 5*time.Millisecond)
+
+	close(release)
+
+	done := waitFinished(c, r, job.JobID)
+	c.Equal(models.JobDone, done.State)
+	c.Equal(100, done.Progress)
+	c.Equal("analysis-example.com", done.AnalysisID)
+
+	done = waitFinished(c, r, failed.JobID)
+	c.Equal(models.JobFailed, done.State)
+	c.Equal("ssllabs_error", done.ErrorCode)
+
+	next, err := r.Submit(ctx, "example.com")
+	c.NoError(err)
+	c.NotEqual(job.JobID, next.JobID)
+}
+
+func TestRunnerResume(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	store := newMemoryStore()
+
+	interrupted, err := models.NewJob("example.com")
+	c.NoError(err)
+
+	interrupted.State = models.JobRunning
+	_, err = store.StoreJob(context.Background(), interrupted)
+	c.NoError(err)
+
+	r := NewRunner(store, func(ctx context.Context, job *models.Job, report ProgressFunc) (string, error) {
+		return "analysis", nil
+	}, 1, 10, time.Second)
+
+	ctx, cancelfunc := context.WithCancel(context.Background())
+	defer cancelfunc()
+
+	r.Start(ctx)
+
+	c.Equal(models.JobDone, waitFinished(c, r, interrupted.JobID).State)
+}
+
+// slowStore blocks the jobs of a domain in StoreJob until release is closed
+type slowStore struct {
+	*memoryStore
+	domain  string
+	storing chan struct{}
+	release chan struct{}
+	stored  int
+}
+
+func (s *slowStore) StoreJob(ctx context.Context, job *models.Job) (*models.Job, error) {
+	if job.DomainName == s.domain {
+		s.stored++
+		s.storing <- struct{}{}
+		<-s.release
+	}
+
+	return s.memoryStore.StoreJob(ctx, job)
+}
+
+func TestRunnerSubmitWithoutLock(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	store := &slowStore{memoryStore: newMemoryStore(), domain: "slow.com", storing: make(chan struct{}, 1), release: make(chan struct{})}
+	r := NewRunner(store, nil, 1, 10, time.Second)
+	ctx := context.Background()
+
+	type result struct {
+		job *models.Job
+		err error
+	}
+
+	results := make(chan result, 2)
+	submit := func() {
+		job, err := r.Submit(ctx, "slow.com")
+		results <- result{job, err}
+	}
+
+	go submit()
+	<-store.storing
+
+	// a slow insert does not block the other domains
+	other, err := r.Submit(ctx, "example.com")
+	c.NoError(err)
+	c.Equal("example.com", other.DomainName)
+
+	// the concurrent submission of the domain waits for the job being stored
+	go submit()
+	time.Sleep(10 * time.Millisecond)
+	close(store.release)
+
+	first, second := <-results, <-results
+	c.NoError(first.err)
+	c.NoError(second.err)
+	c.Equal(first.job.JobID, second.job.JobID)
+	c.Equal(1, store.stored)
+}
+
+func TestRunnerQueueFull(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	r := NewRunner(newMemoryStore(), nil, 1, 0, time.Second)
+
+	_, err := r.Submit(context.Background(), "example.com")
+	c.True(apperr.Is(err, apperr.Unavailable))
+}
+
+func TestRunnerSubmitImport(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	release := make(chan struct{})
+
+	r := NewRunner(newMemoryStore(), func(ctx context.Context, job *models.Job, report ProgressFunc) (string, error) {
+		<-release
+		return "analysis-" + job.DomainName, nil
+	}, 1, 1, time.Second)
+	r.resume = 10 * time.Millisecond
+
+	ctx, cancelfunc := context.WithCancel(context.Background())
+	defer cancelfunc()
+
+	items, err := r.SubmitImport(ctx, "import-1", []string{"a.com", "b.com", "c.com"})
+	c.NoError(err)
+	c.Len(items, 3)
+
+	for _, job := range items {
+		c.Equal(models.JobQueued, job.State)
+		c.Equal("import-1", job.ImportID)
+	}
+
+	r.Start(ctx)
+	close(release)
+
+	for _, job := range items {
+		c.Equal(models.JobDone, waitFinished(c, r, job.JobID).State)
+	}
+}
+
+func TestRunnerSubscribe(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	start := make(chan struct{})
+
+	r := NewRunner(newMemoryStore(), func(ctx context.Context, job *models.Job, report ProgressFunc) (string, error) {
+		<-start
+		report(Event{Stage: "ssllabs", Progress: 40, Message: "assessing"})
+		report(Event{Stage: "whois", Progress: 20, Message: "whois"})
+
+		return "analysis", nil
+	}, 1, 10, time.Second)
+
+	ctx, cancelfunc := context.WithCancel(context.Background())
+	defer cancelfunc()
+
+	r.Start(ctx)
+
+	job, err := r.Submit(ctx, "example.com")
+	c.NoError(err)
+
+	events, unsubscribe := r.Subscribe(job.JobID)
+	defer unsubscribe()
+
+	close(start)
+
+	received := []Event{}
+	for event := range events {
+		received = append(received, event)
+	}
+
+	c.Len(received, 3)
+	c.Equal(Event{JobID: job.JobID, State: models.JobRunning, Stage: "ssllabs", Progress: 40, Message: "assessing"}, received[0])
+	c.Equal(40, received[1].Progress, "the progress never decreases")
+	c.Equal(models.JobDone, received[2].State)
+	c.Equal(100, received[2].Progress)
+}
@@ -0,0 +1,82 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/models"
+)
+
+// TrackedStore lists the tracked domains that must be analyzed again
+type TrackedStore interface {
+	ListDueTrackedDomains(ctx context.Context, now time.Time) ([]*models.TrackedDomain, error)
+	MarkTrackedDomainChecked(ctx context.Context, domainName string, checked time.Time) error
+}
+
+// Scheduler submits a job for every tracked domain whose check interval elapsed
+type Scheduler struct {
+	store  TrackedStore
+	runner *Runner
+	tick   time.Duration
+	now    func() time.Time
+}
+
+// NewScheduler creates a scheduler that looks for due domains every tick
+func NewScheduler(store TrackedStore, runner *Runner, tick time.Duration) *Scheduler {
+	return &Scheduler{
+		store:  store,
+		runner: runner,
+		tick:   tick,
+		now:    time.Now,
+	}
+}
+
+// Start checks the tracked domains every tick until the context is canceled
+func (s *Scheduler) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.tick)
+		defer ticker.Stop()
+
+		for {
+			s.Tick(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Tick submits the due domains and returns the number of submitted jobs.
+// The domains that do not fit in the queue are submitted on the next tick
+func (s *Scheduler) Tick(ctx context.Context) int {
+	now := s.now()
+
+	due, err := s.store.ListDueTrackedDomains(ctx, now)
+	if err != nil {
+		logs.Log().Errorf("cannot list the due tracked domains: %s", err.Error())
+		return 0
+	}
+
+	submitted := 0
+
+	for _, tracked := range due {
+		_, err := s.runner.Submit(ctx, tracked.DomainName)
+		if err != nil {
+			logs.Log().Errorf("cannot schedule the analysis of %s: %s", tracked.DomainName, err.Error())
+			return submitted
+		}
+
+		err = s.store.MarkTrackedDomainChecked(ctx, tracked.DomainName, now)
+		if err != nil {
+			logs.Log().Errorf("cannot mark %s as checked: %s", tracked.DomainName, err.Error())
+		}
+
+		submitted++
+	}
+
+	return submitted
+}
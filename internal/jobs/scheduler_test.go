@@ -0,0 +1,92 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/models"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryTracked is an in memory TrackedStore
+type memoryTracked struct {
+	mu      sync.Mutex
+	domains []*models.TrackedDomain
+}
+
+func (m *memoryTracked) ListDueTrackedDomains(ctx context.Context, now time.Time) ([]*models.TrackedDomain, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	due := []*models.TrackedDomain{}
+
+	for _, tracked := range m.domains {
+		if tracked.Due(now) {
+			due = append(due, tracked)
+		}
+	}
+
+	return due, nil
+}
+
+func (m *memoryTracked) MarkTrackedDomainChecked(ctx context.Context, domainName string, checked time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, tracked := range m.domains {
+		if tracked.DomainName == domainName {
+			tracked.LastCheckDate = &checked
+		}
+	}
+
+	return nil
+}
+
+func TestSchedulerTick(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	enabled, err := models.NewTrackedDomain("example.com")
+	c.NoError(err)
+
+	disabled, err := models.NewTrackedDomain("disabled.com")
+	c.NoError(err)
+	disabled.Enabled = false
+
+	tracked := &memoryTracked{domains: []*models.TrackedDomain{enabled, disabled}}
+	runner := NewRunner(newMemoryStore(), nil, 1, 10, time.Second)
+
+	now := time.Now()
+	scheduler := NewScheduler(tracked, runner, time.Minute)
+	scheduler.now = func() time.Time { return now }
+
+	c.Equal(1, scheduler.Tick(context.Background()))
+	c.Equal(now, *enabled.LastCheckDate)
+	c.Nil(disabled.LastCheckDate)
+
+	c.Equal(0, scheduler.Tick(context.Background()), "the interval did not elapse")
+
+	now = now.Add(time.Duration(enabled.CheckInterval) * time.Second)
+	c.Equal(1, scheduler.Tick(context.Background()))
+}
+
+func TestSchedulerQueueFull(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	first, err := models.NewTrackedDomain("first.com")
+	c.NoError(err)
+
+	second, err := models.NewTrackedDomain("second.com")
+	c.NoError(err)
+
+	tracked := &memoryTracked{domains: []*models.TrackedDomain{first, second}}
+	runner := NewRunner(newMemoryStore(), nil, 1, 1, time.Second)
+
+	c.Equal(1, NewScheduler(tracked, runner, time.Minute).Tick(context.Background()))
+	c.NotNil(first.LastCheckDate)
+	c.Nil(second.LastCheckDate, "the domain is submitted on the next tick")
+}
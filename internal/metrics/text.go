@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ContentType of the Prometheus text exposition format
+	ContentType = "text/plain; version=0.0.4; charset=utf-8"
+	// TypeGauge is the only metric type written by the probes
+	TypeGauge = "gauge"
+)
+
+// Sample is a value of a metric with its labels
+type Sample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+// Family groups the samples of a metric
+type Family struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []Sample
+}
+
+// NewGauge creates a gauge family with a single sample without labels
+func NewGauge(name, help string, value float64) *Family {
+	return &Family{
+		Name:    name,
+		Help:    help,
+		Type:    TypeGauge,
+		Samples: []Sample{{Value: value}},
+	}
+}
+
+// Add appends a sample with labels to the family
+func (f *Family) Add(labels map[string]string, value float64) *Family {
+	f.Samples = append(f.Samples, Sample{Labels: labels, Value: value})
+	return f
+}
+
+// Write prints the families in the Prometheus text exposition format
+func Write(w io.Writer, families []*Family) error {
+	for _, family := range families {
+		if len(family.Samples) == 0 {
+			continue
+		}
+
+		_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", family.Name, escapeHelp(family.Help), family.Name, family.Type)
+		if err != nil {
+			return err
+		}
+
+		for _, sample := range family.Samples {
+			_, err = fmt.Fprintf(w, "%s%s %s\n", family.Name, formatLabels(sample.Labels), formatValue(sample.Value))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// formatLabels prints the labels sorted by name
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, name, escapeLabel(labels[name])))
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// formatValue prints integers without decimals
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// escapeHelp escapes the help text
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
+
+// escapeLabel escapes a label value
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(value)
+}
@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	c := require.New(t)
+
+	families := []*Family{
+		NewGauge("probe_success", "Whether the probe\nsucceeded", 1),
+		{Name: "server_grade", Help: "Grade", Type: TypeGauge},
+		(&Family{Name: "grade", Help: "Grade per server", Type: TypeGauge}).
+			Add(map[string]string{"address": "10.0.0.1", "owner": `ACME "Inc"`}, 18).
+			Add(map[string]string{"address": "::1"}, 2.5),
+	}
+
+	out := new(bytes.Buffer)
+	c.NoError(Write(out, families))
+
+	expected := `# HELP probe_success Whether the probe\nsucceeded
+# TYPE probe_success gauge
+probe_success 1
+# HELP grade Grade per server
+# TYPE grade gauge
+grade{address="10.0.0.1",owner="ACME \"Inc\""} 18
+grade{address="::1"} 2.5
+`
+	c.Equal(expected, out.String())
+}
@@ -0,0 +1,210 @@
+package pagemeta
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/other_project/crockroach/models"
+	"golang.org/x/net/html"
+)
+
+const (
+	// MaxTextSize is the longest normalized text that is kept, the lines after it are dropped
+	MaxTextSize = 256 << 10
+	// maxValueSize is the longest value of a meta tag that is kept
+	maxValueSize = 1024
+	// maxProperties is the largest number of OpenGraph or Twitter properties that are kept
+	maxProperties = 32
+
+	openGraphPrefix = "og:"
+	twitterPrefix   = "twitter:"
+)
+
+var (
+	// hiddenElements do not have visible text
+	hiddenElements = map[string]bool{
+		"head": true, "script": true, "style": true, "noscript": true, "template": true,
+		"svg": true, "math": true, "iframe": true, "object": true, "canvas": true,
+	}
+
+	// blockElements start a new line of the text
+	blockElements = map[string]bool{
+		"address": true, "article": true, "aside": true, "blockquote": true, "br": true, "dd": true,
+		"details": true, "dialog": true, "div": true, "dl": true, "dt": true, "fieldset": true,
+		"figcaption": true, "figure": true, "footer": true, "form": true, "h1": true, "h2": true,
+		"h3": true, "h4": true, "h5": true, "h6": true, "header": true, "hr": true, "li": true,
+		"main": true, "nav": true, "ol": true, "option": true, "p": true, "pre": true, "section": true,
+		"summary": true, "table": true, "td": true, "th": true, "tr": true, "ul": true,
+	}
+)
+
+// Extract returns the metadata of the page, the canonical URL is resolved against base. The
+// first declaration of every value wins
+func Extract(doc *goquery.Document, base *url.URL) models.PageMetadata {
+	metadata := models.PageMetadata{
+		Lang:      clean(doc.Find("html").First().AttrOr("lang", "")),
+		OpenGraph: map[string]string{},
+		Twitter:   map[string]string{},
+	}
+
+	doc.Find("meta[content]").Each(func(i int, s *goquery.Selection) {
+		content := clean(s.AttrOr("content", ""))
+		if content == "" {
+			return
+		}
+
+		// the OpenGraph protocol uses property, many sites use name for both
+		for _, key := range []string{s.AttrOr("name", ""), s.AttrOr("property", "")} {
+			key = strings.ToLower(strings.TrimSpace(key))
+
+			switch {
+			case key == "description" && metadata.Description == "":
+				metadata.Description = content
+			case key == "generator" && metadata.Generator == "":
+				metadata.Generator = content
+			case strings.HasPrefix(key, openGraphPrefix):
+				setProperty(metadata.OpenGraph, strings.TrimPrefix(key, openGraphPrefix), content)
+			case strings.HasPrefix(key, twitterPrefix):
+				setProperty(metadata.Twitter, strings.TrimPrefix(key, twitterPrefix), content)
+			}
+		}
+	})
+
+	doc.Find("link[rel][href]").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		if !containsToken(s.AttrOr("rel", ""), "canonical") {
+			return true
+		}
+
+		target, err := base.Parse(strings.TrimSpace(s.AttrOr("href", "")))
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+			return true
+		}
+
+		metadata.Canonical = target.String()
+
+		return false
+	})
+
+	return metadata
+}
+
+// Text returns the visible text of the body, one block per line. The spaces of every line are
+// collapsed and the empty lines are dropped, so the text only changes when the content does
+func Text(doc *goquery.Document) string {
+	root := doc.Find("body").First()
+	if root.Length() == 0 {
+		root = doc.Selection
+	}
+
+	lines := &textBuilder{}
+
+	for _, node := range root.Nodes {
+		lines.walk(node)
+	}
+
+	lines.flush()
+
+	return strings.Join(lines.lines, "\n")
+}
+
+// textBuilder collects the lines of the visible text
+type textBuilder struct {
+	lines   []string
+	current strings.Builder
+	size    int
+	full    bool
+}
+
+// walk adds the text of the node and its children
+func (b *textBuilder) walk(node *html.Node) {
+	switch node.Type {
+	case html.TextNode:
+		// inline elements do not separate words, the spaces come from the source
+		b.current.WriteString(node.Data)
+
+		return
+	case html.ElementNode:
+		if hiddenElements[node.Data] || hasAttr(node, "hidden") {
+			return
+		}
+	case html.DocumentNode:
+	default:
+		return
+	}
+
+	block := node.Type == html.ElementNode && blockElements[node.Data]
+	if block {
+		b.flush()
+	}
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		b.walk(child)
+	}
+
+	if block {
+		b.flush()
+	}
+}
+
+// flush ends the current line, it is dropped when it is empty or the text is already too long
+func (b *textBuilder) flush() {
+	line := strings.Join(strings.Fields(b.current.String()), " ")
+	b.current.Reset()
+
+	if line == "" || b.full {
+		return
+	}
+
+	if b.size+len(line)+1 > MaxTextSize {
+		b.full = true
+		return
+	}
+
+	b.lines = append(b.lines, line)
+	b.size += len(line) + 1
+}
+
+// setProperty keeps the first value of the property
+func setProperty(properties map[string]string, key, value string) {
+	if key == "" || len(properties) >= maxProperties {
+		return
+	}
+
+	if _, ok := properties[key]; !ok {
+		properties[key] = value
+	}
+}
+
+// clean collapses the spaces of the value and cuts it to maxValueSize bytes
+func clean(value string) string {
+	value = strings.Join(strings.Fields(value), " ")
+	if len(value) <= maxValueSize {
+		return value
+	}
+
+	// a multibyte character cut in half is dropped
+	return strings.ToValidUTF8(value[:maxValueSize], "")
+}
+
+// hasAttr reports if the element has the attribute
+func hasAttr(node *html.Node, name string) bool {
+	for _, attr := range node.Attr {
+		if attr.Key == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// containsToken reports if the space separated list contains the token, ignoring the case
+func containsToken(list, token string) bool {
+	for _, item := range strings.Fields(list) {
+		if strings.EqualFold(item, token) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,81 @@
+package pagemeta
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/require"
+)
+
+// parse returns the document of the page
+func parse(c *require.Assertions, page string) *goquery.Document {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	c.NoError(err)
+
+	return doc
+}
+
+func TestExtract(t *testing.T) {
+	c := require.New(t)
+
+	base, err := url.Parse("https://example.com/blog/post")
+	c.NoError(err)
+
+	doc := parse(c, `<html lang=" es-AR "><head>
+		<meta name="Description" content="  The   example
+			site ">
+		<meta name="description" content="second description">
+		<meta name="generator" content="WordPress 5.6">
+		<meta property="og:title" content="Example">
+		<meta property="og:image" content="https://example.com/card.png">
+		<meta name="og:title" content="ignored, the first one wins">
+		<meta name="twitter:card" content="summary">
+		<meta property="twitter:site" content="@example">
+		<meta name="keywords" content="not kept">
+		<meta property="og:empty" content="">
+		<link rel="canonical" href="/blog/post?ref=home">
+	</head><body></body></html>`)
+
+	metadata := Extract(doc, base)
+	c.Equal("es-AR", metadata.Lang)
+	c.Equal("The example site", metadata.Description)
+	c.Equal("WordPress 5.6", metadata.Generator)
+	c.Equal("https://example.com/blog/post?ref=home", metadata.Canonical)
+	c.Equal(map[string]string{"title": "Example", "image": "https://example.com/card.png"}, metadata.OpenGraph)
+	c.Equal(map[string]string{"card": "summary", "site": "@example"}, metadata.Twitter)
+
+	metadata = Extract(parse(c, `<link rel="canonical" href="javascript:alert(1)"><meta name="description" content="`+strings.Repeat("ñ", maxValueSize)+`">`), base)
+	c.Empty(metadata.Canonical)
+	c.Empty(metadata.Lang)
+	c.Empty(metadata.OpenGraph)
+	c.Len(metadata.Description, maxValueSize)
+	c.Equal(strings.Repeat("ñ", maxValueSize/2), metadata.Description)
+}
+
+func TestText(t *testing.T) {
+	c := require.New(t)
+
+	doc := parse(c, `<html><head><title>Not in the text</title><style>body {}</style></head><body>
+		<header><nav><a href="/">Home</a> | <a href="/blog">Blog</a></nav></header>
+		<script>document.write("not visible")</script>
+		<h1>Wel<b>come</b></h1>
+		<p>First   paragraph<br>second
+			line</p>
+		<div hidden>hidden text</div>
+		<noscript>enable javascript</noscript>
+		<ul><li>one</li><li>two</li></ul>
+		<p>   </p>
+	</body></html>`)
+
+	c.Equal("Home | Blog\nWelcome\nFirst paragraph\nsecond line\none\ntwo", Text(doc))
+
+	// only the content changes the text, not the markup or the spaces
+	same := parse(c, `<body><header><nav><a href="/">Home</a>  |  <a href="/blog">Blog</a></nav></header>
+		<h1 class="title">Welcome</h1><p>First paragraph<br>second line</p><ul><li>one</li><li>two</li></ul></body>`)
+	c.Equal(Text(doc), Text(same))
+
+	long := parse(c, "<body><p>"+strings.Repeat("a", MaxTextSize)+"</p><p>short</p></body>")
+	c.Empty(Text(long), "the lines after the limit are dropped")
+}
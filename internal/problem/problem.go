@@ -0,0 +1,94 @@
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/other_project/crockroach/internal/apperr"
+	"github.com/other_project/crockroach/internal/logs"
+)
+
+// ContentType is the media type of the problem responses (RFC 7807)
+const ContentType = "application/problem+json"
+
+// statuses maps every kind of error to its status code
+var statuses = map[apperr.Kind]int{
+	apperr.Invalid:       http.StatusBadRequest,
+	apperr.Unprocessable: http.StatusUnprocessableEntity,
+	apperr.NotFound:      http.StatusNotFound,
+	apperr.Conflict:      http.StatusConflict,
+	apperr.Unauthorized:  http.StatusUnauthorized,
+	apperr.Forbidden:     http.StatusForbidden,
+	apperr.TooLarge:      http.StatusRequestEntityTooLarge,
+	apperr.RateLimited:   http.StatusTooManyRequests,
+	apperr.Upstream:      http.StatusBadGateway,
+	apperr.Timeout:       http.StatusGatewayTimeout,
+	apperr.Unavailable:   http.StatusServiceUnavailable,
+	apperr.Internal:      http.StatusInternalServerError,
+}
+
+// Problem is the body of an error response. Code is stable and identifies the error,
+// Errors contains details such as the rejected lines of an import
+type Problem struct {
+	Type     string      `json:"type"`
+	Title    string      `json:"title"`
+	Status   int         `json:"status"`
+	Detail   string      `json:"detail,omitempty"`
+	Instance string      `json:"instance,omitempty"`
+	Code     string      `json:"code"`
+	Errors   interface{} `json:"errors,omitempty"`
+}
+
+// Status returns the status code of the error
+func Status(err error) int {
+	return statuses[apperr.KindOf(err)]
+}
+
+// FromError builds the problem of the error, the detail of internal errors is hidden
+func FromError(r *http.Request, err error) *Problem {
+	status := Status(err)
+
+	p := &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: err.Error(),
+		Code:   apperr.CodeOf(err),
+	}
+
+	if status == http.StatusInternalServerError {
+		p.Detail = "unexpected error, see the server logs"
+	}
+
+	if r != nil {
+		p.Instance = r.URL.Path
+	}
+
+	return p
+}
+
+// Error writes the problem of the error
+func Error(w http.ResponseWriter, r *http.Request, err error) {
+	if Status(err) >= http.StatusInternalServerError {
+		logs.Log().Errorf("%s %s: %s", r.Method, r.URL.Path, err.Error())
+	}
+
+	Write(w, FromError(r, err))
+}
+
+// Write writes the problem as JSON with its status code
+func Write(w http.ResponseWriter, p *Problem) {
+	body, err := json.Marshal(p)
+	if err != nil {
+		logs.Log().Errorf("Error Marshal problem %s", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+
+	_, err = w.Write(body)
+	if err != nil {
+		logs.Log().Errorf("Error Write response %s", err.Error())
+	}
+}
@@ -0,0 +1,48 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/other_project/crockroach/internal/apperr"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestError(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	errInvalid := apperr.New(apperr.Unprocessable, "invalid_domain_name", "invalid domain name")
+
+	r := httptest.NewRequest(http.MethodPost, "/domain", nil)
+	rec := httptest.NewRecorder()
+
+	Error(rec, r, fmt.Errorf("%w: exa mple.com", errInvalid))
+	c.Equal(http.StatusUnprocessableEntity, rec.Code)
+	c.Equal(ContentType, rec.Header().Get("Content-Type"))
+
+	p := new(Problem)
+	c.NoError(json.Unmarshal(rec.Body.Bytes(), p))
+	c.Equal("invalid_domain_name", p.Code)
+	c.Equal("invalid domain name: exa mple.com", p.Detail)
+	c.Equal("/domain", p.Instance)
+	c.Equal("Unprocessable Entity", p.Title)
+
+	rec = httptest.NewRecorder()
+	Error(rec, r, errors.New("pq: password authentication failed"))
+	c.Equal(http.StatusInternalServerError, rec.Code)
+	c.NotContains(rec.Body.String(), "password")
+}
+
+func TestStatus(t *testing.T) {
+	c := require.New(t)
+
+	c.Equal(http.StatusBadGateway, Status(apperr.Wrap(apperr.Upstream, "ssllabs_unavailable", errors.New("refused"))))
+	c.Equal(http.StatusGatewayTimeout, Status(apperr.New(apperr.Timeout, "analysis_timeout", "timeout")))
+	c.Equal(http.StatusNotFound, Status(apperr.New(apperr.NotFound, "domain_not_found", "not found")))
+}
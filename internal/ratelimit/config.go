@@ -0,0 +1,29 @@
+package ratelimit
+
+import "github.com/other_project/crockroach/shared/env"
+
+var (
+	// Enabled turns off the limits when it's false
+	Enabled = env.GetBool("RATE_LIMIT_ENABLED", true)
+	// ReadPolicy is the budget of the routes that query stored data
+	ReadPolicy = Policy{
+		Name:       "read",
+		PerMinute:  float64(env.GetInt64("RATE_LIMIT_READ_PER_MINUTE", 120)),
+		Burst:      int(env.GetInt64("RATE_LIMIT_READ_BURST", 30)),
+		DailyQuota: int(env.GetInt64("QUOTA_READ_DAILY", 0)),
+	}
+	// AnalyzePolicy is the budget of the routes that launch analyses against the upstream services
+	AnalyzePolicy = Policy{
+		Name:       "analyze",
+		PerMinute:  float64(env.GetInt64("RATE_LIMIT_ANALYZE_PER_MINUTE", 6)),
+		Burst:      int(env.GetInt64("RATE_LIMIT_ANALYZE_BURST", 3)),
+		DailyQuota: int(env.GetInt64("QUOTA_ANALYZE_DAILY", 500)),
+	}
+	// WritePolicy is the budget of the routes that change the settings, they do not launch analyses
+	WritePolicy = Policy{
+		Name:       "write",
+		PerMinute:  float64(env.GetInt64("RATE_LIMIT_WRITE_PER_MINUTE", 30)),
+		Burst:      int(env.GetInt64("RATE_LIMIT_WRITE_BURST", 10)),
+		DailyQuota: int(env.GetInt64("QUOTA_WRITE_DAILY", 0)),
+	}
+)
@@ -0,0 +1,251 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/other_project/crockroach/internal/apperr"
+	"github.com/other_project/crockroach/internal/auth"
+	"github.com/other_project/crockroach/internal/problem"
+)
+
+const (
+	// maxClients is the number of clients tracked before the idle ones are removed
+	maxClients = 10000
+	// day is the window of the quotas
+	day = 24 * time.Hour
+)
+
+var (
+	// ErrRateLimited when the bucket of the client is empty
+	ErrRateLimited = apperr.New(apperr.RateLimited, "rate_limited", "rate limit exceeded")
+	// ErrQuotaExceeded when the client consumed its daily quota
+	ErrQuotaExceeded = apperr.New(apperr.RateLimited, "quota_exceeded", "daily quota exceeded")
+)
+
+// Policy is the budget of a group of routes
+type Policy struct {
+	// Name identifies the policy in the responses
+	Name string
+	// PerMinute is the number of tokens added to the bucket every minute
+	PerMinute float64
+	// Burst is the capacity of the bucket
+	Burst int
+	// DailyQuota is the number of requests allowed per UTC day, 0 means unlimited
+	DailyQuota int
+}
+
+// Decision is the result of a request against the limiter
+type Decision struct {
+	Allowed        bool
+	Limit          int
+	Remaining      int
+	Reset          time.Duration
+	RetryAfter     time.Duration
+	QuotaLimit     int
+	QuotaRemaining int
+	QuotaExceeded  bool
+}
+
+// contextKey is the type of the limiter stored in the request context by Deferred
+type contextKey struct{}
+
+// bucket is the state of a client
+type bucket struct {
+	tokens   float64
+	last     time.Time
+	quotaDay time.Time
+	used     int
+}
+
+// Limiter applies a policy to every client
+type Limiter struct {
+	mu      sync.Mutex
+	policy  Policy
+	clients map[string]*bucket
+	now     func() time.Time
+}
+
+// New creates a limiter for the policy, the rate and the burst are at least one
+func New(policy Policy) *Limiter {
+	policy.PerMinute = math.Max(policy.PerMinute, 1)
+	if policy.Burst < 1 {
+		policy.Burst = 1
+	}
+
+	return &Limiter{
+		policy:  policy,
+		clients: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow takes a token of the client, the request is rejected when the bucket is empty
+// or when the daily quota was consumed
+func (l *Limiter) Allow(clientID string) Decision {
+	return l.AllowN(clientID, 1)
+}
+
+// AllowN takes a token of the client and charges cost units of its daily quota, the request
+// is rejected when the bucket is empty or when the quota does not have cost units left
+func (l *Limiter) AllowN(clientID string, cost int) Decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	today := now.UTC().Truncate(day)
+	rate := l.policy.PerMinute / time.Minute.Seconds()
+
+	b, ok := l.clients[clientID]
+	if !ok {
+		l.evict(now, rate)
+
+		b = &bucket{tokens: float64(l.policy.Burst), last: now, quotaDay: today}
+		l.clients[clientID] = b
+	}
+
+	b.tokens = math.Min(float64(l.policy.Burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	if !b.quotaDay.Equal(today) {
+		b.quotaDay = today
+		b.used = 0
+	}
+
+	decision := Decision{Limit: l.policy.Burst, QuotaLimit: l.policy.DailyQuota}
+
+	if l.policy.DailyQuota > 0 && b.used+cost > l.policy.DailyQuota {
+		decision.QuotaExceeded = true
+		decision.RetryAfter = today.Add(day).Sub(now)
+	} else if b.tokens < 1 {
+		decision.RetryAfter = seconds((1 - b.tokens) / rate)
+	} else {
+		b.tokens--
+		b.used += cost
+		decision.Allowed = true
+	}
+
+	decision.Remaining = int(b.tokens)
+	decision.Reset = seconds((float64(l.policy.Burst) - b.tokens) / rate)
+
+	if l.policy.DailyQuota > 0 {
+		decision.QuotaRemaining = l.policy.DailyQuota - b.used
+	}
+
+	return decision
+}
+
+// evict removes the clients whose bucket is full and did not use the quota today
+func (l *Limiter) evict(now time.Time, rate float64) {
+	if len(l.clients) < maxClients {
+		return
+	}
+
+	refill := seconds(float64(l.policy.Burst) / rate)
+	today := now.UTC().Truncate(day)
+
+	for id, b := range l.clients {
+		if now.Sub(b.last) >= refill && (b.used == 0 || !b.quotaDay.Equal(today)) {
+			delete(l.clients, id)
+		}
+	}
+}
+
+// Middleware rejects with 429 the requests of the clients that exceeded the policy
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if l.respond(w, r, l.Allow(ClientID(r))) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// Deferred lets the handler charge the policy with Charge, only when the request turns out to
+// need it. A cache hit or a fresh stored analysis does not consume the budget
+func (l *Limiter) Deferred(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, l)))
+	})
+}
+
+// Charge takes a token and cost units of the quota of the policy attached by Deferred. When the
+// client exceeded the policy it writes the 429 response and returns false. Without a policy,
+// because the limits are disabled, it always returns true
+func Charge(w http.ResponseWriter, r *http.Request, cost int) bool {
+	l, ok := r.Context().Value(contextKey{}).(*Limiter)
+	if !ok {
+		return true
+	}
+
+	return l.respond(w, r, l.AllowN(ClientID(r), cost))
+}
+
+// respond sets the rate limit headers of the decision and writes the 429 response when the
+// request is not allowed
+func (l *Limiter) respond(w http.ResponseWriter, r *http.Request, decision Decision) bool {
+	w.Header().Set("RateLimit-Policy", l.policyHeader())
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(decision.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(decision.Reset)))
+
+	if decision.QuotaLimit > 0 {
+		w.Header().Set("X-Quota-Limit", strconv.Itoa(decision.QuotaLimit))
+		w.Header().Set("X-Quota-Remaining", strconv.Itoa(decision.QuotaRemaining))
+	}
+
+	if decision.Allowed {
+		return true
+	}
+
+	err := fmt.Errorf("%w for the %s routes", ErrRateLimited, l.policy.Name)
+	if decision.QuotaExceeded {
+		err = fmt.Errorf("%w for the %s routes", ErrQuotaExceeded, l.policy.Name)
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(decision.RetryAfter)))
+	problem.Error(w, r, err)
+
+	return false
+}
+
+// policyHeader describes the bucket and the quota windows in seconds
+func (l *Limiter) policyHeader() string {
+	window := ceilSeconds(seconds(float64(l.policy.Burst) / (l.policy.PerMinute / time.Minute.Seconds())))
+	header := fmt.Sprintf("%d;w=%d", l.policy.Burst, window)
+
+	if l.policy.DailyQuota > 0 {
+		header += fmt.Sprintf(", %d;w=%d", l.policy.DailyQuota, int(day.Seconds()))
+	}
+
+	return header
+}
+
+// ClientID identifies the client by its API key, or by its IP address when the request is anonymous
+func ClientID(r *http.Request) string {
+	if key := auth.KeyFromContext(r.Context()); key != nil {
+		return "key:" + key.KeyID
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return "ip:" + host
+}
+
+// seconds converts a number of seconds in a duration
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// ceilSeconds rounds up the duration to seconds
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,157 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/other_project/crockroach/internal/auth"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/models"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestLimiter creates a limiter whose clock is moved by the test
+func newTestLimiter(policy Policy) (*Limiter, *time.Time) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	l := New(policy)
+	l.now = func() time.Time { return now }
+
+	return l, &now
+}
+
+func TestAllowBucket(t *testing.T) {
+	c := require.New(t)
+
+	l, now := newTestLimiter(Policy{Name: "analyze", PerMinute: 6, Burst: 2})
+
+	c.True(l.Allow("a").Allowed)
+
+	d := l.Allow("a")
+	c.True(d.Allowed)
+	c.Equal(0, d.Remaining)
+
+	d = l.Allow("a")
+	c.False(d.Allowed)
+	c.Equal(10*time.Second, d.RetryAfter)
+
+	c.True(l.Allow("b").Allowed)
+
+	*now = now.Add(10 * time.Second)
+	c.True(l.Allow("a").Allowed)
+	c.False(l.Allow("a").Allowed)
+}
+
+func TestAllowQuota(t *testing.T) {
+	c := require.New(t)
+
+	l, now := newTestLimiter(Policy{Name: "analyze", PerMinute: 60, Burst: 10, DailyQuota: 2})
+
+	c.True(l.Allow("a").Allowed)
+
+	d := l.Allow("a")
+	c.True(d.Allowed)
+	c.Equal(0, d.QuotaRemaining)
+
+	d = l.Allow("a")
+	c.False(d.Allowed)
+	c.True(d.QuotaExceeded)
+	c.Equal(12*time.Hour, d.RetryAfter)
+
+	*now = now.Add(12 * time.Hour)
+	c.True(l.Allow("a").Allowed)
+}
+
+func TestMiddleware(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	l, _ := newTestLimiter(Policy{Name: "analyze", PerMinute: 1, Burst: 1})
+	handler := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	do := func(remote string, key *models.APIKey) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/domain", nil)
+		r.RemoteAddr = remote
+
+		if key != nil {
+			r = r.WithContext(auth.WithKey(r.Context(), key))
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, r)
+
+		return rec
+	}
+
+	rec := do("10.0.0.1:1234", nil)
+	c.Equal(http.StatusOK, rec.Code)
+	c.Equal("1", rec.Header().Get("RateLimit-Limit"))
+	c.Equal("0", rec.Header().Get("RateLimit-Remaining"))
+	c.Equal("60", rec.Header().Get("RateLimit-Reset"))
+
+	rec = do("10.0.0.1:5678", nil)
+	c.Equal(http.StatusTooManyRequests, rec.Code)
+	c.Equal("60", rec.Header().Get("Retry-After"))
+
+	rec = do("10.0.0.1:5678", &models.APIKey{KeyID: "k1"})
+	c.Equal(http.StatusOK, rec.Code)
+}
+
+func TestAllowNQuota(t *testing.T) {
+	c := require.New(t)
+
+	l, _ := newTestLimiter(Policy{Name: "analyze", PerMinute: 60, Burst: 10, DailyQuota: 5})
+
+	d := l.AllowN("a", 3)
+	c.True(d.Allowed)
+	c.Equal(2, d.QuotaRemaining)
+
+	// the cost does not fit in the quota that is left, nothing is charged
+	d = l.AllowN("a", 3)
+	c.False(d.Allowed)
+	c.True(d.QuotaExceeded)
+	c.Equal(2, d.QuotaRemaining)
+
+	c.True(l.AllowN("a", 2).Allowed)
+}
+
+func TestCharge(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	l, _ := newTestLimiter(Policy{Name: "analyze", PerMinute: 1, Burst: 1})
+
+	// the handler only charges the requests that need a new analysis
+	handler := l.Deferred(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("cached") == "true" {
+			return
+		}
+
+		if !Charge(w, r, 1) {
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	do := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+
+		return rec
+	}
+
+	c.Equal(http.StatusOK, do("/probe?cached=true").Code)
+	c.Equal(http.StatusCreated, do("/probe").Code)
+	c.Equal(http.StatusOK, do("/probe?cached=true").Code)
+
+	rec := do("/probe")
+	c.Equal(http.StatusTooManyRequests, rec.Code)
+	c.Equal("60", rec.Header().Get("Retry-After"))
+
+	// without Deferred there is no policy to charge
+	rec = httptest.NewRecorder()
+	c.True(Charge(rec, httptest.NewRequest(http.MethodGet, "/probe", nil), 1))
+}
@@ -0,0 +1,235 @@
+package rules
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/other_project/crockroach/models"
+)
+
+const (
+	// HistorySize is the number of analyses loaded to evaluate the rules
+	HistorySize = 100
+	// unknownOwner is stored when the whois provider was skipped
+	unknownOwner = "unknown"
+)
+
+// Outcome is the result of a rule for an analysis
+type Outcome struct {
+	Fired   bool
+	Message string
+}
+
+// Evaluate applies the rule to the history of a domain. The history contains the analyses
+// ordered from the newest, the first one is the analysis being evaluated
+func Evaluate(rule *models.Rule, history []*models.Domain, now time.Time) Outcome {
+	if len(history) == 0 {
+		return Outcome{Message: "the domain does not have analyses"}
+	}
+
+	switch rule.Kind {
+	case models.RuleGradeBelow:
+		return gradeBelow(rule, history[0])
+	case models.RuleOwnerChanged:
+		return ownerChanged(history)
+	case models.RuleServerCountDrop:
+		return serverCountDrop(rule, history)
+	case models.RuleDownConsecutive:
+		return downConsecutive(rule, history)
+	case models.RuleGradeDrops:
+		return gradeDrops(rule, history, now)
+	case models.RuleCertExpiry:
+		return certExpiry(rule, history[0], now)
+	case models.RuleDualStack:
+		return dualStack(history[0])
+	case models.RuleLogoChanged:
+		return logoChanged(history)
+	}
+
+	return Outcome{Message: "unknown rule kind " + rule.Kind}
+}
+
+// gradeBelow fires when the grade is worse than the grade of the rule, analyses without a known grade
+// never fire: the tls and scan providers grade the servers "unknown"
+func gradeBelow(rule *models.Rule, current *models.Domain) Outcome {
+	if current.SSLGrade == "" {
+		return Outcome{Message: "the analysis does not have a grade"}
+	}
+
+	if models.GradeRank(current.SSLGrade) < 0 {
+		return Outcome{Message: fmt.Sprintf("grade %s is not known", current.SSLGrade)}
+	}
+
+	if models.IsGradeBelow(current.SSLGrade, rule.Grade) {
+		return Outcome{Fired: true, Message: fmt.Sprintf("grade %s is below %s", current.SSLGrade, rule.Grade)}
+	}
+
+	return Outcome{Message: fmt.Sprintf("grade %s is not below %s", current.SSLGrade, rule.Grade)}
+}
+
+// ownerChanged compares the owners of the servers present in the last two analyses
+func ownerChanged(history []*models.Domain) Outcome {
+	if len(history) < 2 {
+		return Outcome{Message: "there is no previous analysis"}
+	}
+
+	previous := make(map[string]string)
+	for _, server := range history[1].Servers {
+		previous[server.Address] = server.Owner
+	}
+
+	changes := []string{}
+
+	for _, server := range history[0].Servers {
+		owner, ok := previous[server.Address]
+		if !ok || !knownOwner(owner) || !knownOwner(server.Owner) || owner == server.Owner {
+			continue
+		}
+
+		changes = append(changes, fmt.Sprintf("%s from %s to %s", server.Address, owner, server.Owner))
+	}
+
+	if len(changes) == 0 {
+		return Outcome{Message: "the owners did not change"}
+	}
+
+	sort.Strings(changes)
+
+	return Outcome{Fired: true, Message: "owner changed: " + strings.Join(changes, ", ")}
+}
+
+// knownOwner reports if the owner was obtained by whois
+func knownOwner(owner string) bool {
+	return owner != "" && owner != unknownOwner
+}
+
+// serverCountDrop fires when the servers dropped by more than the threshold percent
+func serverCountDrop(rule *models.Rule, history []*models.Domain) Outcome {
+	if len(history) < 2 {
+		return Outcome{Message: "there is no previous analysis"}
+	}
+
+	previous := len(history[1].Servers)
+	current := len(history[0].Servers)
+
+	if previous == 0 || current >= previous {
+		return Outcome{Message: fmt.Sprintf("servers went from %d to %d", previous, current)}
+	}
+
+	drop := int64((previous - current) * 100 / previous)
+	message := fmt.Sprintf("servers dropped %d%% from %d to %d", drop, previous, current)
+
+	return Outcome{Fired: drop > rule.Threshold, Message: message}
+}
+
+// downConsecutive fires when the site was down in the last threshold analyses
+func downConsecutive(rule *models.Rule, history []*models.Domain) Outcome {
+	down := int64(0)
+
+	for _, analysis := range history {
+		if !analysis.IsDown {
+			break
+		}
+
+		down++
+	}
+
+	message := fmt.Sprintf("down in the last %d analyses", down)
+
+	return Outcome{Fired: down >= rule.Threshold, Message: message}
+}
+
+// gradeDrops counts the analyses of the window whose grade is worse than the grade of the analysis before
+func gradeDrops(rule *models.Rule, history []*models.Domain, now time.Time) Outcome {
+	since := now.Add(-time.Duration(rule.Window) * time.Second)
+	drops := int64(0)
+
+	for i := 0; i+1 < len(history); i++ {
+		current, previous := history[i], history[i+1]
+		if current.AnalyzedAt().Before(since) {
+			break
+		}
+
+		if current.SSLGrade == "" || previous.SSLGrade == "" {
+			continue
+		}
+
+		if models.GradeRank(current.SSLGrade) > models.GradeRank(previous.SSLGrade) {
+			drops++
+		}
+	}
+
+	message := fmt.Sprintf("grade dropped %d times in %s", drops, time.Duration(rule.Window)*time.Second)
+
+	return Outcome{Fired: drops >= rule.Threshold, Message: message}
+}
+
+// certExpiry fires when the certificate of a server expires in Threshold days or less, servers
+// without inspection are ignored
+func certExpiry(rule *models.Rule, current *models.Domain, now time.Time) Outcome {
+	expiring := []string{}
+	inspected := 0
+
+	for _, server := range current.Servers {
+		days, ok := server.TLS.DaysLeft(now)
+		if !ok {
+			continue
+		}
+
+		inspected++
+
+		if days <= int(rule.Threshold) {
+			expiring = append(expiring, fmt.Sprintf("%s in %d days", server.Address, days))
+		}
+	}
+
+	if inspected == 0 {
+		return Outcome{Message: "the certificates were not inspected"}
+	}
+
+	if len(expiring) == 0 {
+		return Outcome{Message: fmt.Sprintf("no certificate expires in %d days", rule.Threshold)}
+	}
+
+	sort.Strings(expiring)
+
+	return Outcome{Fired: true, Message: "certificate expires: " + strings.Join(expiring, ", ")}
+}
+
+// dualStack fires when the domain has IPv4 servers and the IPv6 servers are missing or have
+// another grade or certificate
+func dualStack(current *models.Domain) Outcome {
+	check := models.CheckDualStack(current.Servers)
+	if check == nil {
+		return Outcome{Message: "the analysis does not have servers"}
+	}
+
+	if check.Parity() {
+		return Outcome{Message: fmt.Sprintf("%d IPv4 and %d IPv6 servers match", check.IPv4, check.IPv6)}
+	}
+
+	return Outcome{Fired: true, Message: "dual stack mismatch: " + strings.Join(check.Issues, ", ")}
+}
+
+// logoChanged compares the hashes of the logos cached by the last two analyses, a logo that was
+// not cached is not compared
+func logoChanged(history []*models.Domain) Outcome {
+	if len(history) < 2 {
+		return Outcome{Message: "there is no previous analysis"}
+	}
+
+	current := models.LogoHash(history[0].Logo)
+	previous := models.LogoHash(history[1].Logo)
+
+	if current == "" || previous == "" {
+		return Outcome{Message: "the logo was not cached in both analyses"}
+	}
+
+	if current == previous {
+		return Outcome{Message: "the logo did not change"}
+	}
+
+	return Outcome{Fired: true, Message: fmt.Sprintf("logo changed from %.12s to %.12s", previous, current)}
+}
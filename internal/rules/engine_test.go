@@ -0,0 +1,166 @@
+package rules
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/other_project/crockroach/models"
+	"github.com/stretchr/testify/require"
+)
+
+// analysis builds an analysis of example.com with a server per owner
+func analysis(c *require.Assertions, grade string, down bool, analyzedAt time.Time, owners ...string) *models.Domain {
+	domain, err := models.NewDomain(false, down, "example.com", grade, "", "https://example.com/favicon.ico", "Example")
+	c.NoError(err)
+
+	domain.CreationDate = &analyzedAt
+	domain.UpdateDate = &analyzedAt
+
+	for i, owner := range owners {
+		server, err := models.NewServer(fmt.Sprintf("10.0.0.%d", i+1), "A", "US", owner, domain)
+		c.NoError(err)
+
+		domain.Servers = append(domain.Servers, server)
+	}
+
+	return domain
+}
+
+// expiring attaches to the servers of the analysis a certificate that expires after the days
+func expiring(domain *models.Domain, now time.Time, days ...int) *models.Domain {
+	for i, server := range domain.Servers {
+		notAfter := now.Add(time.Duration(days[i])*24*time.Hour + time.Hour)
+		server.TLS = &models.TLSInspection{Chain: []models.Certificate{{NotAfter: notAfter}}}
+	}
+
+	return domain
+}
+
+// withIPv6 adds to the analysis an IPv6 server with the grade
+func withIPv6(c *require.Assertions, domain *models.Domain, grade string) *models.Domain {
+	server, err := models.NewServer("2001:db8::1", grade, "US", "ACME", domain)
+	c.NoError(err)
+
+	domain.Servers = append(domain.Servers, server)
+
+	return domain
+}
+
+// withLogo sets the cached logo of the analysis
+func withLogo(domain *models.Domain, content string) *models.Domain {
+	domain.Logo = models.LogoPath + models.NewLogoImage("https://example.com/favicon.ico", "image/x-icon", []byte(content)).Hash
+
+	return domain
+}
+
+func TestEvaluate(t *testing.T) {
+	c := require.New(t)
+
+	now := time.Now()
+	hour := func(h int) time.Time { return now.Add(-time.Duration(h) * time.Hour) }
+
+	rule := func(kind string, change func(rule *models.Rule)) *models.Rule {
+		rule, err := models.NewRule("rule", kind)
+		c.NoError(err)
+
+		if change != nil {
+			change(rule)
+		}
+
+		return rule
+	}
+
+	cases := []struct {
+		name    string
+		rule    *models.Rule
+		history []*models.Domain
+		fired   bool
+	}{
+		{"grade below", rule(models.RuleGradeBelow, nil), []*models.Domain{analysis(c, "C", false, hour(0), "ACME")}, true},
+		{"grade equal", rule(models.RuleGradeBelow, nil), []*models.Domain{analysis(c, "B", false, hour(0), "ACME")}, false},
+		{"grade above", rule(models.RuleGradeBelow, nil), []*models.Domain{analysis(c, "A+", false, hour(0), "ACME")}, false},
+		{"without grade", rule(models.RuleGradeBelow, nil), []*models.Domain{analysis(c, "", false, hour(0), "ACME")}, false},
+		{"unknown grade", rule(models.RuleGradeBelow, nil), []*models.Domain{analysis(c, "unknown", false, hour(0), "ACME")}, false},
+		{"owner changed", rule(models.RuleOwnerChanged, nil), []*models.Domain{
+			analysis(c, "A", false, hour(0), "OTHER"),
+			analysis(c, "A", false, hour(1), "ACME"),
+		}, true},
+		{"owner unknown", rule(models.RuleOwnerChanged, nil), []*models.Domain{
+			analysis(c, "A", false, hour(0), "unknown"),
+			analysis(c, "A", false, hour(1), "ACME"),
+		}, false},
+		{"owner without previous", rule(models.RuleOwnerChanged, nil), []*models.Domain{analysis(c, "A", false, hour(0), "ACME")}, false},
+		{"servers dropped", rule(models.RuleServerCountDrop, nil), []*models.Domain{
+			analysis(c, "A", false, hour(0), "ACME"),
+			analysis(c, "A", false, hour(1), "ACME", "ACME", "ACME"),
+		}, true},
+		{"servers dropped by half", rule(models.RuleServerCountDrop, nil), []*models.Domain{
+			analysis(c, "A", false, hour(0), "ACME"),
+			analysis(c, "A", false, hour(1), "ACME", "ACME"),
+		}, false},
+		{"down three times", rule(models.RuleDownConsecutive, nil), []*models.Domain{
+			analysis(c, "", true, hour(0)),
+			analysis(c, "", true, hour(1)),
+			analysis(c, "", true, hour(2)),
+		}, true},
+		{"down twice", rule(models.RuleDownConsecutive, nil), []*models.Domain{
+			analysis(c, "", true, hour(0)),
+			analysis(c, "", true, hour(1)),
+			analysis(c, "A", false, hour(2)),
+			analysis(c, "", true, hour(3)),
+		}, false},
+		{"grade dropped twice", rule(models.RuleGradeDrops, nil), []*models.Domain{
+			analysis(c, "C", false, hour(0)),
+			analysis(c, "A", false, hour(1)),
+			analysis(c, "A+", false, hour(2)),
+			analysis(c, "B", false, hour(3)),
+			analysis(c, "A", false, hour(4)),
+		}, true},
+		{"grade dropped out of the window", rule(models.RuleGradeDrops, func(rule *models.Rule) { rule.Window = 3600 * 2 }), []*models.Domain{
+			analysis(c, "C", false, hour(0)),
+			analysis(c, "A", false, hour(3)),
+			analysis(c, "A+", false, hour(4)),
+			analysis(c, "B", false, hour(5)),
+			analysis(c, "A", false, hour(6)),
+		}, false},
+		{"certificate expires", rule(models.RuleCertExpiry, nil), []*models.Domain{
+			expiring(analysis(c, "A", false, hour(0), "ACME", "ACME"), now, 90, 30),
+		}, true},
+		{"certificate expired", rule(models.RuleCertExpiry, nil), []*models.Domain{
+			expiring(analysis(c, "A", false, hour(0), "ACME"), now, -2),
+		}, true},
+		{"certificate valid", rule(models.RuleCertExpiry, func(rule *models.Rule) { rule.Threshold = 7 }), []*models.Domain{
+			expiring(analysis(c, "A", false, hour(0), "ACME"), now, 8),
+		}, false},
+		{"certificate not inspected", rule(models.RuleCertExpiry, nil), []*models.Domain{analysis(c, "A", false, hour(0), "ACME")}, false},
+		{"ipv6 missing", rule(models.RuleDualStack, nil), []*models.Domain{analysis(c, "A", false, hour(0), "ACME")}, true},
+		{"ipv6 with another grade", rule(models.RuleDualStack, nil), []*models.Domain{
+			withIPv6(c, analysis(c, "A", false, hour(0), "ACME"), "B"),
+		}, true},
+		{"dual stack parity", rule(models.RuleDualStack, nil), []*models.Domain{
+			withIPv6(c, analysis(c, "A", false, hour(0), "ACME"), "A"),
+		}, false},
+		{"logo changed", rule(models.RuleLogoChanged, nil), []*models.Domain{
+			withLogo(analysis(c, "A", false, hour(0)), "new"),
+			withLogo(analysis(c, "A", false, hour(1)), "old"),
+		}, true},
+		{"logo did not change", rule(models.RuleLogoChanged, nil), []*models.Domain{
+			withLogo(analysis(c, "A", false, hour(0)), "old"),
+			withLogo(analysis(c, "A", false, hour(1)), "old"),
+		}, false},
+		{"logo cached for the first time", rule(models.RuleLogoChanged, nil), []*models.Domain{
+			withLogo(analysis(c, "A", false, hour(0)), "new"),
+			analysis(c, "A", false, hour(1)),
+		}, false},
+		{"dual stack without servers", rule(models.RuleDualStack, nil), []*models.Domain{analysis(c, "A", true, hour(0))}, false},
+	}
+
+	for _, tc := range cases {
+		outcome := Evaluate(tc.rule, tc.history, now)
+		c.Equal(tc.fired, outcome.Fired, "%s: %s", tc.name, outcome.Message)
+		c.NotEmpty(outcome.Message, tc.name)
+	}
+
+	c.False(Evaluate(rule(models.RuleGradeBelow, nil), nil, now).Fired)
+}
@@ -0,0 +1,117 @@
+package rules
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/other_project/crockroach/internal/storage"
+	"github.com/other_project/crockroach/models"
+)
+
+// Store persists the rules, their evaluations and the alerts
+type Store interface {
+	ListAnalyses(ctx context.Context, domainName string, limit, offset int64) ([]*models.Domain, error)
+	ListRulesForDomain(ctx context.Context, domainName string) ([]*models.Rule, error)
+	GetLastRuleEvaluation(ctx context.Context, ruleID, domainName string) (*models.RuleEvaluation, error)
+	StoreRuleEvaluation(ctx context.Context, evaluation *models.RuleEvaluation) (*models.RuleEvaluation, error)
+	StoreAlert(ctx context.Context, alert *models.Alert) (*models.Alert, error)
+}
+
+// Evaluator applies the rules after every analysis
+type Evaluator struct {
+	store Store
+	now   func() time.Time
+}
+
+// NewEvaluator creates an evaluator that saves the results in the store
+func NewEvaluator(store Store) *Evaluator {
+	return &Evaluator{store: store, now: time.Now}
+}
+
+// EvaluateAnalysis applies the rules of the domain to the stored analysis and saves every evaluation.
+// An alert is created when a rule fires and it did not fire for the previous analysis
+func (e *Evaluator) EvaluateAnalysis(ctx context.Context, analysis *models.Domain) ([]*models.Alert, error) {
+	rules, err := e.store.ListRulesForDomain(ctx, analysis.DomainName)
+	if err != nil || len(rules) == 0 {
+		return nil, err
+	}
+
+	history, err := e.store.ListAnalyses(ctx, analysis.DomainName, HistorySize, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// the stored analyses do not carry the certificates, the analysis being evaluated does
+	if len(history) > 0 && history[0].DomainID == analysis.DomainID {
+		history[0] = analysis
+	} else {
+		history = append([]*models.Domain{analysis}, history...)
+	}
+
+	now := e.now()
+	alerts := []*models.Alert{}
+
+	for _, rule := range rules {
+		alert, err := e.evaluate(ctx, rule, history, now)
+		if err != nil {
+			return alerts, err
+		}
+
+		if alert != nil {
+			alerts = append(alerts, alert)
+		}
+	}
+
+	return alerts, nil
+}
+
+// evaluate saves the evaluation of a rule and returns the alert when the rule started firing
+func (e *Evaluator) evaluate(ctx context.Context, rule *models.Rule, history []*models.Domain, now time.Time) (*models.Alert, error) {
+	analysis := history[0]
+	outcome := Evaluate(rule, history, now)
+
+	previous, err := e.store.GetLastRuleEvaluation(ctx, rule.RuleID, analysis.DomainName)
+	if err != nil && !errors.Is(err, storage.ErrRuleEvaluationNotFound) {
+		return nil, err
+	}
+
+	evaluationID, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+
+	evaluation, err := e.store.StoreRuleEvaluation(ctx, &models.RuleEvaluation{
+		EvaluationID: evaluationID.String(),
+		RuleID:       rule.RuleID,
+		AnalysisID:   analysis.DomainID,
+		DomainName:   analysis.DomainName,
+		Fired:        outcome.Fired,
+		Message:      outcome.Message,
+		CreationDate: &now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !outcome.Fired || (previous != nil && previous.Fired) {
+		return nil, nil
+	}
+
+	alertID, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+
+	return e.store.StoreAlert(ctx, &models.Alert{
+		AlertID:      alertID.String(),
+		RuleID:       rule.RuleID,
+		RuleName:     rule.Name,
+		EvaluationID: evaluation.EvaluationID,
+		AnalysisID:   analysis.DomainID,
+		DomainName:   analysis.DomainName,
+		Message:      outcome.Message,
+		CreationDate: &now,
+	})
+}
@@ -0,0 +1,130 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/other_project/crockroach/internal/storage"
+	"github.com/other_project/crockroach/models"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore is an in memory Store
+type memoryStore struct {
+	analyses    []*models.Domain
+	rules       []*models.Rule
+	evaluations []*models.RuleEvaluation
+	alerts      []*models.Alert
+}
+
+func (m *memoryStore) ListAnalyses(ctx context.Context, domainName string, limit, offset int64) ([]*models.Domain, error) {
+	return m.analyses, nil
+}
+
+func (m *memoryStore) ListRulesForDomain(ctx context.Context, domainName string) ([]*models.Rule, error) {
+	items := []*models.Rule{}
+
+	for _, rule := range m.rules {
+		if rule.AppliesTo(domainName) {
+			items = append(items, rule)
+		}
+	}
+
+	return items, nil
+}
+
+func (m *memoryStore) GetLastRuleEvaluation(ctx context.Context, ruleID, domainName string) (*models.RuleEvaluation, error) {
+	for i := len(m.evaluations) - 1; i >= 0; i-- {
+		if m.evaluations[i].RuleID == ruleID && m.evaluations[i].DomainName == domainName {
+			return m.evaluations[i], nil
+		}
+	}
+
+	return nil, storage.ErrRuleEvaluationNotFound
+}
+
+func (m *memoryStore) StoreRuleEvaluation(ctx context.Context, evaluation *models.RuleEvaluation) (*models.RuleEvaluation, error) {
+	m.evaluations = append(m.evaluations, evaluation)
+
+	return evaluation, nil
+}
+
+func (m *memoryStore) StoreAlert(ctx context.Context, alert *models.Alert) (*models.Alert, error) {
+	m.alerts = append(m.alerts, alert)
+
+	return alert, nil
+}
+
+func TestEvaluateAnalysis(t *testing.T) {
+	c := require.New(t)
+
+	gradeRule, err := models.NewRule("grade", models.RuleGradeBelow)
+	c.NoError(err)
+
+	otherRule, err := models.NewRule("other domain", models.RuleGradeBelow)
+	c.NoError(err)
+
+	otherRule.DomainName = "other.com"
+
+	store := &memoryStore{rules: []*models.Rule{gradeRule, otherRule}}
+	evaluator := NewEvaluator(store)
+
+	grades := []struct {
+		grade string
+		alert bool
+	}{
+		{"A", false},
+		{"C", true},
+		{"F", false},
+		{"A", false},
+		{"C", true},
+	}
+
+	for _, step := range grades {
+		current := analysis(c, step.grade, false, time.Now(), "ACME")
+		store.analyses = append([]*models.Domain{current}, store.analyses...)
+
+		alerts, err := evaluator.EvaluateAnalysis(context.Background(), current)
+		c.NoError(err)
+
+		if step.alert {
+			c.Len(alerts, 1, "grade %s", step.grade)
+			c.Equal(current.DomainID, alerts[0].AnalysisID)
+			c.Equal(gradeRule.Name, alerts[0].RuleName)
+		} else {
+			c.Empty(alerts, "grade %s", step.grade)
+		}
+	}
+
+	c.Len(store.evaluations, len(grades), "every analysis is evaluated, the rule of other.com is not")
+	c.Len(store.alerts, 2, "an alert is created when the rule starts firing")
+
+	// the analysis is added to the history when it was not stored yet
+	current := analysis(c, "A", false, time.Now(), "ACME")
+	_, err = evaluator.EvaluateAnalysis(context.Background(), current)
+	c.NoError(err)
+	c.Equal(current.DomainID, store.evaluations[len(store.evaluations)-1].AnalysisID)
+	c.False(store.evaluations[len(store.evaluations)-1].Fired)
+}
+
+func TestEvaluateAnalysisCertificates(t *testing.T) {
+	c := require.New(t)
+
+	rule, err := models.NewRule("certificate", models.RuleCertExpiry)
+	c.NoError(err)
+
+	now := time.Now()
+	current := expiring(analysis(c, "A", false, now, "ACME"), now, 10)
+
+	// the stored analysis does not carry the certificates
+	stored := *current
+	stored.Servers = []*models.Server{{ServerID: current.Servers[0].ServerID, Address: current.Servers[0].Address}}
+
+	store := &memoryStore{rules: []*models.Rule{rule}, analyses: []*models.Domain{&stored}}
+
+	alerts, err := NewEvaluator(store).EvaluateAnalysis(context.Background(), current)
+	c.NoError(err)
+	c.Len(alerts, 1)
+	c.Contains(alerts[0].Message, "10.0.0.1 in 10 days")
+}
@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/other_project/crockroach/internal/apperr"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/models"
+)
+
+const (
+	createAPIKey = `
+	INSERT INTO api_keys (
+		id,
+		name,
+		prefix,
+		hash,
+		scopes,
+		creationDate
+	) VALUES (
+		$1, $2, $3, $4, $5, $6
+	) RETURNING id, name, prefix, hash, scopes, creationdate, revokeddate;
+	`
+
+	getAPIKeyByHash = `
+	SELECT id, name, prefix, hash, scopes, creationdate, revokeddate
+	FROM api_keys
+	WHERE hash = $1 LIMIT 1
+	`
+
+	listAPIKeys = `
+	SELECT id, name, prefix, hash, scopes, creationdate, revokeddate
+	FROM api_keys
+	ORDER BY creationdate
+	`
+
+	revokeAPIKey = `
+	UPDATE api_keys
+	SET revokeddate = now()
+	WHERE id = $1 AND revokeddate IS NULL
+	`
+
+	// scopesSeparator joins the scopes of a key in a single column
+	scopesSeparator = ","
+)
+
+var (
+	// ErrInvalidAPIKey to ensure if exists the key
+	ErrInvalidAPIKey = apperr.New(apperr.Internal, "invalid_api_key_object", "invalid api key object")
+	// ErrAPIKeyNotFound when the key does not exist or was revoked
+	ErrAPIKeyNotFound = apperr.New(apperr.NotFound, "api_key_not_found", "api key was not found")
+)
+
+// rowScanner is implemented by sql.Row and sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// StoreAPIKey function will store a key, only its hash is saved
+func (q *Queries) StoreAPIKey(ctx context.Context, key *models.APIKey) (*models.APIKey, error) {
+	if key == nil {
+		logs.Log().Errorf("cannot store api key in database %s ", ErrInvalidAPIKey.Error())
+		return nil, ErrInvalidAPIKey
+	}
+
+	row := CockroachClient.QueryRowContext(ctx, createAPIKey, key.KeyID, key.Name, key.Prefix, key.Hash, strings.Join(key.Scopes, scopesSeparator), key.CreationDate)
+	if row.Err() != nil {
+		logs.Log().Errorf("Query error %s", row.Err())
+		return nil, ErrInvalidQuery
+	}
+
+	return scanAPIKey(row)
+}
+
+// GetAPIKeyByHash function will get a key by the hash of its secret
+func (q *Queries) GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	row := CockroachClient.QueryRowContext(ctx, getAPIKeyByHash, hash)
+	if row.Err() != nil {
+		logs.Log().Errorf("Query error %s", row.Err())
+		return nil, ErrInvalidQuery
+	}
+
+	key, err := scanAPIKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAPIKeyNotFound
+	}
+
+	return key, err
+}
+
+// ListAPIKeys function will list every key, including the revoked ones
+func (q *Queries) ListAPIKeys(ctx context.Context) ([]*models.APIKey, error) {
+	rows, err := CockroachClient.QueryContext(ctx, listAPIKeys)
+	if err != nil {
+		logs.Log().Errorf("Query error %s", err.Error())
+		return nil, ErrInvalidQuery
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logs.Log().Errorf("Row error close %s", err.Error())
+		}
+	}()
+
+	items := []*models.APIKey{}
+
+	for rows.Next() {
+		item, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		logs.Log().Errorf("Row error %s", err.Error())
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// RevokeAPIKey function will revoke a key, the record is kept
+func (q *Queries) RevokeAPIKey(ctx context.Context, keyID string) error {
+	result, err := CockroachClient.ExecContext(ctx, revokeAPIKey, keyID)
+	if err != nil {
+		logs.Log().Errorf("Query error %s", err.Error())
+		return ErrInvalidQuery
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+// scanAPIKey copies the columns of a key
+func scanAPIKey(row rowScanner) (*models.APIKey, error) {
+	item := new(models.APIKey)
+
+	var scopes string
+	var revoked sql.NullTime
+
+	err := row.Scan(
+		&item.KeyID,
+		&item.Name,
+		&item.Prefix,
+		&item.Hash,
+		&scopes,
+		&item.CreationDate,
+		&revoked)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	if err != nil {
+		logs.Log().Errorf("Scan error %s", err.Error())
+		return nil, ErrScanRow
+	}
+
+	item.Scopes = strings.Split(scopes, scopesSeparator)
+
+	if revoked.Valid {
+		item.RevokedDate = &revoked.Time
+	}
+
+	return item, nil
+}
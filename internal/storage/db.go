@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/other_project/crockroach/models"
 )
@@ -24,10 +25,56 @@ type DBTX interface {
 	UpdateDomain(ctx context.Context, sslgrade, previouSSL string, domain *models.Domain, serverChanged bool) (*models.Domain, error)
 	DeleteDomain(ctx context.Context, domainID string) error
 	GetDomains(ctx context.Context, time string) ([]models.Domain, error)
+	ListAnalyses(ctx context.Context, domainName string, limit, offset int64) ([]*models.Domain, error)
+	GetAnalysis(ctx context.Context, domainID string) (*models.Domain, error)
 	GetRecordByName(domain *models.Domain) (objects []*models.Domain, err error)
 	NewRecord(domain *models.Domain) (*models.LogDomainStatus, error)
 	ReloadRecord(ctx context.Context) (myObjects map[string]*models.LogDomainStatus, err error)
 	GetLastDomain() []*models.Domain
+	ExportDomains(ctx context.Context, from, to time.Time, fn func(*ExportRow) error) error
+	StoreAPIKey(ctx context.Context, key *models.APIKey) (*models.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]*models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, keyID string) error
+	StoreJob(ctx context.Context, job *models.Job) (*models.Job, error)
+	GetJob(ctx context.Context, jobID string) (*models.Job, error)
+	UpdateJob(ctx context.Context, job *models.Job) (*models.Job, error)
+	ListJobsByState(ctx context.Context, states ...string) ([]*models.Job, error)
+	ListJobsByImport(ctx context.Context, importID string) ([]*models.Job, error)
+	StoreImport(ctx context.Context, record *models.Import) (*models.Import, error)
+	GetImport(ctx context.Context, importID string) (*models.Import, error)
+	StoreTrackedDomain(ctx context.Context, tracked *models.TrackedDomain) (*models.TrackedDomain, error)
+	GetTrackedDomain(ctx context.Context, domainName string) (*models.TrackedDomain, error)
+	ListTrackedDomains(ctx context.Context, tag string, limit, offset int64) ([]*models.TrackedDomain, error)
+	ListDueTrackedDomains(ctx context.Context, now time.Time) ([]*models.TrackedDomain, error)
+	UpdateTrackedDomain(ctx context.Context, tracked *models.TrackedDomain) (*models.TrackedDomain, error)
+	MarkTrackedDomainChecked(ctx context.Context, domainName string, checked time.Time) error
+	DeleteTrackedDomain(ctx context.Context, domainName string) error
+	StoreRule(ctx context.Context, rule *models.Rule) (*models.Rule, error)
+	GetRule(ctx context.Context, ruleID string) (*models.Rule, error)
+	ListRules(ctx context.Context, limit, offset int64) ([]*models.Rule, error)
+	ListRulesForDomain(ctx context.Context, domainName string) ([]*models.Rule, error)
+	UpdateRule(ctx context.Context, rule *models.Rule) (*models.Rule, error)
+	DeleteRule(ctx context.Context, ruleID string) error
+	StoreRuleEvaluation(ctx context.Context, evaluation *models.RuleEvaluation) (*models.RuleEvaluation, error)
+	GetLastRuleEvaluation(ctx context.Context, ruleID, domainName string) (*models.RuleEvaluation, error)
+	ListRuleEvaluations(ctx context.Context, ruleID string, limit, offset int64) ([]*models.RuleEvaluation, error)
+	StoreAlert(ctx context.Context, alert *models.Alert) (*models.Alert, error)
+	ListAlerts(ctx context.Context, domainName string, limit, offset int64) ([]*models.Alert, error)
+	StoreUptimeCheck(ctx context.Context, check *models.UptimeCheck) (*models.UptimeCheck, error)
+	ListUptimeChecks(ctx context.Context, domainName string, since time.Time) ([]*models.UptimeCheck, error)
+	StoreTLSInspection(ctx context.Context, inspection *models.TLSInspection) (*models.TLSInspection, error)
+	GetTLSInspection(ctx context.Context, serverID string) (*models.TLSInspection, error)
+	ListTLSInspections(ctx context.Context, analysisID string) ([]*models.TLSInspection, error)
+	StoreDNSSnapshot(ctx context.Context, snapshot *models.DNSSnapshot) (*models.DNSSnapshot, error)
+	GetLastDNSSnapshot(ctx context.Context, domainName string) (*models.DNSSnapshot, error)
+	ListDNSSnapshots(ctx context.Context, domainName string, limit, offset int64) ([]*models.DNSSnapshot, error)
+	StoreLogo(ctx context.Context, logo *models.LogoImage) (*models.LogoImage, error)
+	GetLogo(ctx context.Context, hash string) (*models.LogoImage, error)
+	StorePageSnapshot(ctx context.Context, snapshot *models.PageSnapshot) (*models.PageSnapshot, error)
+	GetPageSnapshot(ctx context.Context, id string) (*models.PageSnapshot, error)
+	GetLastPageSnapshot(ctx context.Context, domainName string) (*models.PageSnapshot, error)
+	ListPageSnapshots(ctx context.Context, domainName string, limit, offset int64) ([]*models.PageSnapshot, error)
 
 	/*
 		ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
@@ -107,6 +154,16 @@ func GetDomains(ctx context.Context, time string) ([]models.Domain, error) {
 	return Default.GetDomains(ctx, time)
 }
 
+// ListAnalyses function will list the analyses of a domain name
+func ListAnalyses(ctx context.Context, domainName string, limit, offset int64) ([]*models.Domain, error) {
+	return Default.ListAnalyses(ctx, domainName, limit, offset)
+}
+
+// GetAnalysis function will retrieve an analysis with its servers
+func GetAnalysis(ctx context.Context, domainID string) (*models.Domain, error) {
+	return Default.GetAnalysis(ctx, domainID)
+}
+
 // GetRecordByName function will list all the domains by name
 func GetRecordByName(domain *models.Domain) (objects []*models.Domain, err error) {
 	return Default.GetRecordByName(domain)
@@ -127,7 +184,227 @@ func GetLastDomain() []*models.Domain {
 	return Default.GetLastDomain()
 }
 
+// ExportDomains function will stream the analyses updated between from and to
+func ExportDomains(ctx context.Context, from, to time.Time, fn func(*ExportRow) error) error {
+	return Default.ExportDomains(ctx, from, to, fn)
+}
+
+// StoreAPIKey function will store a key in the database.
+func StoreAPIKey(ctx context.Context, key *models.APIKey) (*models.APIKey, error) {
+	return Default.StoreAPIKey(ctx, key)
+}
+
+// GetAPIKeyByHash function will retrieve a key by the hash of its secret
+func GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	return Default.GetAPIKeyByHash(ctx, hash)
+}
+
+// ListAPIKeys function will list all the keys
+func ListAPIKeys(ctx context.Context) ([]*models.APIKey, error) {
+	return Default.ListAPIKeys(ctx)
+}
+
+// RevokeAPIKey function will revoke a key
+func RevokeAPIKey(ctx context.Context, keyID string) error {
+	return Default.RevokeAPIKey(ctx, keyID)
+}
+
+// StoreJob function will store a job in the database.
+func StoreJob(ctx context.Context, job *models.Job) (*models.Job, error) {
+	return Default.StoreJob(ctx, job)
+}
+
+// GetJob function will retrieve a job in the database.
+func GetJob(ctx context.Context, jobID string) (*models.Job, error) {
+	return Default.GetJob(ctx, jobID)
+}
+
+// UpdateJob function will update a job
+func UpdateJob(ctx context.Context, job *models.Job) (*models.Job, error) {
+	return Default.UpdateJob(ctx, job)
+}
+
+// ListJobsByState function will list the jobs in the states
+func ListJobsByState(ctx context.Context, states ...string) ([]*models.Job, error) {
+	return Default.ListJobsByState(ctx, states...)
+}
+
+// ListJobsByImport function will list the jobs of an import
+func ListJobsByImport(ctx context.Context, importID string) ([]*models.Job, error) {
+	return Default.ListJobsByImport(ctx, importID)
+}
+
+// StoreImport function will store an import of domains
+func StoreImport(ctx context.Context, record *models.Import) (*models.Import, error) {
+	return Default.StoreImport(ctx, record)
+}
+
+// GetImport function will get an import by its id
+func GetImport(ctx context.Context, importID string) (*models.Import, error) {
+	return Default.GetImport(ctx, importID)
+}
+
+// StoreTrackedDomain function will add a domain to the watchlist.
+func StoreTrackedDomain(ctx context.Context, tracked *models.TrackedDomain) (*models.TrackedDomain, error) {
+	return Default.StoreTrackedDomain(ctx, tracked)
+}
+
+// GetTrackedDomain function will retrieve a tracked domain by its name.
+func GetTrackedDomain(ctx context.Context, domainName string) (*models.TrackedDomain, error) {
+	return Default.GetTrackedDomain(ctx, domainName)
+}
+
+// ListTrackedDomains function will list the tracked domains with the tag
+func ListTrackedDomains(ctx context.Context, tag string, limit, offset int64) ([]*models.TrackedDomain, error) {
+	return Default.ListTrackedDomains(ctx, tag, limit, offset)
+}
+
+// ListDueTrackedDomains function will list the tracked domains that must be analyzed
+func ListDueTrackedDomains(ctx context.Context, now time.Time) ([]*models.TrackedDomain, error) {
+	return Default.ListDueTrackedDomains(ctx, now)
+}
+
+// UpdateTrackedDomain function will save the settings of a tracked domain.
+func UpdateTrackedDomain(ctx context.Context, tracked *models.TrackedDomain) (*models.TrackedDomain, error) {
+	return Default.UpdateTrackedDomain(ctx, tracked)
+}
+
+// MarkTrackedDomainChecked function will save when the domain was submitted for analysis
+func MarkTrackedDomainChecked(ctx context.Context, domainName string, checked time.Time) error {
+	return Default.MarkTrackedDomainChecked(ctx, domainName, checked)
+}
+
+// DeleteTrackedDomain function will remove a domain from the watchlist.
+func DeleteTrackedDomain(ctx context.Context, domainName string) error {
+	return Default.DeleteTrackedDomain(ctx, domainName)
+}
+
+// StoreRule function will store a rule in the database.
+func StoreRule(ctx context.Context, rule *models.Rule) (*models.Rule, error) {
+	return Default.StoreRule(ctx, rule)
+}
+
+// GetRule function will retrieve a rule in the database.
+func GetRule(ctx context.Context, ruleID string) (*models.Rule, error) {
+	return Default.GetRule(ctx, ruleID)
+}
+
+// ListRules function will list the rules
+func ListRules(ctx context.Context, limit, offset int64) ([]*models.Rule, error) {
+	return Default.ListRules(ctx, limit, offset)
+}
+
+// ListRulesForDomain function will list the enabled rules of the domain
+func ListRulesForDomain(ctx context.Context, domainName string) ([]*models.Rule, error) {
+	return Default.ListRulesForDomain(ctx, domainName)
+}
+
+// UpdateRule function will save the settings of a rule.
+func UpdateRule(ctx context.Context, rule *models.Rule) (*models.Rule, error) {
+	return Default.UpdateRule(ctx, rule)
+}
+
+// DeleteRule function will delete a rule in the database.
+func DeleteRule(ctx context.Context, ruleID string) error {
+	return Default.DeleteRule(ctx, ruleID)
+}
+
+// StoreRuleEvaluation function will store the result of a rule.
+func StoreRuleEvaluation(ctx context.Context, evaluation *models.RuleEvaluation) (*models.RuleEvaluation, error) {
+	return Default.StoreRuleEvaluation(ctx, evaluation)
+}
+
+// GetLastRuleEvaluation function will retrieve the last result of the rule for the domain
+func GetLastRuleEvaluation(ctx context.Context, ruleID, domainName string) (*models.RuleEvaluation, error) {
+	return Default.GetLastRuleEvaluation(ctx, ruleID, domainName)
+}
+
+// ListRuleEvaluations function will list the results of a rule
+func ListRuleEvaluations(ctx context.Context, ruleID string, limit, offset int64) ([]*models.RuleEvaluation, error) {
+	return Default.ListRuleEvaluations(ctx, ruleID, limit, offset)
+}
+
+// StoreAlert function will store an alert in the database.
+func StoreAlert(ctx context.Context, alert *models.Alert) (*models.Alert, error) {
+	return Default.StoreAlert(ctx, alert)
+}
+
+// ListAlerts function will list the alerts of the domain
+func ListAlerts(ctx context.Context, domainName string, limit, offset int64) ([]*models.Alert, error) {
+	return Default.ListAlerts(ctx, domainName, limit, offset)
+}
+
+// StoreUptimeCheck function will store the availability check of an analysis.
+func StoreUptimeCheck(ctx context.Context, check *models.UptimeCheck) (*models.UptimeCheck, error) {
+	return Default.StoreUptimeCheck(ctx, check)
+}
+
+// ListUptimeChecks function will list the availability checks of the domain
+func ListUptimeChecks(ctx context.Context, domainName string, since time.Time) ([]*models.UptimeCheck, error) {
+	return Default.ListUptimeChecks(ctx, domainName, since)
+}
+
+// StoreTLSInspection function will store the certificate chain presented by a server.
+func StoreTLSInspection(ctx context.Context, inspection *models.TLSInspection) (*models.TLSInspection, error) {
+	return Default.StoreTLSInspection(ctx, inspection)
+}
+
+// GetTLSInspection function will get the last inspection of the certificate of the server
+func GetTLSInspection(ctx context.Context, serverID string) (*models.TLSInspection, error) {
+	return Default.GetTLSInspection(ctx, serverID)
+}
+
+// ListTLSInspections function will list the inspections of the servers of an analysis
+func ListTLSInspections(ctx context.Context, analysisID string) ([]*models.TLSInspection, error) {
+	return Default.ListTLSInspections(ctx, analysisID)
+}
+
+// StoreDNSSnapshot function will store the records of the domain resolved during an analysis.
+func StoreDNSSnapshot(ctx context.Context, snapshot *models.DNSSnapshot) (*models.DNSSnapshot, error) {
+	return Default.StoreDNSSnapshot(ctx, snapshot)
+}
+
+// GetLastDNSSnapshot function will get the newest records of the domain
+func GetLastDNSSnapshot(ctx context.Context, domainName string) (*models.DNSSnapshot, error) {
+	return Default.GetLastDNSSnapshot(ctx, domainName)
+}
+
+// ListDNSSnapshots function will list the records of the domain
+func ListDNSSnapshots(ctx context.Context, domainName string, limit, offset int64) ([]*models.DNSSnapshot, error) {
+	return Default.ListDNSSnapshots(ctx, domainName, limit, offset)
+}
+
+// StoreLogo function will store the content of a logo
+func StoreLogo(ctx context.Context, logo *models.LogoImage) (*models.LogoImage, error) {
+	return Default.StoreLogo(ctx, logo)
+}
+
+// GetLogo function will get the logo with the hash
+func GetLogo(ctx context.Context, hash string) (*models.LogoImage, error) {
+	return Default.GetLogo(ctx, hash)
+}
+
+// StorePageSnapshot function will store the page of the domain read during an analysis
+func StorePageSnapshot(ctx context.Context, snapshot *models.PageSnapshot) (*models.PageSnapshot, error) {
+	return Default.StorePageSnapshot(ctx, snapshot)
+}
+
+// GetPageSnapshot function will get the page snapshot with the id
+func GetPageSnapshot(ctx context.Context, id string) (*models.PageSnapshot, error) {
+	return Default.GetPageSnapshot(ctx, id)
+}
+
+// GetLastPageSnapshot function will get the newest page of the domain
+func GetLastPageSnapshot(ctx context.Context, domainName string) (*models.PageSnapshot, error) {
+	return Default.GetLastPageSnapshot(ctx, domainName)
+}
+
+// ListPageSnapshots function will list the pages of the domain
+func ListPageSnapshots(ctx context.Context, domainName string, limit, offset int64) ([]*models.PageSnapshot, error) {
+	return Default.ListPageSnapshots(ctx, domainName, limit, offset)
+}
+
 func init() {
 	Default = &Queries{}
-	CockroachClient = sql.DB{}
+	CockroachClient = &sql.DB{}
 }
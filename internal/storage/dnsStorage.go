@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/other_project/crockroach/internal/apperr"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/models"
+)
+
+const (
+	dnsColumns = `id, analysis_id, domain_name, resolver, records, errors, changes, creationdate`
+
+	createDNSSnapshot = `
+	INSERT INTO dns_snapshots (
+		id,
+		analysis_id,
+		domain_name,
+		resolver,
+		records,
+		errors,
+		changes,
+		creationDate
+	) VALUES (
+		$1, $2, $3, $4, $5, $6, $7, $8
+	) RETURNING ` + dnsColumns + `;
+	`
+
+	listDNSSnapshots = `
+	SELECT ` + dnsColumns + `
+	FROM dns_snapshots
+	WHERE domain_name = $1
+	ORDER BY creationdate DESC
+	LIMIT $2 OFFSET $3
+	`
+)
+
+var (
+	// ErrInvalidDNSSnapshot to ensure if exists the dns snapshot
+	ErrInvalidDNSSnapshot = apperr.New(apperr.Internal, "invalid_dns_snapshot", "invalid dns snapshot object")
+	// ErrDNSSnapshotNotFound when the records of the domain were not resolved
+	ErrDNSSnapshotNotFound = apperr.New(apperr.NotFound, "dns_snapshot_not_found", "the records of the domain were not resolved")
+)
+
+// StoreDNSSnapshot function will store the records of the domain resolved during an analysis
+func (q *Queries) StoreDNSSnapshot(ctx context.Context, snapshot *models.DNSSnapshot) (*models.DNSSnapshot, error) {
+	if snapshot == nil {
+		logs.Log().Errorf("cannot store dns snapshot in database %s ", ErrInvalidDNSSnapshot.Error())
+		return nil, ErrInvalidDNSSnapshot
+	}
+
+	records, err := json.Marshal(snapshot.Records)
+	if err != nil {
+		return nil, ErrInvalidDNSSnapshot
+	}
+
+	failures, err := json.Marshal(snapshot.Errors)
+	if err != nil {
+		return nil, ErrInvalidDNSSnapshot
+	}
+
+	changes, err := json.Marshal(snapshot.Changes)
+	if err != nil {
+		return nil, ErrInvalidDNSSnapshot
+	}
+
+	row := CockroachClient.QueryRowContext(ctx, createDNSSnapshot,
+		snapshot.SnapshotID,
+		snapshot.AnalysisID,
+		snapshot.DomainName,
+		snapshot.Resolver,
+		string(records),
+		string(failures),
+		string(changes),
+		snapshot.CreationDate)
+	if row.Err() != nil {
+		logs.Log().Errorf("Query error %s", row.Err())
+		return nil, ErrInvalidQuery
+	}
+
+	return scanDNSSnapshot(row)
+}
+
+// GetLastDNSSnapshot function will get the newest records of the domain
+func (q *Queries) GetLastDNSSnapshot(ctx context.Context, domainName string) (*models.DNSSnapshot, error) {
+	row := CockroachClient.QueryRowContext(ctx, listDNSSnapshots, domainName, 1, 0)
+	if row.Err() != nil {
+		logs.Log().Errorf("Query error %s", row.Err())
+		return nil, ErrInvalidQuery
+	}
+
+	snapshot, err := scanDNSSnapshot(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDNSSnapshotNotFound
+	}
+
+	return snapshot, err
+}
+
+// ListDNSSnapshots function will list the records of the domain, the newest first
+func (q *Queries) ListDNSSnapshots(ctx context.Context, domainName string, limit, offset int64) ([]*models.DNSSnapshot, error) {
+	rows, err := CockroachClient.QueryContext(ctx, listDNSSnapshots, domainName, limit, offset)
+	if err != nil {
+		logs.Log().Errorf("Query error %s", err.Error())
+		return nil, ErrInvalidQuery
+	}
+
+	defer closeRows(rows)
+
+	items := []*models.DNSSnapshot{}
+
+	for rows.Next() {
+		item, err := scanDNSSnapshot(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		logs.Log().Errorf("Row error %s", err.Error())
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// scanDNSSnapshot copies the columns of a dns snapshot
+func scanDNSSnapshot(row rowScanner) (*models.DNSSnapshot, error) {
+	item := new(models.DNSSnapshot)
+
+	var records, failures, changes []byte
+
+	err := row.Scan(
+		&item.SnapshotID,
+		&item.AnalysisID,
+		&item.DomainName,
+		&item.Resolver,
+		&records,
+		&failures,
+		&changes,
+		&item.CreationDate)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	if err != nil {
+		logs.Log().Errorf("Scan error %s", err.Error())
+		return nil, ErrScanRow
+	}
+
+	for _, column := range []struct {
+		raw    []byte
+		target interface{}
+	}{{records, &item.Records}, {failures, &item.Errors}, {changes, &item.Changes}} {
+		if len(column.raw) == 0 {
+			continue
+		}
+
+		if err := json.Unmarshal(column.raw, column.target); err != nil {
+			logs.Log().Errorf("Scan error dns snapshot %s", err.Error())
+			return nil, ErrScanRow
+		}
+	}
+
+	return item, nil
+}
@@ -5,11 +5,14 @@ import (
 	"database/sql"
 	"errors"
 
+	"github.com/other_project/crockroach/internal/apperr"
 	"github.com/other_project/crockroach/internal/logs"
 	"github.com/other_project/crockroach/models"
 )
 
 const (
+	domainColumns = `id, domain_name, serverchanged, sslgrade, previousslgrade, logo, title, isdown, creationdate, updatedate, logo_changed`
+
 	createDomain = `
 	INSERT INTO domains (
 		id,
@@ -21,28 +24,38 @@ const (
 		title,
 		isdown,
 		creationDate,
-		updateDate
+		updateDate,
+		logo_changed
 	) VALUES (
-		$1, $2, $3, $4, $5, $6, $7, $8, $9, $10
-	) RETURNING *;
+		$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+	) RETURNING ` + domainColumns + `;
 	`
 
 	listDomains = `
-	SELECT * FROM domains
+	SELECT ` + domainColumns + ` FROM domains
 	ORDER BY id
 	LIMIT $1
 	OFFSET $2
 	`
 
 	listDomainsByDate = `
-	SELECT id, domain_name, serverchanged, sslgrade, previousslgrade, logo, title, isdown, creationdate, updatedate 
+	SELECT ` + domainColumns + `
 	FROM domains
 	WHERE domains.updatedate >= now() - '1 hours'::INTERVAL 
     AND domains.updatedate <= now()
 	`
 
+	listAnalysesByName = `
+	SELECT ` + domainColumns + `
+	FROM domains
+	WHERE domain_name = $1
+	ORDER BY updatedate DESC, id
+	LIMIT $2
+	OFFSET $3
+	`
+
 	getDomain = `
-	SELECT * FROM domains
+	SELECT ` + domainColumns + ` FROM domains
 	WHERE id = $1
 	ORDER BY sslgrade DESC
 	LIMIT 1
@@ -66,9 +79,9 @@ const (
 
 	updateDomainN = `
 	UPDATE domains
-	SET sslgrade = $2, previousslgrade = $3, serverchanged = $4, updatedate = now()
+	SET sslgrade = $2, previousslgrade = $3, serverchanged = $4, logo_changed = $5, updatedate = now()
 	WHERE id = $1
-	RETURNING *;
+	RETURNING ` + domainColumns + `;
 	`
 
 	deleteDomain = `
@@ -79,13 +92,13 @@ const (
 
 var (
 	// ErrInvalidDomain to ensure if exists domain
-	ErrInvalidDomain = errors.New("invalid domain object")
+	ErrInvalidDomain = apperr.New(apperr.Internal, "invalid_domain", "invalid domain object")
 	// ErrEmptyDomainID when it's
-	ErrEmptyDomainID = errors.New("cannot be empty domain_id")
+	ErrEmptyDomainID = apperr.New(apperr.Invalid, "empty_domain_id", "cannot be empty domain_id")
 	// ErrDomainNotFound to ensure that domain are returned
-	ErrDomainNotFound = errors.New("domain was not found")
+	ErrDomainNotFound = apperr.New(apperr.NotFound, "domain_not_found", "domain was not found")
 	// ErrEmptyDomain when it does not exist a domain
-	ErrEmptyDomain = errors.New("domain name cannot be empty")
+	ErrEmptyDomain = apperr.New(apperr.Invalid, "empty_domain", "domain name cannot be empty")
 )
 
 // StoreDomain function will store a domain struct
@@ -109,7 +122,7 @@ func (q *Queries) StoreDomain(ctx context.Context, domain *models.Domain) (*mode
 		return nil, ErrInvalidDomain
 	}
 
-	row := tx.QueryRowContext(ctx, createDomain, domain.DomainID, domain.DomainName, domain.ServerChanged, domain.SSLGrade, domain.PreviousSSLGrade, domain.Logo, domain.Title, domain.IsDown, domain.CreationDate, domain.UpdateDate)
+	row := tx.QueryRowContext(ctx, createDomain, domain.DomainID, domain.DomainName, domain.ServerChanged, domain.SSLGrade, domain.PreviousSSLGrade, domain.Logo, domain.Title, domain.IsDown, domain.CreationDate, domain.UpdateDate, domain.LogoChanged)
 	if row.Err() != nil {
 		logs.Log().Errorf("Query error %s", row.Err())
 		return nil, ErrInvalidQuery
@@ -129,6 +142,7 @@ func (q *Queries) StoreDomain(ctx context.Context, domain *models.Domain) (*mode
 		&item.IsDown,
 		&item.CreationDate,
 		&item.UpdateDate,
+		&item.LogoChanged,
 	)
 	if err != nil {
 		logs.Log().Errorf("Scan error %s", err.Error())
@@ -164,7 +178,12 @@ func (q *Queries) GetDomain(ctx context.Context, domainID string) (*models.Domai
 		&item.IsDown,
 		&item.CreationDate,
 		&item.UpdateDate,
+		&item.LogoChanged,
 	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDomainNotFound
+	}
+
 	if err != nil {
 		logs.Log().Errorf("Scan error %s", err.Error())
 		return nil, ErrScanRow
@@ -204,6 +223,7 @@ func (q *Queries) GetDomains(ctx context.Context, time string) ([]models.Domain,
 			&item.IsDown,
 			&item.CreationDate,
 			&item.UpdateDate,
+			&item.LogoChanged,
 		)
 
 		if err != nil {
@@ -263,7 +283,7 @@ func (q *Queries) UpdateDomain(ctx context.Context, sslgrade, previouSSL string,
 		}
 	*/
 
-	row := tx.QueryRowContext(ctx, updateDomainN, domain.DomainID, sslgrade, previouSSL, serverChanged)
+	row := tx.QueryRowContext(ctx, updateDomainN, domain.DomainID, sslgrade, previouSSL, serverChanged, domain.LogoChanged)
 	if row.Err() != nil {
 		logs.Log().Errorf("Query error %s", row.Err())
 		return nil, ErrInvalidQuery
@@ -282,6 +302,7 @@ func (q *Queries) UpdateDomain(ctx context.Context, sslgrade, previouSSL string,
 		&item.IsDown,
 		&item.CreationDate,
 		&item.UpdateDate,
+		&item.LogoChanged,
 	)
 	if err != nil {
 		logs.Log().Errorf("Scan error %s", err.Error())
@@ -312,3 +333,97 @@ func (q *Queries) DeleteDomain(ctx context.Context, domainID string) error {
 
 	return nil
 }
+
+// ListAnalyses function will list the analyses of a domain name with their servers, the newest first
+func (q *Queries) ListAnalyses(ctx context.Context, domainName string, limit, offset int64) ([]*models.Domain, error) {
+	if domainName == "" {
+		return nil, ErrEmptyDomain
+	}
+
+	rows, err := CockroachClient.QueryContext(ctx, listAnalysesByName, domainName, limit, offset)
+	if err != nil {
+		logs.Log().Errorf("Query error %s", err.Error())
+		return nil, ErrInvalidQuery
+	}
+
+	items := []*models.Domain{}
+
+	for rows.Next() {
+		item := new(models.Domain)
+
+		err := rows.Scan(
+			&item.DomainID,
+			&item.DomainName,
+			&item.ServerChanged,
+			&item.SSLGrade,
+			&item.PreviousSSLGrade,
+			&item.Logo,
+			&item.Title,
+			&item.IsDown,
+			&item.CreationDate,
+			&item.UpdateDate,
+			&item.LogoChanged,
+		)
+		if err != nil {
+			logs.Log().Errorf("Scan error %s", err.Error())
+			_ = rows.Close()
+
+			return nil, ErrScanRow
+		}
+
+		items = append(items, item)
+	}
+
+	if err := rows.Close(); err != nil {
+		logs.Log().Errorf("Row error close %s", err.Error())
+		return nil, err
+	}
+
+	if err := rows.Err(); err != nil {
+		logs.Log().Errorf("Row error %s", err.Error())
+		return nil, err
+	}
+
+	for _, item := range items {
+		err = q.loadServers(ctx, item)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return items, nil
+}
+
+// GetAnalysis function will get an analysis with its servers by domainID
+func (q *Queries) GetAnalysis(ctx context.Context, domainID string) (*models.Domain, error) {
+	item, err := q.GetDomain(ctx, domainID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = q.loadServers(ctx, item)
+	if err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// loadServers sets the servers of the analysis with the certificates they presented,
+// the dual stack parity compares them
+func (q *Queries) loadServers(ctx context.Context, analysis *models.Domain) error {
+	servers, err := q.GetServers(ctx, analysis.DomainID)
+	if err != nil {
+		return err
+	}
+
+	inspections, err := q.ListTLSInspections(ctx, analysis.DomainID)
+	if err != nil {
+		return err
+	}
+
+	models.AttachInspections(servers, inspections)
+	analysis.Servers = servers
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/other_project/crockroach/internal/logs"
+)
+
+const (
+	exportDomains = `
+	SELECT domains.id, domains.domain_name, domains.serverchanged, domains.sslgrade, domains.previousslgrade, domains.logo, domains.title, domains.isdown, domains.creationdate, domains.updatedate,
+		servers.id, servers.address, servers.sslgrade, servers.country, servers.owner
+	FROM domains
+	LEFT JOIN servers ON servers.domain_id = domains.id
+	WHERE domains.updatedate >= $1 AND domains.updatedate < $2
+	ORDER BY domains.updatedate, domains.id, servers.address
+	`
+)
+
+// ExportRow is a domain analysis joined with one of its servers.
+// The server fields are empty when the analysis does not have servers
+type ExportRow struct {
+	DomainID         string
+	DomainName       string
+	ServerChanged    bool
+	SSLGrade         string
+	PreviousSSLGrade string
+	Logo             string
+	Title            string
+	IsDown           bool
+	CreationDate     time.Time
+	UpdateDate       time.Time
+	ServerID         string
+	Address          string
+	ServerSSLGrade   string
+	Country          string
+	Owner            string
+}
+
+// ExportDomains streams the analyses updated between from and to, fn is called for every row
+// while the cursor is open so the result is never loaded in memory
+func (q *Queries) ExportDomains(ctx context.Context, from, to time.Time, fn func(*ExportRow) error) error {
+	rows, err := CockroachClient.QueryContext(ctx, exportDomains, from, to)
+	if err != nil {
+		logs.Log().Errorf("Query error %s", err.Error())
+		return ErrInvalidQuery
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logs.Log().Errorf("Row error close %s", err.Error())
+		}
+	}()
+
+	for rows.Next() {
+		row := new(ExportRow)
+
+		var serverID, address, serverSSLGrade, country, owner sql.NullString
+
+		err = rows.Scan(
+			&row.DomainID,
+			&row.DomainName,
+			&row.ServerChanged,
+			&row.SSLGrade,
+			&row.PreviousSSLGrade,
+			&row.Logo,
+			&row.Title,
+			&row.IsDown,
+			&row.CreationDate,
+			&row.UpdateDate,
+			&serverID,
+			&address,
+			&serverSSLGrade,
+			&country,
+			&owner,
+		)
+		if err != nil {
+			logs.Log().Errorf("Scan error %s", err.Error())
+			return ErrScanRow
+		}
+
+		row.ServerID = serverID.String
+		row.Address = address.String
+		row.ServerSSLGrade = serverSSLGrade.String
+		row.Country = country.String
+		row.Owner = owner.String
+
+		err = fn(row)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		logs.Log().Errorf("Row error %s", err.Error())
+		return err
+	}
+
+	return nil
+}
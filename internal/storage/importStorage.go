@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/other_project/crockroach/internal/apperr"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/models"
+)
+
+const (
+	importColumns = `id, total, duplicates, line_errors, creationdate`
+
+	createImport = `
+	INSERT INTO imports (
+		id,
+		total,
+		duplicates,
+		line_errors,
+		creationDate
+	) VALUES (
+		$1, $2, $3, $4, $5
+	) RETURNING ` + importColumns + `;
+	`
+
+	getImport = `
+	SELECT ` + importColumns + `
+	FROM imports
+	WHERE id = $1 LIMIT 1
+	`
+)
+
+var (
+	// ErrInvalidImport to ensure if exists the import
+	ErrInvalidImport = apperr.New(apperr.Internal, "invalid_import", "invalid import object")
+	// ErrImportNotFound when the import does not exist
+	ErrImportNotFound = apperr.New(apperr.NotFound, "import_not_found", "import operation was not found")
+)
+
+// StoreImport function will store an import of domains, its progress is kept by the jobs
+func (q *Queries) StoreImport(ctx context.Context, record *models.Import) (*models.Import, error) {
+	if record == nil {
+		logs.Log().Errorf("cannot store import in database %s ", ErrInvalidImport.Error())
+		return nil, ErrInvalidImport
+	}
+
+	lineErrors, err := json.Marshal(record.LineErrors)
+	if err != nil {
+		return nil, ErrInvalidImport
+	}
+
+	row := CockroachClient.QueryRowContext(ctx, createImport,
+		record.ImportID,
+		record.Total,
+		record.Duplicates,
+		string(lineErrors),
+		record.CreationDate)
+	if row.Err() != nil {
+		logs.Log().Errorf("Query error %s", row.Err())
+		return nil, ErrInvalidQuery
+	}
+
+	return scanImport(row)
+}
+
+// GetImport function will get an import by its id
+func (q *Queries) GetImport(ctx context.Context, importID string) (*models.Import, error) {
+	row := CockroachClient.QueryRowContext(ctx, getImport, importID)
+	if row.Err() != nil {
+		logs.Log().Errorf("Query error %s", row.Err())
+		return nil, ErrInvalidQuery
+	}
+
+	record, err := scanImport(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrImportNotFound
+	}
+
+	return record, err
+}
+
+// scanImport copies the columns of an import
+func scanImport(row rowScanner) (*models.Import, error) {
+	item := new(models.Import)
+
+	var lineErrors []byte
+
+	err := row.Scan(
+		&item.ImportID,
+		&item.Total,
+		&item.Duplicates,
+		&lineErrors,
+		&item.CreationDate)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	if err != nil {
+		logs.Log().Errorf("Scan error %s", err.Error())
+		return nil, ErrScanRow
+	}
+
+	item.LineErrors = []*models.ImportLineError{}
+
+	if err := json.Unmarshal(lineErrors, &item.LineErrors); err != nil {
+		logs.Log().Errorf("Scan error %s", err.Error())
+		return nil, ErrScanRow
+	}
+
+	return item, nil
+}
@@ -0,0 +1,214 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+	"github.com/other_project/crockroach/internal/apperr"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/models"
+)
+
+const (
+	jobColumns = `id, domain_name, state, progress, message, error, error_code, analysis_id, import_id, creationdate, updatedate, starteddate, finisheddate`
+
+	createJob = `
+	INSERT INTO jobs (
+		id,
+		domain_name,
+		state,
+		progress,
+		message,
+		import_id,
+		creationDate,
+		updateDate
+	) VALUES (
+		$1, $2, $3, $4, $5, $6, $7, $8
+	) RETURNING ` + jobColumns + `;
+	`
+
+	getJob = `
+	SELECT ` + jobColumns + `
+	FROM jobs
+	WHERE id = $1 LIMIT 1
+	`
+
+	updateJob = `
+	UPDATE jobs
+	SET state = $2, progress = $3, message = $4, error = $5, error_code = $6, analysis_id = $7, starteddate = $8, finisheddate = $9, updatedate = now()
+	WHERE id = $1
+	RETURNING ` + jobColumns + `;
+	`
+
+	listJobsByState = `
+	SELECT ` + jobColumns + `
+	FROM jobs
+	WHERE state = ANY($1)
+	ORDER BY creationdate
+	`
+
+	listJobsByImport = `
+	SELECT ` + jobColumns + `
+	FROM jobs
+	WHERE import_id = $1
+	ORDER BY creationdate, id
+	`
+)
+
+var (
+	// ErrInvalidJob to ensure if exists the job
+	ErrInvalidJob = apperr.New(apperr.Internal, "invalid_job", "invalid job object")
+	// ErrJobNotFound when the job does not exist
+	ErrJobNotFound = apperr.New(apperr.NotFound, "job_not_found", "job was not found")
+)
+
+// StoreJob function will store a job struct
+func (q *Queries) StoreJob(ctx context.Context, job *models.Job) (*models.Job, error) {
+	if job == nil {
+		logs.Log().Errorf("cannot store job in database %s ", ErrInvalidJob.Error())
+		return nil, ErrInvalidJob
+	}
+
+	var importID sql.NullString
+	if job.ImportID != "" {
+		importID = sql.NullString{String: job.ImportID, Valid: true}
+	}
+
+	row := CockroachClient.QueryRowContext(ctx, createJob, job.JobID, job.DomainName, job.State, job.Progress, job.Message, importID, job.CreationDate, job.UpdateDate)
+	if row.Err() != nil {
+		logs.Log().Errorf("Query error %s", row.Err())
+		return nil, ErrInvalidQuery
+	}
+
+	return scanJob(row)
+}
+
+// GetJob function will get a job struct by jobID
+func (q *Queries) GetJob(ctx context.Context, jobID string) (*models.Job, error) {
+	row := CockroachClient.QueryRowContext(ctx, getJob, jobID)
+	if row.Err() != nil {
+		logs.Log().Errorf("Query error %s", row.Err())
+		return nil, ErrInvalidQuery
+	}
+
+	job, err := scanJob(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrJobNotFound
+	}
+
+	return job, err
+}
+
+// UpdateJob function will save the state, progress and result of a job
+func (q *Queries) UpdateJob(ctx context.Context, job *models.Job) (*models.Job, error) {
+	if job == nil {
+		return nil, ErrInvalidJob
+	}
+
+	var analysisID sql.NullString
+	if job.AnalysisID != "" {
+		analysisID = sql.NullString{String: job.AnalysisID, Valid: true}
+	}
+
+	row := CockroachClient.QueryRowContext(ctx, updateJob, job.JobID, job.State, job.Progress, job.Message, job.Error, job.ErrorCode, analysisID, job.StartedDate, job.FinishedDate)
+	if row.Err() != nil {
+		logs.Log().Errorf("Query error %s", row.Err())
+		return nil, ErrInvalidQuery
+	}
+
+	updated, err := scanJob(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrJobNotFound
+	}
+
+	return updated, err
+}
+
+// ListJobsByState function will list the jobs in one of the states, the oldest first
+func (q *Queries) ListJobsByState(ctx context.Context, states ...string) ([]*models.Job, error) {
+	return queryJobs(ctx, listJobsByState, pq.Array(states))
+}
+
+// ListJobsByImport function will list the jobs of the import in the order of the file
+func (q *Queries) ListJobsByImport(ctx context.Context, importID string) ([]*models.Job, error) {
+	return queryJobs(ctx, listJobsByImport, importID)
+}
+
+// queryJobs runs a query that returns jobs
+func queryJobs(ctx context.Context, query string, args ...interface{}) ([]*models.Job, error) {
+	rows, err := CockroachClient.QueryContext(ctx, query, args...)
+	if err != nil {
+		logs.Log().Errorf("Query error %s", err.Error())
+		return nil, ErrInvalidQuery
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logs.Log().Errorf("Row error close %s", err.Error())
+		}
+	}()
+
+	items := []*models.Job{}
+
+	for rows.Next() {
+		item, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		logs.Log().Errorf("Row error %s", err.Error())
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// scanJob copies the columns of a job
+func scanJob(row rowScanner) (*models.Job, error) {
+	item := new(models.Job)
+
+	var analysisID, importID sql.NullString
+	var started, finished sql.NullTime
+
+	err := row.Scan(
+		&item.JobID,
+		&item.DomainName,
+		&item.State,
+		&item.Progress,
+		&item.Message,
+		&item.Error,
+		&item.ErrorCode,
+		&analysisID,
+		&importID,
+		&item.CreationDate,
+		&item.UpdateDate,
+		&started,
+		&finished)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	if err != nil {
+		logs.Log().Errorf("Scan error %s", err.Error())
+		return nil, ErrScanRow
+	}
+
+	item.AnalysisID = analysisID.String
+	item.ImportID = importID.String
+
+	if started.Valid {
+		item.StartedDate = &started.Time
+	}
+
+	if finished.Valid {
+		item.FinishedDate = &finished.Time
+	}
+
+	return item, nil
+}
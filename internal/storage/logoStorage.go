@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/other_project/crockroach/internal/apperr"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/models"
+)
+
+const (
+	// the content decides the hash, a logo that already exists is left as it is
+	createLogo = `
+	INSERT INTO logos (
+		hash,
+		content_type,
+		size,
+		source_url,
+		content,
+		creationDate
+	) VALUES (
+		$1, $2, $3, $4, $5, $6
+	) ON CONFLICT (hash) DO NOTHING;
+	`
+
+	getLogo = `
+	SELECT hash, content_type, size, source_url, content, creationdate
+	FROM logos
+	WHERE hash = $1
+	`
+)
+
+var (
+	// ErrInvalidLogo to ensure if exists the logo
+	ErrInvalidLogo = apperr.New(apperr.Internal, "invalid_logo", "invalid logo object")
+	// ErrLogoNotFound when there is no logo with the hash
+	ErrLogoNotFound = apperr.New(apperr.NotFound, "logo_not_found", "logo was not found")
+)
+
+// StoreLogo function will store the content of a logo addressed by its hash
+func (q *Queries) StoreLogo(ctx context.Context, logo *models.LogoImage) (*models.LogoImage, error) {
+	if logo == nil || !models.ValidLogoHash(logo.Hash) {
+		logs.Log().Errorf("cannot store logo in database %s ", ErrInvalidLogo.Error())
+		return nil, ErrInvalidLogo
+	}
+
+	_, err := CockroachClient.ExecContext(ctx, createLogo,
+		logo.Hash,
+		logo.ContentType,
+		logo.Size,
+		logo.SourceURL,
+		logo.Content,
+		logo.CreationDate)
+	if err != nil {
+		logs.Log().Errorf("Query error %s", err.Error())
+		return nil, ErrInvalidQuery
+	}
+
+	return logo, nil
+}
+
+// GetLogo function will get the logo with the hash
+func (q *Queries) GetLogo(ctx context.Context, hash string) (*models.LogoImage, error) {
+	row := CockroachClient.QueryRowContext(ctx, getLogo, hash)
+	if row.Err() != nil {
+		logs.Log().Errorf("Query error %s", row.Err())
+		return nil, ErrInvalidQuery
+	}
+
+	item := new(models.LogoImage)
+
+	err := row.Scan(
+		&item.Hash,
+		&item.ContentType,
+		&item.Size,
+		&item.SourceURL,
+		&item.Content,
+		&item.CreationDate)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrLogoNotFound
+	}
+
+	if err != nil {
+		logs.Log().Errorf("Scan error %s", err.Error())
+		return nil, ErrScanRow
+	}
+
+	return item, nil
+}
@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/other_project/crockroach/internal/logs"
+)
+
+// migrations create the tables used by the service, every statement must be idempotent
+// because they are executed each time the server starts
+var migrations = []string{
+	`CREATE TABLE IF NOT EXISTS api_keys (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		name STRING NOT NULL,
+		prefix STRING NOT NULL,
+		hash STRING NOT NULL UNIQUE,
+		scopes STRING NOT NULL,
+		creationDate TIMESTAMPTZ NOT NULL DEFAULT (now()),
+		revokedDate TIMESTAMPTZ NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS jobs (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		domain_name STRING NOT NULL,
+		state STRING NOT NULL,
+		progress INT NOT NULL DEFAULT 0,
+		message STRING NOT NULL DEFAULT '',
+		error STRING NOT NULL DEFAULT '',
+		error_code STRING NOT NULL DEFAULT '',
+		analysis_id UUID NULL,
+		creationDate TIMESTAMPTZ NOT NULL DEFAULT (now()),
+		updateDate TIMESTAMPTZ NOT NULL DEFAULT (now()),
+		startedDate TIMESTAMPTZ NULL,
+		finishedDate TIMESTAMPTZ NULL,
+		INDEX jobs_state_idx (state)
+	)`,
+	`CREATE TABLE IF NOT EXISTS tracked_domains (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		domain_name STRING NOT NULL UNIQUE,
+		check_interval INT NOT NULL,
+		enabled BOOL NOT NULL DEFAULT true,
+		tags STRING[] NOT NULL DEFAULT ARRAY[],
+		notification_targets STRING[] NOT NULL DEFAULT ARRAY[],
+		providers STRING[] NOT NULL DEFAULT ARRAY[],
+		skip_whois BOOL NOT NULL DEFAULT false,
+		lastCheckDate TIMESTAMPTZ NULL,
+		creationDate TIMESTAMPTZ NOT NULL DEFAULT (now()),
+		updateDate TIMESTAMPTZ NOT NULL DEFAULT (now())
+	)`,
+	`CREATE TABLE IF NOT EXISTS rules (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		name STRING NOT NULL,
+		kind STRING NOT NULL,
+		domain_name STRING NOT NULL DEFAULT '',
+		grade STRING NOT NULL DEFAULT '',
+		threshold INT NOT NULL DEFAULT 0,
+		window_seconds INT NOT NULL DEFAULT 0,
+		enabled BOOL NOT NULL DEFAULT true,
+		creationDate TIMESTAMPTZ NOT NULL DEFAULT (now()),
+		updateDate TIMESTAMPTZ NOT NULL DEFAULT (now())
+	)`,
+	`CREATE TABLE IF NOT EXISTS rule_evaluations (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		rule_id UUID NOT NULL,
+		analysis_id UUID NOT NULL,
+		domain_name STRING NOT NULL,
+		fired BOOL NOT NULL,
+		message STRING NOT NULL DEFAULT '',
+		creationDate TIMESTAMPTZ NOT NULL DEFAULT (now()),
+		INDEX rule_evaluations_rule_idx (rule_id, domain_name, creationDate DESC)
+	)`,
+	`CREATE TABLE IF NOT EXISTS alerts (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		rule_id UUID NOT NULL,
+		rule_name STRING NOT NULL,
+		evaluation_id UUID NOT NULL,
+		analysis_id UUID NOT NULL,
+		domain_name STRING NOT NULL,
+		message STRING NOT NULL DEFAULT '',
+		creationDate TIMESTAMPTZ NOT NULL DEFAULT (now()),
+		INDEX alerts_domain_idx (domain_name, creationDate DESC)
+	)`,
+	`CREATE TABLE IF NOT EXISTS uptime_checks (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		analysis_id UUID NOT NULL,
+		domain_name STRING NOT NULL,
+		url STRING NOT NULL,
+		status_code INT NOT NULL DEFAULT 0,
+		redirects STRING[] NOT NULL DEFAULT ARRAY[],
+		dns_ms INT NOT NULL DEFAULT 0,
+		connect_ms INT NOT NULL DEFAULT 0,
+		tls_ms INT NOT NULL DEFAULT 0,
+		ttfb_ms INT NOT NULL DEFAULT 0,
+		total_ms INT NOT NULL DEFAULT 0,
+		error_class STRING NOT NULL DEFAULT '',
+		up BOOL NOT NULL,
+		creationDate TIMESTAMPTZ NOT NULL DEFAULT (now()),
+		INDEX uptime_checks_domain_idx (domain_name, creationDate DESC)
+	)`,
+	`ALTER TABLE tracked_domains ADD COLUMN IF NOT EXISTS health_check JSONB NOT NULL DEFAULT '{}'`,
+	`CREATE TABLE IF NOT EXISTS tls_inspections (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		server_id UUID NOT NULL,
+		analysis_id UUID NOT NULL,
+		address STRING NOT NULL,
+		server_name STRING NOT NULL,
+		tls_version STRING NOT NULL DEFAULT '',
+		chain_verified BOOL NOT NULL DEFAULT false,
+		hostname_verified BOOL NOT NULL DEFAULT false,
+		verify_error STRING NOT NULL DEFAULT '',
+		error STRING NOT NULL DEFAULT '',
+		not_after TIMESTAMPTZ,
+		chain JSONB NOT NULL DEFAULT '[]',
+		creationDate TIMESTAMPTZ NOT NULL DEFAULT (now()),
+		INDEX tls_inspections_server_idx (server_id, creationDate DESC),
+		INDEX tls_inspections_not_after_idx (not_after)
+	)`,
+	`CREATE TABLE IF NOT EXISTS dns_snapshots (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		analysis_id UUID NOT NULL,
+		domain_name STRING NOT NULL,
+		resolver STRING NOT NULL,
+		records JSONB NOT NULL DEFAULT '{}',
+		errors JSONB NOT NULL DEFAULT '{}',
+		changes JSONB NOT NULL DEFAULT '[]',
+		creationDate TIMESTAMPTZ NOT NULL DEFAULT (now()),
+		INDEX dns_snapshots_domain_idx (domain_name, creationDate DESC)
+	)`,
+	`ALTER TABLE IF EXISTS servers
+		ADD COLUMN IF NOT EXISTS geo_country STRING NOT NULL DEFAULT '',
+		ADD COLUMN IF NOT EXISTS asn INT8 NOT NULL DEFAULT 0,
+		ADD COLUMN IF NOT EXISTS asn_org STRING NOT NULL DEFAULT ''`,
+	`CREATE TABLE IF NOT EXISTS logos (
+		hash STRING PRIMARY KEY,
+		content_type STRING NOT NULL,
+		size INT NOT NULL,
+		source_url STRING NOT NULL,
+		content BYTES NOT NULL,
+		creationDate TIMESTAMPTZ NOT NULL DEFAULT (now())
+	)`,
+	`CREATE TABLE IF NOT EXISTS page_snapshots (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		analysis_id UUID NOT NULL,
+		domain_name STRING NOT NULL,
+		url STRING NOT NULL,
+		title STRING NOT NULL,
+		metadata JSONB NOT NULL DEFAULT '{}',
+		text_hash STRING NOT NULL,
+		text STRING NOT NULL,
+		title_changed BOOL NOT NULL DEFAULT false,
+		content_changed BOOL NOT NULL DEFAULT false,
+		creationDate TIMESTAMPTZ NOT NULL DEFAULT (now()),
+		INDEX page_snapshots_domain_idx (domain_name, creationDate DESC)
+	)`,
+	`CREATE TABLE IF NOT EXISTS imports (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		total INT NOT NULL,
+		duplicates INT NOT NULL DEFAULT 0,
+		line_errors JSONB NOT NULL DEFAULT '[]',
+		creationDate TIMESTAMPTZ NOT NULL DEFAULT (now())
+	)`,
+	`ALTER TABLE jobs ADD COLUMN IF NOT EXISTS import_id UUID NULL`,
+	`CREATE INDEX IF NOT EXISTS jobs_import_idx ON jobs (import_id)`,
+	`ALTER TABLE IF EXISTS domains ADD COLUMN IF NOT EXISTS logo_changed BOOL NOT NULL DEFAULT false`,
+	`CREATE INDEX IF NOT EXISTS tls_inspections_analysis_idx ON tls_inspections (analysis_id, creationDate)`,
+}
+
+// Migrate creates the tables that do not exist
+func Migrate(ctx context.Context) error {
+	for _, migration := range migrations {
+		_, err := CockroachClient.ExecContext(ctx, migration)
+		if err != nil {
+			logs.Log().Errorf("Migration error %s", err.Error())
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,189 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/other_project/crockroach/internal/apperr"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/models"
+)
+
+const (
+	pageColumns = `id, analysis_id, domain_name, url, title, metadata, text_hash, text, title_changed, content_changed, creationdate`
+	// pageListColumns skips the text, the lists only need the hash
+	pageListColumns = `id, analysis_id, domain_name, url, title, metadata, text_hash, '' AS text, title_changed, content_changed, creationdate`
+
+	createPageSnapshot = `
+	INSERT INTO page_snapshots (
+		id,
+		analysis_id,
+		domain_name,
+		url,
+		title,
+		metadata,
+		text_hash,
+		text,
+		title_changed,
+		content_changed,
+		creationDate
+	) VALUES (
+		$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+	) RETURNING ` + pageColumns + `;
+	`
+
+	getPageSnapshot = `
+	SELECT ` + pageColumns + `
+	FROM page_snapshots
+	WHERE id = $1
+	`
+
+	getLastPageSnapshot = `
+	SELECT ` + pageColumns + `
+	FROM page_snapshots
+	WHERE domain_name = $1
+	ORDER BY creationdate DESC
+	LIMIT 1
+	`
+
+	listPageSnapshots = `
+	SELECT ` + pageListColumns + `
+	FROM page_snapshots
+	WHERE domain_name = $1
+	ORDER BY creationdate DESC
+	LIMIT $2 OFFSET $3
+	`
+)
+
+var (
+	// ErrInvalidPageSnapshot to ensure if exists the page snapshot
+	ErrInvalidPageSnapshot = apperr.New(apperr.Internal, "invalid_page_snapshot", "invalid page snapshot object")
+	// ErrPageSnapshotNotFound when the page of the domain was not read
+	ErrPageSnapshotNotFound = apperr.New(apperr.NotFound, "page_snapshot_not_found", "the page of the domain was not read")
+)
+
+// StorePageSnapshot function will store the page of the domain read during an analysis
+func (q *Queries) StorePageSnapshot(ctx context.Context, snapshot *models.PageSnapshot) (*models.PageSnapshot, error) {
+	if snapshot == nil {
+		logs.Log().Errorf("cannot store page snapshot in database %s ", ErrInvalidPageSnapshot.Error())
+		return nil, ErrInvalidPageSnapshot
+	}
+
+	metadata, err := json.Marshal(snapshot.Metadata)
+	if err != nil {
+		return nil, ErrInvalidPageSnapshot
+	}
+
+	row := CockroachClient.QueryRowContext(ctx, createPageSnapshot,
+		snapshot.SnapshotID,
+		snapshot.AnalysisID,
+		snapshot.DomainName,
+		snapshot.URL,
+		snapshot.Title,
+		string(metadata),
+		snapshot.TextHash,
+		snapshot.Text,
+		snapshot.TitleChanged,
+		snapshot.ContentChanged,
+		snapshot.CreationDate)
+	if row.Err() != nil {
+		logs.Log().Errorf("Query error %s", row.Err())
+		return nil, ErrInvalidQuery
+	}
+
+	return scanPageSnapshot(row)
+}
+
+// GetPageSnapshot function will get the page snapshot with the id
+func (q *Queries) GetPageSnapshot(ctx context.Context, id string) (*models.PageSnapshot, error) {
+	return q.getPageSnapshot(ctx, getPageSnapshot, id)
+}
+
+// GetLastPageSnapshot function will get the newest page of the domain
+func (q *Queries) GetLastPageSnapshot(ctx context.Context, domainName string) (*models.PageSnapshot, error) {
+	return q.getPageSnapshot(ctx, getLastPageSnapshot, domainName)
+}
+
+// getPageSnapshot runs the query that returns one page snapshot
+func (q *Queries) getPageSnapshot(ctx context.Context, query, arg string) (*models.PageSnapshot, error) {
+	row := CockroachClient.QueryRowContext(ctx, query, arg)
+	if row.Err() != nil {
+		logs.Log().Errorf("Query error %s", row.Err())
+		return nil, ErrInvalidQuery
+	}
+
+	snapshot, err := scanPageSnapshot(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPageSnapshotNotFound
+	}
+
+	return snapshot, err
+}
+
+// ListPageSnapshots function will list the pages of the domain without their text, the newest first
+func (q *Queries) ListPageSnapshots(ctx context.Context, domainName string, limit, offset int64) ([]*models.PageSnapshot, error) {
+	rows, err := CockroachClient.QueryContext(ctx, listPageSnapshots, domainName, limit, offset)
+	if err != nil {
+		logs.Log().Errorf("Query error %s", err.Error())
+		return nil, ErrInvalidQuery
+	}
+
+	defer closeRows(rows)
+
+	items := []*models.PageSnapshot{}
+
+	for rows.Next() {
+		item, err := scanPageSnapshot(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		logs.Log().Errorf("Row error %s", err.Error())
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// scanPageSnapshot copies the columns of a page snapshot
+func scanPageSnapshot(row rowScanner) (*models.PageSnapshot, error) {
+	item := new(models.PageSnapshot)
+
+	var metadata []byte
+
+	err := row.Scan(
+		&item.SnapshotID,
+		&item.AnalysisID,
+		&item.DomainName,
+		&item.URL,
+		&item.Title,
+		&metadata,
+		&item.TextHash,
+		&item.Text,
+		&item.TitleChanged,
+		&item.ContentChanged,
+		&item.CreationDate)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	if err != nil {
+		logs.Log().Errorf("Scan error %s", err.Error())
+		return nil, ErrScanRow
+	}
+
+	if len(metadata) > 0 {
+		if err := json.Unmarshal(metadata, &item.Metadata); err != nil {
+			logs.Log().Errorf("Scan error page snapshot %s", err.Error())
+			return nil, ErrScanRow
+		}
+	}
+
+	return item, nil
+}
@@ -0,0 +1,422 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/other_project/crockroach/internal/apperr"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/models"
+)
+
+const (
+	ruleColumns       = `id, name, kind, domain_name, grade, threshold, window_seconds, enabled, creationdate, updatedate`
+	evaluationColumns = `id, rule_id, analysis_id, domain_name, fired, message, creationdate`
+	alertColumns      = `id, rule_id, rule_name, evaluation_id, analysis_id, domain_name, message, creationdate`
+
+	createRule = `
+	INSERT INTO rules (
+		id,
+		name,
+		kind,
+		domain_name,
+		grade,
+		threshold,
+		window_seconds,
+		enabled,
+		creationDate,
+		updateDate
+	) VALUES (
+		$1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+	) RETURNING ` + ruleColumns + `;
+	`
+
+	getRule = `
+	SELECT ` + ruleColumns + `
+	FROM rules
+	WHERE id = $1 LIMIT 1
+	`
+
+	listRules = `
+	SELECT ` + ruleColumns + `
+	FROM rules
+	ORDER BY creationdate
+	LIMIT $1 OFFSET $2
+	`
+
+	listRulesForDomain = `
+	SELECT ` + ruleColumns + `
+	FROM rules
+	WHERE enabled AND (domain_name = '' OR domain_name = $1)
+	ORDER BY creationdate
+	`
+
+	updateRule = `
+	UPDATE rules
+	SET name = $2, domain_name = $3, grade = $4, threshold = $5, window_seconds = $6, enabled = $7, updatedate = now()
+	WHERE id = $1
+	RETURNING ` + ruleColumns + `;
+	`
+
+	deleteRule = `
+	DELETE FROM rules
+	WHERE id = $1
+	`
+
+	createRuleEvaluation = `
+	INSERT INTO rule_evaluations (
+		id,
+		rule_id,
+		analysis_id,
+		domain_name,
+		fired,
+		message,
+		creationDate
+	) VALUES (
+		$1, $2, $3, $4, $5, $6, $7
+	) RETURNING ` + evaluationColumns + `;
+	`
+
+	getLastRuleEvaluation = `
+	SELECT ` + evaluationColumns + `
+	FROM rule_evaluations
+	WHERE rule_id = $1 AND domain_name = $2
+	ORDER BY creationdate DESC
+	LIMIT 1
+	`
+
+	listRuleEvaluations = `
+	SELECT ` + evaluationColumns + `
+	FROM rule_evaluations
+	WHERE rule_id = $1
+	ORDER BY creationdate DESC
+	LIMIT $2 OFFSET $3
+	`
+
+	createAlert = `
+	INSERT INTO alerts (
+		id,
+		rule_id,
+		rule_name,
+		evaluation_id,
+		analysis_id,
+		domain_name,
+		message,
+		creationDate
+	) VALUES (
+		$1, $2, $3, $4, $5, $6, $7, $8
+	) RETURNING ` + alertColumns + `;
+	`
+
+	listAlerts = `
+	SELECT ` + alertColumns + `
+	FROM alerts
+	WHERE $1 = '' OR domain_name = $1
+	ORDER BY creationdate DESC
+	LIMIT $2 OFFSET $3
+	`
+)
+
+var (
+	// ErrInvalidRule to ensure if exists the rule
+	ErrInvalidRule = apperr.New(apperr.Internal, "invalid_rule", "invalid rule object")
+	// ErrRuleNotFound when the rule does not exist
+	ErrRuleNotFound = apperr.New(apperr.NotFound, "rule_not_found", "rule was not found")
+	// ErrRuleEvaluationNotFound when the rule was never evaluated for the domain
+	ErrRuleEvaluationNotFound = apperr.New(apperr.NotFound, "rule_evaluation_not_found", "the rule was not evaluated")
+)
+
+// StoreRule function will store a rule struct
+func (q *Queries) StoreRule(ctx context.Context, rule *models.Rule) (*models.Rule, error) {
+	if rule == nil {
+		logs.Log().Errorf("cannot store rule in database %s ", ErrInvalidRule.Error())
+		return nil, ErrInvalidRule
+	}
+
+	row := CockroachClient.QueryRowContext(ctx, createRule, rule.RuleID, rule.Name, rule.Kind, rule.DomainName, rule.Grade,
+		rule.Threshold, rule.Window, rule.Enabled, rule.CreationDate, rule.UpdateDate)
+	if row.Err() != nil {
+		logs.Log().Errorf("Query error %s", row.Err())
+		return nil, ErrInvalidQuery
+	}
+
+	return scanRule(row)
+}
+
+// GetRule function will get a rule struct by ruleID
+func (q *Queries) GetRule(ctx context.Context, ruleID string) (*models.Rule, error) {
+	row := CockroachClient.QueryRowContext(ctx, getRule, ruleID)
+	if row.Err() != nil {
+		logs.Log().Errorf("Query error %s", row.Err())
+		return nil, ErrInvalidQuery
+	}
+
+	rule, err := scanRule(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRuleNotFound
+	}
+
+	return rule, err
+}
+
+// ListRules function will list the rules, the oldest first
+func (q *Queries) ListRules(ctx context.Context, limit, offset int64) ([]*models.Rule, error) {
+	return queryRules(ctx, listRules, limit, offset)
+}
+
+// ListRulesForDomain function will list the enabled rules that apply to the domain
+func (q *Queries) ListRulesForDomain(ctx context.Context, domainName string) ([]*models.Rule, error) {
+	return queryRules(ctx, listRulesForDomain, domainName)
+}
+
+// UpdateRule function will save the settings of a rule, the kind cannot change
+func (q *Queries) UpdateRule(ctx context.Context, rule *models.Rule) (*models.Rule, error) {
+	if rule == nil {
+		return nil, ErrInvalidRule
+	}
+
+	row := CockroachClient.QueryRowContext(ctx, updateRule, rule.RuleID, rule.Name, rule.DomainName, rule.Grade,
+		rule.Threshold, rule.Window, rule.Enabled)
+	if row.Err() != nil {
+		logs.Log().Errorf("Query error %s", row.Err())
+		return nil, ErrInvalidQuery
+	}
+
+	updated, err := scanRule(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRuleNotFound
+	}
+
+	return updated, err
+}
+
+// DeleteRule function will delete a rule, its evaluations and alerts are kept
+func (q *Queries) DeleteRule(ctx context.Context, ruleID string) error {
+	result, err := CockroachClient.ExecContext(ctx, deleteRule, ruleID)
+	if err != nil {
+		logs.Log().Errorf("Query error %s", err.Error())
+		return ErrInvalidQuery
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return ErrRuleNotFound
+	}
+
+	return nil
+}
+
+// StoreRuleEvaluation function will store the result of a rule
+func (q *Queries) StoreRuleEvaluation(ctx context.Context, evaluation *models.RuleEvaluation) (*models.RuleEvaluation, error) {
+	row := CockroachClient.QueryRowContext(ctx, createRuleEvaluation, evaluation.EvaluationID, evaluation.RuleID, evaluation.AnalysisID,
+		evaluation.DomainName, evaluation.Fired, evaluation.Message, evaluation.CreationDate)
+	if row.Err() != nil {
+		logs.Log().Errorf("Query error %s", row.Err())
+		return nil, ErrInvalidQuery
+	}
+
+	return scanRuleEvaluation(row)
+}
+
+// GetLastRuleEvaluation function will get the last result of the rule for the domain
+func (q *Queries) GetLastRuleEvaluation(ctx context.Context, ruleID, domainName string) (*models.RuleEvaluation, error) {
+	row := CockroachClient.QueryRowContext(ctx, getLastRuleEvaluation, ruleID, domainName)
+	if row.Err() != nil {
+		logs.Log().Errorf("Query error %s", row.Err())
+		return nil, ErrInvalidQuery
+	}
+
+	evaluation, err := scanRuleEvaluation(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRuleEvaluationNotFound
+	}
+
+	return evaluation, err
+}
+
+// ListRuleEvaluations function will list the results of a rule, the newest first
+func (q *Queries) ListRuleEvaluations(ctx context.Context, ruleID string, limit, offset int64) ([]*models.RuleEvaluation, error) {
+	rows, err := CockroachClient.QueryContext(ctx, listRuleEvaluations, ruleID, limit, offset)
+	if err != nil {
+		logs.Log().Errorf("Query error %s", err.Error())
+		return nil, ErrInvalidQuery
+	}
+
+	defer closeRows(rows)
+
+	items := []*models.RuleEvaluation{}
+
+	for rows.Next() {
+		item, err := scanRuleEvaluation(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		logs.Log().Errorf("Row error %s", err.Error())
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// StoreAlert function will store an alert struct
+func (q *Queries) StoreAlert(ctx context.Context, alert *models.Alert) (*models.Alert, error) {
+	row := CockroachClient.QueryRowContext(ctx, createAlert, alert.AlertID, alert.RuleID, alert.RuleName, alert.EvaluationID,
+		alert.AnalysisID, alert.DomainName, alert.Message, alert.CreationDate)
+	if row.Err() != nil {
+		logs.Log().Errorf("Query error %s", row.Err())
+		return nil, ErrInvalidQuery
+	}
+
+	return scanAlert(row)
+}
+
+// ListAlerts function will list the alerts of the domain, the newest first. An empty name lists every alert
+func (q *Queries) ListAlerts(ctx context.Context, domainName string, limit, offset int64) ([]*models.Alert, error) {
+	rows, err := CockroachClient.QueryContext(ctx, listAlerts, domainName, limit, offset)
+	if err != nil {
+		logs.Log().Errorf("Query error %s", err.Error())
+		return nil, ErrInvalidQuery
+	}
+
+	defer closeRows(rows)
+
+	items := []*models.Alert{}
+
+	for rows.Next() {
+		item, err := scanAlert(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		logs.Log().Errorf("Row error %s", err.Error())
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// queryRules runs a query that returns rules
+func queryRules(ctx context.Context, query string, args ...interface{}) ([]*models.Rule, error) {
+	rows, err := CockroachClient.QueryContext(ctx, query, args...)
+	if err != nil {
+		logs.Log().Errorf("Query error %s", err.Error())
+		return nil, ErrInvalidQuery
+	}
+
+	defer closeRows(rows)
+
+	items := []*models.Rule{}
+
+	for rows.Next() {
+		item, err := scanRule(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		logs.Log().Errorf("Row error %s", err.Error())
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// closeRows releases the cursor
+func closeRows(rows *sql.Rows) {
+	if err := rows.Close(); err != nil {
+		logs.Log().Errorf("Row error close %s", err.Error())
+	}
+}
+
+// scanRule copies the columns of a rule
+func scanRule(row rowScanner) (*models.Rule, error) {
+	item := new(models.Rule)
+
+	err := row.Scan(
+		&item.RuleID,
+		&item.Name,
+		&item.Kind,
+		&item.DomainName,
+		&item.Grade,
+		&item.Threshold,
+		&item.Window,
+		&item.Enabled,
+		&item.CreationDate,
+		&item.UpdateDate)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	if err != nil {
+		logs.Log().Errorf("Scan error %s", err.Error())
+		return nil, ErrScanRow
+	}
+
+	return item, nil
+}
+
+// scanRuleEvaluation copies the columns of an evaluation
+func scanRuleEvaluation(row rowScanner) (*models.RuleEvaluation, error) {
+	item := new(models.RuleEvaluation)
+
+	err := row.Scan(
+		&item.EvaluationID,
+		&item.RuleID,
+		&item.AnalysisID,
+		&item.DomainName,
+		&item.Fired,
+		&item.Message,
+		&item.CreationDate)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	if err != nil {
+		logs.Log().Errorf("Scan error %s", err.Error())
+		return nil, ErrScanRow
+	}
+
+	return item, nil
+}
+
+// scanAlert copies the columns of an alert
+func scanAlert(row rowScanner) (*models.Alert, error) {
+	item := new(models.Alert)
+
+	err := row.Scan(
+		&item.AlertID,
+		&item.RuleID,
+		&item.RuleName,
+		&item.EvaluationID,
+		&item.AnalysisID,
+		&item.DomainName,
+		&item.Message,
+		&item.CreationDate)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	if err != nil {
+		logs.Log().Errorf("Scan error %s", err.Error())
+		return nil, ErrScanRow
+	}
+
+	return item, nil
+}
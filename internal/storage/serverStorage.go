@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 
+	"github.com/other_project/crockroach/internal/apperr"
 	"github.com/other_project/crockroach/internal/logs"
 	"github.com/other_project/crockroach/models"
 	"github.com/other_project/crockroach/shared/env"
@@ -20,21 +21,24 @@ const (
 		owner,
 		domain_id,
 		creationDate,
-		updateDate 
+		updateDate,
+		geo_country,
+		asn,
+		asn_org
 	) VALUES (
-		$1, $2, $3, $4, $5, $6, $7, $8
-	) RETURNING *;
+		$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+	) RETURNING id, address, sslgrade, country, owner, domain_id, creationDate, updateDate, geo_country, asn, asn_org;
 	`
 
 	getServer = `
-	SELECT servers.id, servers.address, servers.sslgrade, servers.country, servers.owner, servers.creationdate, servers.updatedate, domains.id, domains.serverchanged, domains.sslgrade, domains.previousslgrade, domains.logo, domains.title, domains.isdown, domains.creationdate, domains.updatedate 
+	SELECT servers.id, servers.address, servers.sslgrade, servers.country, servers.owner, servers.creationdate, servers.updatedate, servers.geo_country, servers.asn, servers.asn_org, domains.id, domains.serverchanged, domains.sslgrade, domains.previousslgrade, domains.logo, domains.title, domains.isdown, domains.creationdate, domains.updatedate 
 	FROM servers 
 	INNER JOIN domains ON domains.id = servers.domain_id
 	WHERE servers.id = $1 LIMIT 1
 	`
 
 	listServers = `
-	SELECT servers.id, servers.address, servers.sslgrade, servers.country, servers.owner, servers.creationdate, servers.updatedate, domains.id, domains.serverchanged, domains.sslgrade, domains.previousslgrade, domains.logo, domains.title, domains.isdown, domains.creationdate, domains.updatedate 
+	SELECT servers.id, servers.address, servers.sslgrade, servers.country, servers.owner, servers.creationdate, servers.updatedate, servers.geo_country, servers.asn, servers.asn_org, domains.id, domains.serverchanged, domains.sslgrade, domains.previousslgrade, domains.logo, domains.title, domains.isdown, domains.creationdate, domains.updatedate 
 	FROM servers 
 	INNER JOIN domains ON domains.id = servers.domain_id
 	ORDER BY servers.id
@@ -43,7 +47,7 @@ const (
 	`
 
 	listServersByDomain = `
-	SELECT servers.id, servers.address, servers.sslgrade, servers.country, servers.owner, servers.creationdate, servers.updatedate, domains.id, domains.serverchanged, domains.sslgrade, domains.previousslgrade, domains.logo, domains.title, domains.isdown, domains.creationdate, domains.updatedate 
+	SELECT servers.id, servers.address, servers.sslgrade, servers.country, servers.owner, servers.creationdate, servers.updatedate, servers.geo_country, servers.asn, servers.asn_org, domains.id, domains.serverchanged, domains.sslgrade, domains.previousslgrade, domains.logo, domains.title, domains.isdown, domains.creationdate, domains.updatedate 
 	FROM servers 
 	INNER JOIN domains ON domains.id = servers.domain_id
 	WHERE servers.domain_id = $1
@@ -54,7 +58,7 @@ const (
 	UPDATE servers
 	SET sslgrade = $2, updatedate = now()
 	WHERE id = $1
-	RETURNING *
+	RETURNING id, address, sslgrade, country, owner, domain_id, creationDate, updateDate, geo_country, asn, asn_org
 	`
 
 	deleteServer = `
@@ -65,23 +69,23 @@ const (
 
 var (
 	// CockroachClient creates a connection with the CockroachDB
-	CockroachClient = sql.DB{}
+	CockroachClient = &sql.DB{}
 	// ErrInvalidServer to ensure if exists server
-	ErrInvalidServer = errors.New("invalid server object")
+	ErrInvalidServer = apperr.New(apperr.Internal, "invalid_server", "invalid server object")
 	// ErrEmptyServerID in
-	ErrEmptyServerID = errors.New("cannot be empty server_id")
+	ErrEmptyServerID = apperr.New(apperr.Invalid, "empty_server_id", "cannot be empty server_id")
 	// ErrInvalidQuery when the query is launch
-	ErrInvalidQuery = errors.New("cannot query the database")
+	ErrInvalidQuery = apperr.New(apperr.Unavailable, "database_unavailable", "cannot query the database")
 	// ErrEmptySSLGrade to ensure if exist ssl_grade
-	ErrEmptySSLGrade = errors.New("cannot be empty ssl_grade ")
+	ErrEmptySSLGrade = apperr.New(apperr.Invalid, "empty_ssl_grade", "cannot be empty ssl_grade ")
 	// ErrScanRow when the row  is Scan copies the columns from the matched row
-	ErrScanRow = errors.New("cannot scan query result of set")
+	ErrScanRow = apperr.New(apperr.Internal, "database_scan", "cannot scan query result of set")
 	// ErrServerNotFound to ensure that server are returned
-	ErrServerNotFound = errors.New("server was not found")
+	ErrServerNotFound = apperr.New(apperr.NotFound, "server_not_found", "server was not found")
 	// ErrZeroRowsAffected when try to delete a record does not exists
-	ErrZeroRowsAffected = errors.New("cannot record that does not exist")
+	ErrZeroRowsAffected = apperr.New(apperr.NotFound, "record_not_found", "cannot record that does not exist")
 	// ErrEmptyList there are not element
-	ErrEmptyList = errors.New("there are not elements")
+	ErrEmptyList = apperr.New(apperr.NotFound, "empty_list", "there are not elements")
 	// Limit fdfddfgdf
 	Limit = env.GetInt64("LIMIT_QUERY", 100)
 	// Offset fdfw
@@ -109,7 +113,7 @@ func (q *Queries) StoreServer(ctx context.Context, server *models.Server, domain
 		return nil, ErrInvalidServer
 	}
 
-	row := tx.QueryRowContext(ctx, createServer, server.ServerID, server.Address, server.SSLGrade, server.Country, server.Owner, server.Domain.DomainID, server.CreationDate, server.UpdateDate)
+	row := tx.QueryRowContext(ctx, createServer, server.ServerID, server.Address, server.SSLGrade, server.Country, server.Owner, server.Domain.DomainID, server.CreationDate, server.UpdateDate, server.GeoCountry, server.ASN, server.ASNOrg)
 	if row.Err() != nil {
 		logs.Log().Errorf("Query error %s", row.Err())
 		return nil, ErrInvalidQuery
@@ -127,12 +131,17 @@ func (q *Queries) StoreServer(ctx context.Context, server *models.Server, domain
 		&item.Owner,
 		&item.Domain.DomainID,
 		&item.CreationDate,
-		&item.UpdateDate)
+		&item.UpdateDate,
+		&item.GeoCountry,
+		&item.ASN,
+		&item.ASNOrg)
 	if err != nil {
 		logs.Log().Errorf("Scan error: %s", err.Error())
 		return nil, ErrScanRow
 	}
 
+	item.Family = models.AddressFamily(item.Address)
+
 	/*if item.Domain.DomainID == server.Domain.DomainID {
 		item.Domain = server.Domain
 	}*/
@@ -164,6 +173,9 @@ func (q *Queries) GetServer(ctx context.Context, serverID string) (*models.Serve
 		&item.Owner,
 		&item.CreationDate,
 		&item.UpdateDate,
+		&item.GeoCountry,
+		&item.ASN,
+		&item.ASNOrg,
 		&item.Domain.DomainID,
 		&item.Domain.ServerChanged,
 		&item.Domain.SSLGrade,
@@ -173,11 +185,17 @@ func (q *Queries) GetServer(ctx context.Context, serverID string) (*models.Serve
 		&item.Domain.IsDown,
 		&item.Domain.CreationDate,
 		&item.Domain.UpdateDate)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrServerNotFound
+	}
+
 	if err != nil {
 		logs.Log().Errorf("Scan error %s", err.Error())
 		return nil, ErrScanRow
 	}
 
+	item.Family = models.AddressFamily(item.Address)
+
 	return item, nil
 }
 
@@ -211,6 +229,9 @@ func (q *Queries) GetServers(ctx context.Context, domainID string) ([]*models.Se
 			&item.Owner,
 			&item.CreationDate,
 			&item.UpdateDate,
+			&item.GeoCountry,
+			&item.ASN,
+			&item.ASNOrg,
 			&item.Domain.DomainID,
 			&item.Domain.ServerChanged,
 			&item.Domain.SSLGrade,
@@ -224,6 +245,7 @@ func (q *Queries) GetServers(ctx context.Context, domainID string) ([]*models.Se
 			return nil, err
 		}
 
+		item.Family = models.AddressFamily(item.Address)
 		items = append(items, item)
 	}
 
@@ -269,12 +291,17 @@ func (q *Queries) UpdateServer(ctx context.Context, serverID, sslgrade string) (
 		&item.Owner,
 		&item.Domain.DomainID,
 		&item.CreationDate,
-		&item.UpdateDate)
+		&item.UpdateDate,
+		&item.GeoCountry,
+		&item.ASN,
+		&item.ASNOrg)
 	if err != nil {
 		logs.Log().Errorf("Scan error %s", err.Error())
 		return nil, ErrScanRow
 	}
 
+	item.Family = models.AddressFamily(item.Address)
+
 	item.Domain, err = q.GetDomain(ctx, item.Domain.DomainID)
 	if err != nil {
 		return nil, err
@@ -18,7 +18,7 @@ import (
 func InitCockroach() {
 	_ = logs.InitLogger()
 	// CockroachClient creates a connection with the CockroachDB
-	CockroachClient = *cockroachdb.NewSQLClient()
+	CockroachClient = cockroachdb.NewSQLClient()
 
 	tx, err := CockroachClient.Begin()
 	if err != nil {
@@ -72,7 +72,7 @@ func InitCockroach() {
 func InitCockroach() {
 	_ = logs.InitLogger()
 	// CockroachClient creates a connection with the CockroachDB
-	CockroachClient = *cockroachdb.NewSQLClient()
+	CockroachClient = cockroachdb.NewSQLClient()
 
 	tx, err := CockroachClient.Begin()
 	if err != nil {
@@ -90,7 +90,8 @@ func InitCockroach() {
 						title STRING NULL,
 						isdown bool NULL,
 						creationDate TIMESTAMPTZ NOT NULL DEFAULT (now()),
-						updateDate TIMESTAMPTZ NOT NULL DEFAULT (now())
+						updateDate TIMESTAMPTZ NOT NULL DEFAULT (now()),
+						logo_changed bool NOT NULL DEFAULT false
 					)
 					`
 
@@ -108,7 +109,10 @@ func InitCockroach() {
 				owner STRING NULL,
 				domain_id UUID REFERENCES domains (id) ON DELETE CASCADE,
 				creationDate TIMESTAMPTZ NOT NULL DEFAULT (now()),
-				updateDate TIMESTAMPTZ NOT NULL DEFAULT (now())
+				updateDate TIMESTAMPTZ NOT NULL DEFAULT (now()),
+				geo_country STRING NOT NULL DEFAULT '',
+				asn INT8 NOT NULL DEFAULT 0,
+				asn_org STRING NOT NULL DEFAULT ''
 			)
 			`
 
@@ -2,15 +2,15 @@ package storage
 
 import (
 	"context"
-	"errors"
 
+	"github.com/other_project/crockroach/internal/apperr"
 	"github.com/other_project/crockroach/internal/logs"
 	"github.com/other_project/crockroach/models"
 )
 
 var (
 	// ErrEmptyServerByDomain when it trying to fill ssl_grade field
-	ErrEmptyServerByDomain = errors.New("there are not servers in this domain")
+	ErrEmptyServerByDomain = apperr.New(apperr.Unprocessable, "domain_without_servers", "there are not servers in this domain")
 )
 
 // Store provides all functions to execute SQL queries and transactions
@@ -77,7 +77,8 @@ func (store *Store) TransferTxServers(ctx context.Context, arg TransferTxParamsS
 
 		serversNumber := len(result.FromDomain.Servers)
 
-		if len(result.FromDomain.Servers) == 0 {
+		// a down site can be stored without servers
+		if len(result.FromDomain.Servers) == 0 && !arg.FromDomain.IsDown {
 			logs.Log().Errorf(`error Not Found servers of the domain %s:`, ErrEmptyServerByDomain.Error())
 			return ErrEmptyServerByDomain
 		}
@@ -265,49 +266,7 @@ func (store *Store) TransferTxServerChange(ctx context.Context, arg TransferTxPa
 		lastRecord := result.ConsultTable[len(result.ConsultTable)-1]
 		//fmt.Println("lastrecord --> ", lastRecord)
 
-		serverChanged := false
-
-		if result.FromDomain != lastRecord {
-
-			count := 0
-			for i := 0; i < len(lastRecord.Servers); i++ {
-				if result.FromDomain.Servers[i].Address != lastRecord.Servers[i].Address {
-					//fmt.Println(*result.FromDomain.Servers[i], "--- \n ----", *lastRecord.Servers[i])
-					break
-				}
-
-				if result.FromDomain.Servers[i].Country != lastRecord.Servers[i].Country {
-					//fmt.Println(*result.FromDomain.Servers[i], "--- \n ----", *lastRecord.Servers[i])
-					break
-				}
-
-				if result.FromDomain.Servers[i].Owner != lastRecord.Servers[i].Owner {
-					//fmt.Println(*result.FromDomain.Servers[i], "--- \n ----", *lastRecord.Servers[i])
-					break
-				}
-
-				if result.FromDomain.Servers[i].SSLGrade != lastRecord.Servers[i].SSLGrade {
-					//fmt.Println(*result.FromDomain.Servers[i], "--- \n ----", *lastRecord.Servers[i])
-					break
-				}
-				count++
-
-				//fmt.Println()
-				//fmt.Println(result.FromDomain.Servers[i], "--- \n ----", lastRecord.Servers[i])
-			}
-
-			if count != len(lastRecord.Servers) {
-				serverChanged = true
-				/*
-					fmt.Println(count, len(lastRecord.Servers))
-					fmt.Println("serverchangeed --> ", serverChanged)
-					fmt.Println()
-					fmt.Println("result --> ", result.FromDomain, "\n last record -->", lastRecord)
-					fmt.Println()
-					fmt.Println(result.FromDomain.Servers[0], "\n", lastRecord.Servers[0])
-				*/
-			}
-		}
+		serverChanged := compareTwoDomains(result.FromDomain, lastRecord)
 
 		result.ToDomain, err = q.UpdateDomain(ctx, "", "", arg.FromDomain, serverChanged)
 		if err != nil {
@@ -350,7 +309,7 @@ func (store *Store) TransferTxInitialize(ctx context.Context, arg TransferTxPara
 			return err
 		}
 
-		if result.FromServers == nil {
+		if len(result.FromServers) == 0 && !arg.FromDomain.IsDown {
 			return ErrEmptyServerByDomain
 		}
 
@@ -374,9 +333,15 @@ func (store *Store) TransferTxInitialize(ctx context.Context, arg TransferTxPara
 			sslGrade = lastRecord.SSLGrade
 
 			serverChanged = compareTwoDomains(arg.FromDomain, lastRecord)
+			arg.FromDomain.LogoChanged = models.LogoChanged(arg.FromDomain, lastRecord)
 		}
 
-		result.ToDomain, err = q.UpdateDomain(ctx, arg.FromDomain.Servers[0].SSLGrade, sslGrade, arg.FromDomain, serverChanged)
+		grade := ""
+		if len(arg.FromDomain.Servers) > 0 {
+			grade = arg.FromDomain.Servers[0].SSLGrade
+		}
+
+		result.ToDomain, err = q.UpdateDomain(ctx, grade, sslGrade, arg.FromDomain, serverChanged)
 		if err != nil {
 			logs.Log().Errorf(`error updatedomain %s`, err.Error())
 			return err
@@ -390,34 +355,5 @@ func (store *Store) TransferTxInitialize(ctx context.Context, arg TransferTxPara
 
 // compareTwoDomains compare the current domain between a domain 1 hour ago
 func compareTwoDomains(current, lastRecord *models.Domain) bool {
-	serverChanged := false
-
-	if current != lastRecord {
-		count := 0
-
-		for i := 0; i < len(lastRecord.Servers); i++ {
-			if current.Servers[i].Address != lastRecord.Servers[i].Address {
-				break
-			}
-
-			if current.Servers[i].Country != lastRecord.Servers[i].Country {
-				break
-			}
-
-			if current.Servers[i].Owner != lastRecord.Servers[i].Owner {
-				break
-			}
-
-			if current.Servers[i].SSLGrade != lastRecord.Servers[i].SSLGrade {
-				break
-			}
-			count++
-		}
-
-		if count != len(lastRecord.Servers) {
-			serverChanged = true
-		}
-	}
-
-	return serverChanged
+	return models.ServersChanged(current, lastRecord)
 }
@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/other_project/crockroach/internal/apperr"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/models"
+)
+
+const (
+	tlsColumns = `id, server_id, analysis_id, address, server_name, tls_version, chain_verified, hostname_verified, verify_error, error, chain, creationdate`
+
+	createTLSInspection = `
+	INSERT INTO tls_inspections (
+		id,
+		server_id,
+		analysis_id,
+		address,
+		server_name,
+		tls_version,
+		chain_verified,
+		hostname_verified,
+		verify_error,
+		error,
+		not_after,
+		chain,
+		creationDate
+	) VALUES (
+		$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+	) RETURNING ` + tlsColumns + `;
+	`
+
+	getTLSInspection = `
+	SELECT ` + tlsColumns + `
+	FROM tls_inspections
+	WHERE server_id = $1
+	ORDER BY creationdate DESC
+	LIMIT 1
+	`
+
+	listTLSInspectionsByAnalysis = `
+	SELECT ` + tlsColumns + `
+	FROM tls_inspections
+	WHERE analysis_id = $1
+	ORDER BY creationdate ASC
+	`
+)
+
+var (
+	// ErrInvalidTLSInspection to ensure if exists the tls inspection
+	ErrInvalidTLSInspection = apperr.New(apperr.Internal, "invalid_tls_inspection", "invalid tls inspection object")
+	// ErrTLSInspectionNotFound when the certificate of the server was not inspected
+	ErrTLSInspectionNotFound = apperr.New(apperr.NotFound, "certificate_not_found", "the certificate of the server was not inspected")
+)
+
+// StoreTLSInspection function will store the certificate chain presented by a server
+func (q *Queries) StoreTLSInspection(ctx context.Context, inspection *models.TLSInspection) (*models.TLSInspection, error) {
+	if inspection == nil {
+		logs.Log().Errorf("cannot store tls inspection in database %s ", ErrInvalidTLSInspection.Error())
+		return nil, ErrInvalidTLSInspection
+	}
+
+	chain, err := json.Marshal(inspection.Chain)
+	if err != nil {
+		return nil, ErrInvalidTLSInspection
+	}
+
+	// the expiration of the leaf is kept in its own column to query the certificates about to expire
+	var notAfter interface{}
+	if leaf := inspection.Leaf(); leaf != nil {
+		notAfter = leaf.NotAfter
+	}
+
+	row := CockroachClient.QueryRowContext(ctx, createTLSInspection,
+		inspection.InspectionID,
+		inspection.ServerID,
+		inspection.AnalysisID,
+		inspection.Address,
+		inspection.ServerName,
+		inspection.Version,
+		inspection.ChainVerified,
+		inspection.HostnameVerified,
+		inspection.VerifyError,
+		inspection.Error,
+		notAfter,
+		string(chain),
+		inspection.CreationDate)
+	if row.Err() != nil {
+		logs.Log().Errorf("Query error %s", row.Err())
+		return nil, ErrInvalidQuery
+	}
+
+	return scanTLSInspection(row)
+}
+
+// GetTLSInspection function will get the last inspection of the certificate of the server
+func (q *Queries) GetTLSInspection(ctx context.Context, serverID string) (*models.TLSInspection, error) {
+	row := CockroachClient.QueryRowContext(ctx, getTLSInspection, serverID)
+	if row.Err() != nil {
+		logs.Log().Errorf("Query error %s", row.Err())
+		return nil, ErrInvalidQuery
+	}
+
+	inspection, err := scanTLSInspection(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTLSInspectionNotFound
+	}
+
+	return inspection, err
+}
+
+// ListTLSInspections function will list the inspections of the servers of an analysis, the oldest first
+func (q *Queries) ListTLSInspections(ctx context.Context, analysisID string) ([]*models.TLSInspection, error) {
+	rows, err := CockroachClient.QueryContext(ctx, listTLSInspectionsByAnalysis, analysisID)
+	if err != nil {
+		logs.Log().Errorf("Query error %s", err.Error())
+		return nil, ErrInvalidQuery
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logs.Log().Errorf("Row error close %s", err.Error())
+		}
+	}()
+
+	items := []*models.TLSInspection{}
+
+	for rows.Next() {
+		item, err := scanTLSInspection(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		logs.Log().Errorf("Row error %s", err.Error())
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// scanTLSInspection copies the columns of a tls inspection
+func scanTLSInspection(row rowScanner) (*models.TLSInspection, error) {
+	item := new(models.TLSInspection)
+
+	var chain []byte
+
+	err := row.Scan(
+		&item.InspectionID,
+		&item.ServerID,
+		&item.AnalysisID,
+		&item.Address,
+		&item.ServerName,
+		&item.Version,
+		&item.ChainVerified,
+		&item.HostnameVerified,
+		&item.VerifyError,
+		&item.Error,
+		&chain,
+		&item.CreationDate)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	if err != nil {
+		logs.Log().Errorf("Scan error %s", err.Error())
+		return nil, ErrScanRow
+	}
+
+	item.Chain = []models.Certificate{}
+
+	if len(chain) > 0 {
+		err = json.Unmarshal(chain, &item.Chain)
+		if err != nil {
+			logs.Log().Errorf("Scan error chain %s", err.Error())
+			return nil, ErrScanRow
+		}
+	}
+
+	return item, nil
+}
@@ -0,0 +1,295 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/other_project/crockroach/internal/apperr"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/models"
+)
+
+const (
+	trackedColumns = `id, domain_name, check_interval, enabled, tags, notification_targets, providers, skip_whois, health_check, lastcheckdate, creationdate, updatedate`
+
+	createTrackedDomain = `
+	INSERT INTO tracked_domains (
+		id,
+		domain_name,
+		check_interval,
+		enabled,
+		tags,
+		notification_targets,
+		providers,
+		skip_whois,
+		health_check,
+		lastCheckDate,
+		creationDate,
+		updateDate
+	) VALUES (
+		$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+	)
+	ON CONFLICT (domain_name) DO NOTHING
+	RETURNING ` + trackedColumns + `;
+	`
+
+	getTrackedDomain = `
+	SELECT ` + trackedColumns + `
+	FROM tracked_domains
+	WHERE domain_name = $1 LIMIT 1
+	`
+
+	listTrackedDomains = `
+	SELECT ` + trackedColumns + `
+	FROM tracked_domains
+	WHERE $1 = '' OR $1 = ANY(tags)
+	ORDER BY domain_name
+	LIMIT $2 OFFSET $3
+	`
+
+	listDueTrackedDomains = `
+	SELECT ` + trackedColumns + `
+	FROM tracked_domains
+	WHERE enabled AND (lastcheckdate IS NULL OR lastcheckdate + check_interval * INTERVAL '1 second' <= $1)
+	ORDER BY lastcheckdate NULLS FIRST
+	`
+
+	updateTrackedDomain = `
+	UPDATE tracked_domains
+	SET check_interval = $2, enabled = $3, tags = $4, notification_targets = $5, providers = $6, skip_whois = $7, health_check = $8, updatedate = now()
+	WHERE domain_name = $1
+	RETURNING ` + trackedColumns + `;
+	`
+
+	markTrackedDomainChecked = `
+	UPDATE tracked_domains
+	SET lastcheckdate = $2
+	WHERE domain_name = $1
+	`
+
+	deleteTrackedDomain = `
+	DELETE FROM tracked_domains
+	WHERE domain_name = $1
+	`
+)
+
+var (
+	// ErrInvalidTrackedDomain to ensure if exists the tracked domain
+	ErrInvalidTrackedDomain = apperr.New(apperr.Internal, "invalid_tracked_domain", "invalid tracked domain object")
+	// ErrTrackedDomainNotFound when the domain is not tracked
+	ErrTrackedDomainNotFound = apperr.New(apperr.NotFound, "tracked_domain_not_found", "the domain is not tracked")
+	// ErrTrackedDomainExists when the domain is already tracked
+	ErrTrackedDomainExists = apperr.New(apperr.Conflict, "tracked_domain_exists", "the domain is already tracked")
+)
+
+// StoreTrackedDomain function will add a domain to the watchlist
+func (q *Queries) StoreTrackedDomain(ctx context.Context, tracked *models.TrackedDomain) (*models.TrackedDomain, error) {
+	if tracked == nil {
+		logs.Log().Errorf("cannot store tracked domain in database %s ", ErrInvalidTrackedDomain.Error())
+		return nil, ErrInvalidTrackedDomain
+	}
+
+	health, err := json.Marshal(tracked.HealthCheck)
+	if err != nil {
+		return nil, ErrInvalidTrackedDomain
+	}
+
+	row := CockroachClient.QueryRowContext(ctx, createTrackedDomain,
+		tracked.TrackedID,
+		tracked.DomainName,
+		tracked.CheckInterval,
+		tracked.Enabled,
+		pq.Array(tracked.Tags),
+		pq.Array(tracked.NotificationTargets),
+		pq.Array(tracked.Providers),
+		tracked.SkipWHOIS,
+		string(health),
+		tracked.LastCheckDate,
+		tracked.CreationDate,
+		tracked.UpdateDate)
+	if row.Err() != nil {
+		logs.Log().Errorf("Query error %s", row.Err())
+		return nil, ErrInvalidQuery
+	}
+
+	stored, err := scanTrackedDomain(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTrackedDomainExists
+	}
+
+	return stored, err
+}
+
+// GetTrackedDomain function will get a tracked domain by its name
+func (q *Queries) GetTrackedDomain(ctx context.Context, domainName string) (*models.TrackedDomain, error) {
+	row := CockroachClient.QueryRowContext(ctx, getTrackedDomain, domainName)
+	if row.Err() != nil {
+		logs.Log().Errorf("Query error %s", row.Err())
+		return nil, ErrInvalidQuery
+	}
+
+	tracked, err := scanTrackedDomain(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTrackedDomainNotFound
+	}
+
+	return tracked, err
+}
+
+// ListTrackedDomains function will list the tracked domains ordered by name, an empty tag lists every domain
+func (q *Queries) ListTrackedDomains(ctx context.Context, tag string, limit, offset int64) ([]*models.TrackedDomain, error) {
+	return queryTrackedDomains(ctx, listTrackedDomains, tag, limit, offset)
+}
+
+// ListDueTrackedDomains function will list the enabled domains whose interval elapsed at now
+func (q *Queries) ListDueTrackedDomains(ctx context.Context, now time.Time) ([]*models.TrackedDomain, error) {
+	return queryTrackedDomains(ctx, listDueTrackedDomains, now)
+}
+
+// UpdateTrackedDomain function will save the settings of a tracked domain
+func (q *Queries) UpdateTrackedDomain(ctx context.Context, tracked *models.TrackedDomain) (*models.TrackedDomain, error) {
+	if tracked == nil {
+		return nil, ErrInvalidTrackedDomain
+	}
+
+	health, err := json.Marshal(tracked.HealthCheck)
+	if err != nil {
+		return nil, ErrInvalidTrackedDomain
+	}
+
+	row := CockroachClient.QueryRowContext(ctx, updateTrackedDomain,
+		tracked.DomainName,
+		tracked.CheckInterval,
+		tracked.Enabled,
+		pq.Array(tracked.Tags),
+		pq.Array(tracked.NotificationTargets),
+		pq.Array(tracked.Providers),
+		tracked.SkipWHOIS,
+		string(health))
+	if row.Err() != nil {
+		logs.Log().Errorf("Query error %s", row.Err())
+		return nil, ErrInvalidQuery
+	}
+
+	updated, err := scanTrackedDomain(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTrackedDomainNotFound
+	}
+
+	return updated, err
+}
+
+// MarkTrackedDomainChecked function will save when the domain was submitted for analysis
+func (q *Queries) MarkTrackedDomainChecked(ctx context.Context, domainName string, checked time.Time) error {
+	return execTrackedDomain(ctx, markTrackedDomainChecked, domainName, checked)
+}
+
+// DeleteTrackedDomain function will remove a domain from the watchlist, its analyses are kept
+func (q *Queries) DeleteTrackedDomain(ctx context.Context, domainName string) error {
+	return execTrackedDomain(ctx, deleteTrackedDomain, domainName)
+}
+
+// execTrackedDomain runs a statement that must affect a tracked domain
+func execTrackedDomain(ctx context.Context, query string, args ...interface{}) error {
+	result, err := CockroachClient.ExecContext(ctx, query, args...)
+	if err != nil {
+		logs.Log().Errorf("Query error %s", err.Error())
+		return ErrInvalidQuery
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return ErrTrackedDomainNotFound
+	}
+
+	return nil
+}
+
+// queryTrackedDomains runs a query that returns tracked domains
+func queryTrackedDomains(ctx context.Context, query string, args ...interface{}) ([]*models.TrackedDomain, error) {
+	rows, err := CockroachClient.QueryContext(ctx, query, args...)
+	if err != nil {
+		logs.Log().Errorf("Query error %s", err.Error())
+		return nil, ErrInvalidQuery
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logs.Log().Errorf("Row error close %s", err.Error())
+		}
+	}()
+
+	items := []*models.TrackedDomain{}
+
+	for rows.Next() {
+		item, err := scanTrackedDomain(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		logs.Log().Errorf("Row error %s", err.Error())
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// scanTrackedDomain copies the columns of a tracked domain
+func scanTrackedDomain(row rowScanner) (*models.TrackedDomain, error) {
+	item := &models.TrackedDomain{
+		Tags:                []string{},
+		NotificationTargets: []string{},
+		Providers:           []string{},
+	}
+
+	var checked sql.NullTime
+	var health []byte
+
+	err := row.Scan(
+		&item.TrackedID,
+		&item.DomainName,
+		&item.CheckInterval,
+		&item.Enabled,
+		pq.Array(&item.Tags),
+		pq.Array(&item.NotificationTargets),
+		pq.Array(&item.Providers),
+		&item.SkipWHOIS,
+		&health,
+		&checked,
+		&item.CreationDate,
+		&item.UpdateDate)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	if err != nil {
+		logs.Log().Errorf("Scan error %s", err.Error())
+		return nil, ErrScanRow
+	}
+
+	if checked.Valid {
+		item.LastCheckDate = &checked.Time
+	}
+
+	if len(health) > 0 {
+		err = json.Unmarshal(health, &item.HealthCheck)
+		if err != nil {
+			logs.Log().Errorf("Scan error health check %s", err.Error())
+			return nil, ErrScanRow
+		}
+	}
+
+	return item, nil
+}
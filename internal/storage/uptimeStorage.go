@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/other_project/crockroach/internal/apperr"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/models"
+)
+
+const (
+	uptimeColumns = `id, analysis_id, domain_name, url, status_code, redirects, dns_ms, connect_ms, tls_ms, ttfb_ms, total_ms, error_class, up, creationdate`
+
+	createUptimeCheck = `
+	INSERT INTO uptime_checks (
+		id,
+		analysis_id,
+		domain_name,
+		url,
+		status_code,
+		redirects,
+		dns_ms,
+		connect_ms,
+		tls_ms,
+		ttfb_ms,
+		total_ms,
+		error_class,
+		up,
+		creationDate
+	) VALUES (
+		$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+	) RETURNING ` + uptimeColumns + `;
+	`
+
+	listUptimeChecks = `
+	SELECT ` + uptimeColumns + `
+	FROM uptime_checks
+	WHERE domain_name = $1 AND creationdate >= $2
+	ORDER BY creationdate DESC
+	`
+)
+
+var (
+	// ErrInvalidUptimeCheck to ensure if exists the uptime check
+	ErrInvalidUptimeCheck = apperr.New(apperr.Internal, "invalid_uptime_check", "invalid uptime check object")
+)
+
+// StoreUptimeCheck function will store the availability check of an analysis
+func (q *Queries) StoreUptimeCheck(ctx context.Context, check *models.UptimeCheck) (*models.UptimeCheck, error) {
+	if check == nil {
+		logs.Log().Errorf("cannot store uptime check in database %s ", ErrInvalidUptimeCheck.Error())
+		return nil, ErrInvalidUptimeCheck
+	}
+
+	row := CockroachClient.QueryRowContext(ctx, createUptimeCheck,
+		check.CheckID,
+		check.AnalysisID,
+		check.DomainName,
+		check.URL,
+		check.StatusCode,
+		pq.Array(check.Redirects),
+		check.DNSTime,
+		check.ConnectTime,
+		check.TLSTime,
+		check.TTFB,
+		check.TotalTime,
+		check.ErrorClass,
+		check.Up,
+		check.CreationDate)
+	if row.Err() != nil {
+		logs.Log().Errorf("Query error %s", row.Err())
+		return nil, ErrInvalidQuery
+	}
+
+	return scanUptimeCheck(row)
+}
+
+// ListUptimeChecks function will list the checks of the domain created after since, the newest first
+func (q *Queries) ListUptimeChecks(ctx context.Context, domainName string, since time.Time) ([]*models.UptimeCheck, error) {
+	rows, err := CockroachClient.QueryContext(ctx, listUptimeChecks, domainName, since)
+	if err != nil {
+		logs.Log().Errorf("Query error %s", err.Error())
+		return nil, ErrInvalidQuery
+	}
+
+	defer closeRows(rows)
+
+	items := []*models.UptimeCheck{}
+
+	for rows.Next() {
+		item, err := scanUptimeCheck(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		logs.Log().Errorf("Row error %s", err.Error())
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// scanUptimeCheck copies the columns of an uptime check
+func scanUptimeCheck(row rowScanner) (*models.UptimeCheck, error) {
+	item := new(models.UptimeCheck)
+
+	err := row.Scan(
+		&item.CheckID,
+		&item.AnalysisID,
+		&item.DomainName,
+		&item.URL,
+		&item.StatusCode,
+		pq.Array(&item.Redirects),
+		&item.DNSTime,
+		&item.ConnectTime,
+		&item.TLSTime,
+		&item.TTFB,
+		&item.TotalTime,
+		&item.ErrorClass,
+		&item.Up,
+		&item.CreationDate)
+	if err != nil {
+		logs.Log().Errorf("Scan error %s", err.Error())
+		return nil, ErrScanRow
+	}
+
+	return item, nil
+}
@@ -0,0 +1,162 @@
+package tlsinspect
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/models"
+)
+
+const (
+	// DefaultPort is the port of the handshake when the address does not have one
+	DefaultPort = "443"
+)
+
+// versions are the names of the TLS versions
+var versions = map[uint16]string{
+	tls.VersionTLS10: "TLS 1.0",
+	tls.VersionTLS11: "TLS 1.1",
+	tls.VersionTLS12: "TLS 1.2",
+	tls.VersionTLS13: "TLS 1.3",
+}
+
+// VersionName returns the name of the TLS version, empty when it is unknown
+func VersionName(version uint16) string {
+	return versions[version]
+}
+
+// Inspector performs TLS handshakes and verifies the presented chains
+type Inspector struct {
+	// Roots trusted to verify the chains, nil uses the roots of the system
+	Roots *x509.CertPool
+	// Timeout of the connection and the handshake
+	Timeout time.Duration
+	// CipherSuites offered up to TLS 1.2, nil offers the defaults of crypto/tls
+	CipherSuites []uint16
+	now          func() time.Time
+}
+
+// NewInspector creates an inspector that trusts the roots of the system
+func NewInspector(timeout time.Duration) *Inspector {
+	return &Inspector{Timeout: timeout, now: time.Now}
+}
+
+// Inspect connects to the address sending serverName as SNI and records the chain. The chain is
+// read even when it is not trusted, a failed handshake is returned with its Error
+func (i *Inspector) Inspect(ctx context.Context, address, serverName string) *models.TLSInspection {
+	inspectionID, err := uuid.NewV4()
+	if err != nil {
+		logs.Log().Errorf("cannot create the tls inspection id %s", err.Error())
+	}
+
+	now := i.now()
+
+	inspection := &models.TLSInspection{
+		InspectionID: inspectionID.String(),
+		Address:      address,
+		ServerName:   serverName,
+		Chain:        []models.Certificate{},
+		CreationDate: &now,
+	}
+
+	target := address
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		target = net.JoinHostPort(address, DefaultPort)
+	}
+
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: i.Timeout},
+		// the chain is verified below to record why it is not trusted
+		Config: &tls.Config{ServerName: serverName, CipherSuites: i.CipherSuites, InsecureSkipVerify: true}, //nolint:gosec
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", target)
+	if err != nil {
+		inspection.Error = err.Error()
+		return inspection
+	}
+
+	defer func() {
+		if err := conn.Close(); err != nil {
+			logs.Log().Errorf("Error tls connection close %s ", err.Error())
+		}
+	}()
+
+	state := conn.(*tls.Conn).ConnectionState()
+	inspection.Version = VersionName(state.Version)
+
+	for _, cert := range state.PeerCertificates {
+		inspection.Chain = append(inspection.Chain, describe(cert))
+	}
+
+	if len(state.PeerCertificates) == 0 {
+		inspection.VerifyError = "the server did not present a certificate"
+		return inspection
+	}
+
+	leaf := state.PeerCertificates[0]
+	intermediates := x509.NewCertPool()
+
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err = leaf.Verify(x509.VerifyOptions{Roots: i.Roots, Intermediates: intermediates, CurrentTime: now})
+	if err != nil {
+		inspection.VerifyError = err.Error()
+	}
+
+	inspection.ChainVerified = err == nil
+	inspection.HostnameVerified = leaf.VerifyHostname(serverName) == nil
+
+	return inspection
+}
+
+// describe copies the details of the certificate
+func describe(cert *x509.Certificate) models.Certificate {
+	fingerprint := sha256.Sum256(cert.Raw)
+	keyType, keySize := publicKey(cert)
+
+	sans := append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+
+	return models.Certificate{
+		Subject:            cert.Subject.String(),
+		SANs:               sans,
+		Issuer:             cert.Issuer.String(),
+		Serial:             strings.ToUpper(cert.SerialNumber.Text(16)),
+		NotBefore:          cert.NotBefore.UTC(),
+		NotAfter:           cert.NotAfter.UTC(),
+		KeyType:            keyType,
+		KeySize:            keySize,
+		SignatureAlgorithm: cert.SignatureAlgorithm.String(),
+		Fingerprint:        hex.EncodeToString(fingerprint[:]),
+	}
+}
+
+// publicKey returns the algorithm and the size in bits of the key of the certificate
+func publicKey(cert *x509.Certificate) (string, int) {
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return "RSA", key.N.BitLen()
+	case *ecdsa.PublicKey:
+		return "ECDSA", key.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return "Ed25519", 256
+	}
+
+	return cert.PublicKeyAlgorithm.String(), 0
+}
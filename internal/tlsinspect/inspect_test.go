@@ -0,0 +1,83 @@
+package tlsinspect
+
+import (
+	"context"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspect(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	address := server.Listener.Addr().String()
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	inspector := NewInspector(time.Second)
+	inspector.Roots = roots
+
+	// the certificate of httptest covers example.com and 127.0.0.1
+	inspection := inspector.Inspect(context.Background(), address, "example.com")
+	c.Empty(inspection.Error)
+	c.Empty(inspection.VerifyError)
+	c.True(inspection.ChainVerified)
+	c.True(inspection.HostnameVerified)
+	c.Equal("TLS 1.3", inspection.Version)
+	c.Len(inspection.Chain, 1)
+
+	leaf := inspection.Leaf()
+	c.Equal("O=Acme Co", leaf.Subject)
+	c.Contains(leaf.SANs, "example.com")
+	c.Contains(leaf.SANs, "127.0.0.1")
+	c.Equal("RSA", leaf.KeyType)
+	c.Equal(2048, leaf.KeySize)
+	c.Equal("SHA256-RSA", leaf.SignatureAlgorithm)
+	c.Len(leaf.Fingerprint, 64)
+	c.NotEmpty(leaf.Serial)
+	c.True(leaf.NotAfter.After(leaf.NotBefore))
+
+	days, ok := inspection.DaysLeft(time.Now())
+	c.True(ok)
+	c.Greater(days, 365)
+
+	inspection = inspector.Inspect(context.Background(), address, "other.com")
+	c.True(inspection.ChainVerified)
+	c.False(inspection.HostnameVerified, "the certificate does not cover other.com")
+
+	inspection = NewInspector(time.Second).Inspect(context.Background(), address, "example.com")
+	c.False(inspection.ChainVerified, "the test root is not trusted by the system")
+	c.NotEmpty(inspection.VerifyError)
+	c.Len(inspection.Chain, 1, "the chain is recorded when it is not trusted")
+
+	inspector.now = func() time.Time { return leaf.NotAfter.Add(time.Hour) }
+
+	inspection = inspector.Inspect(context.Background(), address, "example.com")
+	c.False(inspection.ChainVerified, "the certificate expired")
+
+	days, _ = inspection.DaysLeft(leaf.NotAfter.Add(25 * time.Hour))
+	c.Equal(-2, days)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	c.NoError(err)
+	closed := listener.Addr().String()
+	c.NoError(listener.Close())
+
+	inspection = inspector.Inspect(context.Background(), closed, "example.com")
+	c.NotEmpty(inspection.Error)
+	c.Empty(inspection.Chain)
+	c.Nil(inspection.Leaf())
+
+	_, ok = inspection.DaysLeft(time.Now())
+	c.False(ok)
+}
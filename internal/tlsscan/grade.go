@@ -0,0 +1,78 @@
+package tlsscan
+
+import (
+	"crypto/tls"
+	"strings"
+	"time"
+
+	"github.com/other_project/crockroach/models"
+)
+
+// Grade derives an SSL Labs like letter grade from the report. Every rule caps the grade and
+// the worst cap wins:
+//
+//   - the certificate is not valid for the server name: M
+//   - the chain is not trusted or the certificate expired: T
+//   - neither TLS 1.2 nor TLS 1.3 is supported: C
+//   - RC4 or 3DES suites are accepted: C
+//   - TLS 1.0 or TLS 1.1 is supported: B
+//   - no suite with forward secrecy is accepted: B
+//   - TLS 1.3 is not supported: A-
+//
+// An endpoint without caps gets an A. SSL Labs grants A+ with HSTS, an HTTP header a TLS scan
+// does not see, so it is never given. The reasons explain every cap, the grade is empty when no
+// handshake succeeded
+func Grade(report *Report) (string, []string) {
+	if len(report.Versions) == 0 {
+		return "", []string{}
+	}
+
+	grade := "A"
+	reasons := []string{}
+
+	limit := func(worst, reason string) {
+		reasons = append(reasons, reason)
+
+		if models.GradeRank(worst) > models.GradeRank(grade) {
+			grade = worst
+		}
+	}
+
+	certificate := report.Certificate
+	if certificate == nil || certificate.Error != "" {
+		limit("T", "the certificate could not be read")
+	} else {
+		if !certificate.HostnameVerified {
+			limit("M", "the certificate is not valid for "+report.ServerName)
+		}
+
+		// the verification of an expired chain fails too
+		if days, ok := certificate.DaysLeft(time.Now()); ok && days < 0 {
+			limit("T", "the certificate expired")
+		} else if !certificate.ChainVerified {
+			limit("T", "the certificate is not trusted: "+certificate.VerifyError)
+		}
+	}
+
+	if !report.Supports(tls.VersionTLS12) && !report.Supports(tls.VersionTLS13) {
+		limit("C", "TLS 1.2 is not supported")
+	}
+
+	if len(report.WeakCiphers) > 0 {
+		limit("C", "weak cipher suites are accepted: "+strings.Join(report.WeakCiphers, ", "))
+	}
+
+	if report.Supports(tls.VersionTLS10) || report.Supports(tls.VersionTLS11) {
+		limit("B", "TLS 1.0 or TLS 1.1 is supported")
+	}
+
+	if !report.ForwardSecrecy {
+		limit("B", "forward secrecy is not supported")
+	}
+
+	if !report.Supports(tls.VersionTLS13) {
+		limit("A-", "TLS 1.3 is not supported")
+	}
+
+	return grade, reasons
+}
@@ -0,0 +1,69 @@
+package tlsscan
+
+import (
+	"testing"
+	"time"
+
+	"github.com/other_project/crockroach/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGrade(t *testing.T) {
+	c := require.New(t)
+
+	valid := func() *models.TLSInspection {
+		return &models.TLSInspection{
+			ChainVerified:    true,
+			HostnameVerified: true,
+			Chain:            []models.Certificate{{NotAfter: time.Now().Add(90 * 24 * time.Hour)}},
+		}
+	}
+
+	report := func(change func(report *Report)) *Report {
+		report := &Report{
+			ServerName:     "example.com",
+			Versions:       []string{"TLS 1.2", "TLS 1.3"},
+			WeakCiphers:    []string{},
+			ForwardSecrecy: true,
+			Certificate:    valid(),
+		}
+
+		if change != nil {
+			change(report)
+		}
+
+		return report
+	}
+
+	cases := []struct {
+		name   string
+		report *Report
+		grade  string
+	}{
+		{"modern", report(nil), "A"},
+		{"without TLS 1.3", report(func(r *Report) { r.Versions = []string{"TLS 1.2"} }), "A-"},
+		{"TLS 1.0", report(func(r *Report) { r.Versions = []string{"TLS 1.0", "TLS 1.2", "TLS 1.3"} }), "B"},
+		{"without forward secrecy", report(func(r *Report) { r.ForwardSecrecy = false }), "B"},
+		{"only TLS 1.1", report(func(r *Report) { r.Versions = []string{"TLS 1.1"} }), "C"},
+		{"RC4", report(func(r *Report) { r.WeakCiphers = []string{"TLS_RSA_WITH_RC4_128_SHA"} }), "C"},
+		{"untrusted", report(func(r *Report) { r.Certificate.ChainVerified = false }), "T"},
+		{"expired", report(func(r *Report) {
+			r.Certificate.ChainVerified = false
+			r.Certificate.Chain[0].NotAfter = time.Now().Add(-48 * time.Hour)
+		}), "T"},
+		{"handshake without certificate", report(func(r *Report) { r.Certificate = &models.TLSInspection{Error: "EOF"} }), "T"},
+		{"other name", report(func(r *Report) { r.Certificate.HostnameVerified = false }), "M"},
+		{"no handshake", report(func(r *Report) { r.Versions = []string{} }), ""},
+	}
+
+	for _, tc := range cases {
+		grade, reasons := Grade(tc.report)
+		c.Equal(tc.grade, grade, "%s: %v", tc.name, reasons)
+
+		if tc.grade == "A" || tc.grade == "" {
+			c.Empty(reasons, tc.name)
+		} else {
+			c.NotEmpty(reasons, tc.name)
+		}
+	}
+}
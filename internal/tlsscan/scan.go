@@ -0,0 +1,219 @@
+package tlsscan
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/internal/tlsinspect"
+	"github.com/other_project/crockroach/models"
+)
+
+const (
+	// ProtocolHTTP2 is the ALPN identifier of HTTP/2
+	ProtocolHTTP2 = "h2"
+)
+
+// probedVersions are the TLS versions tried by the scanner, the oldest first
+var probedVersions = []uint16{tls.VersionTLS10, tls.VersionTLS11, tls.VersionTLS12, tls.VersionTLS13}
+
+// Report is the TLS configuration of an endpoint. Grade is empty when no handshake succeeded
+type Report struct {
+	Address        string                `json:"address"`
+	ServerName     string                `json:"server_name"`
+	Versions       []string              `json:"versions"`
+	WeakCiphers    []string              `json:"weak_ciphers"`
+	ForwardSecrecy bool                  `json:"forward_secrecy"`
+	ALPN           string                `json:"alpn"`
+	HTTP2          bool                  `json:"http2"`
+	OCSPStapled    bool                  `json:"ocsp_stapled"`
+	Certificate    *models.TLSInspection `json:"certificate"`
+	Grade          string                `json:"grade"`
+	Reasons        []string              `json:"reasons"`
+	Error          string                `json:"error,omitempty"`
+}
+
+// Supports reports if the endpoint accepted the TLS version
+func (r *Report) Supports(version uint16) bool {
+	name := tlsinspect.VersionName(version)
+
+	for _, supported := range r.Versions {
+		if supported == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Scanner probes the TLS configuration of the endpoints without an external service
+type Scanner struct {
+	// Roots trusted to verify the certificates, nil uses the roots of the system
+	Roots *x509.CertPool
+	// Timeout of every handshake
+	Timeout time.Duration
+}
+
+// NewScanner creates a scanner that trusts the roots of the system
+func NewScanner(timeout time.Duration) *Scanner {
+	return &Scanner{Timeout: timeout}
+}
+
+// Scan performs a handshake per TLS version and per group of cipher suites with the address,
+// sending serverName as SNI, and grades the endpoint
+func (s *Scanner) Scan(ctx context.Context, address, serverName string) *Report {
+	report := &Report{
+		Address:     address,
+		ServerName:  serverName,
+		Versions:    []string{},
+		WeakCiphers: []string{},
+		Reasons:     []string{},
+	}
+
+	target := address
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		target = net.JoinHostPort(address, tlsinspect.DefaultPort)
+	}
+
+	var best *tls.ConnectionState
+
+	for _, version := range probedVersions {
+		state, err := s.handshake(ctx, target, serverName, version, version, allSuites())
+		if err != nil {
+			report.Error = err.Error()
+			continue
+		}
+
+		report.Versions = append(report.Versions, tlsinspect.VersionName(version))
+		best = state
+	}
+
+	if best == nil {
+		return report
+	}
+
+	report.Error = ""
+	report.ALPN = best.NegotiatedProtocol
+	report.HTTP2 = best.NegotiatedProtocol == ProtocolHTTP2
+	report.OCSPStapled = len(best.OCSPResponse) > 0
+
+	inspector := tlsinspect.NewInspector(s.Timeout)
+	inspector.Roots = s.Roots
+	inspector.CipherSuites = allSuites()
+	report.Certificate = inspector.Inspect(ctx, address, serverName)
+
+	// the suites of TLS 1.3 cannot be chosen and all of them have forward secrecy
+	report.ForwardSecrecy = report.Supports(tls.VersionTLS13)
+	if !report.ForwardSecrecy {
+		_, err := s.handshake(ctx, target, serverName, tls.VersionTLS10, tls.VersionTLS12, forwardSecrecySuites())
+		report.ForwardSecrecy = err == nil
+	}
+
+	report.WeakCiphers = s.weakCiphers(ctx, target, serverName)
+	report.Grade, report.Reasons = Grade(report)
+
+	return report
+}
+
+// weakCiphers offers the weak suites until the endpoint rejects the remaining ones
+func (s *Scanner) weakCiphers(ctx context.Context, target, serverName string) []string {
+	accepted := []string{}
+	offered := weakSuites()
+
+	for len(offered) > 0 {
+		state, err := s.handshake(ctx, target, serverName, tls.VersionTLS10, tls.VersionTLS12, offered)
+		if err != nil {
+			break
+		}
+
+		accepted = append(accepted, tls.CipherSuiteName(state.CipherSuite))
+		offered = without(offered, state.CipherSuite)
+	}
+
+	return accepted
+}
+
+// handshake connects to the target offering the versions between min and max and the suites
+func (s *Scanner) handshake(ctx context.Context, target, serverName string, min, max uint16, suites []uint16) (*tls.ConnectionState, error) {
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: s.Timeout},
+		// the certificate is verified by the inspector, the probes only negotiate
+		Config: &tls.Config{ //nolint:gosec
+			ServerName:         serverName,
+			InsecureSkipVerify: true,
+			MinVersion:         min,
+			MaxVersion:         max,
+			CipherSuites:       suites,
+			NextProtos:         []string{ProtocolHTTP2, "http/1.1"},
+		},
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", target)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := conn.Close(); err != nil {
+			logs.Log().Errorf("Error tls connection close %s ", err.Error())
+		}
+	}()
+
+	state := conn.(*tls.Conn).ConnectionState()
+
+	return &state, nil
+}
+
+// allSuites returns every suite implemented by crypto/tls, the insecure ones included
+func allSuites() []uint16 {
+	suites := []uint16{}
+
+	for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		suites = append(suites, suite.ID)
+	}
+
+	return suites
+}
+
+// forwardSecrecySuites returns the suites with an ephemeral key exchange
+func forwardSecrecySuites() []uint16 {
+	suites := []uint16{}
+
+	for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		if strings.HasPrefix(suite.Name, "TLS_ECDHE_") {
+			suites = append(suites, suite.ID)
+		}
+	}
+
+	return suites
+}
+
+// weakSuites returns the suites that use RC4 or 3DES
+func weakSuites() []uint16 {
+	suites := []uint16{}
+
+	for _, suite := range tls.InsecureCipherSuites() {
+		if strings.Contains(suite.Name, "_RC4_") || strings.Contains(suite.Name, "_3DES_") {
+			suites = append(suites, suite.ID)
+		}
+	}
+
+	return suites
+}
+
+// without returns the suites except the removed one
+func without(suites []uint16, removed uint16) []uint16 {
+	items := []uint16{}
+
+	for _, suite := range suites {
+		if suite != removed {
+			items = append(items, suite)
+		}
+	}
+
+	return items
+}
@@ -0,0 +1,100 @@
+package tlsscan
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/stretchr/testify/require"
+)
+
+// newServer starts a TLS server with the configuration and a scanner that trusts its certificate
+func newServer(c *require.Assertions, config *tls.Config, http2 bool) (*httptest.Server, *Scanner) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = config
+	server.EnableHTTP2 = http2
+	// the probes with versions and suites the server rejects are expected
+	server.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+	server.StartTLS()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	scanner := NewScanner(time.Second)
+	scanner.Roots = roots
+
+	return server, scanner
+}
+
+func TestScan(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	server, scanner := newServer(c, &tls.Config{MinVersion: tls.VersionTLS12}, true)
+	defer server.Close()
+
+	// the certificate of httptest covers example.com
+	report := scanner.Scan(context.Background(), server.Listener.Addr().String(), "example.com")
+	c.Empty(report.Error)
+	c.Equal([]string{"TLS 1.2", "TLS 1.3"}, report.Versions)
+	c.Empty(report.WeakCiphers)
+	c.True(report.ForwardSecrecy)
+	c.True(report.HTTP2)
+	c.Equal(ProtocolHTTP2, report.ALPN)
+	c.False(report.OCSPStapled)
+	c.True(report.Certificate.ChainVerified)
+	c.Equal("A", report.Grade, report.Reasons)
+	c.Empty(report.Reasons)
+
+	report = NewScanner(time.Second).Scan(context.Background(), server.Listener.Addr().String(), "example.com")
+	c.Equal("T", report.Grade, "the test root is not trusted by the system")
+
+	report = scanner.Scan(context.Background(), server.Listener.Addr().String(), "other.com")
+	c.Equal("M", report.Grade)
+}
+
+func TestScanLegacy(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	server, scanner := newServer(c, &tls.Config{
+		MaxVersion: tls.VersionTLS12,
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_RC4_128_SHA,
+		},
+	}, false)
+	defer server.Close()
+
+	report := scanner.Scan(context.Background(), server.Listener.Addr().String(), "example.com")
+	c.Equal([]string{"TLS 1.2"}, report.Versions)
+	c.Equal([]string{"TLS_ECDHE_RSA_WITH_RC4_128_SHA"}, report.WeakCiphers)
+	c.True(report.ForwardSecrecy)
+	c.False(report.HTTP2)
+	c.True(report.Certificate.ChainVerified)
+	c.Equal("C", report.Grade)
+	c.Contains(report.Reasons, "weak cipher suites are accepted: TLS_ECDHE_RSA_WITH_RC4_128_SHA")
+	c.Contains(report.Reasons, "TLS 1.3 is not supported")
+}
+
+func TestScanUnreachable(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	address := server.Listener.Addr().String()
+	server.Close()
+
+	report := NewScanner(time.Second).Scan(context.Background(), address, "example.com")
+	c.NotEmpty(report.Error)
+	c.Empty(report.Versions)
+	c.Empty(report.Grade)
+	c.Nil(report.Certificate)
+}
@@ -0,0 +1,105 @@
+package uptime
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/other_project/crockroach/models"
+)
+
+// Window is a period summarized by the API
+type Window struct {
+	Name     string
+	Duration time.Duration
+}
+
+// Percentiles of the time to first byte in milliseconds
+type Percentiles struct {
+	P50 int64 `json:"p50"`
+	P90 int64 `json:"p90"`
+	P95 int64 `json:"p95"`
+	P99 int64 `json:"p99"`
+}
+
+// Summary is the availability of a domain during a window. Uptime is a percentage,
+// the latency only uses the checks where the site answered
+type Summary struct {
+	Window  string      `json:"window"`
+	Checks  int         `json:"checks"`
+	Up      int         `json:"up"`
+	Uptime  float64     `json:"uptime_percent"`
+	Latency Percentiles `json:"latency_ms"`
+}
+
+var (
+	// Windows are the periods returned by the API, the longest is the last one
+	Windows = []Window{
+		{Name: "24h", Duration: 24 * time.Hour},
+		{Name: "7d", Duration: 7 * 24 * time.Hour},
+		{Name: "30d", Duration: 30 * 24 * time.Hour},
+	}
+)
+
+// Summarize computes a summary for every window ending at now
+func Summarize(checks []*models.UptimeCheck, now time.Time) []Summary {
+	summaries := make([]Summary, 0, len(Windows))
+
+	for _, window := range Windows {
+		summaries = append(summaries, summarize(window, checks, now))
+	}
+
+	return summaries
+}
+
+// summarize computes the summary of the checks created during the window
+func summarize(window Window, checks []*models.UptimeCheck, now time.Time) Summary {
+	since := now.Add(-window.Duration)
+	summary := Summary{Window: window.Name}
+	latencies := []int64{}
+
+	for _, check := range checks {
+		if check.CreationDate == nil || check.CreationDate.Before(since) || check.CreationDate.After(now) {
+			continue
+		}
+
+		summary.Checks++
+
+		if check.Up {
+			summary.Up++
+		}
+
+		if check.Reachable() {
+			latencies = append(latencies, check.TTFB)
+		}
+	}
+
+	if summary.Checks > 0 {
+		summary.Uptime = math.Round(float64(summary.Up)*10000/float64(summary.Checks)) / 100
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	summary.Latency = Percentiles{
+		P50: percentile(latencies, 50),
+		P90: percentile(latencies, 90),
+		P95: percentile(latencies, 95),
+		P99: percentile(latencies, 99),
+	}
+
+	return summary
+}
+
+// percentile returns the nearest rank percentile of the sorted values, 0 without values
+func percentile(sorted []int64, p float64) int64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
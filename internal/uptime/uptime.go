@@ -0,0 +1,223 @@
+package uptime
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/models"
+)
+
+const (
+	// MaxRedirects is the longest redirect chain followed by a check
+	MaxRedirects = 10
+	// maxBodyRead is the part of the body read to measure the total time and to match the expectations
+	maxBodyRead = 1 << 20
+)
+
+var (
+	// ErrTooManyRedirects when the redirect chain is longer than MaxRedirects
+	ErrTooManyRedirects = errors.New("stopped after too many redirects")
+)
+
+// timings collects the phases of the requests, the trace hooks can run in other goroutines
+type timings struct {
+	mu           sync.Mutex
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	firstByte    time.Time
+	dns          time.Duration
+	connect      time.Duration
+	tls          time.Duration
+	failed       string
+}
+
+// trace returns the hooks that fill the timings
+func (t *timings) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mu.Lock()
+			t.dnsStart = time.Now()
+			t.mu.Unlock()
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			t.mu.Lock()
+			t.dns += time.Since(t.dnsStart)
+			t.fail(models.ErrorClassDNS, info.Err)
+			t.mu.Unlock()
+		},
+		ConnectStart: func(network, addr string) {
+			t.mu.Lock()
+			t.connectStart = time.Now()
+			t.mu.Unlock()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			t.mu.Lock()
+			t.connect += time.Since(t.connectStart)
+			t.fail(models.ErrorClassConnect, err)
+			t.mu.Unlock()
+		},
+		TLSHandshakeStart: func() {
+			t.mu.Lock()
+			t.tlsStart = time.Now()
+			t.mu.Unlock()
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			t.mu.Lock()
+			t.tls += time.Since(t.tlsStart)
+			t.fail(models.ErrorClassTLS, err)
+			t.mu.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			t.firstByte = time.Now()
+			t.mu.Unlock()
+		},
+	}
+}
+
+// fail remembers the phase that failed last
+func (t *timings) fail(class string, err error) {
+	if err != nil {
+		t.failed = class
+	}
+}
+
+// Check requests the URL with the expectations of the health check and measures every phase.
+// A failed request does not return an error, it is recorded as a down site with its error class
+func Check(ctx context.Context, client *http.Client, target string, expect models.HealthCheck) *models.UptimeCheck {
+	checkID, err := uuid.NewV4()
+	if err != nil {
+		logs.Log().Errorf("cannot create the uptime check id %s", err.Error())
+	}
+
+	start := time.Now()
+
+	check := &models.UptimeCheck{
+		CheckID:      checkID.String(),
+		URL:          target,
+		Redirects:    []string{},
+		CreationDate: &start,
+	}
+
+	t := &timings{}
+
+	traced := *client
+	traced.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		check.Redirects = append(check.Redirects, req.URL.String())
+
+		if !expect.FollowRedirects() {
+			return http.ErrUseLastResponse
+		}
+
+		if len(via) >= MaxRedirects {
+			return ErrTooManyRedirects
+		}
+
+		return nil
+	}
+
+	request, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, t.trace()), expect.RequestMethod(), target, nil)
+	if err != nil {
+		check.ErrorClass = models.ErrorClassOther
+		return check
+	}
+
+	for name, value := range expect.Headers {
+		if strings.EqualFold(name, "Host") {
+			request.Host = value
+			continue
+		}
+
+		request.Header.Set(name, value)
+	}
+
+	body := []byte{}
+
+	resp, err := traced.Do(request)
+	if err == nil {
+		body, err = ioutil.ReadAll(io.LimitReader(resp.Body, maxBodyRead))
+		if err != nil {
+			logs.Log().Errorf("Error read body of %s %s ", target, err.Error())
+		}
+
+		erro := resp.Body.Close()
+		if erro != nil {
+			logs.Log().Errorf("Error response body close %s ", erro.Error())
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	check.TotalTime = time.Since(start).Milliseconds()
+	check.DNSTime = t.dns.Milliseconds()
+	check.ConnectTime = t.connect.Milliseconds()
+	check.TLSTime = t.tls.Milliseconds()
+
+	if !t.firstByte.IsZero() {
+		check.TTFB = t.firstByte.Sub(start).Milliseconds()
+	}
+
+	if resp != nil {
+		check.StatusCode = resp.StatusCode
+	}
+
+	if err != nil {
+		check.ErrorClass = classify(err, t.failed)
+		return check
+	}
+
+	switch {
+	case !expect.Accepts(resp.StatusCode):
+		check.ErrorClass = models.ErrorClassStatus
+	case expect.ReadsBody() && !expect.MatchBody(body):
+		check.ErrorClass = models.ErrorClassBody
+	case expect.MaxResponseTime > 0 && check.TotalTime > expect.MaxResponseTime:
+		check.ErrorClass = models.ErrorClassSlow
+	default:
+		check.Up = true
+	}
+
+	return check
+}
+
+// classify returns the error class of a failed request, failed is the phase reported by the trace
+func classify(err error, failed string) string {
+	var netErr net.Error
+	var dnsErr *net.DNSError
+	var opErr *net.OpError
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostname x509.HostnameError
+	var invalid x509.CertificateInvalidError
+	var header tls.RecordHeaderError
+
+	switch {
+	case errors.Is(err, ErrTooManyRedirects):
+		return models.ErrorClassRedirects
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return models.ErrorClassTimeout
+	case failed != "":
+		return failed
+	case errors.As(err, &dnsErr):
+		return models.ErrorClassDNS
+	case errors.As(err, &unknownAuthority), errors.As(err, &hostname), errors.As(err, &invalid), errors.As(err, &header):
+		return models.ErrorClassTLS
+	case errors.As(err, &opErr) && opErr.Op == "dial":
+		return models.ErrorClassConnect
+	}
+
+	return models.ErrorClassOther
+}
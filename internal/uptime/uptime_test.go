@@ -0,0 +1,171 @@
+package uptime
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/other_project/crockroach/internal/logs"
+	"github.com/other_project/crockroach/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/home", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/home", func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("<title>Home</title>")) })
+	mux.HandleFunc("/broken", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusServiceUnavailable) })
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) { http.Redirect(w, r, "/loop", http.StatusFound) })
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) { time.Sleep(200 * time.Millisecond) })
+
+	server := httptest.NewTLSServer(mux)
+	defer server.Close()
+
+	check := Check(context.Background(), server.Client(), server.URL+"/", models.HealthCheck{})
+	c.True(check.Up)
+	c.Equal(http.StatusOK, check.StatusCode)
+	c.Equal([]string{server.URL + "/home"}, check.Redirects)
+	c.Empty(check.ErrorClass)
+	c.NotEmpty(check.CheckID)
+	c.GreaterOrEqual(check.TotalTime, check.TTFB)
+
+	check = Check(context.Background(), server.Client(), server.URL+"/broken", models.HealthCheck{})
+	c.False(check.Up)
+	c.True(check.Reachable())
+	c.Equal(models.ErrorClassStatus, check.ErrorClass)
+
+	check = Check(context.Background(), server.Client(), server.URL+"/loop", models.HealthCheck{})
+	c.False(check.Up)
+	c.Equal(http.StatusFound, check.StatusCode)
+	c.Equal(models.ErrorClassRedirects, check.ErrorClass)
+	c.Len(check.Redirects, MaxRedirects)
+
+	client := server.Client()
+	client.Timeout = 50 * time.Millisecond
+
+	check = Check(context.Background(), client, server.URL+"/slow", models.HealthCheck{})
+	c.Equal(models.ErrorClassTimeout, check.ErrorClass)
+
+	check = Check(context.Background(), http.DefaultClient, server.URL+"/", models.HealthCheck{})
+	c.False(check.Up)
+	c.Equal(models.ErrorClassTLS, check.ErrorClass, "the certificate of the test server is not trusted")
+
+	closed := httptest.NewServer(mux)
+	closed.Close()
+
+	check = Check(context.Background(), http.DefaultClient, closed.URL, models.HealthCheck{})
+	c.False(check.Up)
+	c.Equal(models.ErrorClassConnect, check.ErrorClass)
+
+	check = Check(context.Background(), http.DefaultClient, "https://example.invalid", models.HealthCheck{})
+	c.False(check.Up)
+	c.Equal(models.ErrorClassDNS, check.ErrorClass)
+}
+
+func TestCheckExpectations(t *testing.T) {
+	c := require.New(t)
+	c.NoError(logs.InitLogger())
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/login", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/staging", func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		if !ok || user != "ops" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"status": "ok", "version": "1.2.3"}`))
+	})
+	mux.HandleFunc("/empty", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	})
+
+	server := httptest.NewTLSServer(mux)
+	defer server.Close()
+
+	auth := map[string]string{"authorization": "Basic b3BzOnNlY3JldA=="}
+
+	cases := []struct {
+		name   string
+		path   string
+		expect models.HealthCheck
+		up     bool
+		status int
+		class  string
+	}{
+		{"accepted redirect is not followed", "/", models.HealthCheck{AcceptedStatus: []int{301}}, true, http.StatusMovedPermanently, ""},
+		{"redirect is followed by default", "/", models.HealthCheck{}, true, http.StatusOK, ""},
+		{"unauthorized is not accepted", "/staging", models.HealthCheck{}, false, http.StatusUnauthorized, models.ErrorClassStatus},
+		{"unauthorized is accepted", "/staging", models.HealthCheck{AcceptedStatus: []int{401}}, true, http.StatusUnauthorized, ""},
+		{"basic auth with body", "/staging", models.HealthCheck{Headers: auth, BodyContains: `"status": "ok"`, BodyRegex: `"version": "1\.\d+`}, true, http.StatusOK, ""},
+		{"body mismatch", "/staging", models.HealthCheck{Headers: auth, BodyRegex: `"version": "2\.`}, false, http.StatusOK, models.ErrorClassBody},
+		{"no content", "/empty", models.HealthCheck{Method: http.MethodHead, AcceptedStatus: []int{204}}, true, http.StatusNoContent, ""},
+		{"slow response", "/slow", models.HealthCheck{MaxResponseTime: 20}, false, http.StatusOK, models.ErrorClassSlow},
+	}
+
+	for _, tc := range cases {
+		check := Check(context.Background(), server.Client(), server.URL+tc.path, tc.expect)
+		c.Equal(tc.up, check.Up, tc.name)
+		c.Equal(tc.status, check.StatusCode, tc.name)
+		c.Equal(tc.class, check.ErrorClass, tc.name)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	c := require.New(t)
+
+	now := time.Now()
+	checks := []*models.UptimeCheck{}
+
+	add := func(ago time.Duration, up bool, status int, ttfb int64) {
+		created := now.Add(-ago)
+		checks = append(checks, &models.UptimeCheck{Up: up, StatusCode: status, TTFB: ttfb, CreationDate: &created})
+	}
+
+	for i := 1; i <= 10; i++ {
+		add(time.Duration(i)*time.Hour, true, http.StatusOK, int64(i*10))
+	}
+
+	add(2*time.Hour, false, http.StatusBadGateway, 500)
+	add(3*time.Hour, false, 0, 0)
+	add(3*24*time.Hour, false, 0, 0)
+	add(20*24*time.Hour, true, http.StatusOK, 1000)
+	add(40*24*time.Hour, true, http.StatusOK, 1000)
+
+	summaries := Summarize(checks, now)
+	c.Len(summaries, 3)
+
+	day := summaries[0]
+	c.Equal("24h", day.Window)
+	c.Equal(12, day.Checks)
+	c.Equal(10, day.Up)
+	c.Equal(83.33, day.Uptime)
+	c.Equal(Percentiles{P50: 60, P90: 100, P95: 500, P99: 500}, day.Latency)
+
+	c.Equal(13, summaries[1].Checks)
+	c.Equal(76.92, summaries[1].Uptime)
+
+	c.Equal(14, summaries[2].Checks)
+	c.Equal(int64(1000), summaries[2].Latency.P99)
+
+	empty := Summarize(nil, now)[0]
+	c.Equal(0, empty.Checks)
+	c.Equal(float64(0), empty.Uptime)
+	c.Equal(Percentiles{}, empty.Latency)
+}
@@ -1,7 +1,12 @@
 package main
 
 import (
+	"context"
+	"os"
+
 	"github.com/other_project/crockroach/api"
+	"github.com/other_project/crockroach/api/httphand"
+	"github.com/other_project/crockroach/internal/cli"
 	"github.com/other_project/crockroach/internal/logs"
 	"github.com/other_project/crockroach/internal/storage"
 )
@@ -9,8 +14,19 @@ import (
 func main() {
 	_ = logs.InitLogger()
 
+	if len(os.Args) > 1 && os.Args[1] != "serve" {
+		os.Exit(cli.Run(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+	}
+
 	store := storage.NewStore()
-	mux := api.Routes(store)
+	handler := httphand.NewHandlerRequest(store)
+	mux := api.Routes(handler)
 	server := api.NewServer(mux)
+
+	// the jobs are resumed once the database is connected
+	if storage.CockroachClient != nil {
+		handler.Start(context.Background())
+	}
+
 	server.Run()
 }
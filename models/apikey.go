@@ -0,0 +1,128 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/other_project/crockroach/internal/apperr"
+)
+
+const (
+	// ScopeRead allows to query the stored analyses
+	ScopeRead = "read"
+	// ScopeAnalyze allows to launch new analyses
+	ScopeAnalyze = "analyze"
+	// ScopeAdmin allows everything, including the key management
+	ScopeAdmin = "admin"
+
+	// apiKeyPrefix identifies the secrets generated by the service
+	apiKeyPrefix = "crk_"
+	// apiKeyBytes is the entropy of a secret
+	apiKeyBytes = 32
+	// apiKeyVisible is the number of characters of the secret kept to identify the key
+	apiKeyVisible = 12
+)
+
+var (
+	// ErrEmptyKeyName when the key does not have a name
+	ErrEmptyKeyName = apperr.New(apperr.Unprocessable, "empty_key_name", "key name cannot be empty")
+	// ErrInvalidScope when the scope does not exist
+	ErrInvalidScope = apperr.New(apperr.Unprocessable, "invalid_scope", "scope must be read, analyze or admin")
+	// ErrEmptyScopes when the key does not have scopes
+	ErrEmptyScopes = apperr.New(apperr.Unprocessable, "empty_scopes", "at least one scope is required")
+	// Scopes contains every valid scope
+	Scopes = []string{ScopeRead, ScopeAnalyze, ScopeAdmin}
+)
+
+// APIKey model structure for the keys that authenticate the clients.
+// Only the SHA-256 hash of the secret is stored
+type APIKey struct {
+	KeyID        string     `json:"key_id"`
+	Name         string     `json:"name"`
+	Prefix       string     `json:"prefix"`
+	Hash         string     `json:"-"`
+	Scopes       []string   `json:"scopes"`
+	CreationDate *time.Time `json:"creation_date"`
+	RevokedDate  *time.Time `json:"revoked_date,omitempty"`
+}
+
+// NewAPIKey Initialize a new key and returns the secret that must be given to the client
+func NewAPIKey(name string, scopes []string) (key *APIKey, secret string, err error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, "", ErrEmptyKeyName
+	}
+
+	if len(scopes) == 0 {
+		return nil, "", ErrEmptyScopes
+	}
+
+	for _, scope := range scopes {
+		if !validScope(scope) {
+			return nil, "", ErrInvalidScope
+		}
+	}
+
+	random := make([]byte, apiKeyBytes)
+
+	_, err = rand.Read(random)
+	if err != nil {
+		return nil, "", err
+	}
+
+	secret = apiKeyPrefix + base64.RawURLEncoding.EncodeToString(random)
+
+	keyID, err := uuid.NewV4()
+	if err != nil {
+		return nil, "", err
+	}
+
+	created := time.Now()
+
+	key = &APIKey{
+		KeyID:        keyID.String(),
+		Name:         strings.TrimSpace(name),
+		Prefix:       secret[:apiKeyVisible],
+		Hash:         HashAPIKey(secret),
+		Scopes:       scopes,
+		CreationDate: &created,
+	}
+
+	return key, secret, nil
+}
+
+// HashAPIKey returns the hex encoded SHA-256 hash of the secret
+func HashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// HasScope reports if the key grants the scope, admin grants every scope
+func (k *APIKey) HasScope(scope string) bool {
+	if k.RevokedDate != nil {
+		return false
+	}
+
+	for _, granted := range k.Scopes {
+		if granted == scope || granted == ScopeAdmin {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validScope reports if the scope exists
+func validScope(scope string) bool {
+	for _, valid := range Scopes {
+		if scope == valid {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAPIKey(t *testing.T) {
+	c := require.New(t)
+
+	key, secret, err := NewAPIKey("ci", []string{ScopeRead, ScopeAnalyze})
+	c.NoError(err)
+	c.NotEmpty(key.KeyID)
+	c.True(strings.HasPrefix(secret, "crk_"))
+	c.True(strings.HasPrefix(secret, key.Prefix))
+	c.Equal(HashAPIKey(secret), key.Hash)
+	c.NotContains(key.Hash, secret)
+
+	_, other, err := NewAPIKey("ci", []string{ScopeRead})
+	c.NoError(err)
+	c.NotEqual(secret, other)
+
+	_, _, err = NewAPIKey("", []string{ScopeRead})
+	c.EqualError(ErrEmptyKeyName, err.Error())
+
+	_, _, err = NewAPIKey("ci", nil)
+	c.EqualError(ErrEmptyScopes, err.Error())
+
+	_, _, err = NewAPIKey("ci", []string{"write"})
+	c.EqualError(ErrInvalidScope, err.Error())
+}
+
+func TestAPIKeyHasScope(t *testing.T) {
+	c := require.New(t)
+
+	key := &APIKey{Scopes: []string{ScopeRead}}
+	c.True(key.HasScope(ScopeRead))
+	c.False(key.HasScope(ScopeAnalyze))
+
+	admin := &APIKey{Scopes: []string{ScopeAdmin}}
+	c.True(admin.HasScope(ScopeAnalyze))
+
+	revoked := time.Now()
+	admin.RevokedDate = &revoked
+	c.False(admin.HasScope(ScopeRead))
+}
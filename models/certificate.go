@@ -0,0 +1,73 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// Certificate model structure for a certificate of the chain presented by a server
+type Certificate struct {
+	Subject            string    `json:"subject"`
+	SANs               []string  `json:"sans"`
+	Issuer             string    `json:"issuer"`
+	Serial             string    `json:"serial"`
+	NotBefore          time.Time `json:"not_before"`
+	NotAfter           time.Time `json:"not_after"`
+	KeyType            string    `json:"key_type"`
+	KeySize            int       `json:"key_size"`
+	SignatureAlgorithm string    `json:"signature_algorithm"`
+	Fingerprint        string    `json:"sha256_fingerprint"`
+}
+
+// TLSInspection model structure for the TLS handshake with a server. Chain starts with the leaf,
+// Error is filled when the handshake failed and VerifyError when the chain is not trusted
+type TLSInspection struct {
+	InspectionID     string        `json:"inspection_id"`
+	ServerID         string        `json:"server_id"`
+	AnalysisID       string        `json:"analysis_id"`
+	Address          string        `json:"address"`
+	ServerName       string        `json:"server_name"`
+	Version          string        `json:"version"`
+	ChainVerified    bool          `json:"chain_verified"`
+	HostnameVerified bool          `json:"hostname_verified"`
+	VerifyError      string        `json:"verify_error"`
+	Error            string        `json:"error"`
+	Chain            []Certificate `json:"chain"`
+	CreationDate     *time.Time    `json:"creation_date"`
+}
+
+// Leaf returns the certificate of the server, nil when the handshake failed
+func (t *TLSInspection) Leaf() *Certificate {
+	if t == nil || len(t.Chain) == 0 {
+		return nil
+	}
+
+	return &t.Chain[0]
+}
+
+// DaysLeft returns the whole days until the leaf expires, negative when it already expired.
+// ok is false without a leaf
+func (t *TLSInspection) DaysLeft(now time.Time) (days int, ok bool) {
+	leaf := t.Leaf()
+	if leaf == nil {
+		return 0, false
+	}
+
+	return int(math.Floor(leaf.NotAfter.Sub(now).Hours() / 24)), true
+}
+
+// AttachInspections sets on each server its inspection, the inspections are ordered from the oldest
+// so a server inspected twice keeps the last one
+func AttachInspections(servers []*Server, inspections []*TLSInspection) {
+	byServer := map[string]*TLSInspection{}
+
+	for _, inspection := range inspections {
+		byServer[inspection.ServerID] = inspection
+	}
+
+	for _, server := range servers {
+		if inspection, ok := byServer[server.ServerID]; ok {
+			server.TLS = inspection
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package models
+
+import "strings"
+
+// LogoChanged reports if the hash of the cached logo differs from the previous one. A logo that
+// was not cached in both analyses is not compared
+func LogoChanged(current, previous *Domain) bool {
+	if current == nil || previous == nil {
+		return false
+	}
+
+	currentHash, previousHash := LogoHash(current.Logo), LogoHash(previous.Logo)
+
+	return currentHash != "" && previousHash != "" && currentHash != previousHash
+}
+
+// ServersChanged reports if the servers of current differ from the servers of previous.
+// IPv4 and IPv6 servers are compared apart, so the order in which a provider mixes the
+// families is not a change. Inside a family the servers are compared in order by address,
+// grade, country and owner
+func ServersChanged(current, previous *Domain) bool {
+	if current == nil || previous == nil || current == previous {
+		return false
+	}
+
+	if len(current.Servers) != len(previous.Servers) {
+		return true
+	}
+
+	currentFamilies := byFamily(current.Servers)
+	previousFamilies := byFamily(previous.Servers)
+
+	for family, servers := range previousFamilies {
+		if len(currentFamilies[family]) != len(servers) {
+			return true
+		}
+
+		for i, server := range servers {
+			if !sameServer(currentFamilies[family][i], server) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// byFamily groups the servers by address family keeping their order
+func byFamily(servers []*Server) map[string][]*Server {
+	families := make(map[string][]*Server)
+
+	for _, server := range servers {
+		family := AddressFamily(server.Address)
+		families[family] = append(families[family], server)
+	}
+
+	return families
+}
+
+// sameServer compares the attributes tracked between two analyses
+func sameServer(a, b *Server) bool {
+	return a.Address == b.Address &&
+		a.SSLGrade == b.SSLGrade &&
+		a.Country == b.Country &&
+		a.Owner == b.Owner
+}
+
+// DiffDNS compares the records of two snapshots of a domain. A record type whose query failed
+// in any of them is not compared, so a failing resolver is not reported as a change
+func DiffDNS(previous, current *DNSSnapshot) []DNSChange {
+	changes := []DNSChange{}
+
+	if previous == nil || current == nil {
+		return changes
+	}
+
+	for _, recordType := range DNSTypes {
+		if previous.Errors[recordType] != "" || current.Errors[recordType] != "" {
+			continue
+		}
+
+		before := previous.Records.Values(recordType)
+		after := current.Records.Values(recordType)
+
+		change := DNSChange{Type: recordType, Added: missing(after, before), Removed: missing(before, after)}
+		if len(change.Added) > 0 || len(change.Removed) > 0 {
+			changes = append(changes, change)
+		}
+	}
+
+	return changes
+}
+
+// missing returns the values that are not in others
+func missing(values, others []string) []string {
+	items := []string{}
+
+	for _, value := range values {
+		if !contains(others, value) {
+			items = append(items, value)
+		}
+	}
+
+	return items
+}
+
+// maxDiffCells limits the comparison table of DiffText, the lines between the common beginning
+// and end of larger texts are reported as replaced
+const maxDiffCells = 4 << 20
+
+// DiffText compares two texts line by line and returns the lines removed from before and the
+// lines added in after, in the order they appear. A replaced block lists its removed lines first
+func DiffText(before, after string) []TextChange {
+	a, b := textLines(before), textLines(after)
+	changes := []TextChange{}
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	a, b = a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	removed := func(i int) {
+		changes = append(changes, TextChange{Op: TextRemoved, Line: prefix + i + 1, Text: a[i]})
+	}
+
+	added := func(j int) {
+		changes = append(changes, TextChange{Op: TextAdded, Line: prefix + j + 1, Text: b[j]})
+	}
+
+	if len(a)*len(b) > maxDiffCells {
+		for i := range a {
+			removed(i)
+		}
+
+		for j := range b {
+			added(j)
+		}
+
+		return changes
+	}
+
+	// common[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	common := make([][]int32, len(a)+1)
+	for i := range common {
+		common[i] = make([]int32, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				common[i][j] = common[i+1][j+1] + 1
+			case common[i+1][j] >= common[i][j+1]:
+				common[i][j] = common[i+1][j]
+			default:
+				common[i][j] = common[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			i++
+			j++
+		case j == len(b) || (i < len(a) && common[i+1][j] >= common[i][j+1]):
+			removed(i)
+			i++
+		default:
+			added(j)
+			j++
+		}
+	}
+
+	return changes
+}
+
+// textLines splits the text in lines, an empty text does not have lines
+func textLines(text string) []string {
+	if text == "" {
+		return []string{}
+	}
+
+	return strings.Split(text, "\n")
+}
@@ -0,0 +1,142 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServersChanged(t *testing.T) {
+	c := require.New(t)
+
+	previous := &Domain{Servers: []*Server{
+		{Address: "10.0.0.1", SSLGrade: "A", Country: "US", Owner: "Amazon"},
+		{Address: "10.0.0.2", SSLGrade: "A", Country: "US", Owner: "Amazon"},
+	}}
+
+	current := &Domain{Servers: []*Server{
+		{Address: "10.0.0.1", SSLGrade: "A", Country: "US", Owner: "Amazon"},
+		{Address: "10.0.0.2", SSLGrade: "A", Country: "US", Owner: "Amazon"},
+	}}
+
+	c.False(ServersChanged(current, previous))
+	c.False(ServersChanged(current, current))
+	c.False(ServersChanged(current, nil))
+
+	current.Servers[1].SSLGrade = "B"
+	c.True(ServersChanged(current, previous))
+
+	current.Servers = append(previous.Servers, &Server{Address: "10.0.0.3"})
+	c.True(ServersChanged(current, previous))
+
+	current.Servers = previous.Servers[:1]
+	c.True(ServersChanged(current, previous))
+}
+
+func TestServersChangedByFamily(t *testing.T) {
+	c := require.New(t)
+
+	previous := &Domain{Servers: []*Server{
+		{Address: "10.0.0.1", SSLGrade: "A"},
+		{Address: "2001:db8::1", SSLGrade: "A"},
+		{Address: "10.0.0.2", SSLGrade: "A"},
+	}}
+
+	// the families are interleaved in another order
+	current := &Domain{Servers: []*Server{
+		{Address: "2001:db8::1", SSLGrade: "A"},
+		{Address: "10.0.0.1", SSLGrade: "A"},
+		{Address: "10.0.0.2", SSLGrade: "A"},
+	}}
+	c.False(ServersChanged(current, previous))
+
+	// an IPv6 server replaced an IPv4 one
+	current.Servers[2] = &Server{Address: "2001:db8::2", SSLGrade: "A"}
+	c.True(ServersChanged(current, previous))
+}
+
+func TestLogoChanged(t *testing.T) {
+	c := require.New(t)
+
+	servers := []*Server{{Address: "10.0.0.1", SSLGrade: "A"}}
+	previous := &Domain{Servers: servers, Logo: LogoPath + strings.Repeat("a", 64)}
+	current := &Domain{Servers: servers, Logo: LogoPath + strings.Repeat("a", 64)}
+	c.False(LogoChanged(current, previous))
+	c.False(LogoChanged(current, nil))
+
+	// a new logo is not a change of the servers
+	current.Logo = LogoPath + strings.Repeat("b", 64)
+	c.True(LogoChanged(current, previous))
+	c.False(ServersChanged(current, previous))
+
+	// a logo that was not cached is not compared
+	current.Logo = "https://example.com/favicon.ico"
+	c.False(LogoChanged(current, previous))
+}
+
+func TestDiffDNS(t *testing.T) {
+	c := require.New(t)
+
+	previous := &DNSSnapshot{Records: DNSRecords{
+		A:   []string{"192.0.2.1", "192.0.2.2"},
+		NS:  []string{"ns1.example.com"},
+		MX:  []MXRecord{{Host: "mail.example.com", Preference: 10}},
+		CAA: []CAARecord{{Tag: "issue", Value: "letsencrypt.org"}},
+	}}
+
+	current := &DNSSnapshot{Records: DNSRecords{
+		A:   []string{"192.0.2.2", "192.0.2.3"},
+		NS:  []string{"ns1.example.com"},
+		MX:  []MXRecord{{Host: "mail.example.com", Preference: 20}},
+		CAA: []CAARecord{{Tag: "issue", Value: "letsencrypt.org"}},
+		TXT: []string{"v=spf1 -all"},
+	}}
+
+	c.Equal([]DNSChange{
+		{Type: DNSTypeA, Added: []string{"192.0.2.3"}, Removed: []string{"192.0.2.1"}},
+		{Type: DNSTypeMX, Added: []string{"20 mail.example.com"}, Removed: []string{"10 mail.example.com"}},
+		{Type: DNSTypeTXT, Added: []string{"v=spf1 -all"}, Removed: []string{}},
+	}, DiffDNS(previous, current))
+
+	c.Empty(DiffDNS(current, current))
+	c.Empty(DiffDNS(nil, current))
+
+	// a failed query is not a change
+	current.Errors = map[string]string{DNSTypeA: "timeout", DNSTypeMX: "timeout", DNSTypeTXT: "timeout"}
+	c.Empty(DiffDNS(previous, current))
+}
+
+func TestDiffText(t *testing.T) {
+	c := require.New(t)
+
+	c.Empty(DiffText("same\ntext", "same\ntext"))
+	c.Equal([]TextChange{{Op: TextAdded, Line: 1, Text: "first"}}, DiffText("", "first"))
+
+	changes := DiffText("title\nold line\nkept\nremoved\nfooter", "title\nnew line\nkept\nfooter\nadded")
+	c.Equal([]TextChange{
+		{Op: TextRemoved, Line: 2, Text: "old line"},
+		{Op: TextAdded, Line: 2, Text: "new line"},
+		{Op: TextRemoved, Line: 4, Text: "removed"},
+		{Op: TextAdded, Line: 5, Text: "added"},
+	}, changes)
+}
+
+func TestComparePages(t *testing.T) {
+	c := require.New(t)
+
+	previous, err := NewPageSnapshot("example.com", "https://example.com/", "Example", "Welcome", PageMetadata{})
+	c.NoError(err)
+	c.Equal(PageTextHash("Welcome"), previous.TextHash)
+
+	ComparePages(nil, previous)
+	c.False(previous.TitleChanged)
+	c.False(previous.ContentChanged)
+
+	current, err := NewPageSnapshot("example.com", "https://example.com/", "Example", "Hacked", PageMetadata{})
+	c.NoError(err)
+
+	ComparePages(previous, current)
+	c.False(current.TitleChanged)
+	c.True(current.ContentChanged)
+}
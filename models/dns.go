@@ -0,0 +1,114 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// DNSTypeA is the type of the IPv4 addresses
+	DNSTypeA = "A"
+	// DNSTypeAAAA is the type of the IPv6 addresses
+	DNSTypeAAAA = "AAAA"
+	// DNSTypeCNAME is the type of the aliases
+	DNSTypeCNAME = "CNAME"
+	// DNSTypeNS is the type of the name servers
+	DNSTypeNS = "NS"
+	// DNSTypeMX is the type of the mail servers
+	DNSTypeMX = "MX"
+	// DNSTypeTXT is the type of the text records
+	DNSTypeTXT = "TXT"
+	// DNSTypeCAA is the type of the certification authority authorizations
+	DNSTypeCAA = "CAA"
+)
+
+// DNSTypes contains the record types of a snapshot in the order they are queried
+var DNSTypes = []string{DNSTypeA, DNSTypeAAAA, DNSTypeCNAME, DNSTypeNS, DNSTypeMX, DNSTypeTXT, DNSTypeCAA}
+
+// MXRecord model structure for a mail server
+type MXRecord struct {
+	Host       string `json:"host"`
+	Preference uint16 `json:"preference"`
+}
+
+// CAARecord model structure for a certification authority authorization
+type CAARecord struct {
+	Flags uint8  `json:"flags"`
+	Tag   string `json:"tag"`
+	Value string `json:"value"`
+}
+
+// DNSRecords model structure for the records of a domain. CNAME is the chain of aliases
+// followed from the domain, the names do not have the trailing dot
+type DNSRecords struct {
+	A     []string    `json:"a,omitempty"`
+	AAAA  []string    `json:"aaaa,omitempty"`
+	CNAME []string    `json:"cname,omitempty"`
+	NS    []string    `json:"ns,omitempty"`
+	MX    []MXRecord  `json:"mx,omitempty"`
+	TXT   []string    `json:"txt,omitempty"`
+	CAA   []CAARecord `json:"caa,omitempty"`
+}
+
+// DNSChange model structure for the values of a record type that changed between two snapshots
+type DNSChange struct {
+	Type    string   `json:"type"`
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+// DNSSnapshot model structure for the records of a domain resolved during an analysis.
+// Errors contains the record types whose query failed, Changes the differences with the
+// previous snapshot of the domain
+type DNSSnapshot struct {
+	SnapshotID   string            `json:"snapshot_id"`
+	AnalysisID   string            `json:"analysis_id"`
+	DomainName   string            `json:"domain_name"`
+	Resolver     string            `json:"resolver"`
+	Records      DNSRecords        `json:"records"`
+	Errors       map[string]string `json:"errors"`
+	Changes      []DNSChange       `json:"changes"`
+	CreationDate *time.Time        `json:"creation_date"`
+}
+
+// Values returns the records of the type as comparable strings
+func (r DNSRecords) Values(recordType string) []string {
+	values := []string{}
+
+	switch recordType {
+	case DNSTypeA:
+		values = append(values, r.A...)
+	case DNSTypeAAAA:
+		values = append(values, r.AAAA...)
+	case DNSTypeCNAME:
+		values = append(values, r.CNAME...)
+	case DNSTypeNS:
+		values = append(values, r.NS...)
+	case DNSTypeMX:
+		for _, mx := range r.MX {
+			values = append(values, fmt.Sprintf("%d %s", mx.Preference, mx.Host))
+		}
+	case DNSTypeTXT:
+		values = append(values, r.TXT...)
+	case DNSTypeCAA:
+		for _, caa := range r.CAA {
+			values = append(values, fmt.Sprintf("%d %s %q", caa.Flags, caa.Tag, caa.Value))
+		}
+	}
+
+	sort.Strings(values)
+
+	return values
+}
+
+// Addresses returns the IPv4 and IPv6 addresses of the domain
+func (r DNSRecords) Addresses() []string {
+	return append(append([]string{}, r.A...), r.AAAA...)
+}
+
+// NormalizeDNSName lowers the name and removes the trailing dot
+func NormalizeDNSName(name string) string {
+	return strings.TrimSuffix(strings.ToLower(name), ".")
+}
@@ -1,38 +1,44 @@
 package models
 
 import (
-	"errors"
 	"time"
 
 	"github.com/gofrs/uuid"
+	"github.com/other_project/crockroach/internal/apperr"
 )
 
 var (
 	// ErrEmptyDomainName for empty servers
-	ErrEmptyDomainName = errors.New("Domain name cannot be empty")
+	ErrEmptyDomainName = apperr.New(apperr.Unprocessable, "empty_domain_name", "Domain name cannot be empty")
 	// ErrEmptyServers for empty servers
-	ErrEmptyServers = errors.New("servers cannot be empty")
+	ErrEmptyServers = apperr.New(apperr.Unprocessable, "empty_servers", "servers cannot be empty")
 	// ErrEmptyPSSLGrade for empty psslgrade
-	ErrEmptyPSSLGrade = errors.New("previous SSL grade cannot be empty")
+	ErrEmptyPSSLGrade = apperr.New(apperr.Unprocessable, "empty_previous_ssl_grade", "previous SSL grade cannot be empty")
 	// ErrEmptyLogo for empty logo
-	ErrEmptyLogo = errors.New("logo cannot be empty")
+	ErrEmptyLogo = apperr.New(apperr.Unprocessable, "missing_logo", "logo cannot be empty")
 	// ErrEmptyTitle for empty title
-	ErrEmptyTitle = errors.New("title cannot be empty")
+	ErrEmptyTitle = apperr.New(apperr.Unprocessable, "missing_title", "title cannot be empty")
 )
 
 // Domain model structure for domain
 type Domain struct {
-	DomainID         string     `json:"domain_id"`
-	DomainName       string     `json:"domain_name"`
-	Servers          []*Server  `json:"servers"`
-	ServerChanged    bool       `json:"servers_changed"`
-	SSLGrade         string     `json:"ssl_grade"`
-	PreviousSSLGrade string     `json:"previous_ssl_grade"`
-	Logo             string     `json:"logo"`
-	Title            string     `json:"title"`
-	IsDown           bool       `json:"is_down"`
-	CreationDate     *time.Time `json:"creation_date"`
-	UpdateDate       *time.Time `json:"update_date"`
+	DomainID         string        `json:"domain_id"`
+	DomainName       string        `json:"domain_name"`
+	Servers          []*Server     `json:"servers"`
+	ServerChanged    bool          `json:"servers_changed"`
+	LogoChanged      bool          `json:"logo_changed"`
+	SSLGrade         string        `json:"ssl_grade"`
+	PreviousSSLGrade string        `json:"previous_ssl_grade"`
+	Logo             string        `json:"logo"`
+	Title            string        `json:"title"`
+	IsDown           bool          `json:"is_down"`
+	Uptime           *UptimeCheck  `json:"uptime,omitempty"`
+	DNS              *DNSSnapshot  `json:"dns,omitempty"`
+	Page             *PageSnapshot `json:"page,omitempty"`
+	DualStack        *DualStack    `json:"dual_stack,omitempty"`
+	LogoImage        *LogoImage    `json:"logo_image,omitempty"`
+	CreationDate     *time.Time    `json:"creation_date"`
+	UpdateDate       *time.Time    `json:"update_date"`
 }
 
 // NewDomain Initialize a new domain
@@ -65,9 +71,22 @@ func NewDomain(serverChanged, isdown bool, domainName, sslGrade, pSSLGrade, logo
 		PreviousSSLGrade: pSSLGrade,
 		Logo:             logo,
 		Title:            title,
+		IsDown:           isdown,
 		CreationDate:     &created,
 		UpdateDate:       &updated,
 	}
 
 	return domain, nil
 }
+
+// AnalyzedAt returns the last update of the analysis
+func (d *Domain) AnalyzedAt() time.Time {
+	switch {
+	case d.UpdateDate != nil:
+		return d.UpdateDate.UTC()
+	case d.CreationDate != nil:
+		return d.CreationDate.UTC()
+	}
+
+	return time.Time{}
+}
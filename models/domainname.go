@@ -0,0 +1,93 @@
+package models
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/other_project/crockroach/internal/apperr"
+	"golang.org/x/net/idna"
+)
+
+const (
+	// maxDomainNameLength is the maximum length of a domain name in presentation format
+	maxDomainNameLength = 253
+	// maxLabelLength is the maximum length of every label of a domain name
+	maxLabelLength = 63
+)
+
+var (
+	// ErrInvalidDomainName when the value is not a valid host name
+	ErrInvalidDomainName = apperr.New(apperr.Unprocessable, "invalid_domain_name", "invalid domain name")
+)
+
+// NormalizeDomainName converts user input such as "HTTPS://Example.com:443/path" into "example.com".
+// It lowercases the name, removes the scheme, port, path and trailing dot and converts
+// internationalized names to their ASCII form
+func NormalizeDomainName(raw string) (string, error) {
+	name := strings.TrimSpace(raw)
+	if name == "" {
+		return "", ErrEmptyDomainName
+	}
+
+	if strings.Contains(name, "://") {
+		u, err := url.Parse(name)
+		if err != nil {
+			return "", ErrInvalidDomainName
+		}
+
+		name = u.Hostname()
+	} else {
+		if i := strings.IndexAny(name, "/?#"); i >= 0 {
+			name = name[:i]
+		}
+
+		if i := strings.LastIndex(name, ":"); i >= 0 {
+			name = name[:i]
+		}
+	}
+
+	name = strings.TrimSuffix(strings.ToLower(name), ".")
+
+	ascii, err := idna.Lookup.ToASCII(name)
+	if err != nil {
+		return "", ErrInvalidDomainName
+	}
+
+	if !isHostName(ascii) {
+		return "", ErrInvalidDomainName
+	}
+
+	return ascii, nil
+}
+
+// isHostName validates the labels of an ASCII domain name
+func isHostName(name string) bool {
+	if len(name) == 0 || len(name) > maxDomainNameLength {
+		return false
+	}
+
+	labels := strings.Split(name, ".")
+	if len(labels) < 2 {
+		return false
+	}
+
+	for _, label := range labels {
+		if len(label) == 0 || len(label) > maxLabelLength {
+			return false
+		}
+
+		if label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+				return false
+			}
+		}
+	}
+
+	tld := labels[len(labels)-1]
+
+	return strings.Trim(tld, "0123456789") != ""
+}
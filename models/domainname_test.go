@@ -0,0 +1,39 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeDomainName(t *testing.T) {
+	c := require.New(t)
+
+	ttable := []struct {
+		raw      string
+		expected string
+	}{
+		{"example.com", "example.com"},
+		{"  Example.COM.  ", "example.com"},
+		{"https://www.Example.com:8443/path?q=1", "www.example.com"},
+		{"example.com/login", "example.com"},
+		{"example.com:443", "example.com"},
+		{"bücher.de", "xn--bcher-kva.de"},
+	}
+
+	for _, test := range ttable {
+		name, err := NormalizeDomainName(test.raw)
+		c.NoError(err, test.raw)
+		c.Equal(test.expected, name)
+	}
+
+	invalid := []string{"localhost", "192.168.1.1", "-bad.com", "bad_.com", "exa mple.com", "a..com", "http://"}
+
+	for _, raw := range invalid {
+		_, err := NormalizeDomainName(raw)
+		c.Equal(ErrInvalidDomainName, err, raw)
+	}
+
+	_, err := NormalizeDomainName("  ")
+	c.Equal(ErrEmptyDomainName, err)
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"sort"
+)
+
+const (
+	// ParityIPv6Missing when the domain has IPv4 servers but no IPv6 server
+	ParityIPv6Missing = "ipv6_missing"
+	// ParityGradeMismatch when the lowest grade of the IPv6 servers differs from the IPv4 one
+	ParityGradeMismatch = "grade_mismatch"
+	// ParityCertificateMismatch when the IPv6 servers present other certificates than the IPv4 ones
+	ParityCertificateMismatch = "certificate_mismatch"
+)
+
+// DualStack model structure for the parity between the IPv4 and the IPv6 servers of a domain
+type DualStack struct {
+	IPv4      int      `json:"ipv4"`
+	IPv6      int      `json:"ipv6"`
+	IPv4Grade string   `json:"ipv4_grade"`
+	IPv6Grade string   `json:"ipv6_grade"`
+	Issues    []string `json:"issues"`
+}
+
+// Parity reports if the IPv6 servers match the IPv4 servers
+func (d *DualStack) Parity() bool {
+	return len(d.Issues) == 0
+}
+
+// CheckDualStack compares the IPv6 servers with the IPv4 servers. Grades are compared only when
+// both families have a known grade and certificates only when both families were inspected.
+// It returns nil without servers
+func CheckDualStack(servers []*Server) *DualStack {
+	if len(servers) == 0 {
+		return nil
+	}
+
+	families := byFamily(servers)
+	ipv4, ipv6 := families[FamilyIPv4], families[FamilyIPv6]
+
+	check := &DualStack{
+		IPv4:      len(ipv4),
+		IPv6:      len(ipv6),
+		IPv4Grade: LowestGrade(ipv4),
+		IPv6Grade: LowestGrade(ipv6),
+		Issues:    []string{},
+	}
+
+	if len(ipv4) == 0 {
+		return check
+	}
+
+	if len(ipv6) == 0 {
+		check.Issues = append(check.Issues, ParityIPv6Missing)
+		return check
+	}
+
+	if check.IPv4Grade != "" && check.IPv6Grade != "" && check.IPv4Grade != check.IPv6Grade {
+		check.Issues = append(check.Issues, ParityGradeMismatch)
+	}
+
+	ipv4Certificates, ipv6Certificates := fingerprints(ipv4), fingerprints(ipv6)
+	if len(ipv4Certificates) > 0 && len(ipv6Certificates) > 0 && !sameValues(ipv4Certificates, ipv6Certificates) {
+		check.Issues = append(check.Issues, ParityCertificateMismatch)
+	}
+
+	return check
+}
+
+// fingerprints returns the sorted fingerprints of the leaf certificates of the inspected servers
+func fingerprints(servers []*Server) []string {
+	values := []string{}
+
+	for _, server := range servers {
+		leaf := server.TLS.Leaf()
+		if leaf != nil && !contains(values, leaf.Fingerprint) {
+			values = append(values, leaf.Fingerprint)
+		}
+	}
+
+	sort.Strings(values)
+
+	return values
+}
+
+// sameValues compares two sorted lists
+func sameValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckDualStack(t *testing.T) {
+	c := require.New(t)
+
+	certificate := func(fingerprint string) *TLSInspection {
+		return &TLSInspection{Chain: []Certificate{{Fingerprint: fingerprint}}}
+	}
+
+	c.Nil(CheckDualStack(nil))
+
+	check := CheckDualStack([]*Server{{Address: "192.0.2.1", SSLGrade: "A"}})
+	c.Equal(1, check.IPv4)
+	c.Equal([]string{ParityIPv6Missing}, check.Issues)
+	c.False(check.Parity())
+
+	// a domain only reachable over IPv6 does not miss anything
+	check = CheckDualStack([]*Server{{Address: "2001:db8::1", SSLGrade: "A"}})
+	c.True(check.Parity())
+
+	servers := []*Server{
+		{Address: "192.0.2.1", SSLGrade: "A", TLS: certificate("aa")},
+		{Address: "192.0.2.2", SSLGrade: "A", TLS: certificate("aa")},
+		{Address: "2001:db8::1", SSLGrade: "A", TLS: certificate("aa")},
+	}
+
+	check = CheckDualStack(servers)
+	c.True(check.Parity())
+	c.Equal(&DualStack{IPv4: 2, IPv6: 1, IPv4Grade: "A", IPv6Grade: "A", Issues: []string{}}, check)
+
+	servers[2].SSLGrade = "B"
+	servers[2].TLS = certificate("bb")
+	c.Equal([]string{ParityGradeMismatch, ParityCertificateMismatch}, CheckDualStack(servers).Issues)
+
+	// unknown grades and servers without inspection are not compared
+	servers[2].SSLGrade = "unknown"
+	servers[2].TLS = nil
+	c.True(CheckDualStack(servers).Parity())
+}
+
+func TestAttachInspections(t *testing.T) {
+	c := require.New(t)
+
+	// the stored servers come without their certificates
+	servers := []*Server{
+		{ServerID: "v4", Address: "192.0.2.1", SSLGrade: "A"},
+		{ServerID: "v6", Address: "2001:db8::1", SSLGrade: "A"},
+	}
+	c.True(CheckDualStack(servers).Parity())
+
+	AttachInspections(servers, []*TLSInspection{
+		{ServerID: "v4", Chain: []Certificate{{Fingerprint: "aa"}}},
+		{ServerID: "v6", Chain: []Certificate{{Fingerprint: "aa"}}},
+		{ServerID: "v6", Chain: []Certificate{{Fingerprint: "bb"}}},
+	})
+
+	c.Equal("bb", servers[1].TLS.Leaf().Fingerprint)
+	c.Equal([]string{ParityCertificateMismatch}, CheckDualStack(servers).Issues)
+}
@@ -0,0 +1,62 @@
+package models
+
+// grades contains the SSL Labs grades ordered from the best to the worst
+var grades = []string{
+	"A+", "A", "A-",
+	"B+", "B", "B-",
+	"C+", "C", "C-",
+	"D+", "D", "D-",
+	"E+", "E", "E-",
+	"F+", "F", "F-",
+	"T", "M",
+}
+
+// GradeRank returns the position of the grade, a lower rank is a better grade.
+// It returns -1 when the grade is unknown
+func GradeRank(grade string) int {
+	for i, value := range grades {
+		if value == grade {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// IsGradeBelow reports if grade is worse than minGrade, unknown grades are always below
+func IsGradeBelow(grade, minGrade string) bool {
+	rank := GradeRank(grade)
+	if rank == -1 {
+		return true
+	}
+
+	return rank > GradeRank(minGrade)
+}
+
+// LowestGrade returns the worst known grade of the servers, empty if there is none
+func LowestGrade(servers []*Server) string {
+	lowest := ""
+
+	for _, server := range servers {
+		if GradeRank(server.SSLGrade) == -1 {
+			continue
+		}
+
+		if lowest == "" || GradeRank(server.SSLGrade) > GradeRank(lowest) {
+			lowest = server.SSLGrade
+		}
+	}
+
+	return lowest
+}
+
+// GradeValue returns a numeric value for the grade where a higher value is a better grade.
+// The worst known grade is 0 and unknown grades are -1
+func GradeValue(grade string) int {
+	rank := GradeRank(grade)
+	if rank == -1 {
+		return -1
+	}
+
+	return len(grades) - 1 - rank
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGradeRank(t *testing.T) {
+	c := require.New(t)
+
+	c.Equal(0, GradeRank("A+"))
+	c.True(GradeRank("A") < GradeRank("B"))
+	c.True(GradeRank("F") < GradeRank("T"))
+	c.Equal(-1, GradeRank(""))
+	c.Equal(-1, GradeRank("Z"))
+}
+
+func TestIsGradeBelow(t *testing.T) {
+	c := require.New(t)
+
+	c.False(IsGradeBelow("A", "B"))
+	c.False(IsGradeBelow("B", "B"))
+	c.True(IsGradeBelow("C", "B"))
+	c.True(IsGradeBelow("", "B"))
+}
+
+func TestLowestGrade(t *testing.T) {
+	c := require.New(t)
+
+	servers := []*Server{{SSLGrade: "A+"}, {SSLGrade: "B"}, {SSLGrade: ""}, {SSLGrade: "A"}}
+	c.Equal("B", LowestGrade(servers))
+	c.Equal("", LowestGrade(nil))
+}
+
+func TestGradeValue(t *testing.T) {
+	c := require.New(t)
+
+	c.Equal(len(grades)-1, GradeValue("A+"))
+	c.True(GradeValue("A") > GradeValue("B"))
+	c.Equal(0, GradeValue("M"))
+	c.Equal(-1, GradeValue("X"))
+}
@@ -0,0 +1,247 @@
+package models
+
+import (
+	"net/http"
+	"net/textproto"
+	"regexp"
+	"strings"
+
+	"github.com/other_project/crockroach/internal/apperr"
+)
+
+const (
+	// MaxResponseTime is the longest max_response_ms accepted, one minute
+	MaxResponseTime = 60000
+
+	// maxHeaders is the maximum number of custom headers of a health check
+	maxHeaders = 20
+	// maxBodyMatch is the maximum length of the body substring and regex
+	maxBodyMatch = 1024
+	// redactedHeader replaces the value of the credentials when the check is shown
+	redactedHeader = "[redacted]"
+)
+
+var (
+	// ErrInvalidCheckPath when the path of the health check is not absolute
+	ErrInvalidCheckPath = apperr.New(apperr.Unprocessable, "invalid_check_path", "path must start with /")
+	// ErrInvalidCheckMethod when the method of the health check is not GET, HEAD or OPTIONS
+	ErrInvalidCheckMethod = apperr.New(apperr.Unprocessable, "invalid_check_method", "method must be GET, HEAD or OPTIONS")
+	// ErrInvalidStatusCode when an accepted status code is not between 100 and 599
+	ErrInvalidStatusCode = apperr.New(apperr.Unprocessable, "invalid_status_code", "accepted status codes must be between 100 and 599")
+	// ErrInvalidBodyMatch when the body substring or regex is too long, or the regex does not compile
+	ErrInvalidBodyMatch = apperr.New(apperr.Unprocessable, "invalid_body_match", "body_contains and body_regex must have up to 1024 characters and body_regex must be a valid regular expression")
+	// ErrBodyMatchWithHead when a body expectation is combined with a HEAD request
+	ErrBodyMatchWithHead = apperr.New(apperr.Unprocessable, "body_match_with_head", "a HEAD request does not have a body to match")
+	// ErrInvalidCheckHeader when a custom header has an invalid name or value
+	ErrInvalidCheckHeader = apperr.New(apperr.Unprocessable, "invalid_check_header", "up to 20 headers with valid names and values without line breaks")
+	// ErrInvalidResponseTime when max_response_ms is out of range
+	ErrInvalidResponseTime = apperr.New(apperr.Unprocessable, "invalid_max_response_time", "max_response_ms must be between 0 and 60000")
+
+	// checkMethods are the methods a health check can use, they do not change the site
+	checkMethods = []string{http.MethodGet, http.MethodHead, http.MethodOptions}
+	// secretHeaders are hidden when the check is shown
+	secretHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+	// headerName follows the token rule of RFC 7230
+	headerName = regexp.MustCompile("^[!#$%&'*+.^_`|~0-9A-Za-z-]+$")
+)
+
+// HealthCheck model structure for what a healthy answer of a domain looks like. The zero value
+// requests / with GET and accepts any 2xx or 3xx answer after following the redirects.
+// When an accepted status code is a redirect the redirects are not followed
+type HealthCheck struct {
+	Path            string            `json:"path,omitempty"`
+	Method          string            `json:"method,omitempty"`
+	AcceptedStatus  []int             `json:"accepted_status,omitempty"`
+	BodyContains    string            `json:"body_contains,omitempty"`
+	BodyRegex       string            `json:"body_regex,omitempty"`
+	Headers         map[string]string `json:"headers,omitempty"`
+	MaxResponseTime int64             `json:"max_response_ms,omitempty"`
+}
+
+// Validate checks the expectations and canonicalizes the method and the header names
+func (h *HealthCheck) Validate() error {
+	if h.Path != "" && !strings.HasPrefix(h.Path, "/") {
+		return ErrInvalidCheckPath
+	}
+
+	h.Method = strings.ToUpper(strings.TrimSpace(h.Method))
+	if h.Method != "" && !contains(checkMethods, h.Method) {
+		return ErrInvalidCheckMethod
+	}
+
+	for _, code := range h.AcceptedStatus {
+		if code < 100 || code > 599 {
+			return ErrInvalidStatusCode
+		}
+	}
+
+	if len(h.BodyContains) > maxBodyMatch || len(h.BodyRegex) > maxBodyMatch {
+		return ErrInvalidBodyMatch
+	}
+
+	if _, err := regexp.Compile(h.BodyRegex); err != nil {
+		return ErrInvalidBodyMatch
+	}
+
+	if h.Method == http.MethodHead && (h.BodyContains != "" || h.BodyRegex != "") {
+		return ErrBodyMatchWithHead
+	}
+
+	if len(h.Headers) > maxHeaders {
+		return ErrInvalidCheckHeader
+	}
+
+	headers := make(map[string]string, len(h.Headers))
+
+	for name, value := range h.Headers {
+		if !headerName.MatchString(name) || strings.ContainsAny(value, "\r\n") {
+			return ErrInvalidCheckHeader
+		}
+
+		headers[textproto.CanonicalMIMEHeaderKey(name)] = value
+	}
+
+	if len(headers) > 0 {
+		h.Headers = headers
+	}
+
+	if h.MaxResponseTime < 0 || h.MaxResponseTime > MaxResponseTime {
+		return ErrInvalidResponseTime
+	}
+
+	return nil
+}
+
+// URL returns the address requested for the domain
+func (h HealthCheck) URL(domainName string) string {
+	path := h.Path
+	if path == "" {
+		path = "/"
+	}
+
+	return "https://" + domainName + path
+}
+
+// RequestMethod returns the method of the check, GET by default
+func (h HealthCheck) RequestMethod() string {
+	if h.Method == "" {
+		return http.MethodGet
+	}
+
+	return h.Method
+}
+
+// Accepts reports if the status code is a healthy answer
+func (h HealthCheck) Accepts(code int) bool {
+	if len(h.AcceptedStatus) == 0 {
+		return code >= 200 && code < 400
+	}
+
+	for _, accepted := range h.AcceptedStatus {
+		if accepted == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FollowRedirects reports if the check follows the redirects, it stops when a redirect is accepted
+func (h HealthCheck) FollowRedirects() bool {
+	for _, code := range h.AcceptedStatus {
+		if code >= 300 && code < 400 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ReadsBody reports if the body is needed to evaluate the check
+func (h HealthCheck) ReadsBody() bool {
+	return h.BodyContains != "" || h.BodyRegex != ""
+}
+
+// MatchBody reports if the body has the substring and matches the regex of the check
+func (h HealthCheck) MatchBody(body []byte) bool {
+	if h.BodyContains != "" && !strings.Contains(string(body), h.BodyContains) {
+		return false
+	}
+
+	if h.BodyRegex != "" {
+		re, err := regexp.Compile(h.BodyRegex)
+		if err != nil || !re.Match(body) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Redacted returns a copy of the check without the values of the credential headers
+func (h HealthCheck) Redacted() HealthCheck {
+	if len(h.Headers) == 0 {
+		return h
+	}
+
+	headers := make(map[string]string, len(h.Headers))
+
+	for name, value := range h.Headers {
+		if contains(secretHeaders, textproto.CanonicalMIMEHeaderKey(name)) {
+			value = redactedHeader
+		}
+
+		headers[name] = value
+	}
+
+	h.Headers = headers
+
+	return h
+}
+
+// WithSecretsOf returns a copy of the check where the redacted credentials take their value from
+// stored, so a check read from the API can be sent back without losing them. A redacted
+// credential that stored does not have is dropped
+func (h HealthCheck) WithSecretsOf(stored HealthCheck) HealthCheck {
+	if len(h.Headers) == 0 {
+		return h
+	}
+
+	secrets := make(map[string]string, len(stored.Headers))
+
+	for name, value := range stored.Headers {
+		secrets[textproto.CanonicalMIMEHeaderKey(name)] = value
+	}
+
+	headers := make(map[string]string, len(h.Headers))
+
+	for name, value := range h.Headers {
+		key := textproto.CanonicalMIMEHeaderKey(name)
+
+		if value == redactedHeader && contains(secretHeaders, key) {
+			secret, ok := secrets[key]
+			if !ok {
+				continue
+			}
+
+			value = secret
+		}
+
+		headers[name] = value
+	}
+
+	h.Headers = headers
+
+	return h
+}
+
+// contains reports if the value is in the list
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthCheckValidate(t *testing.T) {
+	c := require.New(t)
+
+	check := HealthCheck{Path: "/healthz", Method: "head", AcceptedStatus: []int{200, 301}, Headers: map[string]string{"x-api-key": "abc"}, MaxResponseTime: 2000}
+	c.NoError(check.Validate())
+	c.Equal("HEAD", check.Method)
+	c.Equal(map[string]string{"X-Api-Key": "abc"}, check.Headers)
+	c.Equal("https://example.com/healthz", check.URL("example.com"))
+	c.False(check.FollowRedirects())
+	c.True(check.Accepts(301))
+	c.False(check.Accepts(204))
+
+	empty := HealthCheck{}
+	c.NoError(empty.Validate())
+	c.Equal("https://example.com/", empty.URL("example.com"))
+	c.Equal("GET", empty.RequestMethod())
+	c.True(empty.FollowRedirects())
+	c.True(empty.Accepts(204))
+	c.False(empty.Accepts(500))
+
+	cases := []struct {
+		check HealthCheck
+		err   error
+	}{
+		{HealthCheck{Path: "healthz"}, ErrInvalidCheckPath},
+		{HealthCheck{Method: "POST"}, ErrInvalidCheckMethod},
+		{HealthCheck{AcceptedStatus: []int{99}}, ErrInvalidStatusCode},
+		{HealthCheck{BodyRegex: "("}, ErrInvalidBodyMatch},
+		{HealthCheck{Method: "HEAD", BodyContains: "ok"}, ErrBodyMatchWithHead},
+		{HealthCheck{Headers: map[string]string{"X Bad": "1"}}, ErrInvalidCheckHeader},
+		{HealthCheck{Headers: map[string]string{"X-Good": "1\r\nX-Injected: 1"}}, ErrInvalidCheckHeader},
+		{HealthCheck{MaxResponseTime: MaxResponseTime + 1}, ErrInvalidResponseTime},
+	}
+
+	for _, tc := range cases {
+		c.Equal(tc.err, tc.check.Validate(), "%+v", tc.check)
+	}
+}
+
+func TestHealthCheckBodyAndRedacted(t *testing.T) {
+	c := require.New(t)
+
+	check := HealthCheck{BodyContains: "ok", BodyRegex: `v\d+`}
+	c.True(check.MatchBody([]byte("status ok v2")))
+	c.False(check.MatchBody([]byte("status ok")))
+	c.False(check.MatchBody([]byte("v2")))
+
+	check = HealthCheck{Headers: map[string]string{"Authorization": "Basic b3BzOnNlY3JldA==", "X-Env": "staging"}}
+	redacted := check.Redacted()
+	c.Equal("[redacted]", redacted.Headers["Authorization"])
+	c.Equal("staging", redacted.Headers["X-Env"])
+	c.Equal("Basic b3BzOnNlY3JldA==", check.Headers["Authorization"], "the check is not modified")
+
+	redacted.Headers["cookie"] = "[redacted]"
+	redacted.Headers["X-Env"] = "production"
+	restored := redacted.WithSecretsOf(check)
+	c.Equal("Basic b3BzOnNlY3JldA==", restored.Headers["Authorization"])
+	c.Equal("production", restored.Headers["X-Env"])
+	c.NotContains(restored.Headers, "cookie", "a redacted credential without stored value is dropped")
+	c.Equal("[redacted]", redacted.Headers["Authorization"], "the check is not modified")
+
+	changed := HealthCheck{Headers: map[string]string{"authorization": "Bearer new"}}.WithSecretsOf(check)
+	c.Equal("Bearer new", changed.Headers["authorization"])
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// ImportLineError model structure for a line of an import file that could not be imported
+type ImportLineError struct {
+	Line  int    `json:"line"`
+	Value string `json:"value"`
+	Error string `json:"error"`
+}
+
+// Import model structure for a list of domains imported in bulk. Every domain is analyzed by a
+// job that references the import, so the progress is read from the jobs
+type Import struct {
+	ImportID     string             `json:"import_id"`
+	Total        int                `json:"total"`
+	Duplicates   int                `json:"duplicates"`
+	LineErrors   []*ImportLineError `json:"line_errors"`
+	CreationDate *time.Time         `json:"creation_date"`
+}
+
+// NewImport Initialize an import of total domains
+func NewImport(total, duplicates int, lineErrors []*ImportLineError) (*Import, error) {
+	importID, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+
+	if lineErrors == nil {
+		lineErrors = []*ImportLineError{}
+	}
+
+	created := time.Now()
+
+	return &Import{
+		ImportID:     importID.String(),
+		Total:        total,
+		Duplicates:   duplicates,
+		LineErrors:   lineErrors,
+		CreationDate: &created,
+	}, nil
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/other_project/crockroach/internal/apperr"
+)
+
+const (
+	// JobQueued when the job waits for a worker
+	JobQueued = "queued"
+	// JobRunning when the analysis is in progress
+	JobRunning = "running"
+	// JobDone when the analysis was stored
+	JobDone = "done"
+	// JobFailed when the analysis could not be completed
+	JobFailed = "failed"
+)
+
+var (
+	// ErrEmptyJobDomain when the job does not have a domain
+	ErrEmptyJobDomain = apperr.New(apperr.Unprocessable, "empty_domain_name", "the job domain name cannot be empty")
+)
+
+// Job model structure for an asynchronous analysis
+type Job struct {
+	JobID        string     `json:"job_id"`
+	DomainName   string     `json:"domain_name"`
+	State        string     `json:"state"`
+	Progress     int        `json:"progress"`
+	Message      string     `json:"message"`
+	Error        string     `json:"error"`
+	ErrorCode    string     `json:"error_code"`
+	AnalysisID   string     `json:"analysis_id"`
+	ImportID     string     `json:"import_id"`
+	CreationDate *time.Time `json:"creation_date"`
+	UpdateDate   *time.Time `json:"update_date"`
+	StartedDate  *time.Time `json:"started_date"`
+	FinishedDate *time.Time `json:"finished_date"`
+}
+
+// EndpointProgress is the state of the assessment of a server of the domain
+type EndpointProgress struct {
+	IPAddress     string `json:"ip_address"`
+	StatusMessage string `json:"status_message"`
+	Progress      int    `json:"progress"`
+	Grade         string `json:"grade,omitempty"`
+}
+
+// NewJob Initialize a queued job for the domain
+func NewJob(domainName string) (*Job, error) {
+	if domainName == "" {
+		return nil, ErrEmptyJobDomain
+	}
+
+	jobID, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+
+	created := time.Now()
+
+	return &Job{
+		JobID:        jobID.String(),
+		DomainName:   domainName,
+		State:        JobQueued,
+		CreationDate: &created,
+		UpdateDate:   &created,
+	}, nil
+}
+
+// Finished reports if the job reached a final state
+func (j *Job) Finished() bool {
+	return j.State == JobDone || j.State == JobFailed
+}
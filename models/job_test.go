@@ -0,0 +1,23 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewJob(t *testing.T) {
+	c := require.New(t)
+
+	job, err := NewJob("example.com")
+	c.NoError(err)
+	c.NotEmpty(job.JobID)
+	c.Equal(JobQueued, job.State)
+	c.False(job.Finished())
+
+	job.State = JobFailed
+	c.True(job.Finished())
+
+	_, err = NewJob("")
+	c.EqualError(ErrEmptyJobDomain, err.Error())
+}
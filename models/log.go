@@ -1,13 +1,14 @@
 package models
 
 import (
-	"errors"
 	"time"
+
+	"github.com/other_project/crockroach/internal/apperr"
 )
 
 var (
 	// ErrEmptyDomain when it does not exist a domain
-	ErrEmptyDomain = errors.New("domain name cannot be empty")
+	ErrEmptyDomain = apperr.New(apperr.Unprocessable, "empty_domain", "domain name cannot be empty")
 	// Count when it creates a record
 	Count int64
 )
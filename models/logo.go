@@ -0,0 +1,61 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// LogoPath is the path where the cached logos are served, followed by their hash
+const LogoPath = "/logos/"
+
+// logoHash is the hex encoded SHA-256 of the content of a logo
+var logoHash = regexp.MustCompile("^[0-9a-f]{64}$")
+
+// LogoImage model structure for a logo downloaded by the service, it is addressed by the hash
+// of its content so the same image is stored once
+type LogoImage struct {
+	Hash         string     `json:"hash"`
+	ContentType  string     `json:"content_type"`
+	Size         int64      `json:"size"`
+	SourceURL    string     `json:"source_url"`
+	Content      []byte     `json:"-"`
+	CreationDate *time.Time `json:"creation_date"`
+}
+
+// NewLogoImage Initialize the logo downloaded from sourceURL
+func NewLogoImage(sourceURL, contentType string, content []byte) *LogoImage {
+	sum := sha256.Sum256(content)
+	created := time.Now()
+
+	return &LogoImage{
+		Hash:         hex.EncodeToString(sum[:]),
+		ContentType:  contentType,
+		Size:         int64(len(content)),
+		SourceURL:    sourceURL,
+		Content:      content,
+		CreationDate: &created,
+	}
+}
+
+// ValidLogoHash reports if hash can address a logo
+func ValidLogoHash(hash string) bool {
+	return logoHash.MatchString(hash)
+}
+
+// LogoHash returns the hash of a logo served by the service, empty when the logo is another URL
+func LogoHash(logo string) string {
+	index := strings.LastIndex(logo, LogoPath)
+	if index == -1 {
+		return ""
+	}
+
+	hash := logo[index+len(LogoPath):]
+	if !ValidLogoHash(hash) {
+		return ""
+	}
+
+	return hash
+}
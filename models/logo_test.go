@@ -0,0 +1,29 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLogoImage(t *testing.T) {
+	c := require.New(t)
+
+	logo := NewLogoImage("https://example.com/favicon.ico", "image/x-icon", []byte("icon"))
+	c.True(ValidLogoHash(logo.Hash))
+	c.Equal(int64(4), logo.Size)
+	c.Equal(logo.Hash, NewLogoImage("https://cdn.example.com/other.ico", "image/x-icon", []byte("icon")).Hash, "the hash only depends on the content")
+	c.NotEqual(logo.Hash, NewLogoImage("https://example.com/favicon.ico", "image/x-icon", []byte("other")).Hash)
+}
+
+func TestLogoHash(t *testing.T) {
+	c := require.New(t)
+
+	hash := NewLogoImage("https://example.com/favicon.ico", "image/x-icon", []byte("icon")).Hash
+
+	c.Equal(hash, LogoHash("/logos/"+hash))
+	c.Equal(hash, LogoHash("https://api.example.com/logos/"+hash))
+	c.Empty(LogoHash("https://example.com/favicon.ico"))
+	c.Empty(LogoHash("/logos/not-a-hash"))
+	c.Empty(LogoHash("unknown"))
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+const (
+	// TextAdded is a line that is only in the newer text
+	TextAdded = "added"
+	// TextRemoved is a line that is only in the older text
+	TextRemoved = "removed"
+)
+
+// PageMetadata model structure for the metadata declared in the head of a page. OpenGraph and
+// Twitter contain the og: and twitter: properties without the prefix
+type PageMetadata struct {
+	Description string            `json:"description"`
+	Canonical   string            `json:"canonical"`
+	Lang        string            `json:"lang"`
+	Generator   string            `json:"generator"`
+	OpenGraph   map[string]string `json:"open_graph"`
+	Twitter     map[string]string `json:"twitter"`
+}
+
+// PageSnapshot model structure for the page of a domain read during an analysis. Text is the
+// normalized visible text, one block per line, and TextHash its SHA-256. The changed flags
+// compare with the previous snapshot of the domain
+type PageSnapshot struct {
+	SnapshotID     string       `json:"snapshot_id"`
+	AnalysisID     string       `json:"analysis_id"`
+	DomainName     string       `json:"domain_name"`
+	URL            string       `json:"url"`
+	Title          string       `json:"title"`
+	Metadata       PageMetadata `json:"metadata"`
+	TextHash       string       `json:"text_hash"`
+	Text           string       `json:"-"`
+	TitleChanged   bool         `json:"title_changed"`
+	ContentChanged bool         `json:"content_changed"`
+	CreationDate   *time.Time   `json:"creation_date"`
+}
+
+// TextChange model structure for a line that differs between two texts, Line is its number
+// starting at 1 in the text that has it
+type TextChange struct {
+	Op   string `json:"op"`
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+// NewPageSnapshot creates the snapshot of the page, the hash is computed from the text
+func NewPageSnapshot(domainName, pageURL, title, text string, metadata PageMetadata) (*PageSnapshot, error) {
+	snapshotID, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	return &PageSnapshot{
+		SnapshotID:   snapshotID.String(),
+		DomainName:   domainName,
+		URL:          pageURL,
+		Title:        title,
+		Metadata:     metadata,
+		TextHash:     PageTextHash(text),
+		Text:         text,
+		CreationDate: &now,
+	}, nil
+}
+
+// PageTextHash returns the hex SHA-256 of the normalized text
+func PageTextHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// ComparePages sets the changed flags of the current snapshot, the first snapshot of a domain
+// does not have changes
+func ComparePages(previous, current *PageSnapshot) {
+	if previous == nil || current == nil {
+		return
+	}
+
+	current.TitleChanged = previous.Title != current.Title
+	current.ContentChanged = previous.TextHash != current.TextHash
+}
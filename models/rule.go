@@ -0,0 +1,177 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/other_project/crockroach/internal/apperr"
+)
+
+const (
+	// RuleGradeBelow fires when the grade of the domain is worse than Grade
+	RuleGradeBelow = "grade_below"
+	// RuleOwnerChanged fires when the owner of a server changed since the previous analysis
+	RuleOwnerChanged = "owner_changed"
+	// RuleServerCountDrop fires when the number of servers dropped by more than Threshold percent
+	RuleServerCountDrop = "server_count_drop"
+	// RuleDownConsecutive fires when the site was down in the last Threshold analyses
+	RuleDownConsecutive = "down_consecutive"
+	// RuleGradeDrops fires when the grade dropped Threshold times during the last Window seconds
+	RuleGradeDrops = "grade_drops"
+	// RuleCertExpiry fires when the certificate of a server expires in Threshold days or less
+	RuleCertExpiry = "cert_expiry"
+	// RuleDualStack fires when the IPv6 servers are missing or do not match the IPv4 servers
+	RuleDualStack = "dual_stack"
+	// RuleLogoChanged fires when the cached logo changed since the previous analysis
+	RuleLogoChanged = "logo_changed"
+
+	// MaxRuleThreshold is the maximum number of analyses a rule can look at
+	MaxRuleThreshold = 50
+	// maxRuleWindow is the longest window of a rule, 30 days
+	maxRuleWindow = 30 * 24 * 3600
+	// maxExpiryDays is the longest notice of a cert_expiry rule
+	maxExpiryDays = 365
+)
+
+var (
+	// ErrEmptyRuleName when the rule does not have a name
+	ErrEmptyRuleName = apperr.New(apperr.Unprocessable, "empty_rule_name", "rule name cannot be empty")
+	// ErrInvalidRuleKind when the kind of the rule does not exist
+	ErrInvalidRuleKind = apperr.New(apperr.Unprocessable, "invalid_rule_kind", "kind must be grade_below, owner_changed, server_count_drop, down_consecutive, grade_drops, cert_expiry, dual_stack or logo_changed")
+	// ErrInvalidRuleGrade when the grade of the rule is not an SSL Labs grade
+	ErrInvalidRuleGrade = apperr.New(apperr.Unprocessable, "invalid_rule_grade", "grade must be an SSL Labs grade")
+	// ErrInvalidRuleThreshold when the threshold is out of the range of the kind
+	ErrInvalidRuleThreshold = apperr.New(apperr.Unprocessable, "invalid_rule_threshold", "threshold is out of range for the rule kind")
+	// ErrInvalidRuleWindow when the window is out of range
+	ErrInvalidRuleWindow = apperr.New(apperr.Unprocessable, "invalid_rule_window", "window must be between 60 and 2592000 seconds")
+	// RuleKinds contains every kind of rule
+	RuleKinds = []string{RuleGradeBelow, RuleOwnerChanged, RuleServerCountDrop, RuleDownConsecutive, RuleGradeDrops, RuleCertExpiry, RuleDualStack, RuleLogoChanged}
+)
+
+// Rule model structure for an alert rule, an empty DomainName applies the rule to every domain.
+// Grade is used by grade_below, Threshold by server_count_drop, down_consecutive, grade_drops
+// and cert_expiry (in days), and Window by grade_drops
+type Rule struct {
+	RuleID       string     `json:"rule_id"`
+	Name         string     `json:"name"`
+	Kind         string     `json:"kind"`
+	DomainName   string     `json:"domain_name"`
+	Grade        string     `json:"grade"`
+	Threshold    int64      `json:"threshold"`
+	Window       int64      `json:"window"`
+	Enabled      bool       `json:"enabled"`
+	CreationDate *time.Time `json:"creation_date"`
+	UpdateDate   *time.Time `json:"update_date"`
+}
+
+// RuleEvaluation model structure for the result of a rule after an analysis
+type RuleEvaluation struct {
+	EvaluationID string     `json:"evaluation_id"`
+	RuleID       string     `json:"rule_id"`
+	AnalysisID   string     `json:"analysis_id"`
+	DomainName   string     `json:"domain_name"`
+	Fired        bool       `json:"fired"`
+	Message      string     `json:"message"`
+	CreationDate *time.Time `json:"creation_date"`
+}
+
+// Alert model structure for a rule that started firing for a domain
+type Alert struct {
+	AlertID      string     `json:"alert_id"`
+	RuleID       string     `json:"rule_id"`
+	RuleName     string     `json:"rule_name"`
+	EvaluationID string     `json:"evaluation_id"`
+	AnalysisID   string     `json:"analysis_id"`
+	DomainName   string     `json:"domain_name"`
+	Message      string     `json:"message"`
+	CreationDate *time.Time `json:"creation_date"`
+}
+
+// NewRule Initialize an enabled rule with the default settings of the kind
+func NewRule(name, kind string) (*Rule, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, ErrEmptyRuleName
+	}
+
+	ruleID, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+
+	created := time.Now()
+
+	rule := &Rule{
+		RuleID:       ruleID.String(),
+		Name:         strings.TrimSpace(name),
+		Kind:         kind,
+		Enabled:      true,
+		CreationDate: &created,
+		UpdateDate:   &created,
+	}
+
+	switch kind {
+	case RuleGradeBelow:
+		rule.Grade = "B"
+	case RuleOwnerChanged:
+	case RuleServerCountDrop:
+		rule.Threshold = 50
+	case RuleDownConsecutive:
+		rule.Threshold = 3
+	case RuleGradeDrops:
+		rule.Threshold = 2
+		rule.Window = 24 * 3600
+	case RuleCertExpiry:
+		rule.Threshold = 30
+	case RuleDualStack, RuleLogoChanged:
+	default:
+		return nil, ErrInvalidRuleKind
+	}
+
+	return rule, nil
+}
+
+// Validate checks the settings used by the kind of the rule
+func (r *Rule) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return ErrEmptyRuleName
+	}
+
+	switch r.Kind {
+	case RuleGradeBelow:
+		if GradeRank(r.Grade) == -1 {
+			return ErrInvalidRuleGrade
+		}
+	case RuleOwnerChanged:
+	case RuleServerCountDrop:
+		if r.Threshold < 1 || r.Threshold > 100 {
+			return ErrInvalidRuleThreshold
+		}
+	case RuleDownConsecutive:
+		if r.Threshold < 1 || r.Threshold > MaxRuleThreshold {
+			return ErrInvalidRuleThreshold
+		}
+	case RuleGradeDrops:
+		if r.Threshold < 1 || r.Threshold > MaxRuleThreshold {
+			return ErrInvalidRuleThreshold
+		}
+
+		if r.Window < 60 || r.Window > maxRuleWindow {
+			return ErrInvalidRuleWindow
+		}
+	case RuleCertExpiry:
+		if r.Threshold < 1 || r.Threshold > maxExpiryDays {
+			return ErrInvalidRuleThreshold
+		}
+	case RuleDualStack, RuleLogoChanged:
+	default:
+		return ErrInvalidRuleKind
+	}
+
+	return nil
+}
+
+// AppliesTo reports if the rule is evaluated for the domain
+func (r *Rule) AppliesTo(domainName string) bool {
+	return r.Enabled && (r.DomainName == "" || r.DomainName == domainName)
+}
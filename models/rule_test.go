@@ -0,0 +1,62 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRule(t *testing.T) {
+	c := require.New(t)
+
+	for _, kind := range RuleKinds {
+		rule, err := NewRule("rule", kind)
+		c.NoError(err)
+		c.NoError(rule.Validate(), kind)
+		c.True(rule.AppliesTo("example.com"))
+	}
+
+	_, err := NewRule(" ", RuleGradeBelow)
+	c.Equal(ErrEmptyRuleName, err)
+
+	_, err = NewRule("rule", "grade_above")
+	c.Equal(ErrInvalidRuleKind, err)
+}
+
+func TestRuleValidate(t *testing.T) {
+	c := require.New(t)
+
+	cases := []struct {
+		kind   string
+		modify func(*Rule)
+		err    error
+	}{
+		{RuleGradeBelow, func(r *Rule) { r.Grade = "Z" }, ErrInvalidRuleGrade},
+		{RuleServerCountDrop, func(r *Rule) { r.Threshold = 101 }, ErrInvalidRuleThreshold},
+		{RuleDownConsecutive, func(r *Rule) { r.Threshold = 0 }, ErrInvalidRuleThreshold},
+		{RuleGradeDrops, func(r *Rule) { r.Window = 10 }, ErrInvalidRuleWindow},
+		{RuleOwnerChanged, func(r *Rule) { r.Name = "" }, ErrEmptyRuleName},
+	}
+
+	for _, tc := range cases {
+		rule, err := NewRule("rule", tc.kind)
+		c.NoError(err)
+
+		tc.modify(rule)
+		c.Equal(tc.err, rule.Validate(), tc.kind)
+	}
+}
+
+func TestRuleAppliesTo(t *testing.T) {
+	c := require.New(t)
+
+	rule, err := NewRule("rule", RuleOwnerChanged)
+	c.NoError(err)
+
+	rule.DomainName = "example.com"
+	c.True(rule.AppliesTo("example.com"))
+	c.False(rule.AppliesTo("other.com"))
+
+	rule.Enabled = false
+	c.False(rule.AppliesTo("example.com"))
+}
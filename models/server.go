@@ -1,35 +1,49 @@
 package models
 
 import (
-	"errors"
+	"net"
 	"time"
 
 	"github.com/gofrs/uuid"
+	"github.com/other_project/crockroach/internal/apperr"
+)
+
+const (
+	// FamilyIPv4 is the family of the IPv4 addresses
+	FamilyIPv4 = "ipv4"
+	// FamilyIPv6 is the family of the IPv6 addresses
+	FamilyIPv6 = "ipv6"
 )
 
 var (
 	// ErrEmptyAddress for empty address
-	ErrEmptyAddress = errors.New("address cannot be empty")
+	ErrEmptyAddress = apperr.New(apperr.Unprocessable, "empty_address", "address cannot be empty")
 	// ErrEmptySSLGrade for empty SSLGrade
-	ErrEmptySSLGrade = errors.New("ssl grade cannot be empty")
+	ErrEmptySSLGrade = apperr.New(apperr.Unprocessable, "empty_ssl_grade", "ssl grade cannot be empty")
 	// ErrEmptyCountry for empty country
-	ErrEmptyCountry = errors.New("country cannot be empty")
+	ErrEmptyCountry = apperr.New(apperr.Unprocessable, "empty_country", "country cannot be empty")
 	// ErrEmptyOwner for empty Owner
-	ErrEmptyOwner = errors.New("owner cannot be empty")
+	ErrEmptyOwner = apperr.New(apperr.Unprocessable, "empty_owner", "owner cannot be empty")
 	// ErrEmptyServer when at least one server must be associated with a domain
-	ErrEmptyServer = errors.New("at least one server must be associated with a domain")
+	ErrEmptyServer = apperr.New(apperr.Unprocessable, "server_without_domain", "at least one server must be associated with a domain")
 )
 
-// Server model structure for server
+// Server model structure for server. Country and Owner come from whois, GeoCountry, ASN and
+// ASNOrg from the local GeoIP databases
 type Server struct {
-	ServerID     string     `json:"server_id"`
-	Address      string     `json:"address"`
-	SSLGrade     string     `json:"ssl_grade"`
-	Country      string     `json:"country"`
-	Owner        string     `json:"owner"`
-	Domain       *Domain    `json:"domain_id"`
-	CreationDate *time.Time `json:"creation_date"`
-	UpdateDate   *time.Time `json:"update_date"`
+	ServerID     string         `json:"server_id"`
+	Address      string         `json:"address"`
+	Family       string         `json:"family"`
+	SSLGrade     string         `json:"ssl_grade"`
+	Country      string         `json:"country"`
+	Owner        string         `json:"owner"`
+	GeoCountry   string         `json:"geo_country"`
+	ASN          uint64         `json:"asn"`
+	ASNOrg       string         `json:"asn_org"`
+	Domain       *Domain        `json:"domain_id"`
+	TLS          *TLSInspection `json:"tls,omitempty"`
+	CreationDate *time.Time     `json:"creation_date"`
+	UpdateDate   *time.Time     `json:"update_date"`
 }
 
 // NewServer Initialize a new server
@@ -65,6 +79,7 @@ func NewServer(address, sslGrade, country, owner string, domain *Domain) (server
 	server = &Server{
 		ServerID:     serverID.String(),
 		Address:      address,
+		Family:       AddressFamily(address),
 		SSLGrade:     sslGrade,
 		Country:      country,
 		Owner:        owner,
@@ -75,3 +90,18 @@ func NewServer(address, sslGrade, country, owner string, domain *Domain) (server
 
 	return server, nil
 }
+
+// AddressFamily returns ipv4 or ipv6 for the address, or an empty string when it is not an IP.
+// IPv4 addresses mapped to IPv6 belong to ipv4
+func AddressFamily(address string) string {
+	ip := net.ParseIP(address)
+
+	switch {
+	case ip == nil:
+		return ""
+	case ip.To4() != nil:
+		return FamilyIPv4
+	}
+
+	return FamilyIPv6
+}
@@ -54,3 +54,19 @@ func BenchmarkNewServer(b *testing.B) {
 		}
 	}
 }
+
+func TestAddressFamily(t *testing.T) {
+	c := require.New(t)
+
+	c.Equal(FamilyIPv4, AddressFamily("192.0.2.1"))
+	c.Equal(FamilyIPv4, AddressFamily("::ffff:192.0.2.1"))
+	c.Equal(FamilyIPv6, AddressFamily("2001:db8::1"))
+	c.Equal("", AddressFamily("server1"))
+
+	domain, err := NewDomain(false, false, "google.com", "A+", "B", "https://server.com/icon.png", "Title of the page")
+	c.NoError(err)
+
+	server, err := NewServer("2001:db8::1", "A", "US", "Google LLC", domain)
+	c.NoError(err)
+	c.Equal(FamilyIPv6, server.Family)
+}
@@ -0,0 +1,179 @@
+package models
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/other_project/crockroach/internal/apperr"
+)
+
+const (
+	// DefaultCheckInterval is the time in seconds between the analyses of a tracked domain
+	DefaultCheckInterval = 3600
+	// MinCheckInterval is the shortest interval accepted, SSL Labs assessments take minutes
+	MinCheckInterval = 300
+	// MaxCheckInterval is the longest interval accepted, 30 days
+	MaxCheckInterval = 30 * 24 * 3600
+
+	// maxTags is the maximum number of tags of a tracked domain
+	maxTags = 20
+	// maxTargets is the maximum number of notification targets of a tracked domain
+	maxTargets = 10
+)
+
+var (
+	// ErrInvalidCheckInterval when the interval is out of range
+	ErrInvalidCheckInterval = apperr.New(apperr.Unprocessable, "invalid_check_interval", "check_interval must be between 300 and 2592000 seconds")
+	// ErrInvalidTag when a tag has invalid characters
+	ErrInvalidTag = apperr.New(apperr.Unprocessable, "invalid_tag", "tags must have up to 32 letters, digits, dots, dashes or underscores")
+	// ErrTooManyTags when the domain has more than maxTags tags
+	ErrTooManyTags = apperr.New(apperr.Unprocessable, "too_many_tags", "a tracked domain can have up to 20 tags")
+	// ErrInvalidTarget when a notification target is not an http(s) or mailto URL
+	ErrInvalidTarget = apperr.New(apperr.Unprocessable, "invalid_notification_target", "notification targets must be http, https or mailto URLs")
+	// ErrTooManyTargets when the domain has more than maxTargets targets
+	ErrTooManyTargets = apperr.New(apperr.Unprocessable, "too_many_notification_targets", "a tracked domain can have up to 10 notification targets")
+
+	tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,31}$`)
+)
+
+// TrackedDomain model structure for a domain of the watchlist and its check settings
+type TrackedDomain struct {
+	TrackedID           string      `json:"tracked_id"`
+	DomainName          string      `json:"domain_name"`
+	CheckInterval       int64       `json:"check_interval"`
+	Enabled             bool        `json:"enabled"`
+	Tags                []string    `json:"tags"`
+	NotificationTargets []string    `json:"notification_targets"`
+	Providers           []string    `json:"providers"`
+	SkipWHOIS           bool        `json:"skip_whois"`
+	HealthCheck         HealthCheck `json:"health_check"`
+	LastCheckDate       *time.Time  `json:"last_check_date,omitempty"`
+	CreationDate        *time.Time  `json:"creation_date"`
+	UpdateDate          *time.Time  `json:"update_date"`
+}
+
+// NewTrackedDomain Initialize an enabled tracked domain with the default settings
+func NewTrackedDomain(domainName string) (*TrackedDomain, error) {
+	name, err := NormalizeDomainName(domainName)
+	if err != nil {
+		return nil, err
+	}
+
+	trackedID, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+
+	created := time.Now()
+
+	return &TrackedDomain{
+		TrackedID:           trackedID.String(),
+		DomainName:          name,
+		CheckInterval:       DefaultCheckInterval,
+		Enabled:             true,
+		Tags:                []string{},
+		NotificationTargets: []string{},
+		Providers:           []string{},
+		CreationDate:        &created,
+		UpdateDate:          &created,
+	}, nil
+}
+
+// Validate checks the settings and normalizes the tags, they are lowercased, deduplicated and sorted
+func (t *TrackedDomain) Validate() error {
+	if t.CheckInterval < MinCheckInterval || t.CheckInterval > MaxCheckInterval {
+		return ErrInvalidCheckInterval
+	}
+
+	tags, err := normalizeTags(t.Tags)
+	if err != nil {
+		return err
+	}
+
+	t.Tags = tags
+
+	if len(t.NotificationTargets) > maxTargets {
+		return ErrTooManyTargets
+	}
+
+	for _, target := range t.NotificationTargets {
+		if !validTarget(target) {
+			return ErrInvalidTarget
+		}
+	}
+
+	return t.HealthCheck.Validate()
+}
+
+// Due reports if the domain must be analyzed again
+func (t *TrackedDomain) Due(now time.Time) bool {
+	if !t.Enabled {
+		return false
+	}
+
+	if t.LastCheckDate == nil {
+		return true
+	}
+
+	return !now.Before(t.LastCheckDate.Add(time.Duration(t.CheckInterval) * time.Second))
+}
+
+// HasTag reports if the domain has the tag
+func (t *TrackedDomain) HasTag(tag string) bool {
+	for _, current := range t.Tags {
+		if current == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalizeTags lowercases, deduplicates and sorts the tags
+func normalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool)
+	normalized := []string{}
+
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if !tagPattern.MatchString(tag) {
+			return nil, ErrInvalidTag
+		}
+
+		if seen[tag] {
+			continue
+		}
+
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	if len(normalized) > maxTags {
+		return nil, ErrTooManyTags
+	}
+
+	sort.Strings(normalized)
+
+	return normalized, nil
+}
+
+// validTarget reports if the target is an absolute http(s) URL or a mailto address
+func validTarget(target string) bool {
+	u, err := url.Parse(target)
+	if err != nil {
+		return false
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		return u.Host != ""
+	case "mailto":
+		return strings.Contains(u.Opaque, "@")
+	}
+
+	return false
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTrackedDomain(t *testing.T) {
+	c := require.New(t)
+
+	tracked, err := NewTrackedDomain("HTTPS://Example.com/")
+	c.NoError(err)
+	c.Equal("example.com", tracked.DomainName)
+	c.Equal(int64(DefaultCheckInterval), tracked.CheckInterval)
+	c.True(tracked.Enabled)
+	c.NoError(tracked.Validate())
+
+	_, err = NewTrackedDomain("")
+	c.Equal(ErrEmptyDomainName, err)
+}
+
+func TestTrackedDomainValidate(t *testing.T) {
+	c := require.New(t)
+
+	tracked, err := NewTrackedDomain("example.com")
+	c.NoError(err)
+
+	tracked.Tags = []string{" Prod ", "payments", "prod"}
+	tracked.NotificationTargets = []string{"https://hooks.example.com/tls", "mailto:ops@example.com"}
+	c.NoError(tracked.Validate())
+	c.Equal([]string{"payments", "prod"}, tracked.Tags)
+	c.True(tracked.HasTag("prod"))
+
+	cases := []struct {
+		name   string
+		modify func(*TrackedDomain)
+		err    error
+	}{
+		{"short interval", func(d *TrackedDomain) { d.CheckInterval = 60 }, ErrInvalidCheckInterval},
+		{"long interval", func(d *TrackedDomain) { d.CheckInterval = MaxCheckInterval + 1 }, ErrInvalidCheckInterval},
+		{"invalid tag", func(d *TrackedDomain) { d.Tags = []string{"no spaces"} }, ErrInvalidTag},
+		{"relative target", func(d *TrackedDomain) { d.NotificationTargets = []string{"/hooks"} }, ErrInvalidTarget},
+		{"ftp target", func(d *TrackedDomain) { d.NotificationTargets = []string{"ftp://example.com"} }, ErrInvalidTarget},
+		{"mailto without address", func(d *TrackedDomain) { d.NotificationTargets = []string{"mailto:ops"} }, ErrInvalidTarget},
+	}
+
+	for _, tc := range cases {
+		tracked, err := NewTrackedDomain("example.com")
+		c.NoError(err)
+
+		tc.modify(tracked)
+		c.Equal(tc.err, tracked.Validate(), tc.name)
+	}
+}
+
+func TestTrackedDomainDue(t *testing.T) {
+	c := require.New(t)
+
+	now := time.Now()
+
+	tracked, err := NewTrackedDomain("example.com")
+	c.NoError(err)
+	c.True(tracked.Due(now))
+
+	checked := now.Add(-30 * time.Minute)
+	tracked.LastCheckDate = &checked
+	c.False(tracked.Due(now))
+	c.True(tracked.Due(now.Add(30 * time.Minute)))
+
+	tracked.Enabled = false
+	c.False(tracked.Due(now.Add(time.Hour)))
+}
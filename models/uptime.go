@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	// ErrorClassDNS when the name of the site cannot be resolved
+	ErrorClassDNS = "dns"
+	// ErrorClassConnect when the connection to the site fails
+	ErrorClassConnect = "connect"
+	// ErrorClassTLS when the TLS handshake fails
+	ErrorClassTLS = "tls"
+	// ErrorClassTimeout when the site does not answer in time
+	ErrorClassTimeout = "timeout"
+	// ErrorClassRedirects when the site redirects too many times
+	ErrorClassRedirects = "too_many_redirects"
+	// ErrorClassStatus when the site answers with a status code that is not accepted
+	ErrorClassStatus = "http_status"
+	// ErrorClassBody when the body does not have the expected content
+	ErrorClassBody = "body_mismatch"
+	// ErrorClassSlow when the answer takes longer than the maximum response time
+	ErrorClassSlow = "slow_response"
+	// ErrorClassOther when the request fails for another reason
+	ErrorClassOther = "other"
+)
+
+// UptimeCheck model structure for the availability check of an analysis. The timings are
+// in milliseconds, DNS, connect and TLS add the time of every redirect
+type UptimeCheck struct {
+	CheckID      string     `json:"check_id"`
+	AnalysisID   string     `json:"analysis_id"`
+	DomainName   string     `json:"domain_name"`
+	URL          string     `json:"url"`
+	StatusCode   int        `json:"status_code"`
+	Redirects    []string   `json:"redirects"`
+	DNSTime      int64      `json:"dns_ms"`
+	ConnectTime  int64      `json:"connect_ms"`
+	TLSTime      int64      `json:"tls_ms"`
+	TTFB         int64      `json:"ttfb_ms"`
+	TotalTime    int64      `json:"total_ms"`
+	ErrorClass   string     `json:"error_class"`
+	Up           bool       `json:"up"`
+	CreationDate *time.Time `json:"creation_date"`
+}
+
+// Reachable reports if the site answered, even with an error status code or a redirect loop
+func (u *UptimeCheck) Reachable() bool {
+	return u.StatusCode != 0
+}
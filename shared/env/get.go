@@ -23,14 +23,24 @@ func GetBool(varName string, defaultValue bool) bool {
 		return defaultValue
 	}
 
+	bVal, ok := parseBool(val)
+	if !ok {
+		return defaultValue
+	}
+
+	return bVal
+}
+
+// parseBool parses the boolean spellings accepted by GetBool
+func parseBool(val string) (bool, bool) {
 	switch val {
 	case "1", "t", "T", "true", "TRUE", "True", "yes", "Yes", "YES":
-		return true
+		return true, true
 	case "0", "f", "F", "false", "FALSE", "False", "no", "No", "NO":
-		return false
+		return false, true
 	}
 
-	return defaultValue
+	return false, false
 }
 
 // GetInt64 gets the env var as an int
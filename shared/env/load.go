@@ -0,0 +1,249 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// fileSuffix is appended to a variable name to read its value from a file
+	fileSuffix = "_FILE"
+	// defaultSep is used to split slice values when the sep tag is missing
+	defaultSep = ","
+)
+
+var (
+	// ErrInvalidTarget when Load does not receive a pointer to a struct
+	ErrInvalidTarget = errors.New("env: target must be a non-nil pointer to a struct")
+	// ErrMissingRequired when a required variable is not set
+	ErrMissingRequired = errors.New("required variable is not set")
+	// ErrUnsupportedType when a field type cannot be parsed from a string
+	ErrUnsupportedType = errors.New("unsupported field type")
+
+	durationType = reflect.TypeOf(time.Duration(0))
+	urlType      = reflect.TypeOf(url.URL{})
+)
+
+// FieldError describes a variable that could not be loaded
+type FieldError struct {
+	Var   string
+	Field string
+	Err   error
+}
+
+// Error implements the error interface
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s (%s): %s", e.Var, e.Field, e.Err.Error())
+}
+
+// Unwrap returns the underlying error
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// LoadError aggregates every variable that was missing or invalid
+type LoadError struct {
+	Errors []*FieldError
+}
+
+// Error implements the error interface
+func (e *LoadError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+
+	return "env: cannot load configuration: " + strings.Join(msgs, "; ")
+}
+
+// Load fills the struct pointed to by v from the environment.
+//
+// Fields are configured with the following tags:
+//
+//	env:"NAME"        variable to read; fields without it are skipped unless they are structs
+//	default:"value"   value used when the variable is not set
+//	required:"true"   report an error when the variable is not set and there is no default
+//	sep:","           separator for slice fields
+//	prefix:"DB_"      prefix applied to the variables of a nested struct
+//
+// When NAME is not set but NAME_FILE is, the value is read from that file,
+// which is convenient for secrets mounted by the orchestrator.
+// Every missing or invalid variable is reported in a single *LoadError.
+func Load(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return ErrInvalidTarget
+	}
+
+	loadErr := new(LoadError)
+	loadStruct(rv.Elem(), "", loadErr)
+
+	if len(loadErr.Errors) > 0 {
+		return loadErr
+	}
+
+	return nil
+}
+
+// loadStruct walks the fields of a struct value
+func loadStruct(rv reflect.Value, prefix string, loadErr *LoadError) {
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		value := rv.Field(i)
+		name, hasName := field.Tag.Lookup("env")
+
+		if !hasName {
+			if field.Type.Kind() == reflect.Struct && field.Type != urlType {
+				loadStruct(value, prefix+field.Tag.Get("prefix"), loadErr)
+			}
+
+			continue
+		}
+
+		varName := prefix + name
+
+		raw, ok, err := lookup(varName)
+		if err != nil {
+			loadErr.Errors = append(loadErr.Errors, &FieldError{Var: varName, Field: field.Name, Err: err})
+			continue
+		}
+
+		if !ok {
+			raw, ok = field.Tag.Lookup("default")
+		}
+
+		if !ok {
+			if field.Tag.Get("required") == "true" {
+				loadErr.Errors = append(loadErr.Errors, &FieldError{Var: varName, Field: field.Name, Err: ErrMissingRequired})
+			}
+
+			continue
+		}
+
+		sep := field.Tag.Get("sep")
+		if sep == "" {
+			sep = defaultSep
+		}
+
+		err = setValue(value, raw, sep)
+		if err != nil {
+			loadErr.Errors = append(loadErr.Errors, &FieldError{Var: varName, Field: field.Name, Err: err})
+		}
+	}
+}
+
+// lookup reads a variable, falling back to the file named by NAME_FILE
+func lookup(varName string) (string, bool, error) {
+	val, _ := os.LookupEnv(varName)
+	if val != "" {
+		return val, true, nil
+	}
+
+	path, _ := os.LookupEnv(varName + fileSuffix)
+	if path == "" {
+		return "", false, nil
+	}
+
+	content, err := ioutil.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return "", false, err
+	}
+
+	return strings.TrimRight(string(content), "\r\n"), true, nil
+}
+
+// setValue parses raw according to the kind of the destination
+func setValue(value reflect.Value, raw, sep string) error {
+	switch value.Type() {
+	case durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+
+		value.SetInt(int64(d))
+
+		return nil
+	case urlType:
+		u, err := url.Parse(raw)
+		if err != nil {
+			return err
+		}
+
+		value.Set(reflect.ValueOf(*u))
+
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Bool:
+		b, ok := parseBool(raw)
+		if !ok {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+
+		value.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(raw, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		value.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(raw, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		value.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		value.SetFloat(f)
+	case reflect.Ptr:
+		elem := reflect.New(value.Type().Elem())
+
+		err := setValue(elem.Elem(), raw, sep)
+		if err != nil {
+			return err
+		}
+
+		value.Set(elem)
+	case reflect.Slice:
+		parts := strings.Split(raw, sep)
+		slice := reflect.MakeSlice(value.Type(), len(parts), len(parts))
+
+		for i, part := range parts {
+			err := setValue(slice.Index(i), strings.TrimSpace(part), sep)
+			if err != nil {
+				return err
+			}
+		}
+
+		value.Set(slice)
+	default:
+		return ErrUnsupportedType
+	}
+
+	return nil
+}
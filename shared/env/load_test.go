@@ -0,0 +1,137 @@
+package env
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type loadDatabaseConfig struct {
+	Host string `env:"HOST" default:"localhost"`
+	Port int64  `env:"PORT" default:"26257"`
+	User string `env:"USER" required:"true"`
+}
+
+type loadConfig struct {
+	Name     string             `env:"LOAD_NAME" required:"true"`
+	Debug    bool               `env:"LOAD_DEBUG"`
+	Ratio    float64            `env:"LOAD_RATIO" default:"0.5"`
+	Timeout  time.Duration      `env:"LOAD_TIMEOUT" default:"15s"`
+	Endpoint *url.URL           `env:"LOAD_ENDPOINT"`
+	Tags     []string           `env:"LOAD_TAGS" sep:";"`
+	Ports    []int              `env:"LOAD_PORTS"`
+	Secret   string             `env:"LOAD_SECRET"`
+	Database loadDatabaseConfig `prefix:"LOAD_DB_"`
+}
+
+func withEnv(vars map[string]string, cb func()) {
+	for name, val := range vars {
+		_ = os.Setenv(name, val)
+	}
+
+	defer func() {
+		for name := range vars {
+			_ = os.Unsetenv(name)
+		}
+	}()
+
+	cb()
+}
+
+func TestLoad(t *testing.T) {
+	c := require.New(t)
+
+	dir, err := ioutil.TempDir("", "env")
+	c.NoError(err)
+
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	secretPath := filepath.Join(dir, "secret")
+	c.NoError(ioutil.WriteFile(secretPath, []byte("s3cr3t\n"), 0600))
+
+	vars := map[string]string{
+		"LOAD_NAME":        "crockroach",
+		"LOAD_DEBUG":       "yes",
+		"LOAD_TIMEOUT":     "1m30s",
+		"LOAD_ENDPOINT":    "https://api.ssllabs.com/api/v3",
+		"LOAD_TAGS":        "a;b; c",
+		"LOAD_PORTS":       "80,443",
+		"LOAD_SECRET_FILE": secretPath,
+		"LOAD_DB_USER":     "root",
+		"LOAD_DB_PORT":     "5432",
+	}
+
+	withEnv(vars, func() {
+		var cfg loadConfig
+		c.NoError(Load(&cfg))
+
+		c.Equal("crockroach", cfg.Name)
+		c.True(cfg.Debug)
+		c.Equal(0.5, cfg.Ratio)
+		c.Equal(90*time.Second, cfg.Timeout)
+		c.Equal("api.ssllabs.com", cfg.Endpoint.Host)
+		c.Equal([]string{"a", "b", "c"}, cfg.Tags)
+		c.Equal([]int{80, 443}, cfg.Ports)
+		c.Equal("s3cr3t", cfg.Secret)
+		c.Equal("localhost", cfg.Database.Host)
+		c.Equal(int64(5432), cfg.Database.Port)
+		c.Equal("root", cfg.Database.User)
+	})
+}
+
+func TestLoadAggregatesErrors(t *testing.T) {
+	c := require.New(t)
+
+	vars := map[string]string{
+		"LOAD_DEBUG":   "maybe",
+		"LOAD_TIMEOUT": "forever",
+	}
+
+	withEnv(vars, func() {
+		var cfg loadConfig
+		err := Load(&cfg)
+		c.Error(err)
+
+		var loadErr *LoadError
+		c.True(errors.As(err, &loadErr))
+		c.Len(loadErr.Errors, 4)
+
+		failed := map[string]error{}
+		for _, fieldErr := range loadErr.Errors {
+			failed[fieldErr.Var] = fieldErr.Err
+		}
+
+		c.Equal(ErrMissingRequired, failed["LOAD_NAME"])
+		c.Equal(ErrMissingRequired, failed["LOAD_DB_USER"])
+		c.Contains(failed, "LOAD_DEBUG")
+		c.Contains(failed, "LOAD_TIMEOUT")
+	})
+}
+
+func TestLoadInvalidTarget(t *testing.T) {
+	c := require.New(t)
+
+	var cfg loadConfig
+	c.Equal(ErrInvalidTarget, Load(cfg))
+	c.Equal(ErrInvalidTarget, Load(nil))
+
+	name := "value"
+	c.Equal(ErrInvalidTarget, Load(&name))
+}
+
+func BenchmarkLoad(b *testing.B) {
+	withEnv(map[string]string{"LOAD_NAME": "bench", "LOAD_DB_USER": "root"}, func() {
+		for i := 0; i < b.N; i++ {
+			var cfg loadConfig
+			_ = Load(&cfg)
+		}
+	})
+}